@@ -17,6 +17,9 @@ limitations under the License.
 package ceph
 
 import (
+	"encoding/json"
+	"fmt"
+
 	"github.com/coreos/pkg/capnslog"
 	"github.com/spf13/cobra"
 
@@ -44,10 +47,14 @@ type config struct {
 	devices            string
 	directories        string
 	metadataDevice     string
+	pvcDevicePath      string
+	deviceClasses      string
+	encryptedDevices   string
 	dataDir            string
 	forceFormat        bool
 	location           string
 	cephConfigOverride string
+	cephConfig         string
 	storeConfig        osdconfig.StoreConfig
 	networkInfo        clusterd.NetworkInfo
 	monEndpoints       string
@@ -61,11 +68,15 @@ func init() {
 func AddCommands(command *cobra.Command) {
 	command.AddCommand(operatorCmd)
 	command.AddCommand(agentCmd)
+	command.AddCommand(csiCmd)
 	command.AddCommand(monCmd)
 	command.AddCommand(osdCmd)
 	command.AddCommand(mgrCmd)
+	command.AddCommand(rbdMirrorCmd)
 	command.AddCommand(rgwCmd)
 	command.AddCommand(mdsCmd)
+	command.AddCommand(filesystemVolumeCmd)
+	command.AddCommand(cleanupCmd)
 }
 
 func createContext() *clusterd.Context {
@@ -74,14 +85,32 @@ func createContext() *clusterd.Context {
 		Executor:           executor,
 		ConfigDir:          cfg.dataDir,
 		ConfigFileOverride: cfg.cephConfigOverride,
+		CephConfig:         parseCephConfig(cfg.cephConfig),
 		LogLevel:           rook.Cfg.LogLevel,
 		NetworkInfo:        cfg.NetworkInfo(),
 	}
 }
 
+// parseCephConfig parses the ceph config settings from the cluster CRD. Invalid settings are ignored
+// with a warning so the daemons can still start with the config that rook generates.
+func parseCephConfig(cephConfig string) map[string]map[string]string {
+	if cephConfig == "" {
+		return nil
+	}
+
+	settings := map[string]map[string]string{}
+	if err := json.Unmarshal([]byte(cephConfig), &settings); err != nil {
+		logger.Warningf("failed to parse ceph config settings %s. %+v", cephConfig, err)
+		return nil
+	}
+	return settings
+}
+
 func addCephFlags(command *cobra.Command) {
 	command.Flags().StringVar(&cfg.networkInfo.PublicAddr, "public-ip", "", "public IP address for this machine")
 	command.Flags().StringVar(&cfg.networkInfo.ClusterAddr, "private-ip", "", "private IP address for this machine")
+	command.Flags().StringVar(&cfg.networkInfo.PublicNetwork, "public-network", "", "public network and subnet mask in CIDR notation")
+	command.Flags().StringVar(&cfg.networkInfo.ClusterNetwork, "cluster-network", "", "cluster network and subnet mask in CIDR notation")
 	command.Flags().StringVar(&clusterInfo.Name, "cluster-name", "rookcluster", "ceph cluster name")
 	command.Flags().StringVar(&clusterInfo.FSID, "fsid", "", "the cluster uuid")
 	command.Flags().StringVar(&clusterInfo.MonitorSecret, "mon-secret", "", "the cephx keyring for monitors")
@@ -89,6 +118,7 @@ func addCephFlags(command *cobra.Command) {
 	command.Flags().StringVar(&cfg.monEndpoints, "mon-endpoints", "", "ceph mon endpoints")
 	command.Flags().StringVar(&cfg.dataDir, "config-dir", "/var/lib/rook", "directory for storing configuration")
 	command.Flags().StringVar(&cfg.cephConfigOverride, "ceph-config-override", "", "optional path to a ceph config file that will be appended to the config files that rook generates")
+	command.Flags().StringVar(&cfg.cephConfig, "ceph-config", "", "optional json map of ceph config sections to the settings that will be added to the config files that rook generates")
 
 	// deprecated ipv4 format address
 	// TODO: remove these legacy flags in the future
@@ -106,6 +136,22 @@ func verifyRenamedFlags(cmd *cobra.Command) error {
 	return flags.VerifyRenamedFlags(cmd, renamed)
 }
 
+// verifyNetworks verifies the network flags and selects the local addresses of the daemon in the
+// public and cluster networks, which are not the pod IP when the pod is attached to other networks
+func verifyNetworks() error {
+	networkInfo := cfg.NetworkInfo()
+	if err := clusterd.VerifyNetworkInfo(networkInfo); err != nil {
+		return err
+	}
+
+	networkInfo, err := clusterd.SelectLocalNetworkAddrs(networkInfo)
+	if err != nil {
+		return fmt.Errorf("failed to bind to the configured networks. %+v", err)
+	}
+	cfg.networkInfo = networkInfo
+	return nil
+}
+
 func (c *config) NetworkInfo() clusterd.NetworkInfo {
 	return c.networkInfo.Simplify()
 }
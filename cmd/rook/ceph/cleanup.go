@@ -0,0 +1,73 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph
+
+import (
+	"strings"
+
+	"github.com/rook/rook/cmd/rook/rook"
+	"github.com/rook/rook/pkg/daemon/ceph/cleanup"
+	"github.com/rook/rook/pkg/util/flags"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cleanupDataDir     string
+	cleanupWipeDevices bool
+	cleanupDiskUUIDs   string
+	cleanupClusterFSID string
+)
+
+var cleanupCmd = &cobra.Command{
+	Use:    cleanup.CleanupCommand,
+	Short:  "Removes the data of a deleted cluster from the host",
+	Hidden: true,
+}
+
+func init() {
+	cleanupCmd.Flags().StringVar(&cleanupDataDir, "data-dir", "", "the mounted dataDirHostPath of the cluster to delete the contents of")
+	cleanupCmd.Flags().BoolVar(&cleanupWipeDevices, "wipe-devices", false, "zap the devices with only the partitions created for osds")
+	cleanupCmd.Flags().StringVar(&cleanupDiskUUIDs, "disk-uuids", "", "comma-separated disk uuids of the devices of the cluster that may be zapped")
+	cleanupCmd.Flags().StringVar(&cleanupClusterFSID, "cluster-fsid", "", "the fsid of the cluster whose ceph-volume osds are zapped")
+
+	flags.SetFlagsFromEnv(cleanupCmd.Flags(), rook.RookEnvVarPrefix)
+
+	cleanupCmd.RunE = runCleanup
+}
+
+func runCleanup(cmd *cobra.Command, args []string) error {
+	rook.SetLogLevel()
+
+	rook.LogStartupInfo(cleanupCmd.Flags())
+
+	var diskUUIDs []string
+	if cleanupDiskUUIDs != "" {
+		diskUUIDs = strings.Split(cleanupDiskUUIDs, ",")
+	}
+
+	config := &cleanup.Config{
+		DataDir:     cleanupDataDir,
+		WipeDevices: cleanupWipeDevices,
+		DiskUUIDs:   diskUUIDs,
+		ClusterFSID: cleanupClusterFSID,
+	}
+	if err := cleanup.CleanupHost(createContext(), config); err != nil {
+		rook.TerminateFatal(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/rook/rook/cmd/rook/rook"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume/manager/ceph"
+	"github.com/rook/rook/pkg/daemon/ceph/csi"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/exec"
+	"github.com/rook/rook/pkg/util/flags"
+	"github.com/rook/rook/pkg/version"
+	"github.com/spf13/cobra"
+	k8smount "k8s.io/kubernetes/pkg/util/mount"
+)
+
+var csiCmd = &cobra.Command{
+	Use:    "csi",
+	Short:  "Runs the rook ceph csi driver",
+	Hidden: true,
+}
+
+var (
+	csiEndpoint string
+	csiMode     string
+)
+
+func init() {
+	csiCmd.Flags().StringVar(&csiEndpoint, "csi-endpoint", "unix:///csi/csi.sock", "the unix socket where the csi driver listens")
+	csiCmd.Flags().StringVar(&csiMode, "csi-mode", csi.NodeMode, "the csi services to run (controller|node)")
+	flags.SetFlagsFromEnv(csiCmd.Flags(), rook.RookEnvVarPrefix)
+	csiCmd.RunE = startCSI
+}
+
+func startCSI(cmd *cobra.Command, args []string) error {
+
+	rook.SetLogLevel()
+
+	rook.LogStartupInfo(csiCmd.Flags())
+
+	clientset, apiExtClientset, rookClientset, err := rook.GetClientset()
+	if err != nil {
+		rook.TerminateFatal(fmt.Errorf("failed to get k8s client. %+v", err))
+	}
+
+	logger.Infof("starting rook ceph csi driver in %s mode", csiMode)
+	context := &clusterd.Context{
+		Executor:              &exec.CommandExecutor{},
+		ConfigDir:             k8sutil.DataDir,
+		NetworkInfo:           clusterd.NetworkInfo{},
+		Clientset:             clientset,
+		APIExtensionClientset: apiExtClientset,
+		RookClientset:         rookClientset,
+	}
+
+	var driver *csi.Driver
+	switch csiMode {
+	case csi.ControllerMode:
+		driver = csi.NewControllerDriver(context, csi.DriverName, version.Version)
+	case csi.NodeMode:
+		nodeName := os.Getenv(k8sutil.NodeNameEnvVar)
+		if nodeName == "" {
+			rook.TerminateFatal(fmt.Errorf("the node name must be set with the %s env var", k8sutil.NodeNameEnvVar))
+		}
+		volumeManager, err := ceph.NewVolumeManager(context)
+		if err != nil {
+			rook.TerminateFatal(fmt.Errorf("failed to create volume manager. %+v", err))
+		}
+		mounter := &k8smount.SafeFormatAndMount{
+			Interface: k8smount.New("" /* default mount path */),
+			Exec:      k8smount.NewOsExec(),
+		}
+		driver = csi.NewNodeDriver(context, csi.DriverName, version.Version, nodeName, volumeManager, mounter)
+	default:
+		rook.TerminateFatal(fmt.Errorf("unknown csi mode %s", csiMode))
+	}
+
+	stopChan := make(chan struct{})
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGTERM)
+	go func() {
+		<-sigc
+		logger.Infof("shutdown signal received, exiting...")
+		close(stopChan)
+	}()
+
+	if err := driver.Run(csiEndpoint, stopChan); err != nil {
+		rook.TerminateFatal(fmt.Errorf("failed to run rook ceph csi driver. %+v", err))
+	}
+
+	return nil
+}
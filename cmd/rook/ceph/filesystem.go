@@ -0,0 +1,83 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph
+
+import (
+	"github.com/rook/rook/cmd/rook/rook"
+	filedaemon "github.com/rook/rook/pkg/daemon/ceph/file"
+	mondaemon "github.com/rook/rook/pkg/daemon/ceph/mon"
+	"github.com/rook/rook/pkg/util/flags"
+	"github.com/spf13/cobra"
+	"k8s.io/kubernetes/pkg/util/mount"
+)
+
+var (
+	volumeFilesystemName string
+	volumePath           string
+	volumeQuotaBytes     int64
+	deleteVolume         bool
+)
+
+var filesystemVolumeCmd = &cobra.Command{
+	Use:    filedaemon.VolumeCommand,
+	Short:  "Creates or deletes the directory of a volume in a ceph filesystem",
+	Hidden: true,
+}
+
+func init() {
+	filesystemVolumeCmd.Flags().StringVar(&volumeFilesystemName, "fs-name", "", "name of the filesystem of the volume")
+	filesystemVolumeCmd.Flags().StringVar(&volumePath, "volume-path", "", "path of the volume directory in the filesystem")
+	filesystemVolumeCmd.Flags().Int64Var(&volumeQuotaBytes, "quota-bytes", 0, "max size of the volume directory in bytes")
+	filesystemVolumeCmd.Flags().BoolVar(&deleteVolume, "delete", false, "delete the volume directory instead of creating it")
+	addCephFlags(filesystemVolumeCmd)
+
+	flags.SetFlagsFromEnv(filesystemVolumeCmd.Flags(), rook.RookEnvVarPrefix)
+
+	filesystemVolumeCmd.RunE = runFilesystemVolume
+}
+
+func runFilesystemVolume(cmd *cobra.Command, args []string) error {
+	required := []string{"mon-endpoints", "cluster-name", "admin-secret", "fs-name", "volume-path"}
+	if err := flags.VerifyRequiredFlags(filesystemVolumeCmd, required); err != nil {
+		return err
+	}
+
+	rook.SetLogLevel()
+
+	rook.LogStartupInfo(filesystemVolumeCmd.Flags())
+
+	clusterInfo.Monitors = mondaemon.ParseMonEndpoints(cfg.monEndpoints)
+	config := &filedaemon.VolumeConfig{
+		ClusterInfo:    &clusterInfo,
+		FilesystemName: volumeFilesystemName,
+		Path:           volumePath,
+		QuotaBytes:     volumeQuotaBytes,
+	}
+
+	var err error
+	mounter := mount.New("")
+	if deleteVolume {
+		err = filedaemon.DeleteVolume(mounter, config)
+	} else {
+		err = filedaemon.CreateVolume(mounter, config)
+	}
+	if err != nil {
+		rook.TerminateFatal(err)
+	}
+
+	return nil
+}
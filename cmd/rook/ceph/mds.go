@@ -60,6 +60,10 @@ func initMds(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if err := verifyNetworks(); err != nil {
+		return err
+	}
+
 	rook.SetLogLevel()
 
 	rook.LogStartupInfo(mdsCmd.Flags())
@@ -57,6 +57,10 @@ func initMgr(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if err := verifyNetworks(); err != nil {
+		return err
+	}
+
 	rook.SetLogLevel()
 
 	rook.LogStartupInfo(mgrCmd.Flags())
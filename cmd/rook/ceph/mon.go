@@ -23,6 +23,7 @@ import (
 
 	"github.com/go-ini/ini"
 	"github.com/rook/rook/cmd/rook/rook"
+	"github.com/rook/rook/pkg/clusterd"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	mondaemon "github.com/rook/rook/pkg/daemon/ceph/mon"
 	"github.com/rook/rook/pkg/util/flags"
@@ -60,6 +61,11 @@ func initMon(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// the mon keeps the address of its service, which clients in the public network reach
+	if err := clusterd.VerifyNetworkInfo(cfg.NetworkInfo()); err != nil {
+		return err
+	}
+
 	rook.SetLogLevel()
 
 	rook.LogStartupInfo(monCmd.Flags())
@@ -65,6 +65,7 @@ func startOperator(cmd *cobra.Command, args []string) error {
 	context.Clientset = clientset
 	context.APIExtensionClientset = apiExtClientset
 	context.RookClientset = rookClientset
+	context.Recorder = k8sutil.NewEventRecorder(clientset, containerName)
 	volumeAttachment, err := attachment.New(context)
 	if err != nil {
 		rook.TerminateFatal(err)
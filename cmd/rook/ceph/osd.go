@@ -59,12 +59,20 @@ var filestoreDeviceCmd = &cobra.Command{
 	Short:  "Runs the ceph daemon for a filestore device",
 	Hidden: true,
 }
+var cephVolumeActivateCmd = &cobra.Command{
+	Use:    "activate",
+	Short:  "Activates an osd prepared by ceph-volume and runs the ceph daemon",
+	Hidden: true,
+}
 var (
 	osdDataDeviceFilter string
 	ownerRefID          string
 	mountSourcePath     string
 	mountPath           string
 	osdID               int
+	osdUUID             string
+	osdCephVolume       bool
+	osdFilestore        bool
 	copyBinariesPath    string
 )
 
@@ -77,11 +85,15 @@ func addOSDFlags(command *cobra.Command) {
 	provisionCmd.Flags().StringVar(&osdDataDeviceFilter, "data-device-filter", "", "a regex filter for the device names to use, or \"all\"")
 	provisionCmd.Flags().StringVar(&cfg.directories, "data-directories", "", "comma separated list of directory paths to use for storage")
 	provisionCmd.Flags().StringVar(&cfg.metadataDevice, "metadata-device", "", "device to use for metadata (e.g. a high performance SSD/NVMe device)")
+	provisionCmd.Flags().StringVar(&cfg.pvcDevicePath, "pvc-device-path", "", "path of the block device of the pvc to use for storage")
+	provisionCmd.Flags().StringVar(&cfg.deviceClasses, "device-classes", "", "comma separated list of device:class pairs to override the detected crush device class of the devices")
+	provisionCmd.Flags().StringVar(&cfg.encryptedDevices, "encrypted-devices", "", "comma separated list of device:bool pairs to override whether the osds on the devices are encrypted")
 	provisionCmd.Flags().BoolVar(&cfg.forceFormat, "force-format", false,
 		"true to force the format of any specified devices, even if they already have a filesystem.  BE CAREFUL!")
 
 	// flags for generating the osd config
 	osdConfigCmd.Flags().IntVar(&osdID, "osd-id", -1, "osd id for which to generate config")
+	osdConfigCmd.Flags().BoolVar(&osdCephVolume, "osd-ceph-volume", false, "true if the osd was prepared by ceph-volume")
 
 	// flag for copying the rook binaries for use by a ceph container
 	copyBinariesCmd.Flags().StringVar(&copyBinariesPath, "path", "", "Copy the rook binaries to this path for use by a ceph container")
@@ -90,11 +102,17 @@ func addOSDFlags(command *cobra.Command) {
 	filestoreDeviceCmd.Flags().StringVar(&mountSourcePath, "source-path", "", "the source path of the device to mount")
 	filestoreDeviceCmd.Flags().StringVar(&mountPath, "mount-path", "", "the path where the device should be mounted")
 
+	// flags for activating an osd prepared by ceph-volume
+	cephVolumeActivateCmd.Flags().IntVar(&osdID, "osd-id", -1, "the id of the osd to activate")
+	cephVolumeActivateCmd.Flags().StringVar(&osdUUID, "osd-uuid", "", "the uuid of the osd to activate")
+	cephVolumeActivateCmd.Flags().BoolVar(&osdFilestore, "filestore", false, "true if the osd is a filestore osd")
+
 	// add the subcommands to the parent osd command
 	osdCmd.AddCommand(osdConfigCmd)
 	osdCmd.AddCommand(copyBinariesCmd)
 	osdCmd.AddCommand(provisionCmd)
 	osdCmd.AddCommand(filestoreDeviceCmd)
+	osdCmd.AddCommand(cephVolumeActivateCmd)
 }
 
 func addOSDConfigFlags(command *cobra.Command) {
@@ -107,6 +125,8 @@ func addOSDConfigFlags(command *cobra.Command) {
 	command.Flags().IntVar(&cfg.storeConfig.DatabaseSizeMB, "osd-database-size", osdcfg.DBDefaultSizeMB, "default size (MB) for OSD database (bluestore)")
 	command.Flags().IntVar(&cfg.storeConfig.JournalSizeMB, "osd-journal-size", osdcfg.JournalDefaultSizeMB, "default size (MB) for OSD journal (filestore)")
 	command.Flags().StringVar(&cfg.storeConfig.StoreType, "osd-store", "", "type of backing OSD store to use (bluestore or filestore)")
+	command.Flags().BoolVar(&cfg.storeConfig.EncryptedDevice, "osd-encrypted-device", false, "true to encrypt the OSDs on devices with dm-crypt")
+	command.Flags().StringVar(&cfg.storeConfig.Backend, "osd-backend", "", "backend that provisions the OSDs on devices (rook or ceph-volume)")
 }
 
 func init() {
@@ -117,11 +137,13 @@ func init() {
 	flags.SetFlagsFromEnv(copyBinariesCmd.Flags(), rook.RookEnvVarPrefix)
 	flags.SetFlagsFromEnv(provisionCmd.Flags(), rook.RookEnvVarPrefix)
 	flags.SetFlagsFromEnv(filestoreDeviceCmd.Flags(), rook.RookEnvVarPrefix)
+	flags.SetFlagsFromEnv(cephVolumeActivateCmd.Flags(), rook.RookEnvVarPrefix)
 
 	osdConfigCmd.RunE = writeOSDConfig
 	copyBinariesCmd.RunE = copyRookBinaries
 	provisionCmd.RunE = prepareOSD
 	filestoreDeviceCmd.RunE = runFilestoreDeviceOSD
+	cephVolumeActivateCmd.RunE = runCephVolumeOSD
 }
 
 // Start the osd daemon for filestore running on a device
@@ -131,6 +153,10 @@ func runFilestoreDeviceOSD(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if err := verifyNetworks(); err != nil {
+		return err
+	}
+
 	args = append(args, []string{
 		fmt.Sprintf("--public-addr=%s", cfg.NetworkInfo().PublicAddr),
 		fmt.Sprintf("--cluster-addr=%s", cfg.NetworkInfo().ClusterAddr),
@@ -146,6 +172,35 @@ func runFilestoreDeviceOSD(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// Activate an osd prepared by ceph-volume and start the osd daemon
+func runCephVolumeOSD(cmd *cobra.Command, args []string) error {
+	required := []string{"osd-uuid"}
+	if err := flags.VerifyRequiredFlags(cephVolumeActivateCmd, required); err != nil {
+		return err
+	}
+	if osdID == -1 {
+		return fmt.Errorf("osd id not specified")
+	}
+
+	if err := verifyNetworks(); err != nil {
+		return err
+	}
+
+	args = append(args, []string{
+		fmt.Sprintf("--public-addr=%s", cfg.NetworkInfo().PublicAddr),
+		fmt.Sprintf("--cluster-addr=%s", cfg.NetworkInfo().ClusterAddr),
+	}...)
+
+	commonOSDInit(cephVolumeActivateCmd)
+
+	context := createContext()
+	err := osddaemon.RunCephVolumeOSD(context, osdID, osdUUID, osdFilestore, args)
+	if err != nil {
+		rook.TerminateFatal(err)
+	}
+	return nil
+}
+
 func verifyConfigFlags(configCmd *cobra.Command) error {
 	required := []string{"cluster-id", "node-name"}
 	if err := flags.VerifyRequiredFlags(configCmd, required); err != nil {
@@ -165,6 +220,9 @@ func writeOSDConfig(cmd *cobra.Command, args []string) error {
 	if osdID == -1 {
 		return fmt.Errorf("osd id not specified")
 	}
+	if err := verifyNetworks(); err != nil {
+		return err
+	}
 
 	clientset, _, _, err := rook.GetClientset()
 	if err != nil {
@@ -181,6 +239,13 @@ func writeOSDConfig(cmd *cobra.Command, args []string) error {
 	crushLocation := strings.Join(locArgs, " ")
 	kv := k8sutil.NewConfigMapKVStore(clusterInfo.Name, clientset, metav1.OwnerReference{})
 
+	if osdCephVolume {
+		if err := osddaemon.WriteCephVolumeConfigFile(context, &clusterInfo, osdID, crushLocation); err != nil {
+			logger.Errorf("failed to write osd config file. %+v", err)
+		}
+		return nil
+	}
+
 	if err := osddaemon.WriteConfigFile(context, &clusterInfo, kv, osdID, cfg.storeConfig, cfg.nodeName, crushLocation); err != nil {
 		logger.Errorf("failed to write osd config file. %+v", err)
 	}
@@ -209,6 +274,10 @@ func prepareOSD(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if err := verifyNetworks(); err != nil {
+		return err
+	}
+
 	var dataDevices string
 	var usingDeviceFilter bool
 	if osdDataDeviceFilter != "" {
@@ -221,6 +290,17 @@ func prepareOSD(cmd *cobra.Command, args []string) error {
 	} else {
 		dataDevices = cfg.devices
 	}
+	if cfg.pvcDevicePath != "" && dataDevices != "" {
+		return fmt.Errorf("Only one of --pvc-device-path and --data-devices or --data-device-filter can be specified.")
+	}
+	deviceClasses, err := osddaemon.ParseDeviceClasses(cfg.deviceClasses)
+	if err != nil {
+		return fmt.Errorf("invalid device classes. %+v", err)
+	}
+	encryptedDevices, err := osddaemon.ParseEncryptedDevices(cfg.encryptedDevices)
+	if err != nil {
+		return fmt.Errorf("invalid encrypted devices. %+v", err)
+	}
 
 	clientset, _, rookClientset, err := rook.GetClientset()
 	if err != nil {
@@ -241,8 +321,8 @@ func prepareOSD(cmd *cobra.Command, args []string) error {
 	forceFormat := false
 	ownerRef := cluster.ClusterOwnerRef(clusterInfo.Name, ownerRefID)
 	kv := k8sutil.NewConfigMapKVStore(clusterInfo.Name, clientset, ownerRef)
-	agent := osddaemon.NewAgent(context, dataDevices, usingDeviceFilter, cfg.metadataDevice, cfg.directories, forceFormat,
-		crushLocation, cfg.storeConfig, &clusterInfo, cfg.nodeName, kv)
+	agent := osddaemon.NewAgent(context, dataDevices, usingDeviceFilter, cfg.metadataDevice, cfg.directories, cfg.pvcDevicePath, deviceClasses, encryptedDevices,
+		forceFormat, crushLocation, cfg.storeConfig, &clusterInfo, cfg.nodeName, kv, ownerRef)
 
 	err = osddaemon.Provision(context, agent)
 	if err != nil {
@@ -0,0 +1,91 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph
+
+import (
+	"strings"
+
+	"github.com/rook/rook/cmd/rook/rook"
+	mondaemon "github.com/rook/rook/pkg/daemon/ceph/mon"
+	rbddaemon "github.com/rook/rook/pkg/daemon/ceph/rbd"
+	"github.com/rook/rook/pkg/util/flags"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rbdMirrorName    string
+	rbdMirrorKeyring string
+	rbdMirrorPeers   string
+)
+
+var rbdMirrorCmd = &cobra.Command{
+	Use:    rbddaemon.InitCommand,
+	Short:  "Generates rbd-mirror config",
+	Hidden: true,
+}
+
+func init() {
+	rbdMirrorCmd.Flags().StringVar(&rbdMirrorName, "rbd-mirror-name", "", "name of the rbd-mirror daemon")
+	rbdMirrorCmd.Flags().StringVar(&rbdMirrorKeyring, "rbd-mirror-keyring", "", "the rbd-mirror keyring")
+	rbdMirrorCmd.Flags().StringVar(&rbdMirrorPeers, "peers", "", "comma-separated names of the peer clusters")
+	addCephFlags(rbdMirrorCmd)
+
+	flags.SetFlagsFromEnv(rbdMirrorCmd.Flags(), rook.RookEnvVarPrefix)
+
+	rbdMirrorCmd.RunE = initRBDMirror
+}
+
+func initRBDMirror(cmd *cobra.Command, args []string) error {
+	required := []string{
+		"rbd-mirror-name", "rbd-mirror-keyring",
+		"mon-endpoints", "cluster-name", "mon-secret", "admin-secret"}
+	if err := flags.VerifyRequiredFlags(rbdMirrorCmd, required); err != nil {
+		return err
+	}
+
+	if err := verifyRenamedFlags(rbdMirrorCmd); err != nil {
+		return err
+	}
+
+	if err := verifyNetworks(); err != nil {
+		return err
+	}
+
+	rook.SetLogLevel()
+
+	rook.LogStartupInfo(rbdMirrorCmd.Flags())
+
+	var peers []string
+	if rbdMirrorPeers != "" {
+		peers = strings.Split(rbdMirrorPeers, ",")
+	}
+
+	clusterInfo.Monitors = mondaemon.ParseMonEndpoints(cfg.monEndpoints)
+	config := &rbddaemon.Config{
+		Name:        rbdMirrorName,
+		Keyring:     rbdMirrorKeyring,
+		Peers:       peers,
+		ClusterInfo: &clusterInfo,
+	}
+
+	err := rbddaemon.Initialize(createContext(), config)
+	if err != nil {
+		rook.TerminateFatal(err)
+	}
+
+	return nil
+}
@@ -66,6 +66,10 @@ func initRGW(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if err := verifyNetworks(); err != nil {
+		return err
+	}
+
 	if rgwPort == 0 && rgwSecurePort == 0 {
 		return fmt.Errorf("port or secure port are required")
 	}
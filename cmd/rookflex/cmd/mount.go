@@ -22,19 +22,13 @@ import (
 	"fmt"
 	"net/rpc"
 	"os"
-	"strings"
 
 	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume"
 	"github.com/spf13/cobra"
 	k8smount "k8s.io/kubernetes/pkg/util/mount"
-	"k8s.io/kubernetes/pkg/util/version"
 	"k8s.io/kubernetes/pkg/volume/util"
 )
 
-const (
-	mds_namespace_kernel_support = "4.7"
-)
-
 var (
 	mountCmd = &cobra.Command{
 		Use:   "mount",
@@ -101,6 +95,9 @@ func handleMount(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// Grow the filesystem in case the volume was expanded since it was formatted
+	resizeFilesystem(client, mounter, devicePath, globalVolumeMountPath, opts)
+
 	// Mount the global mount path to pod mount dir
 	err = mount(client, mounter, globalVolumeMountPath, opts)
 	if err != nil {
@@ -158,6 +155,33 @@ func mountDevice(client *rpc.Client, mounter *k8smount.SafeFormatAndMount, devic
 	return nil
 }
 
+// resizeFilesystem grows the filesystem to the size of the device. The volume may have been expanded since the filesystem
+// was created. Growing the filesystem is a no-op if the filesystem already fills the device.
+func resizeFilesystem(client *rpc.Client, mounter *k8smount.SafeFormatAndMount, devicePath, globalVolumeMountPath string, opts *flexvolume.AttachOptions) {
+	if opts.RW == flexvolume.ReadOnly {
+		return
+	}
+
+	var output []byte
+	var err error
+	switch opts.FsType {
+	case "", "ext3", "ext4":
+		// ext filesystems are grown online with the device path
+		output, err = mounter.Exec.Run("resize2fs", devicePath)
+	case "xfs":
+		// xfs filesystems are grown online with the mount path
+		output, err = mounter.Exec.Run("xfs_growfs", "-d", globalVolumeMountPath)
+	default:
+		log(client, fmt.Sprintf("growing filesystem %s on volume %s/%s is not supported", opts.FsType, opts.Pool, opts.Image), false)
+		return
+	}
+	if err != nil {
+		log(client, fmt.Sprintf("failed to grow filesystem on volume %s/%s: %v. output: %s", opts.Pool, opts.Image, err, string(output)), true)
+		return
+	}
+	log(client, fmt.Sprintf("filesystem on volume %s/%s fills device %s", opts.Pool, opts.Image, devicePath), false)
+}
+
 func mount(client *rpc.Client, mounter *k8smount.SafeFormatAndMount, globalVolumeMountPath string, opts *flexvolume.AttachOptions) error {
 
 	log(client, fmt.Sprintf("mounting global mount path %s on %s", globalVolumeMountPath, opts.MountDir), false)
@@ -215,49 +239,24 @@ func mountCephFS(client *rpc.Client, opts *flexvolume.AttachOptions) error {
 
 	// Get client access info
 	var clientAccessInfo flexvolume.ClientAccessInfo
-	err := client.Call("Controller.GetClientAccessInfo", opts.ClusterNamespace, &clientAccessInfo)
+	err := client.Call("Controller.GetClientAccessInfo", opts, &clientAccessInfo)
 	if err != nil {
 		errorMsg := fmt.Sprintf("Attach filesystem %s on cluster %s failed: %v", opts.FsName, opts.ClusterNamespace, err)
 		log(client, errorMsg, true)
 		return fmt.Errorf("Rook: %v", errorMsg)
 	}
 
-	// if a path has not been provided, just use the root of the filesystem.
-	// otherwise, ensure that the provided path starts with the path separator char.
-	path := string(os.PathSeparator)
-	if opts.Path != "" {
-		path = opts.Path
-		if !strings.HasPrefix(path, string(os.PathSeparator)) {
-			path = string(os.PathSeparator) + path
-		}
-	}
-
-	options := []string{fmt.Sprintf("name=%s", clientAccessInfo.UserName), fmt.Sprintf("secret=%s", clientAccessInfo.SecretKey)}
-
 	// Get kernel version
 	var kernelVersion string
-	err = client.Call("Controller.GetKernelVersion", struct{}{} /* no inputs */, &kernelVersion)
+	if err := client.Call("Controller.GetKernelVersion", struct{}{} /* no inputs */, &kernelVersion); err != nil {
+		kernelVersion = ""
+	}
+	options, err := flexvolume.CephFSMountOptions(clientAccessInfo, opts.FsName, kernelVersion)
 	if err != nil {
-		log(client, fmt.Sprintf("WARNING: The node kernel version cannot be detected. The kernel version has to be at least %s in order to specify a filesystem namespace."+
-			" If you have multiple ceph filesystems, the result could be inconsistent", mds_namespace_kernel_support), false)
-	} else {
-		kernelVersionParsed, err := version.ParseGeneric(kernelVersion)
-		if err != nil {
-			log(client, fmt.Sprintf("WARNING: The node kernel version %s cannot be parsed. The kernel version has to be at least %s in order to specify a filesystem namespace."+
-				" If you have multiple ceph filesystems, the result could be inconsistent", kernelVersion, mds_namespace_kernel_support), false)
-		} else {
-			if kernelVersionParsed.AtLeast(version.MustParseGeneric(mds_namespace_kernel_support)) {
-				options = append(options, fmt.Sprintf("mds_namespace=%s", opts.FsName))
-			} else {
-				log(client,
-					fmt.Sprintf("WARNING: The node kernel version is %s, which do not support multiple ceph filesystems. "+
-						"The kernel version has to be at least %s. If you have multiple ceph filesystems, the result could be inconsistent",
-						kernelVersion, mds_namespace_kernel_support), false)
-			}
-		}
+		log(client, fmt.Sprintf("WARNING: %v", err), false)
 	}
 
-	devicePath := fmt.Sprintf("%s:%s", strings.Join(clientAccessInfo.MonAddresses, ","), path)
+	devicePath := flexvolume.CephFSMountDevice(clientAccessInfo.MonAddresses, opts.Path)
 
 	log(client, fmt.Sprintf("mounting ceph filesystem %s on %s to %s", opts.FsName, devicePath, opts.MountDir), false)
 	mounter := getMounter()
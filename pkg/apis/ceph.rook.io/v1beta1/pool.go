@@ -18,7 +18,7 @@ package v1beta1
 import "github.com/rook/rook/pkg/daemon/ceph/model"
 
 func (p *PoolSpec) ToModel(name string) *model.Pool {
-	pool := &model.Pool{Name: name, FailureDomain: p.FailureDomain, CrushRoot: p.CrushRoot}
+	pool := &model.Pool{Name: name, FailureDomain: p.FailureDomain, CrushRoot: p.CrushRoot, DeviceClass: p.DeviceClass}
 	r := p.Replication()
 	if r != nil {
 		pool.ReplicatedConfig.Size = r.Size
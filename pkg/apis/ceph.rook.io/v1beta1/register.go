@@ -61,6 +61,12 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&FilesystemList{},
 		&ObjectStore{},
 		&ObjectStoreList{},
+		&ObjectStoreUser{},
+		&ObjectStoreUserList{},
+		&ObjectBucketClaim{},
+		&ObjectBucketClaimList{},
+		&VolumeSnapshot{},
+		&VolumeSnapshotList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
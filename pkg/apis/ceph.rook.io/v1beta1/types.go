@@ -17,6 +17,7 @@ package v1beta1
 
 import (
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	rook "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
@@ -29,7 +30,6 @@ import (
 // ***************************************************************************
 
 // +genclient
-// +genclient:noStatus
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 type Cluster struct {
@@ -74,6 +74,22 @@ type ClusterSpec struct {
 
 	// Dashboard settings
 	Dashboard DashboardSpec `json:"dashboard,omitempty"`
+
+	// Ceph config settings by section of the config file, such as global, mon, osd, mds or client.rgw.
+	// The settings of each section are a map of the setting names to their values.
+	CephConfig map[string]map[string]string `json:"cephConfig,omitempty"`
+
+	// The actions taken by the operator on the OSDs that are down for longer than the grace period
+	OSDRemediation OSDRemediationSpec `json:"osdRemediation,omitempty"`
+
+	// The data of the cluster that is removed from the hosts when the cluster CRD is deleted
+	CleanupPolicy CleanupPolicySpec `json:"cleanupPolicy,omitempty"`
+
+	// The management of the disruptions of the daemons when nodes are drained
+	DisruptionManagement DisruptionManagementSpec `json:"disruptionManagement,omitempty"`
+
+	// The rbd-mirror daemons that replicate the images of the mirrored pools to and from the peer clusters
+	RBDMirroring RBDMirroringSpec `json:"rbdMirroring,omitempty"`
 }
 
 // VersionSpec represents the settings for the Ceph version that Rook is orchestrating.
@@ -88,6 +104,46 @@ type CephVersionSpec struct {
 	AllowUnsupported bool `json:"allowUnsupported,omitempty"`
 }
 
+// CleanupPolicySpec represents the data that is removed from the hosts of the mons and OSDs by jobs after the daemons
+// of a deleted cluster are stopped. The data of the cluster cannot be recovered after the cleanup.
+type CleanupPolicySpec struct {
+	// Whether to delete the dataDirHostPath of the cluster on the hosts
+	DeleteDataDirOnHosts bool `json:"deleteDataDirOnHosts,omitempty"`
+	// Whether to zap the devices on the hosts with only the partitions created by Rook for OSDs
+	WipeDevices bool `json:"wipeDevices,omitempty"`
+}
+
+// DisruptionManagementSpec represents the management of the disruptions of the daemons when nodes are drained
+type DisruptionManagementSpec struct {
+	// Whether to create PodDisruptionBudgets for the daemons, and to set noout on the OSDs of the failure domain of a drained node
+	// until they are back up
+	ManagePodBudgets bool `json:"managePodBudgets,omitempty"`
+}
+
+// RBDMirroringSpec represents the rbd-mirror daemons of the cluster and the peer clusters they replicate with
+type RBDMirroringSpec struct {
+	// The number of rbd-mirror daemons to run. No daemons are started if zero.
+	Workers int `json:"workers,omitempty"`
+	// The names of the secrets in the namespace of the cluster with the mon hosts and keys of the peer clusters
+	Peers []string `json:"peers,omitempty"`
+}
+
+// OSDRemediationSpec represents the actions taken on the OSDs that are down for longer than the grace period.
+// The actions are taken in order for an OSD that stays down, and are skipped if they are not enabled.
+type OSDRemediationSpec struct {
+	// Whether to restart the pod of the OSD
+	Restart bool `json:"restart,omitempty"`
+	// Whether to mark the OSD out so its data is rebalanced to the other OSDs
+	MarkOut bool `json:"markOut,omitempty"`
+	// Whether to purge the OSD after it is out and the data has rebalanced, and provision the OSDs of its node again
+	// to create a new OSD on a replaced disk
+	Replace bool `json:"replace,omitempty"`
+	// The minimum number of minutes between two actions in the cluster. The default is 10 minutes.
+	IntervalMinutes int `json:"intervalMinutes,omitempty"`
+	// The maximum number of OSDs that can be down in the cluster for the actions to be taken. The default is 1.
+	MaxDownOSDs int `json:"maxDownOSDs,omitempty"`
+}
+
 // DashboardSpec represents the settings for the Ceph dashboard
 type DashboardSpec struct {
 	// Whether to enable the dashboard
@@ -99,20 +155,113 @@ type DashboardSpec struct {
 type ClusterStatus struct {
 	State   ClusterState `json:"state,omitempty"`
 	Message string       `json:"message,omitempty"`
+	// The version of Ceph that is currently running in the cluster
+	CephVersion CephVersionStatus `json:"cephVersion,omitempty"`
+	// The progress of an upgrade to a new Ceph image, if one is in progress
+	Upgrade UpgradeStatus `json:"upgrade,omitempty"`
+	// The health and capacity of the cluster as last reported by Ceph
+	CephStatus *CephStatus `json:"ceph,omitempty"`
+}
+
+// CephStatus represents the health and capacity of the Ceph cluster
+type CephStatus struct {
+	// Health is the health of the cluster: HEALTH_OK, HEALTH_WARN or HEALTH_ERR
+	Health string `json:"health,omitempty"`
+	// Details are the failing health checks by the name of the check
+	Details map[string]CephHealthMessage `json:"details,omitempty"`
+	// LastChecked is the time the status was last read from Ceph
+	LastChecked string `json:"lastChecked,omitempty"`
+	// Mons is the quorum of the mons
+	Mons CephMonsStatus `json:"mons,omitempty"`
+	// OSDs are the number of OSDs that are up and in
+	OSDs CephOSDsStatus `json:"osds,omitempty"`
+	// PGs are the number of placement groups in each state
+	PGs CephPGsStatus `json:"pgs,omitempty"`
+	// Capacity is the raw capacity of the OSDs
+	Capacity CephCapacity `json:"capacity,omitempty"`
+}
+
+// CephHealthMessage represents a failing health check of the Ceph cluster
+type CephHealthMessage struct {
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// CephMonsStatus represents the quorum of the mons
+type CephMonsStatus struct {
+	// Quorum is the names of the mons in quorum
+	Quorum []string `json:"quorum,omitempty"`
+	// Total is the number of mons in the mon map
+	Total int `json:"total"`
+}
+
+// CephOSDsStatus represents the number of OSDs in the cluster
+type CephOSDsStatus struct {
+	Total int `json:"total"`
+	Up    int `json:"up"`
+	In    int `json:"in"`
+}
+
+// CephPGsStatus represents the states of the placement groups
+type CephPGsStatus struct {
+	Total int `json:"total"`
+	// States are the number of placement groups by state, such as active+clean
+	States map[string]int `json:"states,omitempty"`
+}
+
+// CephCapacity represents the raw capacity of the OSDs in bytes
+type CephCapacity struct {
+	BytesTotal     uint64 `json:"bytesTotal"`
+	BytesUsed      uint64 `json:"bytesUsed"`
+	BytesAvailable uint64 `json:"bytesAvailable"`
 }
 
+// CephVersionStatus represents the version of Ceph that all the daemons in the cluster are running
+type CephVersionStatus struct {
+	// Image is the container image the daemons are running
+	Image string `json:"image,omitempty"`
+	// The name of the major release of Ceph running in the cluster
+	Name string `json:"name,omitempty"`
+}
+
+// UpgradeStatus represents the progress of rolling the ceph daemons to a new image
+type UpgradeStatus struct {
+	// Image is the container image the daemons are being upgraded to
+	Image string `json:"image,omitempty"`
+	// Phase is the type of daemon currently being upgraded
+	Phase UpgradePhase `json:"phase,omitempty"`
+	// Message describes the progress of the upgrade or the reason it is paused
+	Message string `json:"message,omitempty"`
+}
+
+type UpgradePhase string
+
 type ClusterState string
 
 const (
-	ClusterStateCreating ClusterState = "Creating"
-	ClusterStateCreated  ClusterState = "Created"
-	ClusterStateUpdating ClusterState = "Updating"
-	ClusterStateError    ClusterState = "Error"
+	ClusterStateCreating  ClusterState = "Creating"
+	ClusterStateCreated   ClusterState = "Created"
+	ClusterStateUpdating  ClusterState = "Updating"
+	ClusterStateUpgrading ClusterState = "Upgrading"
+	ClusterStateDeleting  ClusterState = "Deleting"
+	ClusterStateError     ClusterState = "Error"
+)
+
+const (
+	UpgradePhaseMon       UpgradePhase = "mon"
+	UpgradePhaseMgr       UpgradePhase = "mgr"
+	UpgradePhaseOSD       UpgradePhase = "osd"
+	UpgradePhaseMDS       UpgradePhase = "mds"
+	UpgradePhaseRGW       UpgradePhase = "rgw"
+	UpgradePhaseRBDMirror UpgradePhase = "rbd-mirror"
 )
 
 type MonSpec struct {
 	Count                int  `json:"count"`
 	AllowMultiplePerNode bool `json:"allowMultiplePerNode"`
+	// VolumeClaimTemplate is the template of the PVC that stores the data of each mon instead of the dataDirHostPath.
+	// The mons on PVCs are not bound to a node and move between the nodes with their PVC.
+	VolumeClaimTemplate *v1.PersistentVolumeClaim `json:"volumeClaimTemplate,omitempty"`
 }
 
 // +genclient
@@ -122,7 +271,8 @@ type MonSpec struct {
 type Pool struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
-	Spec              PoolSpec `json:"spec"`
+	Spec              PoolSpec   `json:"spec"`
+	Status            PoolStatus `json:"status,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -141,13 +291,98 @@ type PoolSpec struct {
 	// The root of the crush hierarchy utilized by the pool
 	CrushRoot string `json:"crushRoot"`
 
+	// The crush device class of the OSDs the pool is placed on (e.g. hdd, ssd, or nvme)
+	DeviceClass string `json:"deviceClass"`
+
 	// The replication settings
 	Replicated ReplicatedSpec `json:"replicated"`
 
 	// The erasure code settings
 	ErasureCoded ErasureCodedSpec `json:"erasureCoded"`
+
+	// The minimum number of replicas or chunks of an object that must be available to serve IO. If not set, the default of Ceph is used.
+	MinSize uint `json:"minSize,omitempty"`
+
+	// The number of placement groups of the pool. If not set, the default of the cluster is used.
+	PGNum uint `json:"pgNum,omitempty"`
+
+	// The number of placement groups used for placement. If not set, it is the same as the pgNum.
+	PGPNum uint `json:"pgpNum,omitempty"`
+
+	// The quotas of the pool
+	Quotas QuotaSpec `json:"quotas,omitempty"`
+
+	// The inline compression of the data in the pool by bluestore
+	Compression CompressionSpec `json:"compression,omitempty"`
+
+	// Other properties of the pool set with "ceph osd pool set", such as target_size_ratio
+	Parameters map[string]string `json:"parameters,omitempty"`
+
+	// The mirroring of the images of the pool to the peer clusters of the cluster
+	RBDMirroring PoolMirroringSpec `json:"rbdMirroring,omitempty"`
+}
+
+// QuotaSpec represents the quotas of a pool, where zero means no quota
+type QuotaSpec struct {
+	// The maximum number of bytes stored in the pool
+	MaxBytes uint64 `json:"maxBytes,omitempty"`
+
+	// The maximum number of objects stored in the pool
+	MaxObjects uint64 `json:"maxObjects,omitempty"`
+}
+
+// CompressionSpec represents the inline compression of a pool
+type CompressionSpec struct {
+	// The compression mode: none, passive, aggressive or force
+	Mode string `json:"mode,omitempty"`
+
+	// The compression algorithm, such as snappy, zlib, zstd or lz4
+	Algorithm string `json:"algorithm,omitempty"`
 }
 
+// PoolMirroringSpec represents the mirroring of the images of a pool
+type PoolMirroringSpec struct {
+	// The mirroring mode: pool to mirror all the images with journaling enabled, or image to mirror only the images
+	// where mirroring is enabled. The pool is not mirrored if empty.
+	Mode string `json:"mode,omitempty"`
+}
+
+// PoolStatus represents the status of a pool
+type PoolStatus struct {
+	Phase   PoolPhase `json:"phase,omitempty"`
+	Message string    `json:"message,omitempty"`
+
+	// The properties of the pool in Ceph that differ from the spec, which were changed outside of the operator
+	Drift []PoolPropertyDrift `json:"drift,omitempty"`
+
+	// The health of the mirroring of the images of the pool, if the pool is mirrored
+	Mirroring *PoolMirroringStatus `json:"mirroring,omitempty"`
+}
+
+// PoolMirroringStatus represents the health of the mirroring of a pool as reported by "rbd mirror pool status"
+type PoolMirroringStatus struct {
+	// The health of the mirroring: OK, WARNING, ERROR or UNKNOWN
+	Health string `json:"health,omitempty"`
+
+	// The number of images in each mirroring state, such as replaying or stopped
+	States map[string]int `json:"states,omitempty"`
+}
+
+// PoolPropertyDrift represents a property of a pool in Ceph that does not have the value of the spec
+type PoolPropertyDrift struct {
+	Property string `json:"property"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+type PoolPhase string
+
+const (
+	PoolPhaseReady   PoolPhase = "Ready"
+	PoolPhaseDrifted PoolPhase = "Drifted"
+	PoolPhaseFailed  PoolPhase = "Failed"
+)
+
 // ReplicationSpec represents the spec for replication in a pool
 type ReplicatedSpec struct {
 	// Number of copies per object in a replicated storage pool, including the object itself (required for replicated pool type)
@@ -263,3 +498,134 @@ type GatewaySpec struct {
 	// The resource requirements for the rgw pods
 	Resources v1.ResourceRequirements `json:"resources"`
 }
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ObjectStoreUser represents an S3 user in an object store
+type ObjectStoreUser struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              ObjectStoreUserSpec `json:"spec"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type ObjectStoreUserList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []ObjectStoreUser `json:"items"`
+}
+
+// ObjectStoreUserSpec represent the spec of an object store user
+type ObjectStoreUserSpec struct {
+	// The name of the object store in the same namespace in which the user will be created
+	Store string `json:"store"`
+
+	// The display name of the user. If not set, the name of the user is used.
+	DisplayName string `json:"displayName,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ObjectBucketClaim represents a request for a bucket in an object store
+type ObjectBucketClaim struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              ObjectBucketClaimSpec   `json:"spec"`
+	Status            ObjectBucketClaimStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type ObjectBucketClaimList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []ObjectBucketClaim `json:"items"`
+}
+
+// ObjectBucketClaimSpec represent the spec of a bucket claim
+type ObjectBucketClaimSpec struct {
+	// The name of the storage class that defines the object store where the bucket is created
+	StorageClassName string `json:"storageClassName"`
+
+	// The name of the bucket. If not set, the name is generated from the namespace and name of the claim.
+	BucketName string `json:"bucketName,omitempty"`
+}
+
+// ObjectBucketClaimStatus represents the status of a bucket claim
+type ObjectBucketClaimStatus struct {
+	Phase   ObjectBucketClaimPhase `json:"phase,omitempty"`
+	Message string                 `json:"message,omitempty"`
+
+	// The bucket and the object store where the bucket was provisioned
+	BucketName           string `json:"bucketName,omitempty"`
+	ObjectStoreName      string `json:"objectStoreName,omitempty"`
+	ObjectStoreNamespace string `json:"objectStoreNamespace,omitempty"`
+
+	// The rgw user that owns the bucket
+	UserID string `json:"userId,omitempty"`
+
+	// Whether the bucket is purged or kept when the claim is deleted
+	ReclaimPolicy v1.PersistentVolumeReclaimPolicy `json:"reclaimPolicy,omitempty"`
+}
+
+type ObjectBucketClaimPhase string
+
+const (
+	ObjectBucketClaimPhasePending ObjectBucketClaimPhase = "Pending"
+	ObjectBucketClaimPhaseBound   ObjectBucketClaimPhase = "Bound"
+	ObjectBucketClaimPhaseFailed  ObjectBucketClaimPhase = "Failed"
+)
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// VolumeSnapshot represents a point-in-time snapshot of a block volume provisioned by rook
+type VolumeSnapshot struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              VolumeSnapshotSpec   `json:"spec"`
+	Status            VolumeSnapshotStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type VolumeSnapshotList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []VolumeSnapshot `json:"items"`
+}
+
+// VolumeSnapshotSpec represents the spec of a volume snapshot
+type VolumeSnapshotSpec struct {
+	// The name of the claim in the same namespace whose volume will be snapshotted
+	PersistentVolumeClaimName string `json:"persistentVolumeClaimName"`
+}
+
+// VolumeSnapshotStatus represents the status of a volume snapshot
+type VolumeSnapshotStatus struct {
+	Phase   VolumeSnapshotPhase `json:"phase,omitempty"`
+	Message string              `json:"message,omitempty"`
+
+	// The rbd image and snapshot that back the volume snapshot
+	ClusterNamespace string `json:"clusterNamespace,omitempty"`
+	Pool             string `json:"pool,omitempty"`
+	Image            string `json:"image,omitempty"`
+	SnapshotName     string `json:"snapshotName,omitempty"`
+
+	// The minimum size of a volume restored from the snapshot
+	RestoreSize resource.Quantity `json:"restoreSize,omitempty"`
+}
+
+type VolumeSnapshotPhase string
+
+const (
+	VolumeSnapshotPhasePending VolumeSnapshotPhase = "Pending"
+	VolumeSnapshotPhaseReady   VolumeSnapshotPhase = "Ready"
+	VolumeSnapshotPhaseFailed  VolumeSnapshotPhase = "Failed"
+)
@@ -22,9 +22,129 @@ package v1beta1
 
 import (
 	v1alpha2 "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	v1 "k8s.io/api/core/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephCapacity) DeepCopyInto(out *CephCapacity) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephCapacity.
+func (in *CephCapacity) DeepCopy() *CephCapacity {
+	if in == nil {
+		return nil
+	}
+	out := new(CephCapacity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephHealthMessage) DeepCopyInto(out *CephHealthMessage) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephHealthMessage.
+func (in *CephHealthMessage) DeepCopy() *CephHealthMessage {
+	if in == nil {
+		return nil
+	}
+	out := new(CephHealthMessage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephMonsStatus) DeepCopyInto(out *CephMonsStatus) {
+	*out = *in
+	if in.Quorum != nil {
+		in, out := &in.Quorum, &out.Quorum
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephMonsStatus.
+func (in *CephMonsStatus) DeepCopy() *CephMonsStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CephMonsStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephOSDsStatus) DeepCopyInto(out *CephOSDsStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephOSDsStatus.
+func (in *CephOSDsStatus) DeepCopy() *CephOSDsStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CephOSDsStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephPGsStatus) DeepCopyInto(out *CephPGsStatus) {
+	*out = *in
+	if in.States != nil {
+		in, out := &in.States, &out.States
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephPGsStatus.
+func (in *CephPGsStatus) DeepCopy() *CephPGsStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CephPGsStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephStatus) DeepCopyInto(out *CephStatus) {
+	*out = *in
+	if in.Details != nil {
+		in, out := &in.Details, &out.Details
+		*out = make(map[string]CephHealthMessage, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.Mons.DeepCopyInto(&out.Mons)
+	out.OSDs = in.OSDs
+	in.PGs.DeepCopyInto(&out.PGs)
+	out.Capacity = in.Capacity
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephStatus.
+func (in *CephStatus) DeepCopy() *CephStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CephStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CephVersionSpec) DeepCopyInto(out *CephVersionSpec) {
 	*out = *in
@@ -41,13 +161,45 @@ func (in *CephVersionSpec) DeepCopy() *CephVersionSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephVersionStatus) DeepCopyInto(out *CephVersionStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephVersionStatus.
+func (in *CephVersionStatus) DeepCopy() *CephVersionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CephVersionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CleanupPolicySpec) DeepCopyInto(out *CleanupPolicySpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CleanupPolicySpec.
+func (in *CleanupPolicySpec) DeepCopy() *CleanupPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CleanupPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Cluster) DeepCopyInto(out *Cluster) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -122,8 +274,29 @@ func (in *ClusterSpec) DeepCopyInto(out *ClusterSpec) {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
-	out.Mon = in.Mon
+	in.Mon.DeepCopyInto(&out.Mon)
 	out.Dashboard = in.Dashboard
+	if in.CephConfig != nil {
+		in, out := &in.CephConfig, &out.CephConfig
+		*out = make(map[string]map[string]string, len(*in))
+		for key, val := range *in {
+			var outVal map[string]string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make(map[string]string, len(*in))
+				for key, val := range *in {
+					(*out)[key] = val
+				}
+			}
+			(*out)[key] = outVal
+		}
+	}
+	out.OSDRemediation = in.OSDRemediation
+	out.CleanupPolicy = in.CleanupPolicy
+	out.DisruptionManagement = in.DisruptionManagement
+	in.RBDMirroring.DeepCopyInto(&out.RBDMirroring)
 	return
 }
 
@@ -140,6 +313,13 @@ func (in *ClusterSpec) DeepCopy() *ClusterSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterStatus) DeepCopyInto(out *ClusterStatus) {
 	*out = *in
+	out.CephVersion = in.CephVersion
+	out.Upgrade = in.Upgrade
+	if in.CephStatus != nil {
+		in, out := &in.CephStatus, &out.CephStatus
+		*out = new(CephStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -153,6 +333,22 @@ func (in *ClusterStatus) DeepCopy() *ClusterStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CompressionSpec) DeepCopyInto(out *CompressionSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CompressionSpec.
+func (in *CompressionSpec) DeepCopy() *CompressionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CompressionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DashboardSpec) DeepCopyInto(out *DashboardSpec) {
 	*out = *in
@@ -169,6 +365,22 @@ func (in *DashboardSpec) DeepCopy() *DashboardSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DisruptionManagementSpec) DeepCopyInto(out *DisruptionManagementSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DisruptionManagementSpec.
+func (in *DisruptionManagementSpec) DeepCopy() *DisruptionManagementSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DisruptionManagementSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ErasureCodedSpec) DeepCopyInto(out *ErasureCodedSpec) {
 	*out = *in
@@ -248,11 +460,13 @@ func (in *FilesystemList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FilesystemSpec) DeepCopyInto(out *FilesystemSpec) {
 	*out = *in
-	out.MetadataPool = in.MetadataPool
+	in.MetadataPool.DeepCopyInto(&out.MetadataPool)
 	if in.DataPools != nil {
 		in, out := &in.DataPools, &out.DataPools
 		*out = make([]PoolSpec, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	in.MetadataServer.DeepCopyInto(&out.MetadataServer)
 	return
@@ -307,6 +521,11 @@ func (in *MetadataServerSpec) DeepCopy() *MetadataServerSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MonSpec) DeepCopyInto(out *MonSpec) {
 	*out = *in
+	if in.VolumeClaimTemplate != nil {
+		in, out := &in.VolumeClaimTemplate, &out.VolumeClaimTemplate
+		*out = new(v1.PersistentVolumeClaim)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -320,6 +539,115 @@ func (in *MonSpec) DeepCopy() *MonSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OSDRemediationSpec) DeepCopyInto(out *OSDRemediationSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OSDRemediationSpec.
+func (in *OSDRemediationSpec) DeepCopy() *OSDRemediationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OSDRemediationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectBucketClaim) DeepCopyInto(out *ObjectBucketClaim) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	out.Status = in.Status
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectBucketClaim.
+func (in *ObjectBucketClaim) DeepCopy() *ObjectBucketClaim {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectBucketClaim)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ObjectBucketClaim) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectBucketClaimList) DeepCopyInto(out *ObjectBucketClaimList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ObjectBucketClaim, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectBucketClaimList.
+func (in *ObjectBucketClaimList) DeepCopy() *ObjectBucketClaimList {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectBucketClaimList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ObjectBucketClaimList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectBucketClaimSpec) DeepCopyInto(out *ObjectBucketClaimSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectBucketClaimSpec.
+func (in *ObjectBucketClaimSpec) DeepCopy() *ObjectBucketClaimSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectBucketClaimSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectBucketClaimStatus) DeepCopyInto(out *ObjectBucketClaimStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectBucketClaimStatus.
+func (in *ObjectBucketClaimStatus) DeepCopy() *ObjectBucketClaimStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectBucketClaimStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ObjectStore) DeepCopyInto(out *ObjectStore) {
 	*out = *in
@@ -383,8 +711,8 @@ func (in *ObjectStoreList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ObjectStoreSpec) DeepCopyInto(out *ObjectStoreSpec) {
 	*out = *in
-	out.MetadataPool = in.MetadataPool
-	out.DataPool = in.DataPool
+	in.MetadataPool.DeepCopyInto(&out.MetadataPool)
+	in.DataPool.DeepCopyInto(&out.DataPool)
 	in.Gateway.DeepCopyInto(&out.Gateway)
 	return
 }
@@ -400,7 +728,7 @@ func (in *ObjectStoreSpec) DeepCopy() *ObjectStoreSpec {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Pool) DeepCopyInto(out *Pool) {
+func (in *ObjectStoreUser) DeepCopyInto(out *ObjectStoreUser) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
@@ -408,6 +736,83 @@ func (in *Pool) DeepCopyInto(out *Pool) {
 	return
 }
 
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectStoreUser.
+func (in *ObjectStoreUser) DeepCopy() *ObjectStoreUser {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectStoreUser)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ObjectStoreUser) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectStoreUserList) DeepCopyInto(out *ObjectStoreUserList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ObjectStoreUser, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectStoreUserList.
+func (in *ObjectStoreUserList) DeepCopy() *ObjectStoreUserList {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectStoreUserList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ObjectStoreUserList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectStoreUserSpec) DeepCopyInto(out *ObjectStoreUserSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectStoreUserSpec.
+func (in *ObjectStoreUserSpec) DeepCopy() *ObjectStoreUserSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectStoreUserSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Pool) DeepCopyInto(out *Pool) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Pool.
 func (in *Pool) DeepCopy() *Pool {
 	if in == nil {
@@ -459,11 +864,76 @@ func (in *PoolList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PoolMirroringSpec) DeepCopyInto(out *PoolMirroringSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolMirroringSpec.
+func (in *PoolMirroringSpec) DeepCopy() *PoolMirroringSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PoolMirroringSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PoolMirroringStatus) DeepCopyInto(out *PoolMirroringStatus) {
+	*out = *in
+	if in.States != nil {
+		in, out := &in.States, &out.States
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolMirroringStatus.
+func (in *PoolMirroringStatus) DeepCopy() *PoolMirroringStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PoolMirroringStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PoolPropertyDrift) DeepCopyInto(out *PoolPropertyDrift) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolPropertyDrift.
+func (in *PoolPropertyDrift) DeepCopy() *PoolPropertyDrift {
+	if in == nil {
+		return nil
+	}
+	out := new(PoolPropertyDrift)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PoolSpec) DeepCopyInto(out *PoolSpec) {
 	*out = *in
 	out.Replicated = in.Replicated
 	out.ErasureCoded = in.ErasureCoded
+	out.Quotas = in.Quotas
+	out.Compression = in.Compression
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	out.RBDMirroring = in.RBDMirroring
 	return
 }
 
@@ -477,6 +947,69 @@ func (in *PoolSpec) DeepCopy() *PoolSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PoolStatus) DeepCopyInto(out *PoolStatus) {
+	*out = *in
+	if in.Drift != nil {
+		in, out := &in.Drift, &out.Drift
+		*out = make([]PoolPropertyDrift, len(*in))
+		copy(*out, *in)
+	}
+	if in.Mirroring != nil {
+		in, out := &in.Mirroring, &out.Mirroring
+		*out = new(PoolMirroringStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolStatus.
+func (in *PoolStatus) DeepCopy() *PoolStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PoolStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaSpec) DeepCopyInto(out *QuotaSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaSpec.
+func (in *QuotaSpec) DeepCopy() *QuotaSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RBDMirroringSpec) DeepCopyInto(out *RBDMirroringSpec) {
+	*out = *in
+	if in.Peers != nil {
+		in, out := &in.Peers, &out.Peers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RBDMirroringSpec.
+func (in *RBDMirroringSpec) DeepCopy() *RBDMirroringSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RBDMirroringSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReplicatedSpec) DeepCopyInto(out *ReplicatedSpec) {
 	*out = *in
@@ -492,3 +1025,113 @@ func (in *ReplicatedSpec) DeepCopy() *ReplicatedSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeStatus) DeepCopyInto(out *UpgradeStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeStatus.
+func (in *UpgradeStatus) DeepCopy() *UpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshot) DeepCopyInto(out *VolumeSnapshot) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshot.
+func (in *VolumeSnapshot) DeepCopy() *VolumeSnapshot {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshot)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VolumeSnapshot) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotList) DeepCopyInto(out *VolumeSnapshotList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]VolumeSnapshot, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotList.
+func (in *VolumeSnapshotList) DeepCopy() *VolumeSnapshotList {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VolumeSnapshotList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotSpec) DeepCopyInto(out *VolumeSnapshotSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotSpec.
+func (in *VolumeSnapshotSpec) DeepCopy() *VolumeSnapshotSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotStatus) DeepCopyInto(out *VolumeSnapshotStatus) {
+	*out = *in
+	out.RestoreSize = in.RestoreSize.DeepCopy()
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotStatus.
+func (in *VolumeSnapshotStatus) DeepCopy() *VolumeSnapshotStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -51,6 +51,22 @@ type NFSServerSpec struct {
 
 	// The parameters to configure the NFS export
 	Exports []ExportsSpec `json:"exports,omitempty"`
+
+	// The Rook Ceph cluster of the exports backed by a Ceph filesystem or object store
+	CephCluster *CephClusterSpec `json:"cephCluster,omitempty"`
+}
+
+// CephClusterSpec represents the Rook Ceph cluster used by the NFS server
+type CephClusterSpec struct {
+	// Namespace of the Rook Ceph cluster
+	Namespace string `json:"namespace"`
+
+	// RADOS pool where the grace period and the client recovery data of the NFS servers are stored.
+	// Required when there is more than one replica of the NFS server.
+	RecoveryPool string `json:"recoveryPool,omitempty"`
+
+	// RADOS namespace in the recovery pool. Default is the namespace of the NFS server.
+	RecoveryNamespace string `json:"recoveryNamespace,omitempty"`
 }
 
 // ExportsSpec represents the spec of NFS exports
@@ -63,6 +79,33 @@ type ExportsSpec struct {
 
 	// PVC from which the NFS daemon gets storage for sharing
 	PersistentVolumeClaim v1.PersistentVolumeClaimVolumeSource `json:"persistentVolumeClaim,omitempty"`
+
+	// Ceph filesystem that is shared instead of a PVC
+	CephFilesystem *CephFilesystemExportSpec `json:"cephFilesystem,omitempty"`
+
+	// Bucket of a Ceph object store that is shared instead of a PVC
+	CephObjectStore *CephObjectStoreExportSpec `json:"cephObjectStore,omitempty"`
+}
+
+// CephFilesystemExportSpec represents the spec of an export of a Ceph filesystem
+type CephFilesystemExportSpec struct {
+	// Name of the Ceph filesystem
+	Name string `json:"name"`
+
+	// The directory in the filesystem that is shared. Default is `/`
+	Path string `json:"path,omitempty"`
+}
+
+// CephObjectStoreExportSpec represents the spec of an export of a bucket of a Ceph object store
+type CephObjectStoreExportSpec struct {
+	// Name of the Ceph object store
+	Name string `json:"name"`
+
+	// Name of the object store user that owns the bucket. The keys of the user are read from its secret.
+	User string `json:"user"`
+
+	// The bucket that is shared
+	Bucket string `json:"bucket"`
 }
 
 // ServerSpec represents the spec for configuring the NFS server
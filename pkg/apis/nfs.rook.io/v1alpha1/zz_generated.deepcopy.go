@@ -45,11 +45,69 @@ func (in *AllowedClientsSpec) DeepCopy() *AllowedClientsSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephClusterSpec) DeepCopyInto(out *CephClusterSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephClusterSpec.
+func (in *CephClusterSpec) DeepCopy() *CephClusterSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CephClusterSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephFilesystemExportSpec) DeepCopyInto(out *CephFilesystemExportSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephFilesystemExportSpec.
+func (in *CephFilesystemExportSpec) DeepCopy() *CephFilesystemExportSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CephFilesystemExportSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephObjectStoreExportSpec) DeepCopyInto(out *CephObjectStoreExportSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephObjectStoreExportSpec.
+func (in *CephObjectStoreExportSpec) DeepCopy() *CephObjectStoreExportSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CephObjectStoreExportSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExportsSpec) DeepCopyInto(out *ExportsSpec) {
 	*out = *in
 	in.Server.DeepCopyInto(&out.Server)
 	out.PersistentVolumeClaim = in.PersistentVolumeClaim
+	if in.CephFilesystem != nil {
+		in, out := &in.CephFilesystem, &out.CephFilesystem
+		*out = new(CephFilesystemExportSpec)
+		**out = **in
+	}
+	if in.CephObjectStore != nil {
+		in, out := &in.CephObjectStore, &out.CephObjectStore
+		*out = new(CephObjectStoreExportSpec)
+		**out = **in
+	}
 	return
 }
 
@@ -133,6 +191,11 @@ func (in *NFSServerSpec) DeepCopyInto(out *NFSServerSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.CephCluster != nil {
+		in, out := &in.CephCluster, &out.CephCluster
+		*out = new(CephClusterSpec)
+		**out = **in
+	}
 	return
 }
 
@@ -36,6 +36,24 @@ type StorageScopeSpec struct {
 	Location        string            `json:"location,omitempty"`
 	Config          map[string]string `json:"config"`
 	Selection
+	StorageClassDeviceSets []StorageClassDeviceSet `json:"storageClassDeviceSets,omitempty"`
+	// The labels of the nodes that set the CRUSH location of the OSDs, by CRUSH bucket type. The default labels
+	// are used when it is not set, and the location is not set from labels when it is empty.
+	TopologyLabels map[string]string `json:"topologyLabels,omitempty"`
+}
+
+// StorageClassDeviceSet is a set of block mode PVCs of a storage class, where each PVC is consumed as the
+// raw device of an OSD
+type StorageClassDeviceSet struct {
+	// The name of the set, used as the prefix of the names of its PVCs
+	Name string `json:"name"`
+	// The number of PVCs, and OSDs, in the set
+	Count     int                     `json:"count"`
+	Resources v1.ResourceRequirements `json:"resources,omitempty"`
+	Placement Placement               `json:"placement,omitempty"`
+	Config    map[string]string       `json:"config"`
+	// The template of the PVCs. The volume mode of the PVCs is always Block.
+	VolumeClaimTemplate v1.PersistentVolumeClaim `json:"volumeClaimTemplate"`
 }
 
 type Node struct {
@@ -88,6 +106,22 @@ type NetworkSpec struct {
 
 	// Set of named ports that can be configured for this resource
 	Ports []PortSpec `json:"ports,omitempty"`
+
+	// PublicNetwork is the network that clients use to reach the daemons
+	PublicNetwork NetworkSelection `json:"publicNetwork,omitempty"`
+
+	// ClusterNetwork is the network for the replication and heartbeat traffic between the daemons
+	ClusterNetwork NetworkSelection `json:"clusterNetwork,omitempty"`
+}
+
+// NetworkSelection selects the network of the daemons by its CIDR, and optionally by
+// the name of a Multus network attachment that connects the pods to the network
+type NetworkSelection struct {
+	// CIDR of the network, e.g. 10.1.0.0/16
+	CIDR string `json:"cidr,omitempty"`
+
+	// Attachment is the name of the network attachment, in the form <name> or <namespace>/<name>
+	Attachment string `json:"attachment,omitempty"`
 }
 
 type PortSpec struct {
@@ -87,6 +87,22 @@ func (in *Directory) DeepCopy() *Directory {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkSelection) DeepCopyInto(out *NetworkSelection) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkSelection.
+func (in *NetworkSelection) DeepCopy() *NetworkSelection {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkSelection)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NetworkSpec) DeepCopyInto(out *NetworkSpec) {
 	*out = *in
@@ -96,6 +112,8 @@ func (in *NetworkSpec) DeepCopyInto(out *NetworkSpec) {
 		*out = make([]PortSpec, len(*in))
 		copy(*out, *in)
 	}
+	out.PublicNetwork = in.PublicNetwork
+	out.ClusterNetwork = in.ClusterNetwork
 	return
 }
 
@@ -289,6 +307,32 @@ func (in *Selection) DeepCopy() *Selection {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageClassDeviceSet) DeepCopyInto(out *StorageClassDeviceSet) {
+	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
+	in.Placement.DeepCopyInto(&out.Placement)
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.VolumeClaimTemplate.DeepCopyInto(&out.VolumeClaimTemplate)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageClassDeviceSet.
+func (in *StorageClassDeviceSet) DeepCopy() *StorageClassDeviceSet {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageClassDeviceSet)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StorageScopeSpec) DeepCopyInto(out *StorageScopeSpec) {
 	*out = *in
@@ -308,6 +352,20 @@ func (in *StorageScopeSpec) DeepCopyInto(out *StorageScopeSpec) {
 		}
 	}
 	in.Selection.DeepCopyInto(&out.Selection)
+	if in.StorageClassDeviceSets != nil {
+		in, out := &in.StorageClassDeviceSets, &out.StorageClassDeviceSets
+		*out = make([]StorageClassDeviceSet, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TopologyLabels != nil {
+		in, out := &in.TopologyLabels, &out.TopologyLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
@@ -29,8 +29,11 @@ type CephV1beta1Interface interface {
 	RESTClient() rest.Interface
 	ClustersGetter
 	FilesystemsGetter
+	ObjectBucketClaimsGetter
 	ObjectStoresGetter
+	ObjectStoreUsersGetter
 	PoolsGetter
+	VolumeSnapshotsGetter
 }
 
 // CephV1beta1Client is used to interact with features provided by the ceph.rook.io group.
@@ -46,14 +49,26 @@ func (c *CephV1beta1Client) Filesystems(namespace string) FilesystemInterface {
 	return newFilesystems(c, namespace)
 }
 
+func (c *CephV1beta1Client) ObjectBucketClaims(namespace string) ObjectBucketClaimInterface {
+	return newObjectBucketClaims(c, namespace)
+}
+
 func (c *CephV1beta1Client) ObjectStores(namespace string) ObjectStoreInterface {
 	return newObjectStores(c, namespace)
 }
 
+func (c *CephV1beta1Client) ObjectStoreUsers(namespace string) ObjectStoreUserInterface {
+	return newObjectStoreUsers(c, namespace)
+}
+
 func (c *CephV1beta1Client) Pools(namespace string) PoolInterface {
 	return newPools(c, namespace)
 }
 
+func (c *CephV1beta1Client) VolumeSnapshots(namespace string) VolumeSnapshotInterface {
+	return newVolumeSnapshots(c, namespace)
+}
+
 // NewForConfig creates a new CephV1beta1Client for the given config.
 func NewForConfig(c *rest.Config) (*CephV1beta1Client, error) {
 	config := *c
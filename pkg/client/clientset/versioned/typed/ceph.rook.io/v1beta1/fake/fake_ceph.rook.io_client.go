@@ -36,14 +36,26 @@ func (c *FakeCephV1beta1) Filesystems(namespace string) v1beta1.FilesystemInterf
 	return &FakeFilesystems{c, namespace}
 }
 
+func (c *FakeCephV1beta1) ObjectBucketClaims(namespace string) v1beta1.ObjectBucketClaimInterface {
+	return &FakeObjectBucketClaims{c, namespace}
+}
+
 func (c *FakeCephV1beta1) ObjectStores(namespace string) v1beta1.ObjectStoreInterface {
 	return &FakeObjectStores{c, namespace}
 }
 
+func (c *FakeCephV1beta1) ObjectStoreUsers(namespace string) v1beta1.ObjectStoreUserInterface {
+	return &FakeObjectStoreUsers{c, namespace}
+}
+
 func (c *FakeCephV1beta1) Pools(namespace string) v1beta1.PoolInterface {
 	return &FakePools{c, namespace}
 }
 
+func (c *FakeCephV1beta1) VolumeSnapshots(namespace string) v1beta1.VolumeSnapshotInterface {
+	return &FakeVolumeSnapshots{c, namespace}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeCephV1beta1) RESTClient() rest.Interface {
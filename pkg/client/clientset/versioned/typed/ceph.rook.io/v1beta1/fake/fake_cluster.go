@@ -100,6 +100,18 @@ func (c *FakeClusters) Update(cluster *v1beta1.Cluster) (result *v1beta1.Cluster
 	return obj.(*v1beta1.Cluster), err
 }
 
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeClusters) UpdateStatus(cluster *v1beta1.Cluster) (*v1beta1.Cluster, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(clustersResource, "status", c.ns, cluster), &v1beta1.Cluster{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.Cluster), err
+}
+
 // Delete takes name of the cluster and deletes it. Returns an error if one occurs.
 func (c *FakeClusters) Delete(name string, options *v1.DeleteOptions) error {
 	_, err := c.Fake.
@@ -22,6 +22,12 @@ type ClusterExpansion interface{}
 
 type FilesystemExpansion interface{}
 
+type ObjectBucketClaimExpansion interface{}
+
 type ObjectStoreExpansion interface{}
 
+type ObjectStoreUserExpansion interface{}
+
 type PoolExpansion interface{}
+
+type VolumeSnapshotExpansion interface{}
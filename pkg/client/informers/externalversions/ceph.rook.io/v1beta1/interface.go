@@ -28,10 +28,16 @@ type Interface interface {
 	Clusters() ClusterInformer
 	// Filesystems returns a FilesystemInformer.
 	Filesystems() FilesystemInformer
+	// ObjectBucketClaims returns a ObjectBucketClaimInformer.
+	ObjectBucketClaims() ObjectBucketClaimInformer
 	// ObjectStores returns a ObjectStoreInformer.
 	ObjectStores() ObjectStoreInformer
+	// ObjectStoreUsers returns a ObjectStoreUserInformer.
+	ObjectStoreUsers() ObjectStoreUserInformer
 	// Pools returns a PoolInformer.
 	Pools() PoolInformer
+	// VolumeSnapshots returns a VolumeSnapshotInformer.
+	VolumeSnapshots() VolumeSnapshotInformer
 }
 
 type version struct {
@@ -55,12 +61,27 @@ func (v *version) Filesystems() FilesystemInformer {
 	return &filesystemInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// ObjectBucketClaims returns a ObjectBucketClaimInformer.
+func (v *version) ObjectBucketClaims() ObjectBucketClaimInformer {
+	return &objectBucketClaimInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // ObjectStores returns a ObjectStoreInformer.
 func (v *version) ObjectStores() ObjectStoreInformer {
 	return &objectStoreInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// ObjectStoreUsers returns a ObjectStoreUserInformer.
+func (v *version) ObjectStoreUsers() ObjectStoreUserInformer {
+	return &objectStoreUserInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // Pools returns a PoolInformer.
 func (v *version) Pools() PoolInformer {
 	return &poolInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// VolumeSnapshots returns a VolumeSnapshotInformer.
+func (v *version) VolumeSnapshots() VolumeSnapshotInformer {
+	return &volumeSnapshotInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
@@ -73,10 +73,16 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1beta1().Clusters().Informer()}, nil
 	case v1beta1.SchemeGroupVersion.WithResource("filesystems"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1beta1().Filesystems().Informer()}, nil
+	case v1beta1.SchemeGroupVersion.WithResource("objectbucketclaims"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1beta1().ObjectBucketClaims().Informer()}, nil
 	case v1beta1.SchemeGroupVersion.WithResource("objectstores"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1beta1().ObjectStores().Informer()}, nil
+	case v1beta1.SchemeGroupVersion.WithResource("objectstoreusers"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1beta1().ObjectStoreUsers().Informer()}, nil
 	case v1beta1.SchemeGroupVersion.WithResource("pools"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1beta1().Pools().Informer()}, nil
+	case v1beta1.SchemeGroupVersion.WithResource("volumesnapshots"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1beta1().VolumeSnapshots().Informer()}, nil
 
 		// Group=cockroachdb.rook.io, Version=v1alpha1
 	case cockroachdbrookiov1alpha1.SchemeGroupVersion.WithResource("clusters"):
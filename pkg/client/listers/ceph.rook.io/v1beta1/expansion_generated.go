@@ -34,6 +34,14 @@ type FilesystemListerExpansion interface{}
 // FilesystemNamespaceLister.
 type FilesystemNamespaceListerExpansion interface{}
 
+// ObjectBucketClaimListerExpansion allows custom methods to be added to
+// ObjectBucketClaimLister.
+type ObjectBucketClaimListerExpansion interface{}
+
+// ObjectBucketClaimNamespaceListerExpansion allows custom methods to be added to
+// ObjectBucketClaimNamespaceLister.
+type ObjectBucketClaimNamespaceListerExpansion interface{}
+
 // ObjectStoreListerExpansion allows custom methods to be added to
 // ObjectStoreLister.
 type ObjectStoreListerExpansion interface{}
@@ -42,6 +50,14 @@ type ObjectStoreListerExpansion interface{}
 // ObjectStoreNamespaceLister.
 type ObjectStoreNamespaceListerExpansion interface{}
 
+// ObjectStoreUserListerExpansion allows custom methods to be added to
+// ObjectStoreUserLister.
+type ObjectStoreUserListerExpansion interface{}
+
+// ObjectStoreUserNamespaceListerExpansion allows custom methods to be added to
+// ObjectStoreUserNamespaceLister.
+type ObjectStoreUserNamespaceListerExpansion interface{}
+
 // PoolListerExpansion allows custom methods to be added to
 // PoolLister.
 type PoolListerExpansion interface{}
@@ -49,3 +65,11 @@ type PoolListerExpansion interface{}
 // PoolNamespaceListerExpansion allows custom methods to be added to
 // PoolNamespaceLister.
 type PoolNamespaceListerExpansion interface{}
+
+// VolumeSnapshotListerExpansion allows custom methods to be added to
+// VolumeSnapshotLister.
+type VolumeSnapshotListerExpansion interface{}
+
+// VolumeSnapshotNamespaceListerExpansion allows custom methods to be added to
+// VolumeSnapshotNamespaceLister.
+type VolumeSnapshotNamespaceListerExpansion interface{}
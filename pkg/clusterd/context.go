@@ -22,6 +22,7 @@ import (
 	"github.com/rook/rook/pkg/util/sys"
 	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 )
 
 // The context for loading or applying the configuration state of a service.
@@ -35,6 +36,9 @@ type Context struct {
 	// RookClientset is a typed connection to the rook API
 	RookClientset rookclient.Interface
 
+	// Recorder publishes events about the rook custom resources. Events are not recorded if it is nil.
+	Recorder record.EventRecorder
+
 	// The implementation of executing a console command
 	Executor exec.Executor
 
@@ -47,6 +51,9 @@ type Context struct {
 	// The full path to a config file that can be used to override generated settings
 	ConfigFileOverride string
 
+	// The ceph config settings by section of the config file, which are merged into the generated config files
+	CephConfig map[string]map[string]string
+
 	// Information about the network for this machine and its cluster
 	NetworkInfo NetworkInfo
 
@@ -73,6 +73,68 @@ func VerifyNetworkInfo(networkInfo NetworkInfo) error {
 	return nil
 }
 
+// SelectNetworkAddrs sets the public and cluster addresses to the addresses in the public and cluster
+// networks. When an address is not in its network, it is replaced with the first of the given local
+// addresses that is in the network, which are usually the addresses of the interfaces of the host or pod.
+// An error is returned if there is no local address in a network, since the daemon could not bind to it.
+func SelectNetworkAddrs(networkInfo NetworkInfo, localAddrs []net.Addr) (NetworkInfo, error) {
+	var err error
+	networkInfo.PublicAddr, err = selectNetworkAddr(networkInfo.PublicAddr, networkInfo.PublicNetwork, localAddrs)
+	if err != nil {
+		return networkInfo, fmt.Errorf("failed to select address in public network. %+v", err)
+	}
+
+	networkInfo.ClusterAddr, err = selectNetworkAddr(networkInfo.ClusterAddr, networkInfo.ClusterNetwork, localAddrs)
+	if err != nil {
+		return networkInfo, fmt.Errorf("failed to select address in cluster network. %+v", err)
+	}
+
+	return networkInfo, nil
+}
+
+// SelectLocalNetworkAddrs selects the public and cluster addresses from the addresses of the local interfaces
+func SelectLocalNetworkAddrs(networkInfo NetworkInfo) (NetworkInfo, error) {
+	localAddrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return networkInfo, fmt.Errorf("failed to get interface addresses. %+v", err)
+	}
+
+	return SelectNetworkAddrs(networkInfo, localAddrs)
+}
+
+// AddrInNetwork returns whether the IP address is in the network in CIDR notation. Every address is in an empty network.
+func AddrInNetwork(addr, network string) bool {
+	if network == "" {
+		return true
+	}
+
+	_, ipNet, err := net.ParseCIDR(network)
+	if err != nil {
+		return false
+	}
+
+	ip := net.ParseIP(addr)
+	return ip != nil && ipNet.Contains(ip)
+}
+
+func selectNetworkAddr(addr, network string, localAddrs []net.Addr) (string, error) {
+	if AddrInNetwork(addr, network) {
+		return addr, nil
+	}
+
+	for _, localAddr := range localAddrs {
+		ipNet, ok := localAddr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		if AddrInNetwork(ipNet.IP.String(), network) {
+			return ipNet.IP.String(), nil
+		}
+	}
+
+	return "", fmt.Errorf("no local address in network %s", network)
+}
+
 func verifyIPAddr(addr string) error {
 	if addr == "" {
 		// empty strings are OK
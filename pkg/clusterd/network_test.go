@@ -15,8 +15,12 @@ limitations under the License.
 */
 package clusterd
 
-import "testing"
-import "github.com/stretchr/testify/assert"
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
 
 func TestVerifyNetworkInfo(t *testing.T) {
 	// empty network info is OK
@@ -85,3 +89,48 @@ func TestNetworkInfoSimplify(t *testing.T) {
 	assert.Equal(t, out, in.Simplify())
 
 }
+
+func TestAddrInNetwork(t *testing.T) {
+	assert.True(t, AddrInNetwork("10.1.1.1", "10.1.1.0/24"))
+	assert.False(t, AddrInNetwork("10.1.2.1", "10.1.1.0/24"))
+	assert.False(t, AddrInNetwork("", "10.1.1.0/24"))
+	assert.False(t, AddrInNetwork("10.1.1.1", "10.1.1.0/33"))
+
+	// every address is in an unspecified network
+	assert.True(t, AddrInNetwork("10.1.2.1", ""))
+	assert.True(t, AddrInNetwork("", ""))
+}
+
+func TestSelectNetworkAddrs(t *testing.T) {
+	localAddrs := []net.Addr{
+		&net.IPNet{IP: net.ParseIP("172.17.0.5"), Mask: net.CIDRMask(16, 32)},
+		&net.IPNet{IP: net.ParseIP("10.1.1.5"), Mask: net.CIDRMask(24, 32)},
+		&net.IPNet{IP: net.ParseIP("10.1.2.5"), Mask: net.CIDRMask(24, 32)},
+	}
+
+	// the addresses are not changed without networks
+	in := NetworkInfo{PublicAddr: "172.17.0.5", ClusterAddr: "172.17.0.5"}
+	out, err := SelectNetworkAddrs(in, localAddrs)
+	assert.Nil(t, err)
+	assert.Equal(t, in, out)
+
+	// the addresses in the networks are selected
+	in.PublicNetwork = "10.1.1.0/24"
+	in.ClusterNetwork = "10.1.2.0/24"
+	out, err = SelectNetworkAddrs(in, localAddrs)
+	assert.Nil(t, err)
+	assert.Equal(t, "10.1.1.5", out.PublicAddr)
+	assert.Equal(t, "10.1.2.5", out.ClusterAddr)
+	assert.Equal(t, "10.1.1.0/24", out.PublicNetwork)
+
+	// addresses that are already in the networks are kept
+	in.PublicAddr = "10.1.1.9"
+	out, err = SelectNetworkAddrs(in, localAddrs)
+	assert.Nil(t, err)
+	assert.Equal(t, "10.1.1.9", out.PublicAddr)
+
+	// the daemon cannot bind to a network without a local address
+	in.ClusterNetwork = "10.1.3.0/24"
+	_, err = SelectNetworkAddrs(in, localAddrs)
+	assert.NotNil(t, err)
+}
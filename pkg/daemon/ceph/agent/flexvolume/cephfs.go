@@ -0,0 +1,70 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flexvolume
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"k8s.io/kubernetes/pkg/util/version"
+)
+
+const (
+	// CephFSType is the filesystem type of ceph filesystems mounted with the kernel client
+	CephFSType = "ceph"
+
+	mdsNamespaceKernelSupport = "4.7"
+)
+
+// CephFSMountDevice returns the device for mounting a path in a ceph filesystem with the kernel client
+func CephFSMountDevice(monAddresses []string, path string) string {
+	// if a path has not been provided, just use the root of the filesystem.
+	// otherwise, ensure that the provided path starts with the path separator char.
+	if path == "" {
+		path = string(os.PathSeparator)
+	} else if !strings.HasPrefix(path, string(os.PathSeparator)) {
+		path = string(os.PathSeparator) + path
+	}
+
+	return fmt.Sprintf("%s:%s", strings.Join(monAddresses, ","), path)
+}
+
+// CephFSMountOptions returns the options for mounting a ceph filesystem with the kernel client.
+// The filesystem is selected with the mds_namespace option if the kernel of the node supports it.
+// If it does not, the options are still returned together with an error that should be logged as a warning.
+func CephFSMountOptions(clientAccessInfo ClientAccessInfo, fsName, kernelVersion string) ([]string, error) {
+	options := []string{fmt.Sprintf("name=%s", clientAccessInfo.UserName), fmt.Sprintf("secret=%s", clientAccessInfo.SecretKey)}
+
+	if kernelVersion == "" {
+		return options, fmt.Errorf("The node kernel version cannot be detected. The kernel version has to be at least %s in order to specify a filesystem namespace."+
+			" If you have multiple ceph filesystems, the result could be inconsistent", mdsNamespaceKernelSupport)
+	}
+
+	kernelVersionParsed, err := version.ParseGeneric(kernelVersion)
+	if err != nil {
+		return options, fmt.Errorf("The node kernel version %s cannot be parsed. The kernel version has to be at least %s in order to specify a filesystem namespace."+
+			" If you have multiple ceph filesystems, the result could be inconsistent", kernelVersion, mdsNamespaceKernelSupport)
+	}
+	if !kernelVersionParsed.AtLeast(version.MustParseGeneric(mdsNamespaceKernelSupport)) {
+		return options, fmt.Errorf("The node kernel version is %s, which do not support multiple ceph filesystems. "+
+			"The kernel version has to be at least %s. If you have multiple ceph filesystems, the result could be inconsistent",
+			kernelVersion, mdsNamespaceKernelSupport)
+	}
+
+	return append(options, fmt.Sprintf("mds_namespace=%s", fsName)), nil
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flexvolume
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCephFSMountDevice(t *testing.T) {
+	mons := []string{"1.2.3.4:6789", "1.2.3.5:6789"}
+	assert.Equal(t, "1.2.3.4:6789,1.2.3.5:6789:/", CephFSMountDevice(mons, ""))
+	assert.Equal(t, "1.2.3.4:6789,1.2.3.5:6789:/foo", CephFSMountDevice(mons, "foo"))
+	assert.Equal(t, "1.2.3.4:6789,1.2.3.5:6789:/foo/bar", CephFSMountDevice(mons, "/foo/bar"))
+}
+
+func TestCephFSMountOptions(t *testing.T) {
+	info := ClientAccessInfo{UserName: "admin", SecretKey: "mysecret"}
+
+	// the filesystem is selected on newer kernels
+	options, err := CephFSMountOptions(info, "myfs", "4.15.0-36-generic")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"name=admin", "secret=mysecret", "mds_namespace=myfs"}, options)
+
+	// older kernels cannot select the filesystem
+	options, err = CephFSMountOptions(info, "myfs", "4.4.0")
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"name=admin", "secret=mysecret"}, options)
+
+	// unknown kernels cannot select the filesystem
+	options, err = CephFSMountOptions(info, "myfs", "")
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"name=admin", "secret=mysecret"}, options)
+	_, err = CephFSMountOptions(info, "myfs", "notaversion")
+	assert.NotNil(t, err)
+}
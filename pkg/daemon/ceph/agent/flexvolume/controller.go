@@ -30,6 +30,7 @@ import (
 	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume/attachment"
 	"github.com/rook/rook/pkg/operator/ceph/cluster"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	cephfile "github.com/rook/rook/pkg/operator/ceph/file"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -44,6 +45,8 @@ const (
 	PoolKey               = "pool"
 	ImageKey              = "image"
 	DataPoolKey           = "dataPool"
+	FsNameKey             = "fsName"
+	PathKey               = "path"
 	kubeletDefaultRootDir = "/var/lib/kubelet"
 )
 
@@ -334,11 +337,24 @@ func (c *Controller) GetGlobalMountPath(input GlobalMountPathInput, globalMountP
 	return nil
 }
 
-// GetClientAccessInfo obtains the cluster monitor endpoints, username and secret
-func (c *Controller) GetClientAccessInfo(clusterNamespace string, clientAccessInfo *ClientAccessInfo) error {
-	clusterInfo, _, _, err := mon.LoadClusterInfo(c.context, clusterNamespace)
+// GetClientAccessInfo obtains the cluster monitor endpoints, username and secret for mounting a filesystem
+func (c *Controller) GetClientAccessInfo(opts AttachOptions, clientAccessInfo *ClientAccessInfo) error {
+	info, err := LoadClientAccessInfo(c.context, opts.ClusterNamespace, opts.FsName, opts.VolumeName)
 	if err != nil {
-		return fmt.Errorf("failed to load cluster information from clusters namespace %s: %+v", clusterNamespace, err)
+		return err
+	}
+
+	*clientAccessInfo = *info
+	return nil
+}
+
+// LoadClientAccessInfo loads the monitor endpoints, username and secret that clients use to mount a filesystem.
+// Provisioned volumes are mounted with the client that is restricted to the directory of the volume, and other
+// volumes with the client of the filesystem. The admin key is never given to the clients.
+func LoadClientAccessInfo(context *clusterd.Context, clusterNamespace, fsName, volumeName string) (*ClientAccessInfo, error) {
+	clusterInfo, _, _, err := mon.LoadClusterInfo(context, clusterNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cluster information from clusters namespace %s: %+v", clusterNamespace, err)
 	}
 
 	monEndpoints := make([]string, 0, len(clusterInfo.Monitors))
@@ -346,11 +362,28 @@ func (c *Controller) GetClientAccessInfo(clusterNamespace string, clientAccessIn
 		monEndpoints = append(monEndpoints, monitor.Endpoint)
 	}
 
-	clientAccessInfo.MonAddresses = monEndpoints
-	clientAccessInfo.SecretKey = clusterInfo.AdminSecret
-	clientAccessInfo.UserName = "admin"
+	clientName := ""
+	key := ""
+	if volumeName != "" {
+		clientName = cephfile.VolumeClientName(volumeName)
+		key, err = cephfile.LoadClientKey(context.Clientset, clusterNamespace, clientName)
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to load key of client %s: %+v", clientName, err)
+		}
+	}
+	if key == "" {
+		clientName = cephfile.FilesystemClientName(fsName)
+		key, err = cephfile.LoadClientKey(context.Clientset, clusterNamespace, clientName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load key of client %s for filesystem %s: %+v", clientName, fsName, err)
+		}
+	}
 
-	return nil
+	return &ClientAccessInfo{
+		MonAddresses: monEndpoints,
+		SecretKey:    key,
+		UserName:     clientName,
+	}, nil
 }
 
 // GetKernelVersion returns the kernel version of the current node.
@@ -849,3 +849,40 @@ func containsAttachment(attachment rookalpha.Attachment, attachments []rookalpha
 	}
 	return false
 }
+
+func TestLoadClientAccessInfo(t *testing.T) {
+	clientset := test.New(3)
+	context := &clusterd.Context{Clientset: clientset}
+	clientset.CoreV1().Secrets("ns").Create(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon", Namespace: "ns"},
+		Data:       map[string][]byte{"admin-secret": []byte("adminkey")},
+	})
+	clientset.CoreV1().ConfigMaps("ns").Create(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon-endpoints", Namespace: "ns"},
+		Data:       map[string]string{"data": "a=1.2.3.4:6790"},
+	})
+
+	// the admin key is not used when the filesystem has no client
+	_, err := LoadClientAccessInfo(context, "ns", "myfs", "pvc-1")
+	assert.NotNil(t, err)
+
+	clientset.CoreV1().Secrets("ns").Create(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-client-fs-myfs", Namespace: "ns"},
+		Data:       map[string][]byte{"key": []byte("fskey")},
+	})
+	info, err := LoadClientAccessInfo(context, "ns", "myfs", "pvc-1")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1.2.3.4:6790"}, info.MonAddresses)
+	assert.Equal(t, "fs-myfs", info.UserName)
+	assert.Equal(t, "fskey", info.SecretKey)
+
+	// provisioned volumes are mounted with the client of the volume
+	clientset.CoreV1().Secrets("ns").Create(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-client-fs-volume-pvc-1", Namespace: "ns"},
+		Data:       map[string][]byte{"key": []byte("volumekey")},
+	})
+	info, err = LoadClientAccessInfo(context, "ns", "myfs", "pvc-1")
+	assert.Nil(t, err)
+	assert.Equal(t, "fs-volume-pvc-1", info.UserName)
+	assert.Equal(t, "volumekey", info.SecretKey)
+}
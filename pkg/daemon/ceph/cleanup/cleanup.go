@@ -0,0 +1,224 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cleanup removes the data of a deleted cluster from a host.
+package cleanup
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/util/sys"
+)
+
+const (
+	// CleanupCommand is the `rook ceph` subcommand which removes the data of a deleted cluster from a host
+	CleanupCommand = "cleanup"
+
+	cephVolumeCmd = "ceph-volume"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "cleanup")
+
+// Config is the data of a deleted cluster that is removed from the host
+type Config struct {
+	// The dataDirHostPath of the cluster, mounted in the container. Its contents are deleted if not empty.
+	DataDir string
+	// Whether to zap the devices with only the partitions created for OSDs
+	WipeDevices bool
+	// The disk UUIDs of the devices of the OSDs of the cluster on the host. Other devices are not zapped, since
+	// they may belong to the OSDs of another cluster.
+	DiskUUIDs []string
+	// The fsid of the cluster, which ceph-volume tags the logical volumes of the OSDs of the cluster with
+	ClusterFSID string
+}
+
+// cephVolumeLV is a logical volume of an osd in the output of "ceph-volume lvm list"
+type cephVolumeLV struct {
+	Path    string            `json:"lv_path"`
+	Devices []string          `json:"devices"`
+	Tags    map[string]string `json:"tags"`
+}
+
+// CleanupHost removes the data of a deleted cluster from the host. The daemons of the cluster must be stopped.
+func CleanupHost(context *clusterd.Context, config *Config) error {
+	if config.DataDir != "" {
+		if err := deleteDirContents(config.DataDir); err != nil {
+			return fmt.Errorf("failed to delete the contents of data dir %s. %+v", config.DataDir, err)
+		}
+		logger.Infof("deleted the contents of data dir %s", config.DataDir)
+	}
+
+	if config.WipeDevices {
+		if err := wipeRookDevices(context, config.DiskUUIDs); err != nil {
+			return fmt.Errorf("failed to wipe devices. %+v", err)
+		}
+		if err := zapCephVolumeOSDs(context, config.ClusterFSID); err != nil {
+			return fmt.Errorf("failed to zap ceph-volume osds. %+v", err)
+		}
+	}
+
+	return nil
+}
+
+// deleteDirContents deletes everything in a directory. The directory itself is a mount point of the host path,
+// so it cannot be removed from the container.
+func deleteDirContents(dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, file := range files {
+		if err := os.RemoveAll(path.Join(dir, file.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// wipeRookDevices zaps the devices of the cluster that have partitions which were all created by Rook for OSDs.
+// Devices without partitions are not wiped, since there is no sign that they were used by Rook, and neither are
+// the devices with disk UUIDs of another cluster.
+func wipeRookDevices(context *clusterd.Context, diskUUIDs []string) error {
+	if len(diskUUIDs) == 0 {
+		logger.Infof("no devices of the cluster to wipe")
+		return nil
+	}
+	clusterDisks := map[string]bool{}
+	for _, diskUUID := range diskUUIDs {
+		clusterDisks[strings.ToLower(diskUUID)] = true
+	}
+
+	devices, err := sys.ListDevices(context.Executor)
+	if err != nil {
+		return err
+	}
+
+	for _, device := range devices {
+		device = strings.TrimSpace(device)
+		if device == "" {
+			continue
+		}
+
+		// only whole devices have partitions
+		partitions, _, err := sys.GetDevicePartitions(device, context.Executor)
+		if err != nil {
+			logger.Warningf("skipping device %s. %+v", device, err)
+			continue
+		}
+		if len(partitions) == 0 || !sys.RookOwnsPartitions(partitions) {
+			logger.Debugf("skipping device %s without rook partitions", device)
+			continue
+		}
+
+		diskUUID, err := sys.GetDiskUUID(device, context.Executor)
+		if err != nil {
+			logger.Warningf("skipping device %s. %+v", device, err)
+			continue
+		}
+		if !clusterDisks[strings.ToLower(diskUUID)] {
+			logger.Infof("skipping device %s with disk uuid %s that does not belong to the cluster", device, diskUUID)
+			continue
+		}
+
+		logger.Infof("wiping device %s", device)
+		if err := sys.RemovePartitions(device, context.Executor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// zapCephVolumeOSDs zaps the logical volumes of the OSDs of the cluster that ceph-volume created on the host. The devices
+// with only the logical volumes of the cluster are zapped with their volume groups so they can be used again. The logical
+// volumes of the cluster on devices shared with the OSDs of another cluster are zapped one by one.
+func zapCephVolumeOSDs(context *clusterd.Context, clusterFSID string) error {
+	if clusterFSID == "" {
+		logger.Infof("no cluster fsid to find the ceph-volume osds of the cluster")
+		return nil
+	}
+
+	output, err := context.Executor.ExecuteCommandWithOutput(false, "", cephVolumeCmd, "lvm", "list", "--format", "json")
+	if err != nil {
+		return fmt.Errorf("failed to list ceph-volume osds. %+v", err)
+	}
+	var volumes map[string][]cephVolumeLV
+	if err := json.Unmarshal([]byte(output), &volumes); err != nil {
+		return fmt.Errorf("failed to unmarshal ceph-volume osds. %+v", err)
+	}
+
+	// the devices are only zapped as a whole if all their logical volumes belong to the cluster
+	clusterDevices := map[string]bool{}
+	var clusterLVs []cephVolumeLV
+	for id, lvs := range volumes {
+		for _, lv := range lvs {
+			fsid := lv.Tags["ceph.cluster_fsid"]
+			for _, device := range lv.Devices {
+				if onlyCluster, ok := clusterDevices[device]; !ok || onlyCluster {
+					clusterDevices[device] = fsid == clusterFSID
+				}
+			}
+			if fsid != clusterFSID {
+				logger.Infof("skipping osd %s on %s that belongs to cluster %s", id, lv.Path, fsid)
+				continue
+			}
+			clusterLVs = append(clusterLVs, lv)
+		}
+	}
+
+	var zapDevices []string
+	for device, ok := range clusterDevices {
+		if ok {
+			zapDevices = append(zapDevices, device)
+		}
+	}
+	sort.Strings(zapDevices)
+	for _, device := range zapDevices {
+		logger.Infof("zapping device %s of ceph-volume osds", device)
+		if err := context.Executor.ExecuteCommand(false, "", cephVolumeCmd, "lvm", "zap", "--destroy", device); err != nil {
+			return fmt.Errorf("failed to zap device %s. %+v", device, err)
+		}
+	}
+
+	sort.Slice(clusterLVs, func(i, j int) bool { return clusterLVs[i].Path < clusterLVs[j].Path })
+	for _, lv := range clusterLVs {
+		onZappedDevice := len(lv.Devices) > 0
+		for _, device := range lv.Devices {
+			if !clusterDevices[device] {
+				onZappedDevice = false
+			}
+		}
+		if onZappedDevice {
+			continue
+		}
+		logger.Infof("zapping logical volume %s of ceph-volume osd", lv.Path)
+		if err := context.Executor.ExecuteCommand(false, "", cephVolumeCmd, "lvm", "zap", "--destroy", lv.Path); err != nil {
+			return fmt.Errorf("failed to zap logical volume %s. %+v", lv.Path, err)
+		}
+	}
+	return nil
+}
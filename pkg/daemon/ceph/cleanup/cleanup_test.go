@@ -0,0 +1,119 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cleanup
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCleanupHost(t *testing.T) {
+	dataDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(dataDir)
+	os.MkdirAll(path.Join(dataDir, "rook-ceph", "mon-a"), 0755)
+	ioutil.WriteFile(path.Join(dataDir, "rook-ceph", "mon-a", "keyring"), []byte("key"), 0644)
+
+	var zapped []string
+	var cvZapped []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			if command == "ceph-volume" {
+				// sde only has the osds of the cluster, sdf has the db of an osd of the cluster and of another cluster
+				return `{"0":[{"lv_path":"/dev/ceph-1/osd-block-1","type":"block","devices":["/dev/sde"],"tags":{"ceph.cluster_fsid":"fsid1"}},` +
+					`{"lv_path":"/dev/ceph-2/osd-db-1","type":"db","devices":["/dev/sdf"],"tags":{"ceph.cluster_fsid":"fsid1"}}],` +
+					`"1":[{"lv_path":"/dev/ceph-2/osd-db-2","type":"db","devices":["/dev/sdf"],"tags":{"ceph.cluster_fsid":"fsid2"}}]}`, nil
+			}
+			if args[0] == "--all" {
+				return "sda\nsda1\nsda2\nsdb\nsdb1\nsdc\nsdd\nsdd1", nil
+			}
+			switch args[0] {
+			case "/dev/sda":
+				return `NAME="sda" SIZE="100" TYPE="disk" PKNAME=""
+NAME="sda1" SIZE="10" TYPE="part" PKNAME="sda"
+NAME="sda2" SIZE="90" TYPE="part" PKNAME="sda"`, nil
+			case "/dev/sdb":
+				return `NAME="sdb" SIZE="100" TYPE="disk" PKNAME=""
+NAME="sdb1" SIZE="100" TYPE="part" PKNAME="sdb"`, nil
+			case "/dev/sdc":
+				return `NAME="sdc" SIZE="100" TYPE="disk" PKNAME=""`, nil
+			case "/dev/sdd":
+				return `NAME="sdd" SIZE="100" TYPE="disk" PKNAME=""
+NAME="sdd1" SIZE="100" TYPE="part" PKNAME="sdd"`, nil
+			case "info":
+				// the partitions of sda and sdd were created for osds, sdb has a filesystem of the host
+				if args[2] == "/dev/sda1" || args[2] == "/dev/sda2" || args[2] == "/dev/sdd1" {
+					return "ID_PART_ENTRY_NAME=ROOK-OSD0-BLOCK", nil
+				}
+				return "ID_FS_TYPE=ext4", nil
+			case "--print":
+				// sdd has the partitions of the osd of another cluster
+				if args[1] == "/dev/sdd" {
+					return "Disk identifier (GUID): 11111111-2222-3333-4444-555555555555", nil
+				}
+				return "Disk identifier (GUID): 18E9BD3C-B2EE-4A48-8D37-4B8E5DF41B51", nil
+			}
+			return "", nil
+		},
+		MockExecuteCommand: func(debug bool, actionName string, command string, args ...string) error {
+			if command == "ceph-volume" {
+				assert.Equal(t, []string{"lvm", "zap", "--destroy"}, args[:3])
+				cvZapped = append(cvZapped, args[3])
+				return nil
+			}
+			assert.Equal(t, "sgdisk", command)
+			zapped = append(zapped, args[len(args)-1])
+			return nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	// nothing is removed without a policy
+	err := CleanupHost(context, &Config{})
+	assert.Nil(t, err)
+	files, _ := ioutil.ReadDir(dataDir)
+	assert.Equal(t, 1, len(files))
+	assert.Equal(t, 0, len(zapped))
+
+	// no devices are wiped without the disks of the cluster
+	err = CleanupHost(context, &Config{WipeDevices: true})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(zapped))
+
+	// only the devices of the cluster with rook partitions are wiped, the rook partitions of another cluster survive
+	diskUUIDs := []string{"18e9bd3c-b2ee-4a48-8d37-4b8e5df41b51"}
+	err = CleanupHost(context, &Config{DataDir: dataDir, WipeDevices: true, DiskUUIDs: diskUUIDs})
+	assert.Nil(t, err)
+	files, _ = ioutil.ReadDir(dataDir)
+	assert.Equal(t, 0, len(files))
+	assert.Equal(t, []string{"/dev/sda", "/dev/sda"}, zapped)
+	assert.Equal(t, 0, len(cvZapped))
+
+	// the ceph-volume osds of the cluster are zapped, the volumes of another cluster survive
+	err = CleanupHost(context, &Config{WipeDevices: true, ClusterFSID: "fsid1"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"/dev/sde", "/dev/ceph-2/osd-db-1"}, cvZapped)
+
+	// the data dir may not exist on the host
+	err = CleanupHost(context, &Config{DataDir: path.Join(dataDir, "missing")})
+	assert.Nil(t, err)
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/rook/rook/pkg/clusterd"
+)
+
+// ConfigOptionHelp is the description of a ceph config option
+type ConfigOptionHelp struct {
+	Name               string `json:"name"`
+	Type               string `json:"type"`
+	Level              string `json:"level"`
+	CanUpdateAtRuntime bool   `json:"can_update_at_runtime"`
+}
+
+// SetConfig sets a setting of the daemons in the config database of the mons. The daemons are the daemon type
+// (mon, osd, ...), a daemon name (osd.1, ...) or global. Only supported on mimic or newer.
+func SetConfig(context *clusterd.Context, clusterName, who, key, val string) error {
+	args := []string{"config", "set", who, key, val}
+	if _, err := ExecuteCephCommand(context, clusterName, args); err != nil {
+		return fmt.Errorf("failed to set config %s for %s to \"%s\". %+v", key, who, val, err)
+	}
+	return nil
+}
+
+// RemoveConfig removes a setting of the daemons from the config database of the mons. Only supported on mimic or newer.
+func RemoveConfig(context *clusterd.Context, clusterName, who, key string) error {
+	args := []string{"config", "rm", who, key}
+	if _, err := ExecuteCephCommand(context, clusterName, args); err != nil {
+		return fmt.Errorf("failed to remove config %s for %s. %+v", key, who, err)
+	}
+	return nil
+}
+
+// GetConfigOptionHelp gets the description of a config option, such as whether it can be changed while the
+// daemons are running. Only supported on mimic or newer.
+func GetConfigOptionHelp(context *clusterd.Context, clusterName, key string) (*ConfigOptionHelp, error) {
+	// the options are named with underscores, although the config files also allow spaces
+	name := strings.Replace(strings.TrimSpace(key), " ", "_", -1)
+	args := []string{"config", "help", name}
+	buf, err := ExecuteCephCommand(context, clusterName, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get help for config %s. %+v", key, err)
+	}
+
+	var help ConfigOptionHelp
+	if err := json.Unmarshal(buf, &help); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config help. %+v. %s", err, string(buf))
+	}
+	return &help, nil
+}
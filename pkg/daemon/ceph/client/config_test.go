@@ -0,0 +1,59 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package client
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig(t *testing.T) {
+	var configArgs []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+			configArgs = args
+			if args[1] == "help" {
+				if args[2] == "osd_max_backfills" {
+					return `{"name":"osd_max_backfills","type":"uint","level":"advanced","can_update_at_runtime":true}`, nil
+				}
+				return "", fmt.Errorf("unknown option %s", args[2])
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	err := SetConfig(context, "ns", "osd", "osd max backfills", "2")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"config", "set", "osd", "osd max backfills", "2"}, configArgs[:5])
+
+	err = RemoveConfig(context, "ns", "global", "debug ms")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"config", "rm", "global", "debug ms"}, configArgs[:4])
+
+	// the option names use underscores
+	help, err := GetConfigOptionHelp(context, "ns", "osd max backfills")
+	assert.Nil(t, err)
+	assert.Equal(t, "osd_max_backfills", help.Name)
+	assert.True(t, help.CanUpdateAtRuntime)
+
+	_, err = GetConfigOptionHelp(context, "ns", "foo")
+	assert.NotNil(t, err)
+}
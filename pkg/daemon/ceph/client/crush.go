@@ -178,6 +178,22 @@ func CrushRemove(context *clusterd.Context, clusterName, name string) (string, e
 	return string(buf), nil
 }
 
+// SetDeviceClass sets the crush device class of the osd, replacing any class it already has
+func SetDeviceClass(context *clusterd.Context, clusterName string, osdID int, deviceClass string) error {
+	osdEntity := fmt.Sprintf("osd.%d", osdID)
+	args := []string{"osd", "crush", "rm-device-class", osdEntity}
+	if _, err := ExecuteCephCommand(context, clusterName, args); err != nil {
+		return fmt.Errorf("failed to remove device class of %s. %+v", osdEntity, err)
+	}
+
+	args = []string{"osd", "crush", "set-device-class", deviceClass, osdEntity}
+	if _, err := ExecuteCephCommand(context, clusterName, args); err != nil {
+		return fmt.Errorf("failed to set device class of %s to %s. %+v", osdEntity, deviceClass, err)
+	}
+
+	return nil
+}
+
 func FindOSDInCrushMap(context *clusterd.Context, clusterName string, osdID int) (*CrushFindResult, error) {
 	args := []string{"osd", "find", strconv.Itoa(osdID)}
 	buf, err := ExecuteCephCommand(context, clusterName, args)
@@ -30,6 +30,7 @@ type CephErasureCodeProfile struct {
 	Technique        string `json:"technique"`
 	FailureDomain    string `json:"crush-failure-domain"`
 	CrushRoot        string `json:"crush-root"`
+	DeviceClass      string `json:"crush-device-class"`
 }
 
 func ListErasureCodeProfiles(context *clusterd.Context, clusterName string) ([]string, error) {
@@ -64,7 +65,7 @@ func GetErasureCodeProfileDetails(context *clusterd.Context, clusterName, name s
 	return ecProfileDetails, nil
 }
 
-func CreateErasureCodeProfile(context *clusterd.Context, clusterName string, config model.ErasureCodedPoolConfig, name, failureDomain, crushRoot string) error {
+func CreateErasureCodeProfile(context *clusterd.Context, clusterName string, config model.ErasureCodedPoolConfig, name, failureDomain, crushRoot, deviceClass string) error {
 	// look up the default profile so we can use the default plugin/technique
 	defaultProfile, err := GetErasureCodeProfileDetails(context, clusterName, "default")
 	if err != nil {
@@ -84,6 +85,9 @@ func CreateErasureCodeProfile(context *clusterd.Context, clusterName string, con
 	if crushRoot != "" {
 		profilePairs = append(profilePairs, fmt.Sprintf("crush-root=%s", crushRoot))
 	}
+	if deviceClass != "" {
+		profilePairs = append(profilePairs, fmt.Sprintf("crush-device-class=%s", deviceClass))
+	}
 
 	args := []string{"osd", "erasure-code-profile", "set", name}
 	args = append(args, profilePairs...)
@@ -112,6 +116,7 @@ func ModelPoolToCephPool(modelPool model.Pool) CephStoragePoolDetails {
 		Number:        modelPool.Number,
 		FailureDomain: modelPool.FailureDomain,
 		CrushRoot:     modelPool.CrushRoot,
+		DeviceClass:   modelPool.DeviceClass,
 	}
 
 	if modelPool.Type == model.Replicated {
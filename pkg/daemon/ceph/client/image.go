@@ -21,6 +21,7 @@ import (
 	"syscall"
 
 	"strconv"
+	"strings"
 
 	"regexp"
 
@@ -38,6 +39,15 @@ type CephBlockImage struct {
 	Format int    `json:"format"`
 }
 
+// CephImageMirrorStatus is the mirroring state of an image as reported by "rbd mirror image status"
+type CephImageMirrorStatus struct {
+	Name        string `json:"name"`
+	GlobalID    string `json:"global_id"`
+	State       string `json:"state"`
+	Description string `json:"description"`
+	LastUpdate  string `json:"last_update"`
+}
+
 func ListImages(context *clusterd.Context, clusterName, poolName string) ([]CephBlockImage, error) {
 	args := []string{"ls", "-l", poolName}
 	buf, err := ExecuteRBDCommand(context, clusterName, args)
@@ -111,6 +121,26 @@ func CreateImage(context *clusterd.Context, clusterName, name, poolName, dataPoo
 	return nil, fmt.Errorf("failed to find image %s after creating it", name)
 }
 
+// ResizeImage grows a block storage image to the given size. The size is rounded up to the next MB boundary.
+// Images are never shrunk.
+func ResizeImage(context *clusterd.Context, clusterName, name, poolName string, size uint64) error {
+	if size < ImageMinSize {
+		logger.Warningf("requested image size %d is less than the minimum size of %d, using the minimum.", size, ImageMinSize)
+		size = ImageMinSize
+	}
+	sizeMB := int((size + ImageMinSize - 1) / ImageMinSize)
+
+	imageSpec := getImageSpec(name, poolName)
+	args := []string{"resize", imageSpec, "--size", strconv.Itoa(sizeMB)}
+	buf, err := ExecuteRBDCommandNoFormat(context, clusterName, args)
+	if err != nil {
+		return fmt.Errorf("failed to resize image %s in pool %s to size %d: %+v. output: %s",
+			name, poolName, size, err, string(buf))
+	}
+
+	return nil
+}
+
 func DeleteImage(context *clusterd.Context, clusterName, name, poolName string) error {
 	imageSpec := getImageSpec(name, poolName)
 	args := []string{"rm", imageSpec}
@@ -123,6 +153,161 @@ func DeleteImage(context *clusterd.Context, clusterName, name, poolName string)
 	return nil
 }
 
+// CreateSnapshot creates a point-in-time snapshot of a block storage image
+func CreateSnapshot(context *clusterd.Context, clusterName, name, poolName, snapName string) error {
+	snapSpec := getSnapshotSpec(name, poolName, snapName)
+	args := []string{"snap", "create", snapSpec}
+	buf, err := ExecuteRBDCommandNoFormat(context, clusterName, args)
+	if err != nil {
+		cmdErr, ok := err.(*exec.CommandError)
+		if ok && cmdErr.ExitStatus() == int(syscall.EEXIST) {
+			logger.Warningf("Requested snapshot %s already exists. Continuing", snapSpec)
+			return nil
+		}
+		return fmt.Errorf("failed to create snapshot %s: %+v. output: %s", snapSpec, err, string(buf))
+	}
+
+	return nil
+}
+
+// DeleteSnapshot removes a snapshot of a block storage image. The snapshot must not be protected.
+func DeleteSnapshot(context *clusterd.Context, clusterName, name, poolName, snapName string) error {
+	snapSpec := getSnapshotSpec(name, poolName, snapName)
+	args := []string{"snap", "rm", snapSpec}
+	buf, err := ExecuteRBDCommandNoFormat(context, clusterName, args)
+	if err != nil {
+		return fmt.Errorf("failed to delete snapshot %s: %+v. output: %s", snapSpec, err, string(buf))
+	}
+
+	return nil
+}
+
+// ProtectSnapshot protects a snapshot from being deleted so that it can be cloned
+func ProtectSnapshot(context *clusterd.Context, clusterName, name, poolName, snapName string) error {
+	snapSpec := getSnapshotSpec(name, poolName, snapName)
+	args := []string{"snap", "protect", snapSpec}
+	buf, err := ExecuteRBDCommandNoFormat(context, clusterName, args)
+	if err != nil {
+		cmdErr, ok := err.(*exec.CommandError)
+		if ok && cmdErr.ExitStatus() == int(syscall.EBUSY) {
+			// the snapshot is already protected
+			return nil
+		}
+		return fmt.Errorf("failed to protect snapshot %s: %+v. output: %s", snapSpec, err, string(buf))
+	}
+
+	return nil
+}
+
+// UnprotectSnapshot allows a snapshot to be deleted. The snapshot must not have any clones that are not flattened.
+func UnprotectSnapshot(context *clusterd.Context, clusterName, name, poolName, snapName string) error {
+	snapSpec := getSnapshotSpec(name, poolName, snapName)
+	args := []string{"snap", "unprotect", snapSpec}
+	buf, err := ExecuteRBDCommandNoFormat(context, clusterName, args)
+	if err != nil {
+		cmdErr, ok := err.(*exec.CommandError)
+		if ok && cmdErr.ExitStatus() == int(syscall.EINVAL) {
+			// the snapshot is not protected
+			return nil
+		}
+		return fmt.Errorf("failed to unprotect snapshot %s: %+v. output: %s", snapSpec, err, string(buf))
+	}
+
+	return nil
+}
+
+// ListSnapshots returns the names of the snapshots of a block storage image
+func ListSnapshots(context *clusterd.Context, clusterName, name, poolName string) ([]string, error) {
+	imageSpec := getImageSpec(name, poolName)
+	args := []string{"snap", "ls", imageSpec}
+	buf, err := ExecuteRBDCommand(context, clusterName, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots of image %s: %+v. output: %s", imageSpec, err, string(buf))
+	}
+
+	// the log statements of librados may precede the json result when the log level is debug
+	res := regexp.MustCompile(`(?m)^\[(.*)\]`).FindStringSubmatch(string(buf))
+	if len(res) == 0 {
+		return []string{}, nil
+	}
+
+	var snaps []struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(res[0]), &snaps); err != nil {
+		return nil, fmt.Errorf("unmarshal failed: %+v. raw buffer response: %s", err, string(buf))
+	}
+	names := []string{}
+	for _, snap := range snaps {
+		names = append(names, snap.Name)
+	}
+	return names, nil
+}
+
+// ListSnapshotChildren returns the images cloned from a snapshot in the form "pool/image"
+func ListSnapshotChildren(context *clusterd.Context, clusterName, name, poolName, snapName string) ([]string, error) {
+	snapSpec := getSnapshotSpec(name, poolName, snapName)
+	args := []string{"children", snapSpec}
+	buf, err := ExecuteRBDCommandNoFormat(context, clusterName, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list children of snapshot %s: %+v. output: %s", snapSpec, err, string(buf))
+	}
+
+	children := []string{}
+	for _, line := range strings.Split(string(buf), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			children = append(children, line)
+		}
+	}
+	return children, nil
+}
+
+// CloneImage creates a new image from a protected snapshot of an image.
+// If dataPoolName is not empty, the clone will use poolName as the metadata pool and the dataPoolname for data.
+func CloneImage(context *clusterd.Context, clusterName, parentName, parentPoolName, snapName, name, poolName, dataPoolName string) (*CephBlockImage, error) {
+	snapSpec := getSnapshotSpec(parentName, parentPoolName, snapName)
+	imageSpec := getImageSpec(name, poolName)
+	args := []string{"clone", snapSpec, imageSpec}
+	if dataPoolName != "" {
+		args = append(args, fmt.Sprintf("--data-pool=%s", dataPoolName))
+	}
+
+	buf, err := ExecuteRBDCommandNoFormat(context, clusterName, args)
+	if err != nil {
+		cmdErr, ok := err.(*exec.CommandError)
+		if ok && cmdErr.ExitStatus() == int(syscall.EEXIST) {
+			logger.Warningf("Requested image %s exists in pool %s. Continuing", name, poolName)
+		} else {
+			return nil, fmt.Errorf("failed to clone snapshot %s to image %s: %+v. output: %s", snapSpec, imageSpec, err, string(buf))
+		}
+	}
+
+	images, err := ListImages(context, clusterName, poolName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list images after successfully cloning image %s: %v", name, err)
+	}
+	for i := range images {
+		if images[i].Name == name {
+			return &images[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("failed to find image %s after cloning it", name)
+}
+
+// FlattenImage copies the data of the parent snapshot into a cloned image so that the clone is independent of the snapshot
+func FlattenImage(context *clusterd.Context, clusterName, name, poolName string) error {
+	imageSpec := getImageSpec(name, poolName)
+	args := []string{"flatten", imageSpec}
+	buf, err := ExecuteRBDCommandNoFormat(context, clusterName, args)
+	if err != nil {
+		return fmt.Errorf("failed to flatten image %s: %+v. output: %s", imageSpec, err, string(buf))
+	}
+
+	return nil
+}
+
 // MapImage maps an RBD image using admin cephfx and returns the device path
 func MapImage(context *clusterd.Context, imageName, poolName, clusterName, keyring, monitors string) error {
 	imageSpec := getImageSpec(imageName, poolName)
@@ -169,6 +354,83 @@ func UnMapImage(context *clusterd.Context, imageName, poolName, clusterName, key
 	return nil
 }
 
+// EnableImageMirroring enables the mirroring of an image in a pool mirrored in the image mode.
+// The image must have the journaling feature.
+func EnableImageMirroring(context *clusterd.Context, clusterName, name, poolName string) error {
+	imageSpec := getImageSpec(name, poolName)
+	args := []string{"mirror", "image", "enable", imageSpec}
+	buf, err := ExecuteRBDCommandNoFormat(context, clusterName, args)
+	if err != nil {
+		return fmt.Errorf("failed to enable mirroring of image %s: %+v. output: %s", imageSpec, err, string(buf))
+	}
+
+	return nil
+}
+
+// DisableImageMirroring disables the mirroring of an image. The mirroring of a non-primary image is only disabled with force.
+func DisableImageMirroring(context *clusterd.Context, clusterName, name, poolName string, force bool) error {
+	imageSpec := getImageSpec(name, poolName)
+	args := []string{"mirror", "image", "disable", imageSpec}
+	if force {
+		args = append(args, "--force")
+	}
+	buf, err := ExecuteRBDCommandNoFormat(context, clusterName, args)
+	if err != nil {
+		return fmt.Errorf("failed to disable mirroring of image %s: %+v. output: %s", imageSpec, err, string(buf))
+	}
+
+	return nil
+}
+
+// PromoteImage makes the mirrored image in this cluster the primary image that can be written.
+// Force promotes the image when the primary image in the peer cluster cannot be demoted, e.g. after the loss of the peer.
+func PromoteImage(context *clusterd.Context, clusterName, name, poolName string, force bool) error {
+	imageSpec := getImageSpec(name, poolName)
+	args := []string{"mirror", "image", "promote", imageSpec}
+	if force {
+		args = append(args, "--force")
+	}
+	buf, err := ExecuteRBDCommandNoFormat(context, clusterName, args)
+	if err != nil {
+		return fmt.Errorf("failed to promote image %s: %+v. output: %s", imageSpec, err, string(buf))
+	}
+
+	return nil
+}
+
+// DemoteImage makes the primary image in this cluster non-primary so the image can be promoted in the peer cluster
+func DemoteImage(context *clusterd.Context, clusterName, name, poolName string) error {
+	imageSpec := getImageSpec(name, poolName)
+	args := []string{"mirror", "image", "demote", imageSpec}
+	buf, err := ExecuteRBDCommandNoFormat(context, clusterName, args)
+	if err != nil {
+		return fmt.Errorf("failed to demote image %s: %+v. output: %s", imageSpec, err, string(buf))
+	}
+
+	return nil
+}
+
+// GetImageMirrorStatus returns the mirroring state of an image, such as up+replaying or up+stopped
+func GetImageMirrorStatus(context *clusterd.Context, clusterName, name, poolName string) (*CephImageMirrorStatus, error) {
+	imageSpec := getImageSpec(name, poolName)
+	args := []string{"mirror", "image", "status", imageSpec}
+	buf, err := ExecuteRBDCommand(context, clusterName, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mirroring status of image %s: %+v. output: %s", imageSpec, err, string(buf))
+	}
+
+	var status CephImageMirrorStatus
+	if err := json.Unmarshal(buf, &status); err != nil {
+		return nil, fmt.Errorf("unmarshal failed: %+v. raw buffer response: %s", err, string(buf))
+	}
+
+	return &status, nil
+}
+
 func getImageSpec(name, poolName string) string {
 	return fmt.Sprintf("%s/%s", poolName, name)
 }
+
+func getSnapshotSpec(name, poolName, snapName string) string {
+	return fmt.Sprintf("%s/%s@%s", poolName, name, snapName)
+}
@@ -137,6 +137,113 @@ func TestCreateImage(t *testing.T) {
 
 }
 
+func TestResizeImage(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+
+	resizeCalled := false
+	expectedSizeArg := ""
+	executor.MockExecuteCommandWithOutput = func(debug bool, actionName string, command string, args ...string) (string, error) {
+		switch {
+		case command == "rbd" && args[0] == "resize":
+			resizeCalled = true
+			assert.Equal(t, "pool1/image1", args[1])
+			assert.Equal(t, expectedSizeArg, args[3])
+			return "", nil
+		}
+		return "", fmt.Errorf("unexpected ceph command '%v'", args)
+	}
+
+	// (2 MB + 1 byte) --> 3MB
+	expectedSizeArg = "3"
+	err := ResizeImage(context, "foocluster", "image1", "pool1", uint64(sizeMB*2+1))
+	assert.Nil(t, err)
+	assert.True(t, resizeCalled)
+	resizeCalled = false
+
+	// 0 byte --> 1 MB
+	expectedSizeArg = "1"
+	err = ResizeImage(context, "foocluster", "image1", "pool1", uint64(0))
+	assert.Nil(t, err)
+	assert.True(t, resizeCalled)
+
+	// the output of the rbd tool is returned with the error
+	executor.MockExecuteCommandWithOutput = func(debug bool, actionName string, command string, args ...string) (string, error) {
+		return "mocked detailed ceph error output stream", fmt.Errorf("some mocked error")
+	}
+	err = ResizeImage(context, "foocluster", "image1", "pool1", uint64(sizeMB))
+	assert.NotNil(t, err)
+	assert.True(t, strings.Contains(err.Error(), "mocked detailed ceph error output stream"))
+}
+
+func TestSnapshotAndClone(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+
+	commands := []string{}
+	executor.MockExecuteCommandWithOutput = func(debug bool, actionName string, command string, args ...string) (string, error) {
+		switch {
+		case command == "rbd" && args[0] == "snap":
+			commands = append(commands, args[0]+" "+args[1])
+			assert.Equal(t, "pool1/image1@snap1", args[2])
+			return "", nil
+		case command == "rbd" && args[0] == "clone":
+			commands = append(commands, args[0])
+			assert.Equal(t, "pool1/image1@snap1", args[1])
+			assert.Equal(t, "pool2/image2", args[2])
+			assert.Equal(t, "--data-pool=datapool", args[3])
+			return "", nil
+		case command == "rbd" && args[0] == "ls" && args[1] == "-l":
+			return `[{"image":"image2","size":1048576,"format":2}]`, nil
+		case command == "rbd" && args[0] == "children":
+			commands = append(commands, args[0])
+			return "pool2/image2\npool3/image3\n", nil
+		case command == "rbd" && args[0] == "flatten":
+			commands = append(commands, args[0])
+			assert.Equal(t, "pool2/image2", args[1])
+			return "", nil
+		}
+		return "", fmt.Errorf("unexpected ceph command '%v'", args)
+	}
+
+	err := CreateSnapshot(context, "foocluster", "image1", "pool1", "snap1")
+	assert.Nil(t, err)
+	err = ProtectSnapshot(context, "foocluster", "image1", "pool1", "snap1")
+	assert.Nil(t, err)
+	image, err := CloneImage(context, "foocluster", "image1", "pool1", "snap1", "image2", "pool2", "datapool")
+	assert.Nil(t, err)
+	assert.Equal(t, "image2", image.Name)
+	children, err := ListSnapshotChildren(context, "foocluster", "image1", "pool1", "snap1")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"pool2/image2", "pool3/image3"}, children)
+	err = FlattenImage(context, "foocluster", "image2", "pool2")
+	assert.Nil(t, err)
+	err = UnprotectSnapshot(context, "foocluster", "image1", "pool1", "snap1")
+	assert.Nil(t, err)
+	err = DeleteSnapshot(context, "foocluster", "image1", "pool1", "snap1")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"snap create", "snap protect", "clone", "children", "flatten", "snap unprotect", "snap rm"}, commands)
+
+	// the snapshots of an image are listed by name
+	executor.MockExecuteCommandWithOutput = func(debug bool, actionName string, command string, args ...string) (string, error) {
+		assert.Equal(t, []string{"snap", "ls", "pool1/image1"}, args[:3])
+		return `[{"id":4,"name":"snap1","size":1048576},{"id":5,"name":"snap2","size":1048576}]`, nil
+	}
+	snaps, err := ListSnapshots(context, "foocluster", "image1", "pool1")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"snap1", "snap2"}, snaps)
+
+	// the output of the rbd tool is returned with the error
+	executor.MockExecuteCommandWithOutput = func(debug bool, actionName string, command string, args ...string) (string, error) {
+		return "mocked detailed ceph error output stream", fmt.Errorf("some mocked error")
+	}
+	err = CreateSnapshot(context, "foocluster", "image1", "pool1", "snap1")
+	assert.NotNil(t, err)
+	assert.True(t, strings.Contains(err.Error(), "mocked detailed ceph error output stream"))
+	_, err = CloneImage(context, "foocluster", "image1", "pool1", "snap1", "image2", "pool2", "")
+	assert.NotNil(t, err)
+}
+
 func TestListImageLogLevelInfo(t *testing.T) {
 	executor := &exectest.MockExecutor{}
 	context := &clusterd.Context{Executor: executor}
@@ -237,3 +344,50 @@ func TestListImageLogLevelDebug(t *testing.T) {
 	assert.True(t, listCalled)
 	listCalled = false
 }
+
+func TestImageMirroring(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+
+	commands := []string{}
+	executor.MockExecuteCommandWithOutput = func(debug bool, actionName string, command string, args ...string) (string, error) {
+		if command == "rbd" && args[0] == "mirror" && args[1] == "image" {
+			assert.Equal(t, "pool1/image1", args[3])
+			if args[2] == "status" {
+				return `{"name":"image1","global_id":"abcd","state":"up+replaying","description":"replaying","last_update":"2018-11-05 10:00:00"}`, nil
+			}
+			command := args[2]
+			if args[4] == "--force" {
+				command += " --force"
+			}
+			commands = append(commands, command)
+			return "", nil
+		}
+		return "", fmt.Errorf("unexpected ceph command '%v'", args)
+	}
+
+	err := EnableImageMirroring(context, "foocluster", "image1", "pool1")
+	assert.Nil(t, err)
+	err = DemoteImage(context, "foocluster", "image1", "pool1")
+	assert.Nil(t, err)
+	err = PromoteImage(context, "foocluster", "image1", "pool1", false)
+	assert.Nil(t, err)
+	err = PromoteImage(context, "foocluster", "image1", "pool1", true)
+	assert.Nil(t, err)
+	err = DisableImageMirroring(context, "foocluster", "image1", "pool1", true)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"enable", "demote", "promote", "promote --force", "disable --force"}, commands)
+
+	status, err := GetImageMirrorStatus(context, "foocluster", "image1", "pool1")
+	assert.Nil(t, err)
+	assert.Equal(t, "up+replaying", status.State)
+	assert.Equal(t, "abcd", status.GlobalID)
+
+	// the output of the rbd tool is returned with the error
+	executor.MockExecuteCommandWithOutput = func(debug bool, actionName string, command string, args ...string) (string, error) {
+		return "mocked detailed ceph error output stream", fmt.Errorf("some mocked error")
+	}
+	err = PromoteImage(context, "foocluster", "image1", "pool1", false)
+	assert.NotNil(t, err)
+	assert.True(t, strings.Contains(err.Error(), "mocked detailed ceph error output stream"))
+}
@@ -148,6 +148,27 @@ func OSDRemove(context *clusterd.Context, clusterName string, osdID int) (string
 	return string(buf), err
 }
 
+// OSDAddNoOut sets the noout flag on the osds so they are not marked out while they are down
+func OSDAddNoOut(context *clusterd.Context, clusterName string, osdIDs []int) error {
+	return setOSDFlag(context, clusterName, "add-noout", osdIDs)
+}
+
+// OSDRemoveNoOut clears the noout flag of the osds
+func OSDRemoveNoOut(context *clusterd.Context, clusterName string, osdIDs []int) error {
+	return setOSDFlag(context, clusterName, "rm-noout", osdIDs)
+}
+
+func setOSDFlag(context *clusterd.Context, clusterName, action string, osdIDs []int) error {
+	args := []string{"osd", action}
+	for _, id := range osdIDs {
+		args = append(args, fmt.Sprintf("osd.%d", id))
+	}
+	if _, err := ExecuteCephCommand(context, clusterName, args); err != nil {
+		return fmt.Errorf("failed to %s osds %v. %+v", action, osdIDs, err)
+	}
+	return nil
+}
+
 func DisableScrubbing(context *clusterd.Context, clusterName string) (string, error) {
 	args := []string{"osd", "set", "noscrub"}
 	buf, err := ExecuteCephCommand(context, clusterName, args)
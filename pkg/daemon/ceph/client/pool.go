@@ -16,6 +16,7 @@ limitations under the License.
 package client
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"strconv"
@@ -42,6 +43,14 @@ type CephStoragePoolDetails struct {
 	ErasureCodeProfile string `json:"erasure_code_profile"`
 	FailureDomain      string `json:"failureDomain"`
 	CrushRoot          string `json:"crushRoot"`
+	DeviceClass        string `json:"deviceClass"`
+}
+
+type CephStoragePoolQuota struct {
+	Name       string `json:"pool_name"`
+	Number     int    `json:"pool_id"`
+	MaxObjects uint64 `json:"quota_max_objects"`
+	MaxBytes   uint64 `json:"quota_max_bytes"`
 }
 
 type CephStoragePoolStats struct {
@@ -128,7 +137,7 @@ func CreatePoolWithProfile(context *clusterd.Context, clusterName string, newPoo
 	if newPoolReq.Type == model.ErasureCoded {
 		// create a new erasure code profile for the new pool
 		if err := CreateErasureCodeProfile(context, clusterName, newPoolReq.ErasureCodedConfig, newPool.ErasureCodeProfile,
-			newPoolReq.FailureDomain, newPoolReq.CrushRoot); err != nil {
+			newPoolReq.FailureDomain, newPoolReq.CrushRoot, newPoolReq.DeviceClass); err != nil {
 
 			return fmt.Errorf("failed to create erasure code profile for pool '%s': %+v", newPoolReq.Name, err)
 		}
@@ -255,6 +264,10 @@ func createReplicationCrushRule(context *clusterd.Context, clusterName string, n
 	}
 
 	args := []string{"osd", "crush", "rule", "create-simple", ruleName, crushRoot, failureDomain}
+	if newPool.DeviceClass != "" {
+		// the rule only selects the osds of the device class
+		args = []string{"osd", "crush", "rule", "create-replicated", ruleName, crushRoot, failureDomain, newPool.DeviceClass}
+	}
 	_, err := ExecuteCephCommand(context, clusterName, args)
 	if err != nil {
 		return fmt.Errorf("failed to create crush rule %s. %+v", ruleName, err)
@@ -272,6 +285,53 @@ func SetPoolProperty(context *clusterd.Context, clusterName, name, propName stri
 	return nil
 }
 
+// GetPoolProperty returns the value of a property of the pool as a string
+func GetPoolProperty(context *clusterd.Context, clusterName, name, propName string) (string, error) {
+	args := []string{"osd", "pool", "get", name, propName}
+	buf, err := ExecuteCephCommand(context, clusterName, args)
+	if err != nil {
+		return "", fmt.Errorf("failed to get pool property %s of pool %s. %+v", propName, name, err)
+	}
+
+	// keep the numbers as they were formatted by ceph
+	var props map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(buf))
+	decoder.UseNumber()
+	if err := decoder.Decode(&props); err != nil {
+		return "", fmt.Errorf("unmarshal failed: %+v. raw buffer response: %s", err, string(buf))
+	}
+
+	val, ok := props[propName]
+	if !ok {
+		return "", fmt.Errorf("pool property %s of pool %s not found in response: %s", propName, name, string(buf))
+	}
+	return fmt.Sprint(val), nil
+}
+
+func SetPoolQuota(context *clusterd.Context, clusterName, name, quotaType string, value uint64) error {
+	args := []string{"osd", "pool", "set-quota", name, quotaType, strconv.FormatUint(value, 10)}
+	_, err := ExecuteCephCommand(context, clusterName, args)
+	if err != nil {
+		return fmt.Errorf("failed to set quota %s on pool %s. %+v", quotaType, name, err)
+	}
+	return nil
+}
+
+func GetPoolQuota(context *clusterd.Context, clusterName, name string) (*CephStoragePoolQuota, error) {
+	args := []string{"osd", "pool", "get-quota", name}
+	buf, err := ExecuteCephCommand(context, clusterName, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quota of pool %s. %+v", name, err)
+	}
+
+	var quota CephStoragePoolQuota
+	if err := json.Unmarshal(buf, &quota); err != nil {
+		return nil, fmt.Errorf("unmarshal failed: %+v. raw buffer response: %s", err, string(buf))
+	}
+
+	return &quota, nil
+}
+
 func GetPoolStats(context *clusterd.Context, clusterName string) (*CephStoragePoolStats, error) {
 	args := []string{"df", "detail"}
 	buf, err := ExecuteCephCommand(context, clusterName, args)
@@ -369,3 +429,95 @@ func cephPoolToModelPool(cephPool CephStoragePoolDetails, ecpDetails map[string]
 
 	return pool, nil
 }
+
+// CephPoolMirrorInfo is the mirroring mode and the peers of a pool as reported by "rbd mirror pool info"
+type CephPoolMirrorInfo struct {
+	Mode  string               `json:"mode"`
+	Peers []CephPoolMirrorPeer `json:"peers"`
+}
+
+// CephPoolMirrorPeer is a peer cluster the images of a pool are mirrored with
+type CephPoolMirrorPeer struct {
+	UUID        string `json:"uuid"`
+	ClusterName string `json:"cluster_name"`
+	ClientName  string `json:"client_name"`
+}
+
+// CephPoolMirrorStatus is the health of the mirroring of a pool as reported by "rbd mirror pool status"
+type CephPoolMirrorStatus struct {
+	Summary struct {
+		Health string         `json:"health"`
+		States map[string]int `json:"states"`
+	} `json:"summary"`
+}
+
+// EnablePoolMirroring enables the mirroring of the images of a pool in the "pool" or "image" mode
+func EnablePoolMirroring(context *clusterd.Context, clusterName, poolName, mode string) error {
+	args := []string{"mirror", "pool", "enable", poolName, mode}
+	buf, err := ExecuteRBDCommandNoFormat(context, clusterName, args)
+	if err != nil {
+		return fmt.Errorf("failed to enable mirroring in mode %s on pool %s. %+v. output: %s", mode, poolName, err, string(buf))
+	}
+	return nil
+}
+
+// DisablePoolMirroring disables the mirroring of a pool. The mirroring of the images must be disabled first in the image mode.
+func DisablePoolMirroring(context *clusterd.Context, clusterName, poolName string) error {
+	args := []string{"mirror", "pool", "disable", poolName}
+	buf, err := ExecuteRBDCommandNoFormat(context, clusterName, args)
+	if err != nil {
+		return fmt.Errorf("failed to disable mirroring on pool %s. %+v. output: %s", poolName, err, string(buf))
+	}
+	return nil
+}
+
+// GetPoolMirrorInfo returns the mirroring mode and the peers of a pool
+func GetPoolMirrorInfo(context *clusterd.Context, clusterName, poolName string) (*CephPoolMirrorInfo, error) {
+	args := []string{"mirror", "pool", "info", poolName}
+	buf, err := ExecuteRBDCommand(context, clusterName, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mirroring info of pool %s. %+v", poolName, err)
+	}
+
+	var info CephPoolMirrorInfo
+	if err := json.Unmarshal(buf, &info); err != nil {
+		return nil, fmt.Errorf("unmarshal failed: %+v. raw buffer response: %s", err, string(buf))
+	}
+	return &info, nil
+}
+
+// AddPoolMirrorPeer adds a peer cluster to a pool. The rbd-mirror daemons connect to the peer as the client
+// with the config and keyring of the peer cluster name.
+func AddPoolMirrorPeer(context *clusterd.Context, clusterName, poolName, clientName, peerClusterName string) error {
+	args := []string{"mirror", "pool", "peer", "add", poolName, fmt.Sprintf("%s@%s", clientName, peerClusterName)}
+	buf, err := ExecuteRBDCommandNoFormat(context, clusterName, args)
+	if err != nil {
+		return fmt.Errorf("failed to add mirroring peer %s to pool %s. %+v. output: %s", peerClusterName, poolName, err, string(buf))
+	}
+	return nil
+}
+
+// RemovePoolMirrorPeer removes the peer with the uuid from a pool
+func RemovePoolMirrorPeer(context *clusterd.Context, clusterName, poolName, peerUUID string) error {
+	args := []string{"mirror", "pool", "peer", "remove", poolName, peerUUID}
+	buf, err := ExecuteRBDCommandNoFormat(context, clusterName, args)
+	if err != nil {
+		return fmt.Errorf("failed to remove mirroring peer %s from pool %s. %+v. output: %s", peerUUID, poolName, err, string(buf))
+	}
+	return nil
+}
+
+// GetPoolMirrorStatus returns the health of the mirroring of a pool and the number of images in each mirroring state
+func GetPoolMirrorStatus(context *clusterd.Context, clusterName, poolName string) (*CephPoolMirrorStatus, error) {
+	args := []string{"mirror", "pool", "status", poolName}
+	buf, err := ExecuteRBDCommand(context, clusterName, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mirroring status of pool %s. %+v", poolName, err)
+	}
+
+	var status CephPoolMirrorStatus
+	if err := json.Unmarshal(buf, &status); err != nil {
+		return nil, fmt.Errorf("unmarshal failed: %+v. raw buffer response: %s", err, string(buf))
+	}
+	return &status, nil
+}
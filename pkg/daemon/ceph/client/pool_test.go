@@ -17,6 +17,7 @@ package client
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/rook/rook/pkg/daemon/ceph/model"
@@ -98,13 +99,17 @@ func TestCreateECPoolWithoutOverwrites(t *testing.T) {
 }
 
 func TestCreateReplicaPool(t *testing.T) {
-	testCreateReplicaPool(t, "", "")
+	testCreateReplicaPool(t, "", "", "")
 }
 func TestCreateReplicaPoolWithFailureDomain(t *testing.T) {
-	testCreateReplicaPool(t, "osd", "mycrushroot")
+	testCreateReplicaPool(t, "osd", "mycrushroot", "")
 }
 
-func testCreateReplicaPool(t *testing.T, failureDomain, crushRoot string) {
+func TestCreateReplicaPoolWithDeviceClass(t *testing.T) {
+	testCreateReplicaPool(t, "osd", "mycrushroot", "hdd")
+}
+
+func testCreateReplicaPool(t *testing.T, failureDomain, crushRoot, deviceClass string) {
 	crushRuleCreated := false
 	executor := &exectest.MockExecutor{}
 	context := &clusterd.Context{Executor: executor}
@@ -132,7 +137,12 @@ func testCreateReplicaPool(t *testing.T, failureDomain, crushRoot string) {
 		if args[1] == "crush" {
 			crushRuleCreated = true
 			assert.Equal(t, "rule", args[2])
-			assert.Equal(t, "create-simple", args[3])
+			if deviceClass == "" {
+				assert.Equal(t, "create-simple", args[3])
+			} else {
+				assert.Equal(t, "create-replicated", args[3])
+				assert.Equal(t, deviceClass, args[7])
+			}
 			assert.Equal(t, "mypool", args[4])
 			if crushRoot == "" {
 				assert.Equal(t, "default", args[5])
@@ -149,8 +159,106 @@ func testCreateReplicaPool(t *testing.T, failureDomain, crushRoot string) {
 		return "", fmt.Errorf("unexpected ceph command '%v'", args)
 	}
 
-	p := CephStoragePoolDetails{Name: "mypool", Size: 12345, FailureDomain: failureDomain, CrushRoot: crushRoot}
+	p := CephStoragePoolDetails{Name: "mypool", Size: 12345, FailureDomain: failureDomain, CrushRoot: crushRoot, DeviceClass: deviceClass}
 	err := CreateReplicatedPoolForApp(context, "myns", p, "myapp")
 	assert.Nil(t, err)
 	assert.True(t, crushRuleCreated)
 }
+
+func TestGetPoolProperty(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		assert.Equal(t, []string{"osd", "pool", "get", "mypool"}, args[:4])
+		switch args[4] {
+		case "pg_num":
+			return `{"pool":"mypool","pool_id":1,"pg_num":12345678901}`, nil
+		case "compression_mode":
+			return `{"pool":"mypool","pool_id":1,"compression_mode":"aggressive"}`, nil
+		}
+		return `{"pool":"mypool","pool_id":1}`, nil
+	}
+
+	// large numbers are not converted to floats
+	value, err := GetPoolProperty(context, "myns", "mypool", "pg_num")
+	assert.Nil(t, err)
+	assert.Equal(t, "12345678901", value)
+
+	value, err = GetPoolProperty(context, "myns", "mypool", "compression_mode")
+	assert.Nil(t, err)
+	assert.Equal(t, "aggressive", value)
+
+	_, err = GetPoolProperty(context, "myns", "mypool", "size")
+	assert.NotNil(t, err)
+}
+
+func TestPoolQuota(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		if args[2] == "set-quota" {
+			assert.Equal(t, []string{"osd", "pool", "set-quota", "mypool", "max_bytes", "1024"}, args[:6])
+			return "", nil
+		}
+		assert.Equal(t, []string{"osd", "pool", "get-quota", "mypool"}, args[:4])
+		return `{"pool_name":"mypool","pool_id":1,"quota_max_objects":10,"quota_max_bytes":1024}`, nil
+	}
+
+	err := SetPoolQuota(context, "myns", "mypool", "max_bytes", 1024)
+	assert.Nil(t, err)
+
+	quota, err := GetPoolQuota(context, "myns", "mypool")
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1024), quota.MaxBytes)
+	assert.Equal(t, uint64(10), quota.MaxObjects)
+}
+
+func TestPoolMirroring(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	var commands [][]string
+	executor.MockExecuteCommandWithOutput = func(debug bool, actionName, command string, args ...string) (string, error) {
+		assert.Equal(t, "rbd", command)
+		assert.Equal(t, []string{"mirror", "pool"}, args[:2])
+		switch args[2] {
+		case "info":
+			return `{"mode":"image","peers":[{"uuid":"1234","cluster_name":"site-b","client_name":"client.rbd-mirror-peer"}]}`, nil
+		case "status":
+			return `{"summary":{"health":"WARNING","states":{"replaying":2,"starting_replay":1}}}`, nil
+		}
+		// ignore the flags of the config and keyring
+		for i, arg := range args {
+			if strings.HasPrefix(arg, "--") {
+				args = args[:i]
+				break
+			}
+		}
+		commands = append(commands, args[2:])
+		return "", nil
+	}
+
+	err := EnablePoolMirroring(context, "myns", "mypool", "image")
+	assert.Nil(t, err)
+	err = AddPoolMirrorPeer(context, "myns", "mypool", "client.rbd-mirror-peer", "site-b")
+	assert.Nil(t, err)
+	err = RemovePoolMirrorPeer(context, "myns", "mypool", "1234")
+	assert.Nil(t, err)
+	err = DisablePoolMirroring(context, "myns", "mypool")
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{
+		{"enable", "mypool", "image"},
+		{"peer", "add", "mypool", "client.rbd-mirror-peer@site-b"},
+		{"peer", "remove", "mypool", "1234"},
+		{"disable", "mypool"},
+	}, commands)
+
+	info, err := GetPoolMirrorInfo(context, "myns", "mypool")
+	assert.Nil(t, err)
+	assert.Equal(t, "image", info.Mode)
+	assert.Equal(t, []CephPoolMirrorPeer{{UUID: "1234", ClusterName: "site-b", ClientName: "client.rbd-mirror-peer"}}, info.Peers)
+
+	status, err := GetPoolMirrorStatus(context, "myns", "mypool")
+	assert.Nil(t, err)
+	assert.Equal(t, "WARNING", status.Summary.Health)
+	assert.Equal(t, map[string]int{"replaying": 2, "starting_replay": 1}, status.Summary.States)
+}
@@ -38,6 +38,10 @@ const (
 	DefaultConfigFile = "ceph.conf"
 	// DefaultKeyringFile is the default name of the file where Ceph stores its keyring info
 	DefaultKeyringFile = "keyring"
+	// RGWConfigSection is the section of the ceph config settings in the cluster CRD for the rgw daemons
+	RGWConfigSection = "client.rgw"
+	// RGWClientName is the name of the ceph client the rgw daemons run as
+	RGWClientName = "client.radosgw.gateway"
 )
 
 // GlobalConfig represents the [global] sections of Ceph's config file.
@@ -244,11 +248,44 @@ func createGlobalConfigFileSection(context *clusterd.Context, cluster *ClusterIn
 	}
 
 	configFile := ini.Empty()
-	err := ini.ReflectFrom(configFile, ceph)
-	return configFile, err
+	if err := ini.ReflectFrom(configFile, ceph); err != nil {
+		return nil, err
+	}
+
+	// the settings from the cluster CRD override the generated global settings
+	if err := addCephConfigSections(configFile, context.CephConfig); err != nil {
+		return nil, fmt.Errorf("failed to add ceph config settings. %+v", err)
+	}
+	return configFile, nil
+}
+
+// SectionName returns the name of the section in the config file for a section of the ceph config settings
+// in the cluster CRD. The rgw settings are set on the client the rgw daemons run as.
+func SectionName(section string) string {
+	if section == RGWConfigSection {
+		return RGWClientName
+	}
+	return section
+}
+
+// add the ceph config settings by section to the ini file
+func addCephConfigSections(configFile *ini.File, cephConfig map[string]map[string]string) error {
+	for section, settings := range cephConfig {
+		s, err := configFile.NewSection(SectionName(section))
+		if err != nil {
+			return err
+		}
+		for key, val := range settings {
+			if _, err := s.NewKey(key, val); err != nil {
+				return fmt.Errorf("failed to add key %s to section %s. %v", key, section, err)
+			}
+		}
+	}
+	return nil
 }
 
-// add client config to the ini file
+// add client config to the ini file. The client settings generated by rook take precedence over the ceph
+// config settings of the same client in the cluster CRD.
 func addClientConfigFileSection(configFile *ini.File, clientName, keyringPath string, settings map[string]string) error {
 	s, err := configFile.NewSection(clientName)
 	if err != nil {
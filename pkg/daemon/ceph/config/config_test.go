@@ -116,6 +116,47 @@ debug bluestore = 1234`
 	verifyConfigValue(t, actualConf, "global", "debug bluestore", "1234")
 }
 
+func TestGenerateConfigFileWithCephConfig(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "TestGenerateConfigFileWithCephConfig")
+	if err != nil {
+		t.Fatalf("failed to create temp config dir: %+v", err)
+	}
+	defer os.RemoveAll(configDir)
+
+	context := &clusterd.Context{
+		ConfigDir: configDir,
+		CephConfig: map[string]map[string]string{
+			"global":         {"debug ms": "1", "osd pool default size": "3"},
+			"osd":            {"osd max backfills": "2"},
+			RGWConfigSection: {"rgw cache lru size": "20000", "rgw_zone": "other"},
+		},
+	}
+	clusterInfo := &ClusterInfo{
+		FSID: "myfsid",
+		Name: "foo-cluster",
+		Monitors: map[string]*MonInfo{
+			"node0": {Name: "mon0", Endpoint: "10.0.0.1:6790"},
+		},
+	}
+
+	configFilePath, err := GenerateConfigFile(context, clusterInfo, configDir, RGWClientName, filepath.Join(configDir, "mykeyring"),
+		nil, map[string]string{"rgw_zone": "mystore"})
+	assert.Nil(t, err)
+	actualConf, err := ini.Load(configFilePath)
+	assert.Nil(t, err)
+
+	// the global settings are added and override the generated settings
+	verifyConfigValue(t, actualConf, "global", "fsid", clusterInfo.FSID)
+	verifyConfigValue(t, actualConf, "global", "debug ms", "1")
+	verifyConfigValue(t, actualConf, "global", "osd pool default size", "3")
+	verifyConfigValue(t, actualConf, "osd", "osd max backfills", "2")
+
+	// the rgw settings are set on the rgw client, but cannot override the client settings generated by rook
+	verifyConfigValue(t, actualConf, RGWClientName, "rgw cache lru size", "20000")
+	verifyConfigValue(t, actualConf, RGWClientName, "rgw_zone", "mystore")
+	verifyConfigValue(t, actualConf, RGWClientName, "keyring", filepath.Join(configDir, "mykeyring"))
+}
+
 func verifyConfig(t *testing.T, cephConfig *CephConfig, expectedMonMembers string, loggingLevel int) {
 
 	for _, expectedMon := range strings.Split(expectedMonMembers, " ") {
@@ -0,0 +1,224 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"fmt"
+
+	csi "github.com/container-storage-interface/spec/lib/go/csi/v0"
+	"github.com/rook/rook/pkg/clusterd"
+	ceph "github.com/rook/rook/pkg/daemon/ceph/client"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// the size of block volumes when the claim does not request a capacity
+	defaultVolumeSize = int64(1024 * 1024 * 1024)
+)
+
+// controllerServer creates and deletes volumes
+type controllerServer struct {
+	context *clusterd.Context
+}
+
+func newControllerServer(context *clusterd.Context) *controllerServer {
+	return &controllerServer{context: context}
+}
+
+func (s *controllerServer) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest) (*csi.CreateVolumeResponse, error) {
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing volume name")
+	}
+	if len(req.GetVolumeCapabilities()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "missing volume capabilities")
+	}
+
+	cfg, err := parseVolumeParameters(req.GetParameters())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if cfg.fsName != "" {
+		// filesystem volumes share the path in the filesystem
+		id := &volumeID{volumeType: cephFSVolumeType, clusterNamespace: cfg.clusterNamespace, pool: cfg.fsName, name: cfg.path}
+		logger.Infof("creating filesystem volume %s for %s", id.String(), req.GetName())
+		return &csi.CreateVolumeResponse{
+			Volume: &csi.Volume{
+				Id:         id.String(),
+				Attributes: req.GetParameters(),
+			},
+		}, nil
+	}
+
+	if !supportsCapabilities(rbdVolumeType, req.GetVolumeCapabilities()) {
+		return nil, status.Error(codes.InvalidArgument, "block volumes cannot be written by multiple nodes")
+	}
+
+	size := defaultVolumeSize
+	if req.GetCapacityRange() != nil && req.GetCapacityRange().GetRequiredBytes() > 0 {
+		size = req.GetCapacityRange().GetRequiredBytes()
+	}
+
+	if err := s.loadCephCluster(cfg.clusterNamespace); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	// creating the image is idempotent, an existing image with the same name is returned
+	logger.Infof("creating block volume %s/%s in cluster %s", cfg.pool, req.GetName(), cfg.clusterNamespace)
+	image, err := ceph.CreateImage(s.context, cfg.clusterNamespace, req.GetName(), cfg.pool, cfg.dataPool, uint64(size))
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	id := &volumeID{volumeType: rbdVolumeType, clusterNamespace: cfg.clusterNamespace, pool: cfg.pool, name: image.Name}
+	return &csi.CreateVolumeResponse{
+		Volume: &csi.Volume{
+			Id:            id.String(),
+			CapacityBytes: int64(image.Size),
+			Attributes:    req.GetParameters(),
+		},
+	}, nil
+}
+
+func (s *controllerServer) DeleteVolume(ctx context.Context, req *csi.DeleteVolumeRequest) (*csi.DeleteVolumeResponse, error) {
+	id, err := parseVolumeID(req.GetVolumeId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if id.volumeType == cephFSVolumeType {
+		// the shared filesystem is not removed with the volume
+		return &csi.DeleteVolumeResponse{}, nil
+	}
+
+	if err := s.loadCephCluster(id.clusterNamespace); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	// deleting a volume that does not exist succeeds
+	images, err := ceph.ListImages(s.context, id.clusterNamespace, id.pool)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	found := false
+	for _, image := range images {
+		if image.Name == id.name {
+			found = true
+			break
+		}
+	}
+	if !found {
+		logger.Infof("block volume %s was already deleted", id.String())
+		return &csi.DeleteVolumeResponse{}, nil
+	}
+
+	logger.Infof("deleting block volume %s/%s in cluster %s", id.pool, id.name, id.clusterNamespace)
+	if err := ceph.DeleteImage(s.context, id.clusterNamespace, id.name, id.pool); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &csi.DeleteVolumeResponse{}, nil
+}
+
+// loadCephCluster writes the admin config of the Ceph cluster to run the rbd commands against the cluster.
+// The config is written before each call since the mons of the cluster may have changed.
+func (s *controllerServer) loadCephCluster(clusterNamespace string) error {
+	clusterInfo, _, _, err := mon.LoadClusterInfo(s.context, clusterNamespace)
+	if err != nil {
+		return fmt.Errorf("failed to load ceph cluster %s. %+v", clusterNamespace, err)
+	}
+	if err := cephconfig.GenerateAdminConnectionConfig(s.context, clusterInfo); err != nil {
+		return fmt.Errorf("failed to write config of ceph cluster %s. %+v", clusterNamespace, err)
+	}
+	return nil
+}
+
+func (s *controllerServer) ValidateVolumeCapabilities(ctx context.Context, req *csi.ValidateVolumeCapabilitiesRequest) (*csi.ValidateVolumeCapabilitiesResponse, error) {
+	id, err := parseVolumeID(req.GetVolumeId())
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if len(req.GetVolumeCapabilities()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "missing volume capabilities")
+	}
+
+	if !supportsCapabilities(id.volumeType, req.GetVolumeCapabilities()) {
+		return &csi.ValidateVolumeCapabilitiesResponse{Supported: false, Message: "block volumes cannot be written by multiple nodes"}, nil
+	}
+	return &csi.ValidateVolumeCapabilitiesResponse{Supported: true}, nil
+}
+
+func (s *controllerServer) ControllerGetCapabilities(ctx context.Context, req *csi.ControllerGetCapabilitiesRequest) (*csi.ControllerGetCapabilitiesResponse, error) {
+	return &csi.ControllerGetCapabilitiesResponse{
+		Capabilities: []*csi.ControllerServiceCapability{
+			{
+				Type: &csi.ControllerServiceCapability_Rpc{
+					Rpc: &csi.ControllerServiceCapability_RPC{
+						Type: csi.ControllerServiceCapability_RPC_CREATE_DELETE_VOLUME,
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+func (s *controllerServer) ControllerPublishVolume(ctx context.Context, req *csi.ControllerPublishVolumeRequest) (*csi.ControllerPublishVolumeResponse, error) {
+	// volumes are attached by the node service when they are staged
+	return nil, status.Error(codes.Unimplemented, "")
+}
+
+func (s *controllerServer) ControllerUnpublishVolume(ctx context.Context, req *csi.ControllerUnpublishVolumeRequest) (*csi.ControllerUnpublishVolumeResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "")
+}
+
+func (s *controllerServer) ListVolumes(ctx context.Context, req *csi.ListVolumesRequest) (*csi.ListVolumesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "")
+}
+
+func (s *controllerServer) GetCapacity(ctx context.Context, req *csi.GetCapacityRequest) (*csi.GetCapacityResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "")
+}
+
+func (s *controllerServer) CreateSnapshot(ctx context.Context, req *csi.CreateSnapshotRequest) (*csi.CreateSnapshotResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "")
+}
+
+func (s *controllerServer) DeleteSnapshot(ctx context.Context, req *csi.DeleteSnapshotRequest) (*csi.DeleteSnapshotResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "")
+}
+
+func (s *controllerServer) ListSnapshots(ctx context.Context, req *csi.ListSnapshotsRequest) (*csi.ListSnapshotsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "")
+}
+
+// supportsCapabilities returns whether the volume type supports all the access modes.
+// Block volumes can be mounted read-only on many nodes, but they can only be written by a single node.
+func supportsCapabilities(volumeType string, capabilities []*csi.VolumeCapability) bool {
+	if volumeType == cephFSVolumeType {
+		return true
+	}
+	for _, c := range capabilities {
+		if c.GetAccessMode().GetMode() == csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER ||
+			c.GetAccessMode().GetMode() == csi.VolumeCapability_AccessMode_MULTI_NODE_SINGLE_WRITER {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	csi "github.com/container-storage-interface/spec/lib/go/csi/v0"
+	"github.com/rook/rook/pkg/clusterd"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCreateAndDeleteBlockVolume(t *testing.T) {
+	commands := []string{}
+	imageList := `[{"image":"pvc-1","size":2097152,"format":2}]`
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			commands = append(commands, args[0])
+			if args[0] == "create" {
+				assert.Equal(t, "replicapool/pvc-1", args[1])
+				assert.Equal(t, "2", args[3])
+			}
+			if args[0] == "ls" {
+				return imageList, nil
+			}
+			return "", nil
+		},
+	}
+	// the config of the cluster is written by the driver, the config dir starts empty
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	clientset := testop.New(3)
+	s := newControllerServer(&clusterd.Context{Clientset: clientset, Executor: executor, ConfigDir: configDir})
+	capabilities := []*csi.VolumeCapability{
+		{AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER}},
+	}
+
+	// the volume cannot be created before the cluster exists
+	_, err := s.CreateVolume(context.Background(), &csi.CreateVolumeRequest{
+		Name:               "pvc-1",
+		VolumeCapabilities: capabilities,
+		Parameters:         map[string]string{"pool": "replicapool", "clusterNamespace": "rook-ceph"},
+	})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, 0, len(commands))
+
+	clientset.CoreV1().Secrets("rook-ceph").Create(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon", Namespace: "rook-ceph"},
+		Data:       map[string][]byte{"cluster-name": []byte("rook-ceph"), "fsid": []byte("myfsid"), "admin-secret": []byte("adminkey")},
+	})
+	clientset.CoreV1().ConfigMaps("rook-ceph").Create(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon-endpoints", Namespace: "rook-ceph"},
+		Data:       map[string]string{"data": "a=1.2.3.4:6790"},
+	})
+
+	resp, err := s.CreateVolume(context.Background(), &csi.CreateVolumeRequest{
+		Name:               "pvc-1",
+		CapacityRange:      &csi.CapacityRange{RequiredBytes: 2 * 1024 * 1024},
+		VolumeCapabilities: capabilities,
+		Parameters:         map[string]string{"pool": "replicapool", "clusterNamespace": "rook-ceph"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "rbd/rook-ceph/replicapool/pvc-1", resp.Volume.Id)
+	assert.Equal(t, int64(2097152), resp.Volume.CapacityBytes)
+	assert.Equal(t, []string{"create", "ls"}, commands)
+	_, err = os.Stat(path.Join(configDir, "rook-ceph", "rook-ceph.config"))
+	assert.Nil(t, err)
+	_, err = os.Stat(path.Join(configDir, "rook-ceph", "client.admin.keyring"))
+	assert.Nil(t, err)
+
+	// block volumes cannot be written by multiple nodes
+	_, err = s.CreateVolume(context.Background(), &csi.CreateVolumeRequest{
+		Name: "pvc-2",
+		VolumeCapabilities: []*csi.VolumeCapability{
+			{AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER}},
+		},
+		Parameters: map[string]string{"pool": "replicapool"},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// the image is deleted
+	commands = []string{}
+	_, err = s.DeleteVolume(context.Background(), &csi.DeleteVolumeRequest{VolumeId: resp.Volume.Id})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ls", "rm"}, commands)
+
+	// deleting a volume that does not exist succeeds
+	commands = []string{}
+	imageList = `[]`
+	_, err = s.DeleteVolume(context.Background(), &csi.DeleteVolumeRequest{VolumeId: resp.Volume.Id})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ls"}, commands)
+
+	// the volume id must be valid
+	_, err = s.DeleteVolume(context.Background(), &csi.DeleteVolumeRequest{VolumeId: "pvc-1"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestCreateFilesystemVolume(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			assert.Fail(t, "no commands expected for filesystem volumes")
+			return "", nil
+		},
+	}
+	s := newControllerServer(&clusterd.Context{Executor: executor})
+	capabilities := []*csi.VolumeCapability{
+		{AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER}},
+	}
+
+	resp, err := s.CreateVolume(context.Background(), &csi.CreateVolumeRequest{
+		Name:               "pvc-1",
+		VolumeCapabilities: capabilities,
+		Parameters:         map[string]string{"fsName": "myfs", "path": "/shared"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "cephfs/rook-ceph/myfs//shared", resp.Volume.Id)
+
+	validate, err := s.ValidateVolumeCapabilities(context.Background(), &csi.ValidateVolumeCapabilitiesRequest{VolumeId: resp.Volume.Id, VolumeCapabilities: capabilities})
+	assert.Nil(t, err)
+	assert.True(t, validate.Supported)
+
+	_, err = s.DeleteVolume(context.Background(), &csi.DeleteVolumeRequest{VolumeId: resp.Volume.Id})
+	assert.Nil(t, err)
+}
+
+func TestParseVolumeParameters(t *testing.T) {
+	cfg, err := parseVolumeParameters(map[string]string{"pool": "replicapool", "dataPool": "ecpool"})
+	assert.Nil(t, err)
+	assert.Equal(t, "replicapool", cfg.pool)
+	assert.Equal(t, "ecpool", cfg.dataPool)
+	assert.Equal(t, "rook-ceph", cfg.clusterNamespace)
+
+	cfg, err = parseVolumeParameters(map[string]string{"fsName": "myfs", "clusterNamespace": "other"})
+	assert.Nil(t, err)
+	assert.Equal(t, "myfs", cfg.fsName)
+	assert.Equal(t, "/", cfg.path)
+	assert.Equal(t, "other", cfg.clusterNamespace)
+
+	// either a pool or a filesystem is required
+	_, err = parseVolumeParameters(map[string]string{})
+	assert.NotNil(t, err)
+	_, err = parseVolumeParameters(map[string]string{"pool": "replicapool", "fsName": "myfs"})
+	assert.NotNil(t, err)
+
+	// unknown parameters are not allowed
+	_, err = parseVolumeParameters(map[string]string{"pool": "replicapool", "foo": "bar"})
+	assert.NotNil(t, err)
+}
+
+func TestParseVolumeID(t *testing.T) {
+	id, err := parseVolumeID("rbd/rook-ceph/replicapool/pvc-1")
+	assert.Nil(t, err)
+	assert.Equal(t, &volumeID{volumeType: "rbd", clusterNamespace: "rook-ceph", pool: "replicapool", name: "pvc-1"}, id)
+
+	// the path of a filesystem volume is the rest of the id
+	id, err = parseVolumeID("cephfs/rook-ceph/myfs//a/b")
+	assert.Nil(t, err)
+	assert.Equal(t, "/a/b", id.name)
+	assert.Equal(t, "cephfs/rook-ceph/myfs//a/b", id.String())
+
+	_, err = parseVolumeID("rbd/rook-ceph/replicapool")
+	assert.NotNil(t, err)
+	_, err = parseVolumeID("nfs/rook-ceph/replicapool/pvc-1")
+	assert.NotNil(t, err)
+	_, err = parseVolumeID("rbd//replicapool/pvc-1")
+	assert.NotNil(t, err)
+}
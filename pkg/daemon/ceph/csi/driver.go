@@ -0,0 +1,135 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package csi implements the container storage interface services for rook ceph block and filesystem volumes.
+package csi
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+
+	csi "github.com/container-storage-interface/spec/lib/go/csi/v0"
+	"github.com/coreos/pkg/capnslog"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	k8smount "k8s.io/kubernetes/pkg/util/mount"
+)
+
+const (
+	// DriverName is the name of the rook ceph csi driver
+	DriverName = "csi.ceph.rook.io"
+
+	// ControllerMode runs the identity and controller services
+	ControllerMode = "controller"
+	// NodeMode runs the identity and node services
+	NodeMode = "node"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "ceph-csi")
+
+// Driver serves the csi services for rook ceph volumes
+type Driver struct {
+	name       string
+	identity   *identityServer
+	controller *controllerServer
+	node       *nodeServer
+}
+
+// NewControllerDriver creates a driver that serves the identity and controller services
+func NewControllerDriver(context *clusterd.Context, name, version string) *Driver {
+	return &Driver{
+		name:       name,
+		identity:   newIdentityServer(name, version),
+		controller: newControllerServer(context),
+	}
+}
+
+// NewNodeDriver creates a driver that serves the identity and node services on the given node
+func NewNodeDriver(context *clusterd.Context, name, version, nodeID string, volumeManager flexvolume.VolumeManager, mounter *k8smount.SafeFormatAndMount) *Driver {
+	return &Driver{
+		name:     name,
+		identity: newIdentityServer(name, version),
+		node:     newNodeServer(context, nodeID, volumeManager, mounter),
+	}
+}
+
+// Run serves the csi services on the unix socket endpoint until the stop channel is closed
+func (d *Driver) Run(endpoint string, stopCh chan struct{}) error {
+	socketPath, err := parseEndpoint(endpoint)
+	if err != nil {
+		return err
+	}
+
+	// remove the socket left behind by a previous instance of the driver
+	if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove socket %s. %+v", socketPath, err)
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s. %+v", socketPath, err)
+	}
+
+	server := grpc.NewServer(grpc.UnaryInterceptor(logRequest))
+	csi.RegisterIdentityServer(server, d.identity)
+	if d.controller != nil {
+		csi.RegisterControllerServer(server, d.controller)
+	}
+	if d.node != nil {
+		csi.RegisterNodeServer(server, d.node)
+	}
+
+	logger.Infof("csi driver %s listening on %s", d.name, socketPath)
+	go server.Serve(listener)
+
+	<-stopCh
+	logger.Infof("stopping csi driver %s", d.name)
+	server.GracefulStop()
+	return nil
+}
+
+func parseEndpoint(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid endpoint %s. %+v", endpoint, err)
+	}
+	if u.Scheme != "unix" {
+		return "", fmt.Errorf("endpoint %s must be a unix socket", endpoint)
+	}
+
+	path := u.Path
+	if u.Host != "" {
+		// the endpoint is relative, e.g. unix://csi.sock
+		path = u.Host + path
+	}
+	if path == "" {
+		return "", fmt.Errorf("endpoint %s has no socket path", endpoint)
+	}
+	return path, nil
+}
+
+func logRequest(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	logger.Debugf("csi call %s: %+v", info.FullMethod, req)
+	resp, err := handler(ctx, req)
+	if err != nil {
+		logger.Errorf("csi call %s failed. %+v", info.FullMethod, err)
+	}
+	return resp, err
+}
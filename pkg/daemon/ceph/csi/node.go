@@ -0,0 +1,258 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"fmt"
+	"os"
+
+	csi "github.com/container-storage-interface/spec/lib/go/csi/v0"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8smount "k8s.io/kubernetes/pkg/util/mount"
+	"k8s.io/kubernetes/pkg/volume/util"
+)
+
+const (
+	defaultFSType = "ext4"
+)
+
+// nodeServer attaches and mounts volumes on the node
+type nodeServer struct {
+	context       *clusterd.Context
+	nodeID        string
+	volumeManager flexvolume.VolumeManager
+	mounter       *k8smount.SafeFormatAndMount
+}
+
+func newNodeServer(context *clusterd.Context, nodeID string, volumeManager flexvolume.VolumeManager, mounter *k8smount.SafeFormatAndMount) *nodeServer {
+	return &nodeServer{
+		context:       context,
+		nodeID:        nodeID,
+		volumeManager: volumeManager,
+		mounter:       mounter,
+	}
+}
+
+// NodeStageVolume attaches a block volume and mounts it, or mounts a filesystem volume, to the global staging path on the node
+func (s *nodeServer) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolumeRequest) (*csi.NodeStageVolumeResponse, error) {
+	id, err := parseVolumeID(req.GetVolumeId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	stagingPath := req.GetStagingTargetPath()
+	if stagingPath == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing staging target path")
+	}
+	if req.GetVolumeCapability() == nil {
+		return nil, status.Error(codes.InvalidArgument, "missing volume capability")
+	}
+	if req.GetVolumeCapability().GetBlock() != nil {
+		return nil, status.Error(codes.InvalidArgument, "raw block volumes are not supported")
+	}
+
+	notMnt, err := s.ensureMountPoint(stagingPath)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if !notMnt {
+		logger.Infof("volume %s is already staged at %s", id.String(), stagingPath)
+		return &csi.NodeStageVolumeResponse{}, nil
+	}
+
+	if id.volumeType == cephFSVolumeType {
+		err = s.mountFilesystem(id, stagingPath, req.GetVolumeCapability().GetMount().GetMountFlags())
+	} else {
+		err = s.mountBlock(id, stagingPath, req.GetVolumeCapability())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	logger.Infof("volume %s has been staged at %s", id.String(), stagingPath)
+	return &csi.NodeStageVolumeResponse{}, nil
+}
+
+// NodeUnstageVolume unmounts the volume from the global staging path and detaches block volumes from the node
+func (s *nodeServer) NodeUnstageVolume(ctx context.Context, req *csi.NodeUnstageVolumeRequest) (*csi.NodeUnstageVolumeResponse, error) {
+	id, err := parseVolumeID(req.GetVolumeId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	stagingPath := req.GetStagingTargetPath()
+	if stagingPath == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing staging target path")
+	}
+
+	if err := util.UnmountPath(stagingPath, s.mounter.Interface); err != nil {
+		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to unmount %s. %+v", stagingPath, err))
+	}
+
+	if id.volumeType == rbdVolumeType {
+		if err := s.volumeManager.Detach(id.name, id.pool, id.clusterNamespace, false); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+
+	logger.Infof("volume %s has been unstaged from %s", id.String(), stagingPath)
+	return &csi.NodeUnstageVolumeResponse{}, nil
+}
+
+// NodePublishVolume bind mounts the staged volume to the target path of the pod
+func (s *nodeServer) NodePublishVolume(ctx context.Context, req *csi.NodePublishVolumeRequest) (*csi.NodePublishVolumeResponse, error) {
+	id, err := parseVolumeID(req.GetVolumeId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	stagingPath := req.GetStagingTargetPath()
+	targetPath := req.GetTargetPath()
+	if stagingPath == "" || targetPath == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing staging target path or target path")
+	}
+	if req.GetVolumeCapability() == nil {
+		return nil, status.Error(codes.InvalidArgument, "missing volume capability")
+	}
+
+	notMnt, err := s.ensureMountPoint(targetPath)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if !notMnt {
+		logger.Infof("volume %s is already published at %s", id.String(), targetPath)
+		return &csi.NodePublishVolumeResponse{}, nil
+	}
+
+	options := []string{"bind"}
+	if req.GetReadonly() {
+		options = append(options, "ro")
+	}
+	if err := s.mounter.Interface.Mount(stagingPath, targetPath, "", options); err != nil {
+		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to mount %s to %s. %+v", stagingPath, targetPath, err))
+	}
+
+	logger.Infof("volume %s has been published at %s", id.String(), targetPath)
+	return &csi.NodePublishVolumeResponse{}, nil
+}
+
+// NodeUnpublishVolume unmounts the volume from the target path of the pod
+func (s *nodeServer) NodeUnpublishVolume(ctx context.Context, req *csi.NodeUnpublishVolumeRequest) (*csi.NodeUnpublishVolumeResponse, error) {
+	targetPath := req.GetTargetPath()
+	if req.GetVolumeId() == "" || targetPath == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing volume id or target path")
+	}
+
+	if err := util.UnmountPath(targetPath, s.mounter.Interface); err != nil {
+		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to unmount %s. %+v", targetPath, err))
+	}
+
+	logger.Infof("volume %s has been unpublished from %s", req.GetVolumeId(), targetPath)
+	return &csi.NodeUnpublishVolumeResponse{}, nil
+}
+
+func (s *nodeServer) NodeGetId(ctx context.Context, req *csi.NodeGetIdRequest) (*csi.NodeGetIdResponse, error) {
+	return &csi.NodeGetIdResponse{NodeId: s.nodeID}, nil
+}
+
+func (s *nodeServer) NodeGetInfo(ctx context.Context, req *csi.NodeGetInfoRequest) (*csi.NodeGetInfoResponse, error) {
+	return &csi.NodeGetInfoResponse{NodeId: s.nodeID}, nil
+}
+
+func (s *nodeServer) NodeGetCapabilities(ctx context.Context, req *csi.NodeGetCapabilitiesRequest) (*csi.NodeGetCapabilitiesResponse, error) {
+	return &csi.NodeGetCapabilitiesResponse{
+		Capabilities: []*csi.NodeServiceCapability{
+			{
+				Type: &csi.NodeServiceCapability_Rpc{
+					Rpc: &csi.NodeServiceCapability_RPC{
+						Type: csi.NodeServiceCapability_RPC_STAGE_UNSTAGE_VOLUME,
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+// ensureMountPoint creates the directory of the mount point if needed and returns whether it is not mounted yet
+func (s *nodeServer) ensureMountPoint(path string) (bool, error) {
+	notMnt, err := s.mounter.Interface.IsLikelyNotMountPoint(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return false, fmt.Errorf("failed to check if %s is a mount point. %+v", path, err)
+		}
+		if err := os.MkdirAll(path, 0750); err != nil {
+			return false, fmt.Errorf("failed to create mount point %s. %+v", path, err)
+		}
+		notMnt = true
+	}
+	return notMnt, nil
+}
+
+func (s *nodeServer) mountBlock(id *volumeID, stagingPath string, capability *csi.VolumeCapability) error {
+	devicePath, err := s.volumeManager.Attach(id.name, id.pool, id.clusterNamespace)
+	if err != nil {
+		return fmt.Errorf("failed to attach volume %s/%s. %+v", id.pool, id.name, err)
+	}
+
+	fsType := capability.GetMount().GetFsType()
+	if fsType == "" {
+		fsType = defaultFSType
+	}
+	options := capability.GetMount().GetMountFlags()
+	if capability.GetAccessMode().GetMode() == csi.VolumeCapability_AccessMode_MULTI_NODE_READER_ONLY ||
+		capability.GetAccessMode().GetMode() == csi.VolumeCapability_AccessMode_SINGLE_NODE_READER_ONLY {
+		options = append(options, flexvolume.ReadOnly)
+	}
+
+	if err := s.mounter.FormatAndMount(devicePath, stagingPath, fsType, options); err != nil {
+		// the image is detached so that it is not attached again each time the staging is retried
+		if detachErr := s.volumeManager.Detach(id.name, id.pool, id.clusterNamespace, false); detachErr != nil {
+			logger.Errorf("failed to detach volume %s/%s after the mount failed. %+v", id.pool, id.name, detachErr)
+		}
+		return fmt.Errorf("failed to mount volume %s [%s] to %s. %+v", devicePath, fsType, stagingPath, err)
+	}
+	return nil
+}
+
+func (s *nodeServer) mountFilesystem(id *volumeID, stagingPath string, mountFlags []string) error {
+	clientAccessInfo, err := flexvolume.LoadClientAccessInfo(s.context, id.clusterNamespace, id.pool, "")
+	if err != nil {
+		return err
+	}
+
+	kernelVersion := ""
+	node, err := s.context.Clientset.CoreV1().Nodes().Get(s.nodeID, metav1.GetOptions{})
+	if err != nil {
+		logger.Warningf("failed to get kernel version of node %s. %+v", s.nodeID, err)
+	} else {
+		kernelVersion = node.Status.NodeInfo.KernelVersion
+	}
+	options, err := flexvolume.CephFSMountOptions(*clientAccessInfo, id.pool, kernelVersion)
+	if err != nil {
+		logger.Warning(err.Error())
+	}
+	options = append(options, mountFlags...)
+
+	devicePath := flexvolume.CephFSMountDevice(clientAccessInfo.MonAddresses, id.name)
+	logger.Infof("mounting ceph filesystem %s on %s to %s", id.pool, devicePath, stagingPath)
+	if err := s.mounter.Interface.Mount(devicePath, stagingPath, flexvolume.CephFSType, options); err != nil {
+		return fmt.Errorf("failed to mount filesystem %s to %s with monitor %s. %+v", id.pool, stagingPath, devicePath, err)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	csi "github.com/container-storage-interface/spec/lib/go/csi/v0"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	k8smount "k8s.io/kubernetes/pkg/util/mount"
+	utilexec "k8s.io/utils/exec"
+)
+
+type fakeVolumeManager struct {
+	attached map[string]string
+}
+
+func (f *fakeVolumeManager) Init() error {
+	return nil
+}
+
+func (f *fakeVolumeManager) Attach(image, pool, clusterNamespace string) (string, error) {
+	f.attached[pool+"/"+image] = "/dev/rbd0"
+	return "/dev/rbd0", nil
+}
+
+func (f *fakeVolumeManager) Detach(image, pool, clusterNamespace string, force bool) error {
+	delete(f.attached, pool+"/"+image)
+	return nil
+}
+
+func TestStageAndPublishBlockVolume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	volumeManager := &fakeVolumeManager{attached: map[string]string{}}
+	mounter := &k8smount.FakeMounter{}
+	exec := k8smount.NewFakeExec(func(cmd string, args ...string) ([]byte, error) {
+		return nil, nil
+	})
+	s := newNodeServer(&clusterd.Context{}, "node1", volumeManager, &k8smount.SafeFormatAndMount{Interface: mounter, Exec: exec})
+
+	volumeID := "rbd/rook-ceph/replicapool/pvc-1"
+	stagingPath := path.Join(dir, "staging")
+	targetPath := path.Join(dir, "target")
+	capability := &csi.VolumeCapability{
+		AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{FsType: "xfs"}},
+		AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER},
+	}
+
+	// the image is attached and mounted to the staging path
+	_, err = s.NodeStageVolume(context.Background(), &csi.NodeStageVolumeRequest{VolumeId: volumeID, StagingTargetPath: stagingPath, VolumeCapability: capability})
+	assert.Nil(t, err)
+	assert.Equal(t, "/dev/rbd0", volumeManager.attached["replicapool/pvc-1"])
+	assert.Equal(t, 1, len(mounter.MountPoints))
+	assert.Equal(t, "/dev/rbd0", mounter.MountPoints[0].Device)
+	assert.Equal(t, "xfs", mounter.MountPoints[0].Type)
+
+	// staging again does not mount again
+	_, err = s.NodeStageVolume(context.Background(), &csi.NodeStageVolumeRequest{VolumeId: volumeID, StagingTargetPath: stagingPath, VolumeCapability: capability})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(mounter.MountPoints))
+
+	// the staging path is bind mounted to the target path
+	_, err = s.NodePublishVolume(context.Background(), &csi.NodePublishVolumeRequest{VolumeId: volumeID, StagingTargetPath: stagingPath, TargetPath: targetPath, VolumeCapability: capability, Readonly: true})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(mounter.MountPoints))
+	assert.Equal(t, "/dev/rbd0", mounter.MountPoints[1].Device)
+
+	_, err = s.NodeUnpublishVolume(context.Background(), &csi.NodeUnpublishVolumeRequest{VolumeId: volumeID, TargetPath: targetPath})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(mounter.MountPoints))
+
+	// the image is unmounted and detached
+	_, err = s.NodeUnstageVolume(context.Background(), &csi.NodeUnstageVolumeRequest{VolumeId: volumeID, StagingTargetPath: stagingPath})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(mounter.MountPoints))
+	assert.Equal(t, 0, len(volumeManager.attached))
+}
+
+func TestStageBlockVolumeMountFails(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	volumeManager := &fakeVolumeManager{attached: map[string]string{}}
+	mounter := &k8smount.FakeMounter{}
+	// fsck finds errors on the device that it cannot correct
+	exec := k8smount.NewFakeExec(func(cmd string, args ...string) ([]byte, error) {
+		if cmd == "fsck" {
+			return nil, utilexec.CodeExitError{Err: errors.New("uncorrected errors"), Code: 4}
+		}
+		return nil, nil
+	})
+	s := newNodeServer(&clusterd.Context{}, "node1", volumeManager, &k8smount.SafeFormatAndMount{Interface: mounter, Exec: exec})
+	capability := &csi.VolumeCapability{
+		AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{}},
+		AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER},
+	}
+
+	// the image is detached when it cannot be mounted
+	_, err = s.NodeStageVolume(context.Background(), &csi.NodeStageVolumeRequest{VolumeId: "rbd/rook-ceph/replicapool/pvc-1", StagingTargetPath: path.Join(dir, "staging"), VolumeCapability: capability})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, 0, len(mounter.MountPoints))
+	assert.Equal(t, 0, len(volumeManager.attached))
+}
+
+func TestStageInvalidArguments(t *testing.T) {
+	s := newNodeServer(&clusterd.Context{}, "node1", &fakeVolumeManager{attached: map[string]string{}}, &k8smount.SafeFormatAndMount{Interface: &k8smount.FakeMounter{}})
+	mount := &csi.VolumeCapability{AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{}}}
+	block := &csi.VolumeCapability{AccessType: &csi.VolumeCapability_Block{Block: &csi.VolumeCapability_BlockVolume{}}}
+
+	_, err := s.NodeStageVolume(context.Background(), &csi.NodeStageVolumeRequest{VolumeId: "pvc-1", StagingTargetPath: "/staging", VolumeCapability: mount})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = s.NodeStageVolume(context.Background(), &csi.NodeStageVolumeRequest{VolumeId: "rbd/rook-ceph/replicapool/pvc-1", VolumeCapability: mount})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = s.NodeStageVolume(context.Background(), &csi.NodeStageVolumeRequest{VolumeId: "rbd/rook-ceph/replicapool/pvc-1", StagingTargetPath: "/staging", VolumeCapability: block})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = s.NodePublishVolume(context.Background(), &csi.NodePublishVolumeRequest{VolumeId: "rbd/rook-ceph/replicapool/pvc-1", StagingTargetPath: "/staging", VolumeCapability: mount})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	id, err := s.NodeGetId(context.Background(), &csi.NodeGetIdRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, "node1", id.NodeId)
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	rbdVolumeType    = "rbd"
+	cephFSVolumeType = "cephfs"
+
+	poolKey             = "pool"
+	dataPoolKey         = "datapool"
+	clusterNamespaceKey = "clusternamespace"
+	fsNameKey           = "fsname"
+	pathKey             = "path"
+
+	// defaultClusterNamespace is the namespace of the cluster of the volumes when the storage class does not set it.
+	// It is the same default as the one of the operator and the flex driver.
+	defaultClusterNamespace = "rook-ceph"
+)
+
+// volumeID identifies a volume in all the csi calls. Block volumes are identified by the pool and the image,
+// and filesystem volumes by the filesystem and the path in the filesystem.
+type volumeID struct {
+	volumeType       string
+	clusterNamespace string
+	// the pool of a block volume or the filesystem of a filesystem volume
+	pool string
+	// the image of a block volume or the path of a filesystem volume
+	name string
+}
+
+func (v *volumeID) String() string {
+	return strings.Join([]string{v.volumeType, v.clusterNamespace, v.pool, v.name}, "/")
+}
+
+func parseVolumeID(id string) (*volumeID, error) {
+	// the path of a filesystem volume may contain separators, so it is the last part of the id
+	parts := strings.SplitN(id, "/", 4)
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("invalid volume id %s", id)
+	}
+
+	v := &volumeID{volumeType: parts[0], clusterNamespace: parts[1], pool: parts[2], name: parts[3]}
+	if v.volumeType != rbdVolumeType && v.volumeType != cephFSVolumeType {
+		return nil, fmt.Errorf("unknown type %s of volume id %s", v.volumeType, id)
+	}
+	if v.clusterNamespace == "" || v.pool == "" || v.name == "" {
+		return nil, fmt.Errorf("invalid volume id %s", id)
+	}
+	return v, nil
+}
+
+// volumeConfig is the configuration of a volume from the parameters of the storage class
+type volumeConfig struct {
+	clusterNamespace string
+
+	// block volumes
+	pool     string
+	dataPool string
+
+	// filesystem volumes
+	fsName string
+	path   string
+}
+
+func parseVolumeParameters(params map[string]string) (*volumeConfig, error) {
+	var cfg volumeConfig
+
+	for k, v := range params {
+		switch strings.ToLower(k) {
+		case poolKey:
+			cfg.pool = v
+		case dataPoolKey:
+			cfg.dataPool = v
+		case clusterNamespaceKey:
+			cfg.clusterNamespace = v
+		case fsNameKey:
+			cfg.fsName = v
+		case pathKey:
+			cfg.path = v
+		default:
+			return nil, fmt.Errorf("invalid option %q for csi driver %s", k, DriverName)
+		}
+	}
+
+	if cfg.pool == "" && cfg.fsName == "" {
+		return nil, fmt.Errorf("StorageClass for csi driver %s must contain either the 'pool' or the 'fsName' parameter", DriverName)
+	}
+	if cfg.pool != "" && cfg.fsName != "" {
+		return nil, fmt.Errorf("StorageClass for csi driver %s cannot contain both the 'pool' and the 'fsName' parameters", DriverName)
+	}
+
+	if cfg.clusterNamespace == "" {
+		cfg.clusterNamespace = defaultClusterNamespace
+	}
+	if cfg.fsName != "" && cfg.path == "" {
+		cfg.path = "/"
+	}
+
+	return &cfg, nil
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package file provides methods for managing the directories of volumes in Ceph filesystems.
+package file
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"k8s.io/kubernetes/pkg/util/mount"
+)
+
+const (
+	// VolumeCommand is the `rook ceph` subcommand which creates or deletes the directory of a volume in a filesystem
+	VolumeCommand = "filesystem-volume"
+
+	quotaMaxBytesAttr = "ceph.quota.max_bytes"
+	kernelVersionPath = "/proc/sys/kernel/osrelease"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "cephfs-volume")
+
+// VolumeConfig is the configuration of a directory in a filesystem that backs a volume
+type VolumeConfig struct {
+	ClusterInfo    *cephconfig.ClusterInfo
+	FilesystemName string
+	// The path of the directory in the filesystem
+	Path string
+	// The max size of the directory in bytes. No quota is set if zero.
+	QuotaBytes int64
+}
+
+// the directory where the temporary mount points of the filesystems are created
+var mountPointParentDir = ""
+
+// setQuota limits the size of a directory. Only ceph filesystems support the quota attribute, so it is replaced by the tests.
+var setQuota = func(dir string, bytes int64) error {
+	return syscall.Setxattr(dir, quotaMaxBytesAttr, []byte(strconv.FormatInt(bytes, 10)), 0)
+}
+
+// CreateVolume creates the directory of a volume in the filesystem and sets the quota of the directory
+func CreateVolume(mounter mount.Interface, config *VolumeConfig) error {
+	if err := validateVolumePath(config.Path); err != nil {
+		return err
+	}
+
+	return withFilesystemRoot(mounter, config, func(root string) error {
+		volumeDir := path.Join(root, config.Path)
+		if err := os.MkdirAll(volumeDir, 0777); err != nil {
+			return fmt.Errorf("failed to create directory %s in filesystem %s. %+v", config.Path, config.FilesystemName, err)
+		}
+		if config.QuotaBytes > 0 {
+			if err := setQuota(volumeDir, config.QuotaBytes); err != nil {
+				return fmt.Errorf("failed to set quota of %d bytes on directory %s in filesystem %s. %+v", config.QuotaBytes, config.Path, config.FilesystemName, err)
+			}
+		}
+		logger.Infof("created directory %s in filesystem %s with a quota of %d bytes", config.Path, config.FilesystemName, config.QuotaBytes)
+		return nil
+	})
+}
+
+// DeleteVolume removes the directory of a volume and all its content from the filesystem
+func DeleteVolume(mounter mount.Interface, config *VolumeConfig) error {
+	if err := validateVolumePath(config.Path); err != nil {
+		return err
+	}
+
+	return withFilesystemRoot(mounter, config, func(root string) error {
+		if err := os.RemoveAll(path.Join(root, config.Path)); err != nil {
+			return fmt.Errorf("failed to remove directory %s from filesystem %s. %+v", config.Path, config.FilesystemName, err)
+		}
+		logger.Infof("removed directory %s from filesystem %s", config.Path, config.FilesystemName)
+		return nil
+	})
+}
+
+// the directory of a volume cannot be the root of the filesystem or outside of the filesystem
+func validateVolumePath(volumePath string) error {
+	if path.Clean("/"+volumePath) == "/" {
+		return fmt.Errorf("invalid volume path %q. the root of the filesystem cannot be a volume", volumePath)
+	}
+	for _, part := range strings.Split(volumePath, "/") {
+		if part == ".." {
+			return fmt.Errorf("invalid volume path %q", volumePath)
+		}
+	}
+	return nil
+}
+
+// withFilesystemRoot mounts the root of the filesystem to a temporary directory while running the given func
+func withFilesystemRoot(mounter mount.Interface, config *VolumeConfig, f func(root string) error) error {
+	root, err := ioutil.TempDir(mountPointParentDir, "cephfs-")
+	if err != nil {
+		return fmt.Errorf("failed to create mount point. %+v", err)
+	}
+	defer os.Remove(root)
+
+	monAddresses := make([]string, 0, len(config.ClusterInfo.Monitors))
+	for _, monitor := range config.ClusterInfo.Monitors {
+		monAddresses = append(monAddresses, monitor.Endpoint)
+	}
+	clientAccessInfo := flexvolume.ClientAccessInfo{
+		MonAddresses: monAddresses,
+		UserName:     "admin",
+		SecretKey:    config.ClusterInfo.AdminSecret,
+	}
+
+	kernelVersion, err := ioutil.ReadFile(kernelVersionPath)
+	if err != nil {
+		logger.Warningf("failed to read kernel version. %+v", err)
+	}
+	options, err := flexvolume.CephFSMountOptions(clientAccessInfo, config.FilesystemName, strings.TrimSpace(string(kernelVersion)))
+	if err != nil {
+		logger.Warning(err.Error())
+	}
+
+	device := flexvolume.CephFSMountDevice(monAddresses, "/")
+	if err := mounter.Mount(device, root, flexvolume.CephFSType, options); err != nil {
+		return fmt.Errorf("failed to mount filesystem %s with monitor %s. %+v", config.FilesystemName, device, err)
+	}
+	defer func() {
+		if err := mounter.Unmount(root); err != nil {
+			logger.Warningf("failed to unmount filesystem %s from %s. %+v", config.FilesystemName, root, err)
+		}
+	}()
+
+	return f(root)
+}
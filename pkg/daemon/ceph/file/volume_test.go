@@ -0,0 +1,90 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/kubernetes/pkg/util/mount"
+)
+
+func TestCreateAndDeleteVolume(t *testing.T) {
+	quotas := map[string]int64{}
+	setQuota = func(dir string, bytes int64) error {
+		quotas[path.Base(dir)] = bytes
+		return nil
+	}
+
+	// the fake mounter does not mount anything, so the directories are created in the temporary mount point
+	var err error
+	mountPointParentDir, err = ioutil.TempDir("", "")
+	assert.Nil(t, err)
+	defer os.RemoveAll(mountPointParentDir)
+
+	var root string
+	mounter := &mount.FakeMounter{}
+	config := &VolumeConfig{
+		ClusterInfo: &cephconfig.ClusterInfo{
+			AdminSecret: "mysecret",
+			Monitors:    map[string]*cephconfig.MonInfo{"a": {Name: "a", Endpoint: "1.2.3.4:6790"}},
+		},
+		FilesystemName: "myfs",
+		Path:           "/volumes/pvc-1",
+		QuotaBytes:     1024,
+	}
+	err = withFilesystemRoot(mounter, config, func(dir string) error {
+		root = dir
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []mount.FakeAction{{Action: mount.FakeActionMount, Target: root, Source: "1.2.3.4:6790:/", FSType: "ceph"}, {Action: mount.FakeActionUnmount, Target: root}}, mounter.Log)
+
+	// the temporary mount point is removed when the filesystem is unmounted
+	_, err = os.Stat(root)
+	assert.True(t, os.IsNotExist(err))
+
+	mounter.ResetLog()
+	err = CreateVolume(mounter, config)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1024), quotas["pvc-1"])
+	assert.Equal(t, 2, len(mounter.Log))
+	assert.Equal(t, 0, len(mounter.MountPoints))
+
+	// no quota is set without a size
+	delete(quotas, "pvc-1")
+	config.QuotaBytes = 0
+	err = CreateVolume(mounter, config)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(quotas))
+
+	err = DeleteVolume(mounter, config)
+	assert.Nil(t, err)
+
+	// the root of the filesystem cannot be a volume
+	mounter.ResetLog()
+	config.Path = "/"
+	assert.NotNil(t, CreateVolume(mounter, config))
+	assert.NotNil(t, DeleteVolume(mounter, config))
+	config.Path = "/volumes/../.."
+	assert.NotNil(t, DeleteVolume(mounter, config))
+	assert.Equal(t, 0, len(mounter.Log))
+}
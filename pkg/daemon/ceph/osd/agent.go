@@ -35,6 +35,7 @@ import (
 	"github.com/rook/rook/pkg/util"
 	"github.com/rook/rook/pkg/util/proc"
 	"github.com/rook/rook/pkg/util/sys"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
@@ -54,27 +55,36 @@ type OsdAgent struct {
 	usingDeviceFilter bool
 	metadataDevice    string
 	directories       string
+	pvcDevicePath     string
+	deviceClasses     map[string]string
+	encryptedDevices  map[string]bool
 	procMan           *proc.ProcManager
 	storeConfig       config.StoreConfig
 	kv                *k8sutil.ConfigMapKVStore
 	configCounter     int32
 	osdsCompleted     chan struct{}
+	ownerRef          metav1.OwnerReference
 }
 
-func NewAgent(context *clusterd.Context, devices string, usingDeviceFilter bool, metadataDevice, directories string, forceFormat bool,
-	location string, storeConfig config.StoreConfig, cluster *cephconfig.ClusterInfo, nodeName string, kv *k8sutil.ConfigMapKVStore) *OsdAgent {
+func NewAgent(context *clusterd.Context, devices string, usingDeviceFilter bool, metadataDevice, directories, pvcDevicePath string, deviceClasses map[string]string,
+	encryptedDevices map[string]bool, forceFormat bool, location string, storeConfig config.StoreConfig, cluster *cephconfig.ClusterInfo, nodeName string,
+	kv *k8sutil.ConfigMapKVStore, ownerRef metav1.OwnerReference) *OsdAgent {
 
 	return &OsdAgent{
 		devices:           devices,
 		usingDeviceFilter: usingDeviceFilter,
 		metadataDevice:    metadataDevice,
 		directories:       directories,
+		pvcDevicePath:     pvcDevicePath,
+		deviceClasses:     deviceClasses,
+		encryptedDevices:  encryptedDevices,
 		forceFormat:       forceFormat,
 		location:          location,
 		storeConfig:       storeConfig,
 		cluster:           cluster,
 		nodeName:          nodeName,
 		kv:                kv,
+		ownerRef:          ownerRef,
 		procMan:           proc.New(context.Executor),
 		osdProc:           make(map[int]*proc.MonitoredProc),
 	}
@@ -167,6 +177,9 @@ func (a *OsdAgent) configureDevices(context *clusterd.Context, devices *DeviceOs
 	for _, entry := range scheme.Entries {
 		config := &osdConfig{id: entry.ID, uuid: entry.OsdUUID, configRoot: context.ConfigDir,
 			partitionScheme: entry, storeConfig: a.storeConfig, kv: a.kv, storeName: config.GetConfigStoreName(a.nodeName)}
+		if dataDetails, err := getDataPartitionDetails(config); err == nil {
+			config.deviceClass = a.getDeviceClass(context, dataDetails.Device)
+		}
 		osd, err := a.prepareOSD(context, config)
 		if err != nil {
 			return osds, fmt.Errorf("failed to config osd %d. %+v", entry.ID, err)
@@ -224,12 +237,7 @@ func (a *OsdAgent) getPartitionPerfScheme(context *clusterd.Context, devices *De
 		return nil, fmt.Errorf("failed to load partition scheme: %+v", err)
 	}
 
-	nameToUUID := map[string]string{}
-	for _, disk := range context.Devices {
-		if disk.UUID != "" {
-			nameToUUID[disk.Name] = disk.UUID
-		}
-	}
+	nameToUUID := deviceNameToUUID(context)
 	for _, device := range context.Devices {
 		logger.Debugf("context.Device: %+v", device)
 	}
@@ -280,6 +288,7 @@ func (a *OsdAgent) getPartitionPerfScheme(context *clusterd.Context, devices *De
 			schemeEntry := config.NewPerfSchemeEntry(a.storeConfig.StoreType)
 			schemeEntry.ID = *osdID
 			schemeEntry.OsdUUID = *osdUUID
+			schemeEntry.Encrypted = a.isDeviceEncrypted(name)
 
 			if metadataEntry != nil && perfScheme.Metadata != nil {
 				// we have a metadata device, so put the metadata partitions on it and the data partition on its own disk
@@ -312,6 +321,17 @@ func (a *OsdAgent) getPartitionPerfScheme(context *clusterd.Context, devices *De
 	return perfScheme, nil
 }
 
+// maps the names of the discovered devices to their disk uuids
+func deviceNameToUUID(context *clusterd.Context) map[string]string {
+	nameToUUID := map[string]string{}
+	for _, disk := range context.Devices {
+		if disk.UUID != "" {
+			nameToUUID[disk.Name] = disk.UUID
+		}
+	}
+	return nameToUUID
+}
+
 // determines if the given device name is already in use with existing/committed partitions
 func isDeviceInUse(name string, nameToUUID map[string]string, scheme *config.PerfScheme) bool {
 	parts := findPartitionsForDevice(name, nameToUUID, scheme)
@@ -382,6 +402,15 @@ func (a *OsdAgent) prepareOSD(context *clusterd.Context, cfg *osdConfig) (*oposd
 
 	cfg.rootPath = getOSDRootDir(cfg.configRoot, cfg.id)
 
+	if isEncryptedDevice(cfg) {
+		// the key encrypts the partitions of a new osd or opens the partitions of an existing osd
+		key, err := a.getOrCreateEncryptionKey(context, cfg.id)
+		if err != nil {
+			return nil, err
+		}
+		cfg.encryptionKey = key
+	}
+
 	// if the osd is using filestore on a device and it's previously been formatted/partitioned,
 	// go ahead and remount the device now.
 	devPartInfo, err := remountFilestoreDeviceIfNeeded(context, cfg)
@@ -412,6 +441,12 @@ func (a *OsdAgent) prepareOSD(context *clusterd.Context, cfg *osdConfig) (*oposd
 				}
 			}
 
+			if skipFormat && isEncryptedDevice(cfg) {
+				if err := openEncryptedPartitions(context, cfg); err != nil {
+					return nil, err
+				}
+			}
+
 			if !skipFormat {
 				devPartInfo, err = formatDevice(context, cfg, a.forceFormat, a.storeConfig)
 				if err != nil {
@@ -485,6 +520,7 @@ func getOSDInfo(clusterName string, config *osdConfig, devPartInfo *devicePartIn
 		UUID:        config.uuid.String(),
 		IsFileStore: isFilestore(config),
 		IsDirectory: config.dir,
+		IsEncrypted: isEncryptedDevice(config),
 	}
 	if devPartInfo != nil {
 		osd.DevicePartUUID = devPartInfo.deviceUUID
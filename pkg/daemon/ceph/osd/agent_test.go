@@ -236,11 +236,11 @@ func testOSDAgentWithDevicesHelper(t *testing.T, storeConfig config.StoreConfig)
 	assert.Equal(t, 0, startCount) // 2 OSD procs should be started
 
 	if storeConfig.StoreType == config.Bluestore {
-		assert.Equal(t, 11, outputExecCount) // Bluestore has 2 extra output exec calls to get device properties of each device to determine CRUSH weight
+		assert.Equal(t, 15, outputExecCount) // Bluestore has 2 extra output exec calls to get device properties of each device to determine CRUSH weight
 		assert.Equal(t, 5, execCount)        // 1 osd mkfs for sdx, 3 partition steps for sdy, 1 osd mkfs for sdy
 	} else {
-		assert.Equal(t, 9, outputExecCount)
-		assert.Equal(t, 10, execCount) // 1 for remount sdx, 1 osd mkfs for sdx, 3 partition steps for sdy, 1 mkfs for sdy, 1 mount for sdy, 1 osd mkfs for sdy
+		assert.Equal(t, 13, outputExecCount) // 2 calls for each device to set the ssd device class
+		assert.Equal(t, 10, execCount)       // 1 for remount sdx, 1 osd mkfs for sdx, 3 partition steps for sdy, 1 mkfs for sdy, 1 mount for sdy, 1 osd mkfs for sdy
 	}
 }
 
@@ -353,8 +353,8 @@ func createTestAgent(t *testing.T, devices, configDir, nodeName string, storeCon
 	}
 	cluster := &cephconfig.ClusterInfo{Name: "myclust"}
 	context := &clusterd.Context{ConfigDir: configDir, Executor: executor, Clientset: testop.New(1)}
-	agent := NewAgent(context, devices, false, "", "", forceFormat, location, *storeConfig,
-		cluster, nodeName, mockKVStore())
+	agent := NewAgent(context, devices, false, "", "", "", nil, nil, forceFormat, location, *storeConfig,
+		cluster, nodeName, mockKVStore(), metav1.OwnerReference{})
 
 	return agent, executor, context
 }
@@ -421,7 +421,7 @@ func TestGetPartitionPerfScheme(t *testing.T) {
 	}
 	context.Executor = executor
 
-	devices, err := getAvailableDevices(context, "sda,sdb", "sdc", false)
+	devices, err := getAvailableDevices(context, "sda,sdb", "sdc", false, nil)
 	assert.Nil(t, err)
 	scheme, err := a.getPartitionPerfScheme(context, devices)
 	assert.Nil(t, err)
@@ -498,7 +498,7 @@ func TestGetPartitionSchemeDiskInUse(t *testing.T) {
 
 	// get the partition scheme based on the available devices.  Since sda is already in use, the partition
 	// scheme returned should reflect that.
-	devices, err := getAvailableDevices(context, "sda", "", false)
+	devices, err := getAvailableDevices(context, "sda", "", false, nil)
 	scheme, err := a.getPartitionPerfScheme(context, devices)
 	assert.Nil(t, err)
 
@@ -566,7 +566,7 @@ func TestGetPartitionSchemeDiskNameChanged(t *testing.T) {
 
 	// get the current partition scheme.  This should notice that the device names changed and update the
 	// partition scheme to have the latest device names
-	devices, err := getAvailableDevices(context, "sda-changed", "nvme01", false)
+	devices, err := getAvailableDevices(context, "sda-changed", "nvme01", false, nil)
 	scheme, err := a.getPartitionPerfScheme(context, devices)
 	assert.Nil(t, err)
 	require.NotNil(t, scheme)
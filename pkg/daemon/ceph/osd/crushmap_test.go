@@ -23,6 +23,7 @@ import (
 	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
 	"github.com/rook/rook/pkg/util"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/rook/rook/pkg/util/sys"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -71,3 +72,54 @@ func testCrushMapHelper(t *testing.T, storeConfig *config.StoreConfig) {
 	err := addOSDToCrushMap(context, cfg, "rook", location)
 	assert.Nil(t, err)
 }
+
+func TestCrushMapDeviceClass(t *testing.T) {
+	var commands []string
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutput = func(debug bool, name string, command string, args ...string) (string, error) {
+		commands = append(commands, strings.Join(args[:4], " "))
+		return "", nil
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	cfg := &osdConfig{id: 23, rootPath: "/", deviceClass: "nvme"}
+	err := addOSDToCrushMap(context, cfg, "rook", "root=default host=node1")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"osd crush create-or-move 23",
+		"osd crush rm-device-class osd.23",
+		"osd crush set-device-class nvme",
+	}, commands)
+}
+
+func TestGetDeviceClass(t *testing.T) {
+	context := &clusterd.Context{Devices: []*sys.LocalDisk{
+		{Name: "sda", Rotational: true},
+		{Name: "sdb", Rotational: false},
+		{Name: "nvme0n1", Rotational: false},
+	}}
+	agent := &OsdAgent{deviceClasses: map[string]string{"sdb": "fast"}}
+
+	assert.Equal(t, "hdd", agent.getDeviceClass(context, "sda"))
+	assert.Equal(t, "fast", agent.getDeviceClass(context, "sdb"))
+	assert.Equal(t, "nvme", agent.getDeviceClass(context, "nvme0n1"))
+	assert.Equal(t, "", agent.getDeviceClass(context, "sdc"))
+
+	agent.deviceClasses = nil
+	assert.Equal(t, "ssd", agent.getDeviceClass(context, "sdb"))
+}
+
+func TestParseDeviceClasses(t *testing.T) {
+	classes, err := ParseDeviceClasses("sda:ssd,nvme0n1:fast")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"sda": "ssd", "nvme0n1": "fast"}, classes)
+
+	classes, err = ParseDeviceClasses("")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(classes))
+
+	_, err = ParseDeviceClasses("sda")
+	assert.NotNil(t, err)
+	_, err = ParseDeviceClasses("sda:")
+	assert.NotNil(t, err)
+}
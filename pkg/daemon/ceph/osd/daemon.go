@@ -21,6 +21,7 @@ import (
 	"path"
 	"regexp"
 
+	"strconv"
 	"strings"
 
 	"github.com/coreos/pkg/capnslog"
@@ -56,6 +57,28 @@ func RunFilestoreOnDevice(context *clusterd.Context, mountSourcePath, mountPath
 	return nil
 }
 
+// RunCephVolumeOSD activates an osd prepared by ceph-volume and starts the osd daemon in the foreground. The data dir
+// of the osd is mounted by ceph-volume in the container of the daemon.
+func RunCephVolumeOSD(context *clusterd.Context, osdID int, osdUUID string, filestore bool, cephArgs []string) error {
+	logger.Infof("activating ceph-volume osd %d", osdID)
+
+	storeFlag := "--bluestore"
+	if filestore {
+		storeFlag = "--filestore"
+	}
+	args := []string{"lvm", "activate", "--no-systemd", storeFlag, strconv.Itoa(osdID), osdUUID}
+	if err := context.Executor.ExecuteCommand(false, "", cephVolumeCmd, args...); err != nil {
+		return fmt.Errorf("failed to activate osd %d. %+v", osdID, err)
+	}
+
+	// run the ceph-osd daemon
+	if err := context.Executor.ExecuteCommand(false, "", "ceph-osd", cephArgs...); err != nil {
+		return fmt.Errorf("failed to start osd. %+v", err)
+	}
+
+	return nil
+}
+
 func Provision(context *clusterd.Context, agent *OsdAgent) error {
 
 	// set the initial orchestration status
@@ -64,6 +87,10 @@ func Provision(context *clusterd.Context, agent *OsdAgent) error {
 		return err
 	}
 
+	if err := validateBackend(agent.storeConfig.Backend); err != nil {
+		return err
+	}
+
 	// set the crush location in the osd config file
 	cephConfig := cephconfig.CreateDefaultCephConfig(context, agent.cluster, path.Join(context.ConfigDir, agent.cluster.Name))
 	cephConfig.GlobalConfig.CrushLocation = agent.location
@@ -80,10 +107,20 @@ func Provision(context *clusterd.Context, agent *OsdAgent) error {
 	}
 	context.Devices = rawDevices
 
+	if err := agent.resolvePVCDevice(context); err != nil {
+		return err
+	}
+
 	logger.Infof("creating and starting the osds")
 
+	// the opened encrypted partitions of the osds are discovered as crypt devices named after the partition uuids
+	scheme, err := config.LoadScheme(agent.kv, config.GetConfigStoreName(agent.nodeName))
+	if err != nil {
+		return fmt.Errorf("failed to load the partition scheme. %+v", err)
+	}
+
 	// determine the set of devices that can/should be used for OSDs.
-	devices, err := getAvailableDevices(context, agent.devices, agent.metadataDevice, agent.usingDeviceFilter)
+	devices, err := getAvailableDevices(context, agent.devices, agent.metadataDevice, agent.usingDeviceFilter, getSchemePartitionUUIDs(scheme))
 	if err != nil {
 		return fmt.Errorf("failed to get available devices. %+v", err)
 	}
@@ -108,10 +145,9 @@ func Provision(context *clusterd.Context, agent *OsdAgent) error {
 	}
 
 	// start the desired OSDs on devices
-	logger.Infof("configuring osd devices: %+v", devices)
-	deviceOSDs, err := agent.configureDevices(context, devices)
+	deviceOSDs, err := agent.configureAllDevices(context, devices)
 	if err != nil {
-		return fmt.Errorf("failed to configure devices. %+v", err)
+		return err
 	}
 
 	// start up the OSDs for directories
@@ -149,7 +185,72 @@ func Provision(context *clusterd.Context, agent *OsdAgent) error {
 	return nil
 }
 
-func getAvailableDevices(context *clusterd.Context, desiredDevices string, metadataDevice string, usingDeviceFilter bool) (*DeviceOsdMapping, error) {
+// configureAllDevices configures the osds on the devices with the backend of the store config. With the ceph-volume
+// backend, the devices with osds in the partition scheme of rook are still configured by rook.
+func (a *OsdAgent) configureAllDevices(context *clusterd.Context, devices *DeviceOsdMapping) ([]oposd.OSDInfo, error) {
+	rookDevices := devices
+	var cvDevices *DeviceOsdMapping
+	if a.storeConfig.Backend == config.CephVolumeBackend {
+		var err error
+		rookDevices, cvDevices, err = a.splitRookDevices(context, devices)
+		if err != nil {
+			return nil, fmt.Errorf("failed to split devices between the backends. %+v", err)
+		}
+	}
+
+	logger.Infof("configuring osd devices: %+v", rookDevices)
+	osds, err := a.configureDevices(context, rookDevices)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure devices. %+v", err)
+	}
+
+	if cvDevices != nil {
+		logger.Infof("configuring osd devices with ceph-volume: %+v", cvDevices)
+		cvOSDs, err := a.configureCVDevices(context, cvDevices)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure devices with ceph-volume. %+v", err)
+		}
+		osds = append(osds, cvOSDs...)
+	}
+	return osds, nil
+}
+
+// resolvePVCDevice selects the block device of the pvc attached to the pod as the only device of the osd.
+// The device is found by its kernel name in the discovered devices of the host, and it is partitioned like
+// any other device so the osd can find its partitions by their partuuid on whichever node the pvc is attached.
+func (a *OsdAgent) resolvePVCDevice(context *clusterd.Context) error {
+	if a.pvcDevicePath == "" {
+		return nil
+	}
+
+	name, err := sys.GetDeviceKernelName(a.pvcDevicePath, context.Executor)
+	if err != nil {
+		return fmt.Errorf("failed to resolve the pvc device %s. %+v", a.pvcDevicePath, err)
+	}
+	logger.Infof("pvc device %s is device %s", a.pvcDevicePath, name)
+	a.devices = name
+	a.usingDeviceFilter = false
+	return nil
+}
+
+// getSchemePartitionUUIDs returns the uuids of all the partitions in the partition scheme of the node
+func getSchemePartitionUUIDs(scheme *config.PerfScheme) map[string]bool {
+	uuids := map[string]bool{}
+	for _, entry := range scheme.Entries {
+		for _, details := range entry.Partitions {
+			uuids[details.PartitionUUID] = true
+		}
+	}
+	if scheme.Metadata != nil {
+		for _, part := range scheme.Metadata.Partitions {
+			uuids[part.PartitionUUID] = true
+		}
+	}
+	return uuids
+}
+
+func getAvailableDevices(context *clusterd.Context, desiredDevices string, metadataDevice string, usingDeviceFilter bool,
+	partitionUUIDs map[string]bool) (*DeviceOsdMapping, error) {
 
 	var deviceList []string
 	if !usingDeviceFilter {
@@ -167,6 +268,11 @@ func getAvailableDevices(context *clusterd.Context, desiredDevices string, metad
 		if device.Type == sys.PartType {
 			continue
 		}
+		if device.Type == sys.CryptType && (device.Parent != "" || partitionUUIDs[device.Name]) {
+			// an opened encrypted partition, such as the partition of an encrypted osd, cannot be the device of a new osd
+			logger.Infof("skipping device %s that is an opened encrypted partition", device.Name)
+			continue
+		}
 		ownPartitions, fs, err := sys.CheckIfDeviceAvailable(context.Executor, device.Name)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get device %s info. %+v", device.Name, err)
@@ -210,7 +210,7 @@ NAME="sdb1" SIZE="30" TYPE="part" PKNAME="sdb"`, nil
 	}
 
 	// select all devices, including nvme01 for metadata
-	mapping, err := getAvailableDevices(context, "all", "nvme01", true)
+	mapping, err := getAvailableDevices(context, "all", "nvme01", true, nil)
 	assert.Nil(t, err)
 	assert.Equal(t, 5, len(mapping.Entries))
 	assert.Equal(t, -1, mapping.Entries["sda"].Data)
@@ -222,34 +222,73 @@ NAME="sdb1" SIZE="30" TYPE="part" PKNAME="sdb"`, nil
 	assert.Equal(t, 0, len(mapping.Entries["nvme01"].Metadata))
 
 	// select no devices both using and not using a filter
-	mapping, err = getAvailableDevices(context, "", "", false)
+	mapping, err = getAvailableDevices(context, "", "", false, nil)
 	assert.Nil(t, err)
 	assert.Equal(t, 0, len(mapping.Entries))
 
-	mapping, err = getAvailableDevices(context, "", "", true)
+	mapping, err = getAvailableDevices(context, "", "", true, nil)
 	assert.Nil(t, err)
 	assert.Equal(t, 0, len(mapping.Entries))
 
 	// select the sd* devices
-	mapping, err = getAvailableDevices(context, "^sd.$", "", true)
+	mapping, err = getAvailableDevices(context, "^sd.$", "", true, nil)
 	assert.Nil(t, err)
 	assert.Equal(t, 2, len(mapping.Entries))
 	assert.Equal(t, -1, mapping.Entries["sda"].Data)
 	assert.Equal(t, -1, mapping.Entries["sdd"].Data)
 
 	// select an exact device
-	mapping, err = getAvailableDevices(context, "sdd", "", false)
+	mapping, err = getAvailableDevices(context, "sdd", "", false, nil)
 	assert.Nil(t, err)
 	assert.Equal(t, 1, len(mapping.Entries))
 	assert.Equal(t, -1, mapping.Entries["sdd"].Data)
 
 	// select all devices except those that have a prefix of "s"
-	mapping, err = getAvailableDevices(context, "^[^s]", "", true)
+	mapping, err = getAvailableDevices(context, "^[^s]", "", true, nil)
 	assert.Nil(t, err)
 	assert.Equal(t, 3, len(mapping.Entries))
 	assert.Equal(t, -1, mapping.Entries["rda"].Data)
 	assert.Equal(t, -1, mapping.Entries["rdb"].Data)
 	assert.Equal(t, -1, mapping.Entries["nvme01"].Data)
+
+	// the opened encrypted partitions are skipped, whether their parent is known or they are in the partition scheme
+	scheme := config.NewPerfScheme()
+	scheme.Entries = append(scheme.Entries, &config.PerfSchemeEntry{Partitions: map[config.PartitionType]*config.PerfSchemePartitionDetails{
+		config.BlockPartitionType: {PartitionUUID: "2a5c1e3a-5a8e-4a8b-b0c1-7a0a0e3b1d92"},
+	}})
+	context.Devices = []*sys.LocalDisk{
+		{Name: "sda"},
+		{Name: "4b2e5ad9-0a6b-4e62-8bd5-02d1c6a2a5f1", Type: sys.CryptType, Parent: "sdb1"},
+		{Name: "2a5c1e3a-5a8e-4a8b-b0c1-7a0a0e3b1d92", Type: sys.CryptType},
+		{Name: "luks-data", Type: sys.CryptType},
+	}
+	mapping, err = getAvailableDevices(context, "all", "", true, getSchemePartitionUUIDs(scheme))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(mapping.Entries))
+	assert.Equal(t, -1, mapping.Entries["sda"].Data)
+	assert.Equal(t, -1, mapping.Entries["luks-data"].Data)
+}
+
+func TestResolvePVCDevice(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, name string, command string, args ...string) (string, error) {
+			assert.Equal(t, "lsblk", command)
+			assert.Equal(t, "/mnt/set1-0", args[0])
+			return "xvdf", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	// the agent provisions only the device of the pvc
+	a := &OsdAgent{devices: "", usingDeviceFilter: true, pvcDevicePath: "/mnt/set1-0"}
+	assert.Nil(t, a.resolvePVCDevice(context))
+	assert.Equal(t, "xvdf", a.devices)
+	assert.False(t, a.usingDeviceFilter)
+
+	// without a pvc the devices are not changed
+	a = &OsdAgent{devices: "sda"}
+	assert.Nil(t, a.resolvePVCDevice(context))
+	assert.Equal(t, "sda", a.devices)
 }
 
 func TestGetRemovedDevices(t *testing.T) {
@@ -19,6 +19,7 @@ package osd
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -62,6 +63,10 @@ type osdConfig struct {
 	partitionScheme *config.PerfSchemeEntry
 	kv              *k8sutil.ConfigMapKVStore
 	storeName       string
+	// the crush device class of the osd, which is not set for directories
+	deviceClass string
+	// the dm-crypt key of the partitions of the osd, which is only set for encrypted devices
+	encryptionKey string
 }
 
 type Device struct {
@@ -208,6 +213,13 @@ func partitionOSD(context *clusterd.Context, cfg *osdConfig) (*devicePartInfo, e
 		return nil, fmt.Errorf("failed to partition /dev/%s. %+v", dataDetails.Device, err)
 	}
 
+	if isEncryptedDevice(cfg) {
+		// the osd uses the opened devices of the encrypted partitions instead of the partitions
+		if err := encryptPartitions(context, cfg); err != nil {
+			return nil, err
+		}
+	}
+
 	var devPartInfo *devicePartInfo
 	if cfg.partitionScheme.StoreType == config.Filestore {
 		// the OSD is using filestore, create a filesystem for the device (format it) and mount it under config root
@@ -253,6 +265,7 @@ func prepareFilestoreDevice(context *clusterd.Context, cfg *osdConfig, doFormat
 	if err != nil {
 		return nil, fmt.Errorf("failed waiting for %s: %+v", dataPartPath, err)
 	}
+	dataPartPath = getPartitionPath(cfg, dataPartDetails)
 
 	if doFormat {
 		// perform the format and retry if needed
@@ -294,6 +307,11 @@ func remountFilestoreDeviceIfNeeded(context *clusterd.Context, cfg *osdConfig) (
 			// the current saved partition scheme entry exists, meaning the partitions have already been created.
 			// we need to remount the device/partitions now so that the OSD's config will show up under the config
 			// root again.
+			if isEncryptedDevice(cfg) {
+				if err := openEncryptedPartitions(context, cfg); err != nil {
+					return nil, err
+				}
+			}
 			doFormat := false
 			devPartInfo, err = prepareFilestoreDevice(context, cfg, doFormat)
 			if err != nil {
@@ -460,6 +478,15 @@ func WriteConfigFile(context *clusterd.Context, cluster *cephconfig.ClusterInfo,
 			break
 		}
 	}
+	if isEncryptedDevice(cfg) {
+		// the partitions of the osd are opened before the osd starts
+		if cfg.encryptionKey, err = loadEncryptionKey(context, cluster.Name, osdID); err != nil {
+			return fmt.Errorf("failed to load the encryption key of osd %d. %+v", osdID, err)
+		}
+		if err := openEncryptedPartitions(context, cfg); err != nil {
+			return err
+		}
+	}
 	// if not identified as a device, confirm that it is found in the map of directories
 	if !device {
 		cfg.dir = true
@@ -483,11 +510,32 @@ func WriteConfigFile(context *clusterd.Context, cluster *cephconfig.ClusterInfo,
 	if err != nil {
 		return err
 	}
+
+	if device && isOSDFilesystemCreated(cfg) {
+		if _, err := os.Stat(getOSDKeyringPath(cfg.rootPath)); os.IsNotExist(err) {
+			// the osd dir is not on this node, such as when the osd followed its pvc to another node
+			if err := restoreOSDDir(cfg, context, cluster); err != nil {
+				return fmt.Errorf("failed to restore the dir of osd %d. %+v", osdID, err)
+			}
+		}
+	}
 	confFile := getOSDConfFilePath(cfg.rootPath, cluster.Name)
 	util.WriteFileToLog(logger, confFile)
 	return nil
 }
 
+// restoreOSDDir recreates the keyring and the files of the osd dir from the backup of its filesystem
+func restoreOSDDir(cfg *osdConfig, context *clusterd.Context, cluster *cephconfig.ClusterInfo) error {
+	logger.Infof("restoring the dir of osd %d at %s", cfg.id, cfg.rootPath)
+	if err := cephconfig.GenerateAdminConnectionConfig(context, cluster); err != nil {
+		return fmt.Errorf("failed to write connection config. %+v", err)
+	}
+	if err := addOSDAuth(context, cluster.Name, cfg.id, cfg.rootPath); err != nil {
+		return err
+	}
+	return repairOSDFileSystem(cfg)
+}
+
 func writeConfigFile(cfg *osdConfig, context *clusterd.Context, cluster *cephconfig.ClusterInfo, location string) error {
 	cephConfig := cephconfig.CreateDefaultCephConfig(context, cluster, cfg.rootPath)
 	if isBluestore(cfg) {
@@ -612,7 +660,7 @@ func addOSDToCrushMap(context *clusterd.Context, config *osdConfig, clusterName,
 		if err != nil {
 			return fmt.Errorf("failed to get data partition details for osd %d (%s): %+v", osdID, osdDataPath, err)
 		}
-		dataPartPath := filepath.Join(diskByPartUUID, dataPartDetails.PartitionUUID)
+		dataPartPath := getPartitionPath(config, dataPartDetails)
 		devProps, err := sys.GetDevicePropertiesFromPath(dataPartPath, context.Executor)
 		if err != nil {
 			return fmt.Errorf("failed to get device properties for %s: %+v", dataPartPath, err)
@@ -642,9 +690,67 @@ func addOSDToCrushMap(context *clusterd.Context, config *osdConfig, clusterName,
 		return fmt.Errorf("failed adding %s to crush map: %+v", osdEntity, err)
 	}
 
+	if config.deviceClass != "" {
+		logger.Infof("setting device class of %s to %s", osdEntity, config.deviceClass)
+		if err := client.SetDeviceClass(context, clusterName, osdID, config.deviceClass); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// ParseDeviceClasses parses a comma separated list of device:class pairs
+func ParseDeviceClasses(deviceClasses string) (map[string]string, error) {
+	classes, err := parseDevicePairs(deviceClasses)
+	if err != nil {
+		return nil, fmt.Errorf("device class %s", err.Error())
+	}
+	return classes, nil
+}
+
+// parseDevicePairs parses a comma separated list of device:value pairs
+func parseDevicePairs(devicePairs string) (map[string]string, error) {
+	values := map[string]string{}
+	if devicePairs == "" {
+		return values, nil
+	}
+
+	for _, pair := range strings.Split(devicePairs, ",") {
+		parts := strings.Split(pair, ":")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("%s is not in the form device:value", pair)
+		}
+		values[parts[0]] = parts[1]
+	}
+
+	return values, nil
+}
+
+// getDeviceClass returns the crush device class of the device, either from the device config or
+// detected from the kind of disk: nvme for nvme disks, hdd for rotational disks and ssd otherwise
+func (a *OsdAgent) getDeviceClass(context *clusterd.Context, name string) string {
+	if class, ok := a.deviceClasses[name]; ok {
+		return class
+	}
+
+	for _, device := range context.Devices {
+		if device.Name != name {
+			continue
+		}
+		if strings.HasPrefix(device.Name, "nvme") {
+			return "nvme"
+		}
+		if device.Rotational {
+			return "hdd"
+		}
+		return "ssd"
+	}
+
+	logger.Warningf("device %s not found to detect its device class", name)
+	return ""
+}
+
 func getBluestorePartitionPaths(cfg *osdConfig) (string, string, string, error) {
 	if !isBluestoreDevice(cfg) {
 		return "", "", "", fmt.Errorf("must be bluestore device to get bluestore partition paths: %+v", cfg)
@@ -663,9 +769,9 @@ func getBluestorePartitionPaths(cfg *osdConfig) (string, string, string, error)
 		return "", "", "", fmt.Errorf("failed to find block partition for osd %d", cfg.id)
 	}
 
-	return filepath.Join(diskByPartUUID, walPartition.PartitionUUID),
-		filepath.Join(diskByPartUUID, dbPartition.PartitionUUID),
-		filepath.Join(diskByPartUUID, blockPartition.PartitionUUID),
+	return getPartitionPath(cfg, walPartition),
+		getPartitionPath(cfg, dbPartition),
+		getPartitionPath(cfg, blockPartition),
 		nil
 
 }
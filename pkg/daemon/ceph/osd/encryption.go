@@ -0,0 +1,195 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/sys"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// the number of random bytes of a dm-crypt key
+	encryptionKeySize = 32
+)
+
+// ParseEncryptedDevices parses a comma separated list of device:bool pairs
+func ParseEncryptedDevices(encryptedDevices string) (map[string]bool, error) {
+	pairs, err := parseDevicePairs(encryptedDevices)
+	if err != nil {
+		return nil, fmt.Errorf("encrypted device %s", err.Error())
+	}
+
+	encrypted := map[string]bool{}
+	for device, value := range pairs {
+		if encrypted[device], err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("invalid encryption %s of device %s. %+v", value, device, err)
+		}
+	}
+	return encrypted, nil
+}
+
+// isDeviceEncrypted returns whether the osd on the device is encrypted, either from the device config or
+// from the store config of the node
+func (a *OsdAgent) isDeviceEncrypted(name string) bool {
+	if encrypted, ok := a.encryptedDevices[name]; ok {
+		return encrypted
+	}
+	return a.storeConfig.EncryptedDevice
+}
+
+// getOrCreateEncryptionKey returns the dm-crypt key of the osd from its secret, or generates a new key
+// and stores it in the secret if the osd has no key yet
+func (a *OsdAgent) getOrCreateEncryptionKey(context *clusterd.Context, osdID int) (string, error) {
+	key, err := loadEncryptionKey(context, a.cluster.Name, osdID)
+	if err == nil {
+		return key, nil
+	}
+	if !errors.IsNotFound(err) {
+		return "", err
+	}
+
+	b := make([]byte, encryptionKeySize)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate the encryption key of osd %d. %+v", osdID, err)
+	}
+	key = base64.StdEncoding.EncodeToString(b)
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      config.EncryptionKeySecretName(osdID),
+			Namespace: a.cluster.Name,
+		},
+		Data: map[string][]byte{config.EncryptionKeySecretKey: []byte(key)},
+		Type: k8sutil.RookType,
+	}
+	k8sutil.SetOwnerRef(context.Clientset, a.cluster.Name, &secret.ObjectMeta, &a.ownerRef)
+	if _, err := context.Clientset.CoreV1().Secrets(a.cluster.Name).Create(secret); err != nil {
+		return "", fmt.Errorf("failed to save the encryption key of osd %d. %+v", osdID, err)
+	}
+
+	logger.Infof("stored the encryption key of osd %d in secret %s", osdID, secret.Name)
+	return key, nil
+}
+
+// loadEncryptionKey returns the dm-crypt key of the osd from its secret
+func loadEncryptionKey(context *clusterd.Context, namespace string, osdID int) (string, error) {
+	secret, err := context.Clientset.CoreV1().Secrets(namespace).Get(config.EncryptionKeySecretName(osdID), metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	key, ok := secret.Data[config.EncryptionKeySecretKey]
+	if !ok || len(key) == 0 {
+		return "", fmt.Errorf("encryption key of osd %d not found in secret %s", osdID, secret.Name)
+	}
+	return string(key), nil
+}
+
+// encryptPartitions formats the partitions of the osd as LUKS devices with the key of the osd and opens them
+func encryptPartitions(context *clusterd.Context, cfg *osdConfig) error {
+	return withKeyFile(cfg, func(keyFile string) error {
+		for _, details := range cfg.partitionScheme.Partitions {
+			partPath := filepath.Join(diskByPartUUID, details.PartitionUUID)
+			if err := waitForPath(partPath, context.Executor); err != nil {
+				return fmt.Errorf("failed waiting for %s: %+v", partPath, err)
+			}
+
+			logger.Infof("encrypting partition %s of osd %d", partPath, cfg.id)
+			if err := sys.EncryptDevice(partPath, keyFile, context.Executor); err != nil {
+				return fmt.Errorf("failed to encrypt partition %s of osd %d. %+v", partPath, cfg.id, err)
+			}
+			if err := sys.OpenEncryptedDevice(partPath, details.PartitionUUID, keyFile, context.Executor); err != nil {
+				return fmt.Errorf("failed to open partition %s of osd %d. %+v", partPath, cfg.id, err)
+			}
+		}
+		return nil
+	})
+}
+
+// openEncryptedPartitions opens the LUKS partitions of the osd with the key of the osd. The partitions
+// that are already open, such as when the osd pod is restarted, are skipped.
+func openEncryptedPartitions(context *clusterd.Context, cfg *osdConfig) error {
+	return withKeyFile(cfg, func(keyFile string) error {
+		for _, details := range cfg.partitionScheme.Partitions {
+			if _, err := context.Executor.ExecuteStat(encryptedPartitionPath(details)); err == nil {
+				continue
+			}
+
+			partPath := filepath.Join(diskByPartUUID, details.PartitionUUID)
+			if err := waitForPath(partPath, context.Executor); err != nil {
+				return fmt.Errorf("failed waiting for %s: %+v", partPath, err)
+			}
+
+			logger.Infof("opening encrypted partition %s of osd %d", partPath, cfg.id)
+			if err := sys.OpenEncryptedDevice(partPath, details.PartitionUUID, keyFile, context.Executor); err != nil {
+				return fmt.Errorf("failed to open partition %s of osd %d. %+v", partPath, cfg.id, err)
+			}
+		}
+		return nil
+	})
+}
+
+// withKeyFile writes the key of the osd to a temporary file for cryptsetup, which is removed after the action
+func withKeyFile(cfg *osdConfig, action func(keyFile string) error) error {
+	if cfg.encryptionKey == "" {
+		return fmt.Errorf("encryption key of osd %d not loaded", cfg.id)
+	}
+
+	f, err := ioutil.TempFile("", fmt.Sprintf("osd%d-key", cfg.id))
+	if err != nil {
+		return fmt.Errorf("failed to create the key file of osd %d. %+v", cfg.id, err)
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString(cfg.encryptionKey)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("failed to write the key file of osd %d. %+v", cfg.id, err)
+	}
+
+	return action(f.Name())
+}
+
+// encryptedPartitionPath returns the path of the opened device of an encrypted partition, which is named after the partition uuid
+func encryptedPartitionPath(details *config.PerfSchemePartitionDetails) string {
+	return filepath.Join(sys.DevMapperDir, details.PartitionUUID)
+}
+
+// getPartitionPath returns the path where the osd uses the partition, which is the opened device for encrypted osds
+func getPartitionPath(cfg *osdConfig, details *config.PerfSchemePartitionDetails) string {
+	if isEncryptedDevice(cfg) {
+		return encryptedPartitionPath(details)
+	}
+	return filepath.Join(diskByPartUUID, details.PartitionUUID)
+}
+
+func isEncryptedDevice(cfg *osdConfig) bool {
+	return !cfg.dir && cfg.partitionScheme != nil && cfg.partitionScheme.Encrypted
+}
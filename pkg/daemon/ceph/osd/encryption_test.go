@@ -0,0 +1,190 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/rook/rook/pkg/util/sys"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseEncryptedDevices(t *testing.T) {
+	encrypted, err := ParseEncryptedDevices("sda:true,sdb:false")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]bool{"sda": true, "sdb": false}, encrypted)
+
+	encrypted, err = ParseEncryptedDevices("")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(encrypted))
+
+	_, err = ParseEncryptedDevices("sda")
+	assert.NotNil(t, err)
+	_, err = ParseEncryptedDevices("sda:maybe")
+	assert.NotNil(t, err)
+}
+
+func TestIsDeviceEncrypted(t *testing.T) {
+	agent := &OsdAgent{encryptedDevices: map[string]bool{"sda": false, "sdb": true}}
+	assert.False(t, agent.isDeviceEncrypted("sda"))
+	assert.True(t, agent.isDeviceEncrypted("sdb"))
+	assert.False(t, agent.isDeviceEncrypted("sdc"))
+
+	// the store config encrypts the devices that do not override it
+	agent.storeConfig.EncryptedDevice = true
+	assert.False(t, agent.isDeviceEncrypted("sda"))
+	assert.True(t, agent.isDeviceEncrypted("sdc"))
+}
+
+func TestEncryptionKey(t *testing.T) {
+	clientset := testop.New(1)
+	context := &clusterd.Context{Clientset: clientset}
+	agent := &OsdAgent{cluster: &cephconfig.ClusterInfo{Name: "myclust"}}
+
+	_, err := loadEncryptionKey(context, "myclust", 3)
+	assert.True(t, errors.IsNotFound(err))
+
+	// a new key is stored in the secret of the osd
+	key, err := agent.getOrCreateEncryptionKey(context, 3)
+	assert.Nil(t, err)
+	assert.NotEqual(t, "", key)
+	secret, err := clientset.CoreV1().Secrets("myclust").Get("rook-ceph-osd-3-encryption-key", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, key, string(secret.Data["dmcrypt-key"]))
+
+	// the existing key is returned
+	existing, err := agent.getOrCreateEncryptionKey(context, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, key, existing)
+	loaded, err := loadEncryptionKey(context, "myclust", 3)
+	assert.Nil(t, err)
+	assert.Equal(t, key, loaded)
+
+	// each osd has its own key
+	other, err := agent.getOrCreateEncryptionKey(context, 4)
+	assert.Nil(t, err)
+	assert.NotEqual(t, key, other)
+}
+
+func TestPartitionEncryptedOSD(t *testing.T) {
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+
+	var commands [][]string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommand: func(debug bool, name string, command string, args ...string) error {
+			commands = append(commands, append([]string{command}, args...))
+			for i := range args {
+				if args[i] == "--key-file" {
+					// the key of the osd is in the key file while cryptsetup runs
+					key, err := ioutil.ReadFile(args[i+1])
+					assert.Nil(t, err)
+					assert.Equal(t, "mykey", string(key))
+				}
+			}
+			return nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor, ConfigDir: configDir}
+	context.Devices = []*sys.LocalDisk{{Name: "sda", Size: 100}}
+
+	storeConfig := config.StoreConfig{StoreType: config.Bluestore, WalSizeMB: 1, DatabaseSizeMB: 2}
+	entry := config.NewPerfSchemeEntry(storeConfig.StoreType)
+	entry.ID = 1
+	entry.OsdUUID = uuid.Must(uuid.NewRandom())
+	entry.Encrypted = true
+	config.PopulateCollocatedPerfSchemeEntry(entry, "sda", storeConfig)
+
+	cfg := &osdConfig{configRoot: configDir, rootPath: filepath.Join(configDir, "osd1"), id: entry.ID, uuid: entry.OsdUUID,
+		partitionScheme: entry, kv: mockKVStore(), storeName: config.GetConfigStoreName("node123")}
+
+	// the key of the osd is required
+	_, err := partitionOSD(context, cfg)
+	assert.NotNil(t, err)
+
+	// each partition is formatted as a luks device and opened
+	commands = nil
+	cfg.encryptionKey = "mykey"
+	_, err = partitionOSD(context, cfg)
+	assert.Nil(t, err)
+	assert.Equal(t, 9, len(commands))
+	luksFormat, luksOpen := 0, 0
+	for _, command := range commands[3:] {
+		assert.Equal(t, "cryptsetup", command[0])
+		if command[len(command)-3] == "luksOpen" {
+			luksOpen++
+		} else if command[len(command)-2] == "luksFormat" {
+			luksFormat++
+		}
+	}
+	assert.Equal(t, 3, luksFormat)
+	assert.Equal(t, 3, luksOpen)
+
+	// bluestore uses the opened devices
+	walPath, dbPath, blockPath, err := getBluestorePartitionPaths(cfg)
+	assert.Nil(t, err)
+	assert.Equal(t, "/dev/mapper/"+entry.Partitions[config.WalPartitionType].PartitionUUID, walPath)
+	assert.Equal(t, "/dev/mapper/"+entry.Partitions[config.DatabasePartitionType].PartitionUUID, dbPath)
+	assert.Equal(t, "/dev/mapper/"+entry.Partitions[config.BlockPartitionType].PartitionUUID, blockPath)
+}
+
+func TestOpenEncryptedPartitions(t *testing.T) {
+	storeConfig := config.StoreConfig{StoreType: config.Bluestore}
+	entry := config.NewPerfSchemeEntry(storeConfig.StoreType)
+	entry.ID = 1
+	entry.Encrypted = true
+	config.PopulateCollocatedPerfSchemeEntry(entry, "sda", storeConfig)
+	blockUUID := entry.Partitions[config.BlockPartitionType].PartitionUUID
+
+	var opened []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommand: func(debug bool, name string, command string, args ...string) error {
+			assert.Equal(t, "cryptsetup", command)
+			assert.Equal(t, "luksOpen", args[len(args)-3])
+			opened = append(opened, args[len(args)-1])
+			return nil
+		},
+		MockExecuteStat: func(name string) (os.FileInfo, error) {
+			// only the block partition is not open yet
+			if name == "/dev/mapper/"+blockUUID {
+				return nil, os.ErrNotExist
+			}
+			return nil, nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+	cfg := &osdConfig{id: 1, partitionScheme: entry, encryptionKey: "mykey"}
+
+	err := openEncryptedPartitions(context, cfg)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{blockUUID}, opened)
+
+	// the partitions of osds that are not encrypted are used directly
+	entry.Encrypted = false
+	assert.Equal(t, "/dev/disk/by-partuuid/"+blockUUID, getPartitionPath(cfg, entry.Partitions[config.BlockPartitionType]))
+}
@@ -0,0 +1,261 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	oposd "github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	"github.com/rook/rook/pkg/util"
+)
+
+const (
+	cephVolumeCmd = "ceph-volume"
+	// ceph-volume creates the data dirs of the osds with the default cluster name
+	cephVolumeOSDDataFmt = "/var/lib/ceph/osd/ceph-%d"
+	// the types of the logical volumes with the data of bluestore and filestore osds
+	cephVolumeBlockType = "block"
+	cephVolumeDataType  = "data"
+)
+
+var (
+	// ceph-volume loads the keyring of the bootstrap-osd client from where it is found for the default cluster name
+	cephVolumeBootstrapKeyringPath = "/var/lib/ceph/bootstrap-osd/ceph.keyring"
+)
+
+// cephVolumeLV is a logical volume of an osd in the output of "ceph-volume lvm list"
+type cephVolumeLV struct {
+	Path string            `json:"lv_path"`
+	Type string            `json:"type"`
+	Tags map[string]string `json:"tags"`
+}
+
+// cvBatchKey identifies the devices that are prepared with the same settings by a "ceph-volume lvm batch"
+type cvBatchKey struct {
+	deviceClass string
+	encrypted   bool
+}
+
+func validateBackend(backend string) error {
+	switch backend {
+	case "", config.RookBackend, config.CephVolumeBackend:
+		return nil
+	}
+	return fmt.Errorf("unknown osd backend %s", backend)
+}
+
+// splitRookDevices separates the devices with osds in the partition scheme of rook, which are still configured
+// by rook, from the devices that are provisioned by ceph-volume
+func (a *OsdAgent) splitRookDevices(context *clusterd.Context, devices *DeviceOsdMapping) (*DeviceOsdMapping, *DeviceOsdMapping, error) {
+	scheme, err := config.LoadScheme(a.kv, config.GetConfigStoreName(a.nodeName))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load partition scheme: %+v", err)
+	}
+
+	nameToUUID := deviceNameToUUID(context)
+	rookDevices := &DeviceOsdMapping{Entries: map[string]*DeviceOsdIDEntry{}}
+	cvDevices := &DeviceOsdMapping{Entries: map[string]*DeviceOsdIDEntry{}}
+	for name, mapping := range devices.Entries {
+		if isDeviceInUse(name, nameToUUID, scheme) {
+			rookDevices.Entries[name] = mapping
+		} else {
+			cvDevices.Entries[name] = mapping
+		}
+	}
+	return rookDevices, cvDevices, nil
+}
+
+// configureCVDevices prepares osds on the devices with ceph-volume and returns all the osds of the cluster that
+// ceph-volume has prepared on the node. The osds are activated by ceph-volume in their pods.
+func (a *OsdAgent) configureCVDevices(context *clusterd.Context, devices *DeviceOsdMapping) ([]oposd.OSDInfo, error) {
+	batches := a.getCVBatches(devices)
+	if len(batches) > 0 {
+		if err := writeCVBootstrapKeyring(context, a.cluster.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, args := range batches {
+		if err := context.Executor.ExecuteCommand(false, "", cephVolumeCmd, args...); err != nil {
+			return nil, fmt.Errorf("failed ceph-volume batch %v. %+v", args, err)
+		}
+	}
+
+	return a.getCVOSDs(context)
+}
+
+// getCVBatches returns the arguments of a "ceph-volume lvm batch" for each set of devices with the same device class
+// and encryption. The metadata device is shared by the devices that keep the default device class and encryption.
+func (a *OsdAgent) getCVBatches(devices *DeviceOsdMapping) [][]string {
+	var metadataDevice string
+	batches := map[cvBatchKey][]string{}
+	for name, mapping := range devices.Entries {
+		if isDeviceDesiredForData(mapping) {
+			key := cvBatchKey{deviceClass: a.deviceClasses[name], encrypted: a.isDeviceEncrypted(name)}
+			batches[key] = append(batches[key], name)
+		} else if isDeviceDesiredForMetadata(mapping, nil) {
+			metadataDevice = name
+		}
+	}
+
+	defaultKey := cvBatchKey{encrypted: a.storeConfig.EncryptedDevice}
+	if metadataDevice != "" && len(batches[defaultKey]) == 0 {
+		logger.Warningf("metadata device %s is not used since there are no new devices with the default device class and encryption", metadataDevice)
+	}
+
+	keys := make([]cvBatchKey, 0, len(batches))
+	for key := range batches {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].deviceClass != keys[j].deviceClass {
+			return keys[i].deviceClass < keys[j].deviceClass
+		}
+		return !keys[i].encrypted && keys[j].encrypted
+	})
+
+	storeFlag := "--bluestore"
+	if a.storeConfig.StoreType == config.Filestore {
+		storeFlag = "--filestore"
+	}
+
+	var allArgs [][]string
+	for _, key := range keys {
+		args := []string{"lvm", "batch", "--prepare", "--yes", storeFlag}
+		if key.encrypted {
+			args = append(args, "--dmcrypt")
+		}
+		if key.deviceClass != "" {
+			args = append(args, "--crush-device-class", key.deviceClass)
+		}
+
+		names := batches[key]
+		sort.Strings(names)
+		if key == defaultKey && metadataDevice != "" {
+			// ceph-volume puts the metadata of the osds on the solid state device of the batch
+			names = append(names, metadataDevice)
+		}
+		for _, name := range names {
+			args = append(args, path.Join("/dev", name))
+		}
+		allArgs = append(allArgs, args)
+	}
+
+	return allArgs
+}
+
+// writeCVBootstrapKeyring writes the keyring of the bootstrap-osd client where ceph-volume loads it to register the osds
+func writeCVBootstrapKeyring(context *clusterd.Context, clusterName string) error {
+	if err := createOSDBootstrapKeyring(context, clusterName); err != nil {
+		return fmt.Errorf("failed to create bootstrap osd keyring. %+v", err)
+	}
+
+	keyring, err := ioutil.ReadFile(getBootstrapOSDKeyringPath(context.ConfigDir, clusterName))
+	if err != nil {
+		return fmt.Errorf("failed to read bootstrap osd keyring. %+v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(cephVolumeBootstrapKeyringPath), 0744); err != nil {
+		return fmt.Errorf("failed to create dir for the bootstrap osd keyring. %+v", err)
+	}
+	if err := ioutil.WriteFile(cephVolumeBootstrapKeyringPath, keyring, 0600); err != nil {
+		return fmt.Errorf("failed to write bootstrap osd keyring to %s. %+v", cephVolumeBootstrapKeyringPath, err)
+	}
+	return nil
+}
+
+// getCVOSDs lists the osds of the cluster that ceph-volume has prepared on the logical volumes of the node
+func (a *OsdAgent) getCVOSDs(context *clusterd.Context) ([]oposd.OSDInfo, error) {
+	output, err := context.Executor.ExecuteCommandWithOutput(false, "", cephVolumeCmd, "lvm", "list", "--format", "json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ceph-volume osds. %+v", err)
+	}
+
+	var volumes map[string][]cephVolumeLV
+	if err := json.Unmarshal([]byte(output), &volumes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal ceph-volume osds. %+v", err)
+	}
+
+	var osds []oposd.OSDInfo
+	for id, lvs := range volumes {
+		for _, lv := range lvs {
+			if lv.Type != cephVolumeBlockType && lv.Type != cephVolumeDataType {
+				// the db, wal and journal volumes belong to the osd of its block or data volume
+				continue
+			}
+			if lv.Tags["ceph.cluster_fsid"] != a.cluster.FSID {
+				logger.Infof("skipping osd %s on %s that belongs to cluster %s", id, lv.Path, lv.Tags["ceph.cluster_fsid"])
+				continue
+			}
+
+			osdID, err := strconv.Atoi(id)
+			if err != nil {
+				return nil, fmt.Errorf("invalid id %s of ceph-volume osd on %s. %+v", id, lv.Path, err)
+			}
+			osds = append(osds, getCVOSDInfo(context.ConfigDir, a.cluster.Name, osdID, lv))
+		}
+	}
+
+	sort.Slice(osds, func(i, j int) bool { return osds[i].ID < osds[j].ID })
+	logger.Infof("%d ceph-volume osds found on this node", len(osds))
+	return osds, nil
+}
+
+func getCVOSDInfo(configRoot, clusterName string, osdID int, lv cephVolumeLV) oposd.OSDInfo {
+	dataPath := fmt.Sprintf(cephVolumeOSDDataFmt, osdID)
+	osd := oposd.OSDInfo{
+		ID:           osdID,
+		DataPath:     dataPath,
+		Config:       getOSDConfFilePath(getOSDRootDir(configRoot, osdID), clusterName),
+		Cluster:      clusterName,
+		KeyringPath:  getOSDKeyringPath(dataPath),
+		UUID:         lv.Tags["ceph.osd_fsid"],
+		IsFileStore:  lv.Type == cephVolumeDataType,
+		IsCephVolume: true,
+	}
+	if osd.IsFileStore {
+		osd.Journal = getOSDJournalPath(dataPath)
+	}
+	return osd
+}
+
+// WriteCephVolumeConfigFile writes the config file of an osd prepared by ceph-volume. The keyring and the
+// store settings of the osd are found in its data dir after it is activated.
+func WriteCephVolumeConfigFile(context *clusterd.Context, cluster *cephconfig.ClusterInfo, osdID int, location string) error {
+	rootPath := getOSDRootDir(context.ConfigDir, osdID)
+	cephConfig := cephconfig.CreateDefaultCephConfig(context, cluster, rootPath)
+	cephConfig.CrushLocation = location
+
+	logger.Infof("updating config for ceph-volume osd %d", osdID)
+	confFile, err := cephconfig.GenerateConfigFile(context, cluster, rootPath, fmt.Sprintf("osd.%d", osdID),
+		getOSDKeyringPath(fmt.Sprintf(cephVolumeOSDDataFmt, osdID)), cephConfig, nil)
+	if err != nil {
+		return fmt.Errorf("failed to write OSD %d config file: %+v", osdID, err)
+	}
+
+	util.WriteFileToLog(logger, confFile)
+	return nil
+}
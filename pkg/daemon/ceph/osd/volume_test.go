@@ -0,0 +1,179 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/rook/rook/pkg/util/sys"
+	"github.com/stretchr/testify/assert"
+)
+
+const cephVolumeListOutput = `{
+	"0": [
+		{"lv_path": "/dev/ceph-a/osd-block-a", "type": "block", "tags": {"ceph.cluster_fsid": "myfsid", "ceph.osd_fsid": "uuid-0", "ceph.osd_id": "0"}},
+		{"lv_path": "/dev/ceph-m/osd-db-a", "type": "db", "tags": {"ceph.cluster_fsid": "myfsid", "ceph.osd_fsid": "uuid-0", "ceph.osd_id": "0"}}
+	],
+	"2": [
+		{"lv_path": "/dev/ceph-b/osd-data-b", "type": "data", "tags": {"ceph.cluster_fsid": "myfsid", "ceph.osd_fsid": "uuid-2", "ceph.osd_id": "2"}},
+		{"lv_path": "/dev/ceph-b/osd-journal-b", "type": "journal", "tags": {"ceph.cluster_fsid": "myfsid", "ceph.osd_fsid": "uuid-2", "ceph.osd_id": "2"}}
+	],
+	"5": [
+		{"lv_path": "/dev/ceph-c/osd-block-c", "type": "block", "tags": {"ceph.cluster_fsid": "otherfsid", "ceph.osd_fsid": "uuid-5", "ceph.osd_id": "5"}}
+	]
+}`
+
+func TestValidateBackend(t *testing.T) {
+	assert.Nil(t, validateBackend(""))
+	assert.Nil(t, validateBackend("rook"))
+	assert.Nil(t, validateBackend("ceph-volume"))
+	assert.NotNil(t, validateBackend("lvm"))
+}
+
+func TestCephVolumeBatches(t *testing.T) {
+	agent := &OsdAgent{
+		deviceClasses:    map[string]string{"sdc": "fast"},
+		encryptedDevices: map[string]bool{"sdd": true},
+		storeConfig:      config.StoreConfig{StoreType: config.Bluestore},
+	}
+	devices := &DeviceOsdMapping{Entries: map[string]*DeviceOsdIDEntry{
+		"sdb":     {Data: unassignedOSDID},
+		"sda":     {Data: unassignedOSDID},
+		"sdc":     {Data: unassignedOSDID},
+		"sdd":     {Data: unassignedOSDID},
+		"nvme0n1": {Data: unassignedOSDID, Metadata: []int{}},
+	}}
+
+	// the devices with the same device class and encryption are prepared together, and the metadata
+	// device is only shared by the devices with the default settings
+	batches := agent.getCVBatches(devices)
+	assert.Equal(t, [][]string{
+		{"lvm", "batch", "--prepare", "--yes", "--bluestore", "/dev/sda", "/dev/sdb", "/dev/nvme0n1"},
+		{"lvm", "batch", "--prepare", "--yes", "--bluestore", "--dmcrypt", "/dev/sdd"},
+		{"lvm", "batch", "--prepare", "--yes", "--bluestore", "--crush-device-class", "fast", "/dev/sdc"},
+	}, batches)
+
+	agent.storeConfig.StoreType = config.Filestore
+	devices = &DeviceOsdMapping{Entries: map[string]*DeviceOsdIDEntry{"sda": {Data: unassignedOSDID}}}
+	batches = agent.getCVBatches(devices)
+	assert.Equal(t, [][]string{{"lvm", "batch", "--prepare", "--yes", "--filestore", "/dev/sda"}}, batches)
+
+	assert.Equal(t, 0, len(agent.getCVBatches(&DeviceOsdMapping{Entries: map[string]*DeviceOsdIDEntry{}})))
+}
+
+func TestSplitRookDevices(t *testing.T) {
+	kv := mockKVStore()
+	nodeName := "node1"
+	_, _, diskUUID := mockPartitionSchemeEntry(t, 1, "sda", nil, kv, nodeName)
+
+	context := &clusterd.Context{Devices: []*sys.LocalDisk{{Name: "sda", UUID: diskUUID}, {Name: "sdb", UUID: "other-uuid"}}}
+	agent := &OsdAgent{kv: kv, nodeName: nodeName}
+	devices := &DeviceOsdMapping{Entries: map[string]*DeviceOsdIDEntry{
+		"sda": {Data: unassignedOSDID},
+		"sdb": {Data: unassignedOSDID},
+	}}
+
+	// the device with an osd in the partition scheme of rook is still configured by rook
+	rookDevices, cvDevices, err := agent.splitRookDevices(context, devices)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(rookDevices.Entries))
+	assert.NotNil(t, rookDevices.Entries["sda"])
+	assert.Equal(t, 1, len(cvDevices.Entries))
+	assert.NotNil(t, cvDevices.Entries["sdb"])
+}
+
+func TestConfigureCephVolumeDevices(t *testing.T) {
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	defaultKeyringPath := cephVolumeBootstrapKeyringPath
+	cephVolumeBootstrapKeyringPath = filepath.Join(configDir, "bootstrap-osd", "ceph.keyring")
+	defer func() { cephVolumeBootstrapKeyringPath = defaultKeyringPath }()
+
+	var commands [][]string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			return `{"key":"mysecurekey"}`, nil
+		},
+		MockExecuteCommand: func(debug bool, name string, command string, args ...string) error {
+			commands = append(commands, append([]string{command}, args...))
+			return nil
+		},
+		MockExecuteCommandWithOutput: func(debug bool, name string, command string, args ...string) (string, error) {
+			assert.Equal(t, "ceph-volume", command)
+			assert.Equal(t, []string{"lvm", "list", "--format", "json"}, args)
+			return cephVolumeListOutput, nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor, ConfigDir: configDir}
+	agent := &OsdAgent{cluster: &cephconfig.ClusterInfo{Name: "myclust", FSID: "myfsid"}}
+	devices := &DeviceOsdMapping{Entries: map[string]*DeviceOsdIDEntry{"sda": {Data: unassignedOSDID}}}
+
+	osds, err := agent.configureCVDevices(context, devices)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"ceph-volume", "lvm", "batch", "--prepare", "--yes", "--bluestore", "/dev/sda"}}, commands)
+
+	// ceph-volume registers the osds with the bootstrap-osd keyring
+	keyring, err := ioutil.ReadFile(cephVolumeBootstrapKeyringPath)
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(string(keyring), "key = mysecurekey"))
+
+	// only the osds of the cluster are returned
+	assert.Equal(t, 2, len(osds))
+	assert.Equal(t, 0, osds[0].ID)
+	assert.Equal(t, "uuid-0", osds[0].UUID)
+	assert.Equal(t, "/var/lib/ceph/osd/ceph-0", osds[0].DataPath)
+	assert.Equal(t, "/var/lib/ceph/osd/ceph-0/keyring", osds[0].KeyringPath)
+	assert.Equal(t, filepath.Join(configDir, "osd0", "myclust.config"), osds[0].Config)
+	assert.True(t, osds[0].IsCephVolume)
+	assert.False(t, osds[0].IsFileStore)
+	assert.Equal(t, 2, osds[1].ID)
+	assert.True(t, osds[1].IsFileStore)
+	assert.Equal(t, "/var/lib/ceph/osd/ceph-2/journal", osds[1].Journal)
+
+	// the existing osds are still returned when there are no new devices
+	commands = nil
+	osds, err = agent.configureCVDevices(context, &DeviceOsdMapping{Entries: map[string]*DeviceOsdIDEntry{}})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(commands))
+	assert.Equal(t, 2, len(osds))
+}
+
+func TestRunCephVolumeOSD(t *testing.T) {
+	var commands [][]string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommand: func(debug bool, name string, command string, args ...string) error {
+			commands = append(commands, append([]string{command}, args...))
+			return nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	err := RunCephVolumeOSD(context, 2, "uuid-2", true, []string{"--foreground", "--id", "2"})
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{
+		{"ceph-volume", "lvm", "activate", "--no-systemd", "--filestore", "2", "uuid-2"},
+		{"ceph-osd", "--foreground", "--id", "2"},
+	}, commands)
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package rbd for the Ceph rbd-mirror daemons.
+package rbd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/util"
+)
+
+var (
+	logger          = capnslog.NewPackageLogger("github.com/rook/rook", "cephrbd")
+	keyringTemplate = `
+[client.rbd-mirror.%s]
+	key = %s
+	caps mon = "profile rbd"
+	caps osd = "profile rbd"
+`
+	peerConfigTemplate = `[global]
+	mon host = %s
+`
+	peerKeyringTemplate = `[client.%s]
+	key = %s
+`
+)
+
+const (
+	// InitCommand is the `rook ceph` subcommand which will perform rbd-mirror initialization
+	InitCommand = "rbd-mirror-init"
+
+	// PeersDir is the dir where the secrets of the peer clusters are mounted in the rbd-mirror pods
+	PeersDir = "/etc/rook-peers"
+	// PeerMonHostKey is the key of the mon hosts of the peer cluster in a peer secret
+	PeerMonHostKey = "mon-host"
+	// PeerKeyKey is the key of the key of the peer user in a peer secret
+	PeerKeyKey = "key"
+	// PeerUserKey is the key of the optional name of the peer user in a peer secret
+	PeerUserKey = "user"
+	// DefaultPeerUser is the user of the peer cluster the rbd-mirror daemons connect as if the peer secret has no user
+	DefaultPeerUser = "rbd-mirror-peer"
+)
+
+// Config contains the necessary parameters Rook needs to know to set up an rbd-mirror daemon for a Ceph cluster.
+type Config struct {
+	ClusterInfo *cephconfig.ClusterInfo
+	Name        string
+	Keyring     string
+	// The names of the peer clusters, which are the names of the peer secrets mounted under the PeersDir
+	Peers []string
+}
+
+// Initialize generates the configuration files for an rbd-mirror daemon and the peer clusters it connects to
+func Initialize(context *clusterd.Context, config *Config) error {
+	logger.Infof("Creating config for rbd-mirror %s with peers %v", config.Name, config.Peers)
+	config.ClusterInfo.Log(logger)
+	if err := generateConfigFiles(context, config); err != nil {
+		return fmt.Errorf("failed to generate rbd-mirror config files. %+v", err)
+	}
+
+	for _, peer := range config.Peers {
+		if err := writePeerConfig(PeersDir, cephconfig.DefaultConfigDir, peer); err != nil {
+			return fmt.Errorf("failed to generate config of peer %s. %+v", peer, err)
+		}
+	}
+
+	util.WriteFileToLog(logger, cephconfig.DefaultConfigFilePath())
+
+	return nil
+}
+
+func generateConfigFiles(context *clusterd.Context, config *Config) error {
+	confDir := path.Join(context.ConfigDir, fmt.Sprintf("rbd-mirror-%s", config.Name))
+	keyringPath := path.Join(confDir, "keyring")
+	username := fmt.Sprintf("client.rbd-mirror.%s", config.Name)
+	logger.Infof("Conf files: dir=%s keyring=%s", confDir, keyringPath)
+	_, err := cephconfig.GenerateConfigFile(context, config.ClusterInfo, confDir,
+		username, keyringPath, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create config file. %+v", err)
+	}
+
+	keyringEval := func(key string) string {
+		return fmt.Sprintf(keyringTemplate, config.Name, key)
+	}
+
+	err = cephconfig.WriteKeyring(keyringPath, config.Keyring, keyringEval)
+	if err != nil {
+		return fmt.Errorf("failed to create rbd-mirror keyring. %+v", err)
+	}
+
+	return nil
+}
+
+// writePeerConfig writes the config and keyring that rbd-mirror loads for a peer cluster by its name
+// from the mon hosts and the key in the peer secret
+func writePeerConfig(peersDir, configDir, peer string) error {
+	monHost, err := readPeerValue(peersDir, peer, PeerMonHostKey)
+	if err != nil {
+		return err
+	}
+	if monHost == "" {
+		return fmt.Errorf("peer secret %s has no %s", peer, PeerMonHostKey)
+	}
+	key, err := readPeerValue(peersDir, peer, PeerKeyKey)
+	if err != nil {
+		return err
+	}
+	if key == "" {
+		return fmt.Errorf("peer secret %s has no %s", peer, PeerKeyKey)
+	}
+	// the user is optional in the secret
+	user, _ := readPeerValue(peersDir, peer, PeerUserKey)
+	if user == "" {
+		user = DefaultPeerUser
+	}
+
+	configPath := path.Join(configDir, fmt.Sprintf("%s.conf", peer))
+	if err := ioutil.WriteFile(configPath, []byte(fmt.Sprintf(peerConfigTemplate, monHost)), 0644); err != nil {
+		return fmt.Errorf("failed to write config %s. %+v", configPath, err)
+	}
+	keyringPath := path.Join(configDir, fmt.Sprintf("%s.client.%s.keyring", peer, user))
+	if err := ioutil.WriteFile(keyringPath, []byte(fmt.Sprintf(peerKeyringTemplate, user, key)), 0600); err != nil {
+		return fmt.Errorf("failed to write keyring %s. %+v", keyringPath, err)
+	}
+
+	logger.Infof("wrote config of peer %s with mon hosts %s and user %s", peer, monHost, user)
+	return nil
+}
+
+func readPeerValue(peersDir, peer, key string) (string, error) {
+	value, err := ioutil.ReadFile(path.Join(peersDir, peer, key))
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s of peer %s. %+v", key, peer, err)
+	}
+	return strings.TrimSpace(string(value)), nil
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rbd
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWritePeerConfig(t *testing.T) {
+	peersDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(peersDir)
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+
+	os.MkdirAll(path.Join(peersDir, "site-b"), 0755)
+	ioutil.WriteFile(path.Join(peersDir, "site-b", PeerMonHostKey), []byte("10.0.0.1:6789,10.0.0.2:6789\n"), 0644)
+
+	// the key is required
+	err := writePeerConfig(peersDir, configDir, "site-b")
+	assert.NotNil(t, err)
+
+	ioutil.WriteFile(path.Join(peersDir, "site-b", PeerKeyKey), []byte("peerkey"), 0644)
+	err = writePeerConfig(peersDir, configDir, "site-b")
+	assert.Nil(t, err)
+	config, err := ioutil.ReadFile(path.Join(configDir, "site-b.conf"))
+	assert.Nil(t, err)
+	assert.Equal(t, "[global]\n\tmon host = 10.0.0.1:6789,10.0.0.2:6789\n", string(config))
+	keyring, err := ioutil.ReadFile(path.Join(configDir, "site-b.client.rbd-mirror-peer.keyring"))
+	assert.Nil(t, err)
+	assert.Equal(t, "[client.rbd-mirror-peer]\n\tkey = peerkey\n", string(keyring))
+
+	// the user of the peer can be set in the secret
+	ioutil.WriteFile(path.Join(peersDir, "site-b", PeerUserKey), []byte("mirror"), 0644)
+	err = writePeerConfig(peersDir, configDir, "site-b")
+	assert.Nil(t, err)
+	_, err = os.Stat(path.Join(configDir, "site-b.client.mirror.keyring"))
+	assert.Nil(t, err)
+}
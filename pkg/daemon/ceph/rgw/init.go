@@ -97,7 +97,7 @@ func generateConfigFiles(context *clusterd.Context, config *Config) error {
 		"rgw_zonegroup":                  config.Name,
 	}
 	configFile, err := cephconfig.GenerateConfigFile(context, config.ClusterInfo, getRGWConfDir(context.ConfigDir),
-		"client.radosgw.gateway", getRGWKeyringPath(context.ConfigDir), nil, settings)
+		cephconfig.RGWClientName, getRGWKeyringPath(context.ConfigDir), nil, settings)
 	if err != nil {
 		return fmt.Errorf("failed to create config file. %+v", err)
 	}
@@ -0,0 +1,52 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rgw
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// CreateBucket creates a bucket through the S3 api of the object store at the given endpoint.
+// The bucket is owned by the user with the given keys. If the user already owns the bucket, no error is returned.
+func CreateBucket(endpoint, accessKey, secretKey, bucketName string) error {
+	logger.Infof("Creating bucket: %s", bucketName)
+
+	// the ceph object store must use the 'us-east-1' default aws region
+	awsConfig := aws.NewConfig().
+		WithRegion("us-east-1").
+		WithCredentials(credentials.NewStaticCredentials(accessKey, secretKey, "")).
+		WithEndpoint(endpoint).
+		WithS3ForcePathStyle(true).
+		WithDisableSSL(true)
+
+	s3client := s3.New(session.New(), awsConfig)
+	_, err := s3client.CreateBucket(&s3.CreateBucketInput{Bucket: aws.String(bucketName)})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeBucketAlreadyOwnedByYou {
+			return nil
+		}
+		return fmt.Errorf("failed to create bucket %s: %+v", bucketName, err)
+	}
+
+	return nil
+}
@@ -26,6 +26,8 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -197,3 +199,52 @@ func TestDiscoverFlexDir(t *testing.T) {
 	assert.Equal(t, "env var", source)
 	assert.Equal(t, "/my/flex/path/", path)
 }
+
+func TestStartCSI(t *testing.T) {
+	clientset := test.New(3)
+	namespace := "ns"
+	a := New(clientset)
+
+	os.Setenv(csiProvisionerImageEnv, "myregistry/csi-provisioner:v1")
+	defer os.Unsetenv(csiProvisionerImageEnv)
+
+	// the agent that was started before the csi driver was enabled is removed
+	clientset.Extensions().DaemonSets(namespace).Create(&extensions.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-agent", Namespace: namespace}})
+	err := a.StartCSI(namespace, "rook/rook:myversion", "mysa")
+	assert.Nil(t, err)
+	_, err = clientset.Extensions().DaemonSets(namespace).Get("rook-ceph-agent", metav1.GetOptions{})
+	assert.True(t, errors.IsNotFound(err))
+
+	// the node plugin runs the driver registrar and the rook driver
+	ds, err := clientset.Extensions().DaemonSets(namespace).Get("rook-ceph-csi-plugin", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "mysa", ds.Spec.Template.Spec.ServiceAccountName)
+	assert.True(t, ds.Spec.Template.Spec.HostNetwork)
+	containers := ds.Spec.Template.Spec.Containers
+	assert.Equal(t, 2, len(containers))
+	assert.Equal(t, defaultRegistrarImage, containers[0].Image)
+	assert.Equal(t, "rook/rook:myversion", containers[1].Image)
+	assert.Equal(t, []string{"ceph", "csi", "--csi-mode=node", "--csi-endpoint=unix:///csi/csi.sock"}, containers[1].Args)
+	assert.True(t, *containers[1].SecurityContext.Privileged)
+	assert.Equal(t, 6, len(ds.Spec.Template.Spec.Volumes))
+	assert.Equal(t, "/var/lib/kubelet/plugins/csi.ceph.rook.io", ds.Spec.Template.Spec.Volumes[0].HostPath.Path)
+
+	// the controller runs the external provisioner and attacher next to the rook driver
+	d, err := clientset.Extensions().Deployments(namespace).Get("rook-ceph-csi-controller", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), *d.Spec.Replicas)
+	containers = d.Spec.Template.Spec.Containers
+	assert.Equal(t, 3, len(containers))
+	assert.Equal(t, "myregistry/csi-provisioner:v1", containers[0].Image)
+	assert.Equal(t, defaultAttacherImage, containers[1].Image)
+	assert.Equal(t, []string{"ceph", "csi", "--csi-mode=controller", "--csi-endpoint=unix:///csi/csi.sock"}, containers[2].Args)
+	assert.Equal(t, "/var/lib/rook", containers[2].VolumeMounts[1].MountPath)
+	assert.Equal(t, 2, len(d.Spec.Template.Spec.Volumes))
+
+	// starting again updates the existing resources
+	err = a.StartCSI(namespace, "rook/rook:newversion", "mysa")
+	assert.Nil(t, err)
+	d, err = clientset.Extensions().Deployments(namespace).Get("rook-ceph-csi-controller", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "rook/rook:newversion", d.Spec.Template.Spec.Containers[2].Image)
+}
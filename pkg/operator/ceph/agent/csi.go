@@ -0,0 +1,262 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/rook/rook/pkg/daemon/ceph/csi"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	kserrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	csiPluginDaemonsetName  = "rook-ceph-csi-plugin"
+	csiControllerName       = "rook-ceph-csi-controller"
+	csiEnableEnv            = "ROOK_CSI_ENABLE"
+	csiRegistrarImageEnv    = "ROOK_CSI_REGISTRAR_IMAGE"
+	csiProvisionerImageEnv  = "ROOK_CSI_PROVISIONER_IMAGE"
+	csiAttacherImageEnv     = "ROOK_CSI_ATTACHER_IMAGE"
+	csiKubeletDirPathEnv    = "ROOK_CSI_KUBELET_DIR_PATH"
+	defaultRegistrarImage   = "quay.io/k8scsi/driver-registrar:v0.3.0"
+	defaultProvisionerImage = "quay.io/k8scsi/csi-provisioner:v0.3.0"
+	defaultAttacherImage    = "quay.io/k8scsi/csi-attacher:v0.3.0"
+	defaultKubeletDirPath   = "/var/lib/kubelet"
+	csiSocketDir            = "/csi"
+	csiAddress              = "/csi/csi.sock"
+)
+
+// CSIEnabled returns whether the csi driver is deployed instead of the flex volume agent
+func CSIEnabled() bool {
+	return os.Getenv(csiEnableEnv) == "true"
+}
+
+// StartCSI deploys the csi driver. The node plugin runs in a daemonset on every node and the controller plugin
+// runs in a deployment with the external provisioner and attacher. The agent daemonset of the flex driver is
+// removed if it was started before the csi driver was enabled.
+func (a *Agent) StartCSI(namespace, rookImage, serviceAccount string) error {
+	if err := k8sutil.DeleteDaemonset(a.clientset, namespace, agentDaemonsetName); err != nil {
+		return fmt.Errorf("failed to remove the agent daemonset: %+v", err)
+	}
+	if err := a.createCSIPluginDaemonSet(namespace, rookImage, serviceAccount); err != nil {
+		return fmt.Errorf("failed to start csi plugin daemonset: %+v", err)
+	}
+	if err := a.createCSIController(namespace, rookImage, serviceAccount); err != nil {
+		return fmt.Errorf("failed to start csi controller: %+v", err)
+	}
+	return nil
+}
+
+func (a *Agent) createCSIPluginDaemonSet(namespace, rookImage, serviceAccount string) error {
+	kubeletDirPath := envOrDefault(csiKubeletDirPathEnv, defaultKubeletDirPath)
+	pluginDirPath := path.Join(kubeletDirPath, "plugins", csi.DriverName)
+
+	libModulesDirPath := os.Getenv(libModulesPathDirEnv)
+	if libModulesDirPath == "" {
+		libModulesDirPath = "/lib/modules"
+	}
+
+	privileged := true
+	bidirectional := v1.MountPropagationBidirectional
+	ds := &extensions.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: csiPluginDaemonsetName,
+		},
+		Spec: extensions.DaemonSetSpec{
+			UpdateStrategy: extensions.DaemonSetUpdateStrategy{
+				Type: extensions.RollingUpdateDaemonSetStrategyType,
+			},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"app": csiPluginDaemonsetName,
+					},
+				},
+				Spec: v1.PodSpec{
+					ServiceAccountName: serviceAccount,
+					Containers: []v1.Container{
+						{
+							Name:  "driver-registrar",
+							Image: envOrDefault(csiRegistrarImageEnv, defaultRegistrarImage),
+							Args: []string{
+								"--v=5",
+								"--csi-address=$(ADDRESS)",
+								fmt.Sprintf("--kubelet-registration-path=%s", path.Join(pluginDirPath, "csi.sock")),
+							},
+							Env: []v1.EnvVar{
+								{Name: "ADDRESS", Value: csiAddress},
+								{Name: "KUBE_NODE_NAME", ValueFrom: &v1.EnvVarSource{FieldRef: &v1.ObjectFieldSelector{FieldPath: "spec.nodeName"}}},
+							},
+							VolumeMounts: []v1.VolumeMount{
+								{Name: "plugin-dir", MountPath: csiSocketDir},
+								{Name: "registration-dir", MountPath: "/registration"},
+							},
+						},
+						{
+							Name:  csiPluginDaemonsetName,
+							Image: rookImage,
+							Args:  []string{"ceph", "csi", fmt.Sprintf("--csi-mode=%s", csi.NodeMode), fmt.Sprintf("--csi-endpoint=unix://%s", csiAddress)},
+							SecurityContext: &v1.SecurityContext{
+								Privileged: &privileged,
+							},
+							VolumeMounts: []v1.VolumeMount{
+								{Name: "plugin-dir", MountPath: csiSocketDir},
+								// the volumes are staged in the plugins dir and published in the pods dir of the kubelet
+								{Name: "kubelet-dir", MountPath: kubeletDirPath, MountPropagation: &bidirectional},
+								{Name: "dev", MountPath: "/dev"},
+								{Name: "sys", MountPath: "/sys"},
+								{Name: "libmodules", MountPath: "/lib/modules"},
+							},
+							Env: []v1.EnvVar{
+								k8sutil.NamespaceEnvVar(),
+								k8sutil.NodeEnvVar(),
+							},
+						},
+					},
+					Volumes: []v1.Volume{
+						hostPathVolume("plugin-dir", pluginDirPath),
+						hostPathVolume("registration-dir", path.Join(kubeletDirPath, "plugins")),
+						hostPathVolume("kubelet-dir", kubeletDirPath),
+						hostPathVolume("dev", "/dev"),
+						hostPathVolume("sys", "/sys"),
+						hostPathVolume("libmodules", libModulesDirPath),
+					},
+					HostNetwork: true,
+				},
+			},
+		},
+	}
+
+	// the csi plugin tolerates the same taints as the agent
+	tolerationValue := os.Getenv(agentDaemonsetTolerationEnv)
+	if tolerationValue != "" {
+		ds.Spec.Template.Spec.Tolerations = []v1.Toleration{
+			{
+				Effect:   v1.TaintEffect(tolerationValue),
+				Operator: v1.TolerationOpExists,
+				Key:      os.Getenv(agentDaemonsetTolerationKeyEnv),
+			},
+		}
+	}
+
+	_, err := a.clientset.Extensions().DaemonSets(namespace).Create(ds)
+	if err != nil {
+		if !kserrors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create %s daemon set. %+v", csiPluginDaemonsetName, err)
+		}
+		logger.Infof("%s daemonset already exists, updating ...", csiPluginDaemonsetName)
+		if _, err = a.clientset.Extensions().DaemonSets(namespace).Update(ds); err != nil {
+			return fmt.Errorf("failed to update %s daemon set. %+v", csiPluginDaemonsetName, err)
+		}
+	} else {
+		logger.Infof("%s daemonset started", csiPluginDaemonsetName)
+	}
+	return nil
+}
+
+func (a *Agent) createCSIController(namespace, rookImage, serviceAccount string) error {
+	replicas := int32(1)
+	socketMount := v1.VolumeMount{Name: "socket-dir", MountPath: csiSocketDir}
+	addressEnv := v1.EnvVar{Name: "ADDRESS", Value: csiAddress}
+	d := &extensions.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: csiControllerName,
+		},
+		Spec: extensions.DeploymentSpec{
+			Replicas: &replicas,
+			// only a single controller may provision and attach volumes at a time
+			Strategy: extensions.DeploymentStrategy{
+				Type: extensions.RecreateDeploymentStrategyType,
+			},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"app": csiControllerName,
+					},
+				},
+				Spec: v1.PodSpec{
+					ServiceAccountName: serviceAccount,
+					Containers: []v1.Container{
+						{
+							Name:         "csi-provisioner",
+							Image:        envOrDefault(csiProvisionerImageEnv, defaultProvisionerImage),
+							Args:         []string{"--v=5", fmt.Sprintf("--provisioner=%s", csi.DriverName), "--csi-address=$(ADDRESS)"},
+							Env:          []v1.EnvVar{addressEnv},
+							VolumeMounts: []v1.VolumeMount{socketMount},
+						},
+						{
+							Name:         "csi-attacher",
+							Image:        envOrDefault(csiAttacherImageEnv, defaultAttacherImage),
+							Args:         []string{"--v=5", "--csi-address=$(ADDRESS)"},
+							Env:          []v1.EnvVar{addressEnv},
+							VolumeMounts: []v1.VolumeMount{socketMount},
+						},
+						{
+							Name:  csiControllerName,
+							Image: rookImage,
+							Args:  []string{"ceph", "csi", fmt.Sprintf("--csi-mode=%s", csi.ControllerMode), fmt.Sprintf("--csi-endpoint=unix://%s", csiAddress)},
+							Env:   []v1.EnvVar{k8sutil.NamespaceEnvVar()},
+							// the driver writes the admin config of the clusters to the data dir
+							VolumeMounts: []v1.VolumeMount{socketMount, {Name: k8sutil.DataDirVolume, MountPath: k8sutil.DataDir}},
+						},
+					},
+					Volumes: []v1.Volume{
+						{Name: "socket-dir", VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}},
+						{Name: k8sutil.DataDirVolume, VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}},
+					},
+				},
+			},
+		},
+	}
+
+	_, err := a.clientset.Extensions().Deployments(namespace).Create(d)
+	if err != nil {
+		if !kserrors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create %s deployment. %+v", csiControllerName, err)
+		}
+		logger.Infof("%s deployment already exists, updating ...", csiControllerName)
+		if _, err = a.clientset.Extensions().Deployments(namespace).Update(d); err != nil {
+			return fmt.Errorf("failed to update %s deployment. %+v", csiControllerName, err)
+		}
+	} else {
+		logger.Infof("%s deployment started", csiControllerName)
+	}
+	return nil
+}
+
+func hostPathVolume(name, hostPath string) v1.Volume {
+	return v1.Volume{
+		Name: name,
+		VolumeSource: v1.VolumeSource{
+			HostPath: &v1.HostPathVolumeSource{
+				Path: hostPath,
+			},
+		},
+	}
+}
+
+func envOrDefault(name, defaultValue string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return defaultValue
+}
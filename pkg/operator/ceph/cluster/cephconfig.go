@@ -0,0 +1,263 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mgr"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/rook/rook/pkg/operator/ceph/file"
+	"github.com/rook/rook/pkg/operator/ceph/object"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// the key of the settings that have been applied to the running daemons in the ceph config configmap
+	cephConfigAppliedKey = "applied"
+	// the annotation of the pods that restarts the daemons when the settings change
+	cephConfigHashAnnotation = "ceph.rook.io/config-hash"
+)
+
+// the daemons are restarted in the same order as they are upgraded
+var cephConfigDaemons = []string{mon.AppName, mgr.AppName, osd.AppName, file.AppName, object.AppName}
+
+// saveCephConfig saves the ceph config settings of the cluster CRD in the configmap that the daemons load their settings
+// from when they start. The settings that were applied to the running daemons are kept until applyCephConfig applies the changes.
+func (c *cluster) saveCephConfig() error {
+	settings, err := marshalCephConfig(c.Spec.CephConfig)
+	if err != nil {
+		return err
+	}
+
+	cm, err := c.context.Clientset.CoreV1().ConfigMaps(c.Namespace).Get(k8sutil.CephConfigName, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get configmap %s. %+v", k8sutil.CephConfigName, err)
+		}
+
+		// the daemons have not been started yet or will start with these settings
+		cm = &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      k8sutil.CephConfigName,
+				Namespace: c.Namespace,
+			},
+			Data: map[string]string{
+				k8sutil.CephConfigVal: settings,
+				cephConfigAppliedKey:  settings,
+			},
+		}
+		k8sutil.SetOwnerRef(c.context.Clientset, c.Namespace, &cm.ObjectMeta, &c.ownerRef)
+		if _, err := c.context.Clientset.CoreV1().ConfigMaps(c.Namespace).Create(cm); err != nil {
+			return fmt.Errorf("failed to create configmap %s. %+v", k8sutil.CephConfigName, err)
+		}
+		return nil
+	}
+
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	if value, ok := cm.Data[k8sutil.CephConfigVal]; ok && value == settings {
+		return nil
+	}
+	cm.Data[k8sutil.CephConfigVal] = settings
+	if _, err := c.context.Clientset.CoreV1().ConfigMaps(c.Namespace).Update(cm); err != nil {
+		return fmt.Errorf("failed to update configmap %s. %+v", k8sutil.CephConfigName, err)
+	}
+	return nil
+}
+
+// applyCephConfig applies the changes of the ceph config settings to the running daemons. On mimic or newer the settings
+// are set in the config database of the mons, which the daemons read at runtime. The daemons are restarted when the
+// settings cannot be changed at runtime.
+func (c *cluster) applyCephConfig() error {
+	cm, err := c.context.Clientset.CoreV1().ConfigMaps(c.Namespace).Get(k8sutil.CephConfigName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get configmap %s. %+v", k8sutil.CephConfigName, err)
+	}
+	settings := cm.Data[k8sutil.CephConfigVal]
+	if settings == cm.Data[cephConfigAppliedKey] {
+		return nil
+	}
+
+	applied, err := unmarshalCephConfig(cm.Data[cephConfigAppliedKey])
+	if err != nil {
+		return err
+	}
+	desired, err := unmarshalCephConfig(settings)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("applying ceph config settings to the daemons in namespace %s", c.Namespace)
+	restart := c.setCephConfig(applied, desired)
+	if err := c.restartCephConfigDaemons(restart, k8sutil.Hash(settings)); err != nil {
+		return err
+	}
+
+	cm.Data[cephConfigAppliedKey] = settings
+	if _, err := c.context.Clientset.CoreV1().ConfigMaps(c.Namespace).Update(cm); err != nil {
+		return fmt.Errorf("failed to update configmap %s. %+v", k8sutil.CephConfigName, err)
+	}
+	logger.Infof("applied ceph config settings to the daemons in namespace %s", c.Namespace)
+	return nil
+}
+
+// setCephConfig sets the changed settings in the config database of the mons and returns the daemons that must be
+// restarted to apply the changes. The running daemons only apply new settings at runtime since the settings in their
+// config file take precedence over the config database.
+func (c *cluster) setCephConfig(applied, desired map[string]map[string]string) map[string]bool {
+	live := cephv1beta1.VersionAtLeast(c.Spec.CephVersion.Name, cephv1beta1.Mimic)
+	restart := map[string]bool{}
+	restartSection := func(section string) {
+		for _, daemon := range sectionDaemons(section) {
+			restart[daemon] = true
+		}
+	}
+
+	for section, settings := range desired {
+		who := cephconfig.SectionName(section)
+		for key, val := range settings {
+			oldVal, ok := applied[section][key]
+			if ok && oldVal == val {
+				continue
+			}
+			if !live {
+				restartSection(section)
+				continue
+			}
+			if err := client.SetConfig(c.context, c.Namespace, who, key, val); err != nil {
+				logger.Warningf("restarting the daemons to apply setting %s. %+v", key, err)
+				restartSection(section)
+				continue
+			}
+			if ok || !c.canUpdateAtRuntime(key) {
+				restartSection(section)
+			}
+		}
+	}
+
+	for section, settings := range applied {
+		who := cephconfig.SectionName(section)
+		for key := range settings {
+			if _, ok := desired[section][key]; ok {
+				continue
+			}
+			if live {
+				if err := client.RemoveConfig(c.context, c.Namespace, who, key); err != nil {
+					logger.Warningf("%+v", err)
+				}
+			}
+			restartSection(section)
+		}
+	}
+
+	return restart
+}
+
+func (c *cluster) canUpdateAtRuntime(key string) bool {
+	help, err := client.GetConfigOptionHelp(c.context, c.Namespace, key)
+	if err != nil {
+		logger.Warningf("restarting the daemons to apply setting %s. %+v", key, err)
+		return false
+	}
+	return help.CanUpdateAtRuntime
+}
+
+// restartCephConfigDaemons restarts the deployments of the daemons one at a time. The health of the cluster is checked
+// before each restart the same as during upgrades. Deployments already restarted with the settings are skipped on retries.
+func (c *cluster) restartCephConfigDaemons(restart map[string]bool, hash string) error {
+	for _, daemon := range cephConfigDaemons {
+		if !restart[daemon] {
+			continue
+		}
+
+		deployments, err := k8sutil.GetDeployments(c.context.Clientset, c.Namespace, fmt.Sprintf("%s=%s", k8sutil.AppAttr, daemon))
+		if err != nil {
+			return fmt.Errorf("failed to get %s deployments. %+v", daemon, err)
+		}
+		for i := range deployments.Items {
+			d := &deployments.Items[i]
+			if d.Spec.Template.Annotations[cephConfigHashAnnotation] == hash {
+				continue
+			}
+			if err := checkUpgradeHealth(c.context, c.Namespace); err != nil {
+				return fmt.Errorf("paused restarting deployment %s to apply the ceph config settings. %+v", d.Name, err)
+			}
+
+			logger.Infof("restarting deployment %s to apply the ceph config settings", d.Name)
+			if d.Spec.Template.Annotations == nil {
+				d.Spec.Template.Annotations = map[string]string{}
+			}
+			d.Spec.Template.Annotations[cephConfigHashAnnotation] = hash
+			if err := k8sutil.UpdateDeploymentAndWait(c.context, d, c.Namespace); err != nil {
+				return fmt.Errorf("failed to restart deployment %s. %+v", d.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// sectionDaemons returns the daemons that load the settings of a section of the config file
+func sectionDaemons(section string) []string {
+	switch strings.SplitN(section, ".", 2)[0] {
+	case "global":
+		return cephConfigDaemons
+	case "mon":
+		return []string{mon.AppName}
+	case "mgr":
+		return []string{mgr.AppName}
+	case "osd":
+		return []string{osd.AppName}
+	case "mds":
+		return []string{file.AppName}
+	case "client":
+		return []string{object.AppName}
+	}
+	return nil
+}
+
+func marshalCephConfig(settings map[string]map[string]string) (string, error) {
+	if len(settings) == 0 {
+		return "", nil
+	}
+	b, err := json.Marshal(settings)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal ceph config settings. %+v", err)
+	}
+	return string(b), nil
+}
+
+func unmarshalCephConfig(settings string) (map[string]map[string]string, error) {
+	result := map[string]map[string]string{}
+	if settings == "" {
+		return result, nil
+	}
+	if err := json.Unmarshal([]byte(settings), &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal ceph config settings. %+v", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSaveAndApplyCephConfig(t *testing.T) {
+	var configArgs [][]string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			configArgs = append(configArgs, args)
+			if args[1] == "help" {
+				return `{"name":"` + args[2] + `","can_update_at_runtime":true}`, nil
+			}
+			return "", nil
+		},
+	}
+	clientset := testop.New(1)
+	context := &clusterd.Context{Clientset: clientset, Executor: executor}
+	c := &cluster{Namespace: "ns", context: context, Spec: &cephv1beta1.ClusterSpec{
+		CephVersion: cephv1beta1.CephVersionSpec{Name: cephv1beta1.Mimic},
+		CephConfig:  map[string]map[string]string{"osd": {"osd max backfills": "2"}},
+	}}
+
+	// the settings of a new cluster are loaded when the daemons start
+	assert.Nil(t, c.saveCephConfig())
+	assert.Nil(t, c.applyCephConfig())
+	assert.Equal(t, 0, len(configArgs))
+	cm, err := clientset.CoreV1().ConfigMaps("ns").Get(k8sutil.CephConfigName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, `{"osd":{"osd max backfills":"2"}}`, cm.Data[k8sutil.CephConfigVal])
+
+	// new settings are set in the config database of the mons
+	c.Spec.CephConfig = map[string]map[string]string{"osd": {"osd max backfills": "2"}, "client.rgw": {"rgw cache lru size": "20000"}}
+	assert.Nil(t, c.saveCephConfig())
+	assert.Nil(t, c.applyCephConfig())
+	assert.Equal(t, []string{"config", "set", "client.radosgw.gateway", "rgw cache lru size", "20000"}, configArgs[0][:5])
+	assert.Equal(t, []string{"config", "help", "rgw_cache_lru_size"}, configArgs[1][:3])
+	cm, err = clientset.CoreV1().ConfigMaps("ns").Get(k8sutil.CephConfigName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cm.Data[k8sutil.CephConfigVal], cm.Data[cephConfigAppliedKey])
+}
+
+func TestSetCephConfigRestarts(t *testing.T) {
+	runtime := true
+	var configArgs [][]string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			configArgs = append(configArgs, args)
+			if args[1] == "help" {
+				if runtime {
+					return `{"can_update_at_runtime":true}`, nil
+				}
+				return `{"can_update_at_runtime":false}`, nil
+			}
+			return "", nil
+		},
+	}
+	c := &cluster{Namespace: "ns", context: &clusterd.Context{Executor: executor}, Spec: &cephv1beta1.ClusterSpec{
+		CephVersion: cephv1beta1.CephVersionSpec{Name: cephv1beta1.Mimic},
+	}}
+	applied := map[string]map[string]string{"osd": {"osd max backfills": "2"}, "mds": {"mds cache memory limit": "1073741824"}}
+
+	// added settings that can be changed at runtime do not restart the daemons
+	desired := map[string]map[string]string{"osd": {"osd max backfills": "2", "osd recovery sleep": "1"}, "mds": {"mds cache memory limit": "1073741824"}}
+	assert.Equal(t, map[string]bool{}, c.setCephConfig(applied, desired))
+
+	// changed settings restart the daemons since the settings in the config file take precedence
+	desired = map[string]map[string]string{"osd": {"osd max backfills": "4"}, "mds": {"mds cache memory limit": "1073741824"}}
+	assert.Equal(t, map[string]bool{"rook-ceph-osd": true}, c.setCephConfig(applied, desired))
+
+	// removed settings are removed from the config database and restart the daemons
+	configArgs = nil
+	desired = map[string]map[string]string{"osd": {"osd max backfills": "2"}}
+	assert.Equal(t, map[string]bool{"rook-ceph-mds": true}, c.setCephConfig(applied, desired))
+	assert.Equal(t, []string{"config", "rm", "mds", "mds cache memory limit"}, configArgs[0][:4])
+
+	// added settings that cannot be changed at runtime restart the daemons
+	runtime = false
+	desired = map[string]map[string]string{"osd": {"osd max backfills": "2"}, "mds": {"mds cache memory limit": "1073741824"}, "global": {"ms type": "async"}}
+	assert.Equal(t, 5, len(c.setCephConfig(applied, desired)))
+
+	// luminous has no config database so all the changes restart the daemons
+	configArgs = nil
+	c.Spec.CephVersion.Name = cephv1beta1.Luminous
+	desired = map[string]map[string]string{"osd": {"osd max backfills": "2", "osd recovery sleep": "1"}, "mds": {"mds cache memory limit": "1073741824"}}
+	assert.Equal(t, map[string]bool{"rook-ceph-osd": true}, c.setCephConfig(applied, desired))
+	assert.Equal(t, 0, len(configArgs))
+}
+
+func TestSectionDaemons(t *testing.T) {
+	assert.Equal(t, []string{"rook-ceph-mon", "rook-ceph-mgr", "rook-ceph-osd", "rook-ceph-mds", "rook-ceph-rgw"}, sectionDaemons("global"))
+	assert.Equal(t, []string{"rook-ceph-osd"}, sectionDaemons("osd"))
+	assert.Equal(t, []string{"rook-ceph-osd"}, sectionDaemons("osd.3"))
+	assert.Equal(t, []string{"rook-ceph-rgw"}, sectionDaemons("client.rgw"))
+	assert.Equal(t, 0, len(sectionDaemons("foo")))
+}
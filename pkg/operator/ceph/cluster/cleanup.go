@@ -0,0 +1,349 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	"github.com/rook/rook/pkg/daemon/ceph/cleanup"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	osdconfig "github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	batch "k8s.io/api/batch/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/kubernetes/pkg/kubelet/apis"
+)
+
+const (
+	cleanupAppName    = "rook-ceph-cleanup"
+	cleanupJobNameFmt = "rook-ceph-cleanup-%s"
+	cleanupJobTimeout = 15 * time.Minute
+)
+
+var (
+	// the interval and timeout of waiting for the pods of the daemons to be gone before the hosts are cleaned up
+	daemonsStoppedInterval = 5 * time.Second
+	daemonsStoppedTimeout  = 10 * time.Minute
+)
+
+// startDelete handles the deletion of the cluster in a goroutine, since waiting for the daemons to stop and for the
+// cleanup jobs to complete would block the events of all the clusters. The progress of the deletion is tracked in
+// the status of the cluster, and the deletion is not started again while it is in progress. The finalizer is removed
+// when the deletion succeeds.
+func (c *ClusterController) startDelete(cluster *cephv1beta1.Cluster) {
+	c.deletionLock.Lock()
+	defer c.deletionLock.Unlock()
+	if c.deletions[cluster.Namespace] {
+		logger.Infof("deletion of cluster %s is already in progress", cluster.Namespace)
+		return
+	}
+	c.deletions[cluster.Namespace] = true
+
+	// the daemons must not be started again while their data is removed
+	policy := cluster.Spec.CleanupPolicy
+	if policy.DeleteDataDirOnHosts || policy.WipeDevices {
+		c.stopCluster(cluster.Namespace)
+	}
+
+	go func() {
+		defer func() {
+			c.deletionLock.Lock()
+			delete(c.deletions, cluster.Namespace)
+			c.deletionLock.Unlock()
+		}()
+
+		if err := c.updateClusterStatus(cluster.Namespace, cluster.Name, cephv1beta1.ClusterStateDeleting, ""); err != nil {
+			logger.Errorf("failed to update cluster status in namespace %s: %+v", cluster.Namespace, err)
+		}
+
+		if err := c.handleDelete(cluster, time.Duration(clusterDeleteRetryInterval)*time.Second); err != nil {
+			message := fmt.Sprintf("failed to delete cluster. %+v", err)
+			logger.Error(message)
+			if err := c.updateClusterStatus(cluster.Namespace, cluster.Name, cephv1beta1.ClusterStateError, message); err != nil {
+				logger.Errorf("failed to update cluster status in namespace %s: %+v", cluster.Namespace, err)
+			}
+			return
+		}
+
+		// remove the finalizer from the latest cluster object since its status was updated, which indicates to k8s
+		// that the resource can safely be deleted
+		latest, err := c.context.RookClientset.CephV1beta1().Clusters(cluster.Namespace).Get(cluster.Name, metav1.GetOptions{})
+		if err != nil {
+			logger.Errorf("failed to get cluster %s to remove its finalizer. %+v", cluster.Namespace, err)
+			return
+		}
+		c.removeFinalizer(latest)
+	}()
+}
+
+// deletionInProgress returns whether the deletion of the cluster in the namespace is in progress
+func (c *ClusterController) deletionInProgress(namespace string) bool {
+	c.deletionLock.Lock()
+	defer c.deletionLock.Unlock()
+	return c.deletions[namespace]
+}
+
+// cleanupHosts removes the data of a deleted cluster from the hosts of its mons and OSDs according to the cleanup
+// policy. The daemons of the cluster are deleted first, then a job runs on each of the hosts. An error is returned
+// if any of the jobs does not succeed, and the failed jobs are left behind to see their logs. They are run again
+// the next time the deletion is handled. The orchestration of the cluster must be stopped before.
+func (c *ClusterController) cleanupHosts(cluster *cephv1beta1.Cluster) error {
+	policy := cluster.Spec.CleanupPolicy
+	if !policy.DeleteDataDirOnHosts && !policy.WipeDevices {
+		return nil
+	}
+
+	hostnames, err := c.getCleanupHosts(cluster.Namespace)
+	if err != nil {
+		return fmt.Errorf("failed to get the hosts to clean up. %+v", err)
+	}
+	if len(hostnames) == 0 {
+		logger.Infof("no hosts to clean up for cluster %s", cluster.Namespace)
+		return nil
+	}
+
+	if err := c.deleteDaemons(cluster.Namespace); err != nil {
+		return fmt.Errorf("failed to stop the daemons of cluster %s. %+v", cluster.Namespace, err)
+	}
+
+	clusterFSID := ""
+	if policy.WipeDevices {
+		clusterFSID = c.getClusterFSID(cluster.Namespace)
+	}
+
+	logger.Infof("cleaning up hosts %v of cluster %s", hostnames, cluster.Namespace)
+	var jobs []*batch.Job
+	for _, hostname := range hostnames {
+		var diskUUIDs []string
+		if policy.WipeDevices {
+			diskUUIDs, err = c.getOSDDiskUUIDs(cluster.Namespace, hostname)
+			if err != nil {
+				return fmt.Errorf("failed to get the osd devices on host %s. %+v", hostname, err)
+			}
+		}
+		job := c.cleanupJob(cluster, hostname, diskUUIDs, clusterFSID)
+		if err := k8sutil.RunReplaceableJob(c.context.Clientset, job); err != nil {
+			return fmt.Errorf("failed to start job %s. %+v", job.Name, err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	var failed []string
+	for _, job := range jobs {
+		if err := k8sutil.WaitForJobCompletion(c.context.Clientset, job, cleanupJobTimeout); err != nil {
+			logger.Errorf("failed to clean up host %s. %+v", job.Spec.Template.Spec.NodeSelector[apis.LabelHostname], err)
+			failed = append(failed, job.Name)
+			continue
+		}
+		if err := k8sutil.DeleteBatchJob(c.context.Clientset, cluster.Namespace, job.Name, false); err != nil {
+			logger.Warningf("failed to delete job %s. %+v", job.Name, err)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("cleanup jobs %v did not succeed", failed)
+	}
+
+	logger.Infof("cleaned up the hosts of cluster %s", cluster.Namespace)
+	return nil
+}
+
+// getCleanupHosts returns the hostnames of the nodes of the mons and OSDs of the cluster, and of the nodes of the
+// cleanup jobs that did not succeed when the deletion was handled before.
+func (c *ClusterController) getCleanupHosts(namespace string) ([]string, error) {
+	hosts := map[string]bool{}
+
+	selector := fmt.Sprintf("%s=%s,%s in (%s,%s)", k8sutil.ClusterAttr, namespace, k8sutil.AppAttr, mon.AppName, osd.AppName)
+	deployments, err := c.context.Clientset.ExtensionsV1beta1().Deployments(namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mon and osd deployments. %+v", err)
+	}
+	for _, d := range deployments.Items {
+		if hostname := d.Spec.Template.Spec.NodeSelector[apis.LabelHostname]; hostname != "" {
+			hosts[hostname] = true
+		}
+	}
+
+	jobs, err := c.context.Clientset.Batch().Jobs(namespace).List(metav1.ListOptions{LabelSelector: k8sutil.AppAttr + "=" + cleanupAppName})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cleanup jobs. %+v", err)
+	}
+	for _, job := range jobs.Items {
+		if hostname := job.Spec.Template.Spec.NodeSelector[apis.LabelHostname]; hostname != "" {
+			hosts[hostname] = true
+		}
+	}
+
+	var hostnames []string
+	for hostname := range hosts {
+		hostnames = append(hostnames, hostname)
+	}
+	sort.Strings(hostnames)
+	return hostnames, nil
+}
+
+// getOSDDiskUUIDs returns the disk UUIDs of the devices of the OSDs of the cluster on the host, from the partition
+// scheme of the node. Only these devices are wiped, since the hosts may have the devices of other clusters.
+func (c *ClusterController) getOSDDiskUUIDs(namespace, hostname string) ([]string, error) {
+	kv := k8sutil.NewConfigMapKVStore(namespace, c.context.Clientset, metav1.OwnerReference{})
+	scheme, err := osdconfig.LoadScheme(kv, osdconfig.GetConfigStoreName(hostname))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the partition scheme. %+v", err)
+	}
+
+	disks := map[string]bool{}
+	if scheme.Metadata != nil && scheme.Metadata.DiskUUID != "" {
+		disks[scheme.Metadata.DiskUUID] = true
+	}
+	for _, entry := range scheme.Entries {
+		for _, partition := range entry.Partitions {
+			if partition.DiskUUID != "" {
+				disks[partition.DiskUUID] = true
+			}
+		}
+	}
+
+	var diskUUIDs []string
+	for diskUUID := range disks {
+		diskUUIDs = append(diskUUIDs, diskUUID)
+	}
+	sort.Strings(diskUUIDs)
+	return diskUUIDs, nil
+}
+
+// getClusterFSID returns the fsid of the cluster, which the logical volumes of the OSDs created by ceph-volume are
+// tagged with. The ceph-volume OSDs are not zapped if the fsid cannot be found.
+func (c *ClusterController) getClusterFSID(namespace string) string {
+	clusterInfo, _, _, err := mon.LoadClusterInfo(c.context, namespace)
+	if err != nil {
+		logger.Warningf("failed to load the fsid of cluster %s to zap its ceph-volume osds. %+v", namespace, err)
+		return ""
+	}
+	return clusterInfo.FSID
+}
+
+// stopCluster stops the orchestration of the cluster so that its daemons are not started again
+func (c *ClusterController) stopCluster(namespace string) {
+	if cluster, ok := c.clusterMap[namespace]; ok {
+		close(cluster.stopCh)
+		delete(c.clusterMap, namespace)
+	}
+}
+
+// deleteDaemons deletes the deployments of the cluster and waits for their pods to be gone
+func (c *ClusterController) deleteDaemons(namespace string) error {
+	selector := fmt.Sprintf("%s=%s", k8sutil.ClusterAttr, namespace)
+	deployments, err := c.context.Clientset.ExtensionsV1beta1().Deployments(namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return fmt.Errorf("failed to list deployments. %+v", err)
+	}
+	for _, d := range deployments.Items {
+		if err := k8sutil.DeleteDeployment(c.context.Clientset, namespace, d.Name); err != nil {
+			return err
+		}
+	}
+
+	return wait.PollImmediate(daemonsStoppedInterval, daemonsStoppedTimeout, func() (bool, error) {
+		pods, err := c.context.Clientset.CoreV1().Pods(namespace).List(metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return false, fmt.Errorf("failed to list pods. %+v", err)
+		}
+		for _, pod := range pods.Items {
+			if pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed {
+				logger.Infof("waiting for pod %s to be gone", pod.Name)
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+// cleanupJob returns the job that removes the data of the cluster from a host. Only the devices with the given disk
+// UUIDs and the ceph-volume OSDs of the cluster fsid are wiped. The job tolerates the taints tolerated by the mons
+// and OSDs, so it runs on the same hosts.
+func (c *ClusterController) cleanupJob(cluster *cephv1beta1.Cluster, hostname string, diskUUIDs []string, clusterFSID string) *batch.Job {
+	name := k8sutil.TruncateNodeName(cleanupJobNameFmt, hostname)
+	labels := map[string]string{
+		k8sutil.AppAttr:     cleanupAppName,
+		k8sutil.ClusterAttr: cluster.Namespace,
+	}
+	args := []string{"ceph", cleanup.CleanupCommand}
+	var volumes []v1.Volume
+	var volumeMounts []v1.VolumeMount
+	if cluster.Spec.CleanupPolicy.DeleteDataDirOnHosts && cluster.Spec.DataDirHostPath != "" {
+		args = append(args, fmt.Sprintf("--data-dir=%s", k8sutil.DataDir))
+		volumes = append(volumes, v1.Volume{Name: k8sutil.DataDirVolume, VolumeSource: v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: cluster.Spec.DataDirHostPath}}})
+		volumeMounts = append(volumeMounts, v1.VolumeMount{Name: k8sutil.DataDirVolume, MountPath: k8sutil.DataDir})
+	}
+	if cluster.Spec.CleanupPolicy.WipeDevices {
+		args = append(args, "--wipe-devices=true")
+		if len(diskUUIDs) > 0 {
+			args = append(args, fmt.Sprintf("--disk-uuids=%s", strings.Join(diskUUIDs, ",")))
+		}
+		if clusterFSID != "" {
+			args = append(args, fmt.Sprintf("--cluster-fsid=%s", clusterFSID))
+		}
+		volumes = append(volumes, v1.Volume{Name: "devices", VolumeSource: v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: "/dev"}}})
+		volumeMounts = append(volumeMounts, v1.VolumeMount{Name: "devices", MountPath: "/dev"})
+		volumes = append(volumes, v1.Volume{Name: "udev", VolumeSource: v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: "/run/udev"}}})
+		volumeMounts = append(volumeMounts, v1.VolumeMount{Name: "udev", MountPath: "/run/udev"})
+	}
+
+	var tolerations []v1.Toleration
+	tolerations = append(tolerations, cephv1beta1.GetMonPlacement(cluster.Spec.Placement).Tolerations...)
+	tolerations = append(tolerations, cephv1beta1.GetOSDPlacement(cluster.Spec.Placement).Tolerations...)
+
+	privileged := true
+	return &batch.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: cluster.Namespace,
+			Labels:    labels,
+		},
+		Spec: batch.JobSpec{
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Args:         args,
+							Name:         "cleanup",
+							Image:        c.rookImage,
+							VolumeMounts: volumeMounts,
+							// the devices are zapped on the host
+							SecurityContext: &v1.SecurityContext{
+								Privileged: &privileged,
+							},
+						},
+					},
+					NodeSelector:  map[string]string{apis.LabelHostname: hostname},
+					Tolerations:   tolerations,
+					Volumes:       volumes,
+					RestartPolicy: v1.RestartPolicyOnFailure,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,230 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume/attachment"
+	osdconfig "github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	testop "github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/kubelet/apis"
+)
+
+func testDeployment(name, app, hostname string) *extensions.Deployment {
+	d := &extensions.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "ns",
+			Labels:    map[string]string{"app": app, "rook_cluster": "ns"},
+		},
+	}
+	if hostname != "" {
+		d.Spec.Template.Spec.NodeSelector = map[string]string{apis.LabelHostname: hostname}
+	}
+	return d
+}
+
+func TestCleanupHosts(t *testing.T) {
+	daemonsStoppedInterval = time.Millisecond
+	clientset := testop.New(3)
+	context := &clusterd.Context{Clientset: clientset}
+	controller := NewClusterController(context, "rook/rook:myversion", &attachment.MockAttachment{
+		MockList: func(namespace string) (*rookalpha.VolumeList, error) {
+			return &rookalpha.VolumeList{}, nil
+		},
+	})
+
+	clientset.ExtensionsV1beta1().Deployments("ns").Create(testDeployment("rook-ceph-mon-a", "rook-ceph-mon", "node1"))
+	clientset.ExtensionsV1beta1().Deployments("ns").Create(testDeployment("rook-ceph-osd-0", "rook-ceph-osd", "node2"))
+	clientset.ExtensionsV1beta1().Deployments("ns").Create(testDeployment("rook-ceph-osd-1", "rook-ceph-osd", "node2"))
+	clientset.ExtensionsV1beta1().Deployments("ns").Create(testDeployment("rook-ceph-mgr-a", "rook-ceph-mgr", "node3"))
+	clientset.CoreV1().Pods("ns").Create(&v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon-a-123", Namespace: "ns", Labels: map[string]string{"rook_cluster": "ns"}},
+		Status:     v1.PodStatus{Phase: v1.PodRunning},
+	})
+	clientset.CoreV1().Secrets("ns").Create(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon", Namespace: "ns"},
+		Data:       map[string][]byte{"cluster-name": []byte("ns"), "fsid": []byte("myfsid")},
+	})
+
+	// nothing is cleaned up without a policy
+	cluster := &cephv1beta1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "ns"}}
+	err := controller.handleDelete(cluster, time.Microsecond)
+	assert.Nil(t, err)
+	deployments, _ := clientset.ExtensionsV1beta1().Deployments("ns").List(metav1.ListOptions{})
+	assert.Equal(t, 4, len(deployments.Items))
+
+	hostnames, err := controller.getCleanupHosts("ns")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"node1", "node2"}, hostnames)
+
+	// the pod of the mon is gone after the deployments are deleted, then the job on node2 fails
+	go func() {
+		for i := 0; i < 100; i++ {
+			deployments, _ := clientset.ExtensionsV1beta1().Deployments("ns").List(metav1.ListOptions{})
+			if len(deployments.Items) == 0 {
+				clientset.CoreV1().Pods("ns").Delete("rook-ceph-mon-a-123", &metav1.DeleteOptions{})
+				break
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		for i := 0; i < 100; i++ {
+			job1, err1 := clientset.Batch().Jobs("ns").Get("rook-ceph-cleanup-node1", metav1.GetOptions{})
+			job2, err2 := clientset.Batch().Jobs("ns").Get("rook-ceph-cleanup-node2", metav1.GetOptions{})
+			if err1 == nil && err2 == nil {
+				job1.Status.Succeeded = 1
+				clientset.Batch().Jobs("ns").Update(job1)
+				job2.Status.Failed = 1
+				clientset.Batch().Jobs("ns").Update(job2)
+				return
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}()
+
+	cluster.Spec.DataDirHostPath = "/var/lib/rook"
+	cluster.Spec.CleanupPolicy = cephv1beta1.CleanupPolicySpec{DeleteDataDirOnHosts: true, WipeDevices: true}
+	err = controller.handleDelete(cluster, time.Microsecond)
+	assert.NotNil(t, err)
+	deployments, _ = clientset.ExtensionsV1beta1().Deployments("ns").List(metav1.ListOptions{})
+	assert.Equal(t, 0, len(deployments.Items))
+
+	// the job that succeeded is deleted, and the failed job is left behind so it runs again on the next attempt
+	_, err = clientset.Batch().Jobs("ns").Get("rook-ceph-cleanup-node1", metav1.GetOptions{})
+	assert.True(t, errors.IsNotFound(err))
+	job, err := clientset.Batch().Jobs("ns").Get("rook-ceph-cleanup-node2", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Contains(t, job.Spec.Template.Spec.Containers[0].Args, "--cluster-fsid=myfsid")
+	hostnames, err = controller.getCleanupHosts("ns")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"node2"}, hostnames)
+}
+
+func TestStartDelete(t *testing.T) {
+	context := &clusterd.Context{Clientset: testop.New(1), RookClientset: rookfake.NewSimpleClientset()}
+	controller := NewClusterController(context, "rook/rook:myversion", &attachment.MockAttachment{
+		MockList: func(namespace string) (*rookalpha.VolumeList, error) {
+			return &rookalpha.VolumeList{}, nil
+		},
+	})
+	cluster := &cephv1beta1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "mycluster", Namespace: "ns", Finalizers: []string{finalizerName}}}
+	cluster, err := context.RookClientset.CephV1beta1().Clusters("ns").Create(cluster)
+	assert.Nil(t, err)
+
+	// the deletion is not started again while it is in progress
+	controller.deletions["ns"] = true
+	controller.startDelete(cluster)
+	cluster, err = context.RookClientset.CephV1beta1().Clusters("ns").Get("mycluster", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{finalizerName}, cluster.Finalizers)
+	assert.Equal(t, cephv1beta1.ClusterState(""), cluster.Status.State)
+
+	// the finalizer is removed in the background after the deletion succeeded
+	delete(controller.deletions, "ns")
+	controller.startDelete(cluster)
+	for i := 0; i < 100 && controller.deletionInProgress("ns"); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.False(t, controller.deletionInProgress("ns"))
+	cluster, err = context.RookClientset.CephV1beta1().Clusters("ns").Get("mycluster", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(cluster.Finalizers))
+	assert.Equal(t, cephv1beta1.ClusterStateDeleting, cluster.Status.State)
+}
+
+func TestGetOSDDiskUUIDs(t *testing.T) {
+	clientset := testop.New(1)
+	controller := &ClusterController{context: &clusterd.Context{Clientset: clientset}}
+
+	// no devices without a partition scheme
+	diskUUIDs, err := controller.getOSDDiskUUIDs("ns", "node1")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(diskUUIDs))
+
+	// the disks of the osd partitions and of the metadata device
+	scheme := osdconfig.NewPerfScheme()
+	scheme.Metadata = osdconfig.NewMetadataDeviceInfo("sdc")
+	scheme.Metadata.DiskUUID = "uuid-c"
+	entry := osdconfig.NewPerfSchemeEntry(osdconfig.Bluestore)
+	entry.Partitions[osdconfig.BlockPartitionType] = &osdconfig.PerfSchemePartitionDetails{Device: "sdb", DiskUUID: "uuid-b"}
+	entry.Partitions[osdconfig.WalPartitionType] = &osdconfig.PerfSchemePartitionDetails{Device: "sdc", DiskUUID: "uuid-c"}
+	scheme.Entries = append(scheme.Entries, entry)
+	entry = osdconfig.NewPerfSchemeEntry(osdconfig.Bluestore)
+	entry.Partitions[osdconfig.BlockPartitionType] = &osdconfig.PerfSchemePartitionDetails{Device: "sda", DiskUUID: "uuid-a"}
+	scheme.Entries = append(scheme.Entries, entry)
+	kv := k8sutil.NewConfigMapKVStore("ns", clientset, metav1.OwnerReference{})
+	err = scheme.SaveScheme(kv, osdconfig.GetConfigStoreName("node1"))
+	assert.Nil(t, err)
+
+	diskUUIDs, err = controller.getOSDDiskUUIDs("ns", "node1")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"uuid-a", "uuid-b", "uuid-c"}, diskUUIDs)
+
+	// the scheme of another node
+	diskUUIDs, err = controller.getOSDDiskUUIDs("ns", "node2")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(diskUUIDs))
+}
+
+func TestCleanupJob(t *testing.T) {
+	controller := &ClusterController{rookImage: "rook/rook:myversion"}
+	cluster := &cephv1beta1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns"},
+		Spec: cephv1beta1.ClusterSpec{
+			DataDirHostPath: "/var/lib/mydata",
+			CleanupPolicy:   cephv1beta1.CleanupPolicySpec{DeleteDataDirOnHosts: true},
+			Placement: rookalpha.PlacementSpec{
+				"mon": rookalpha.Placement{Tolerations: []v1.Toleration{{Key: "mon"}}},
+				"osd": rookalpha.Placement{Tolerations: []v1.Toleration{{Key: "osd"}}},
+			},
+		},
+	}
+
+	job := controller.cleanupJob(cluster, "node1", nil, "")
+	assert.Equal(t, "rook-ceph-cleanup-node1", job.Name)
+	assert.Equal(t, "ns", job.Namespace)
+	podSpec := job.Spec.Template.Spec
+	assert.Equal(t, map[string]string{apis.LabelHostname: "node1"}, podSpec.NodeSelector)
+	assert.Equal(t, []v1.Toleration{{Key: "mon"}, {Key: "osd"}}, podSpec.Tolerations)
+	container := podSpec.Containers[0]
+	assert.Equal(t, "rook/rook:myversion", container.Image)
+	assert.Equal(t, []string{"ceph", "cleanup", "--data-dir=/var/lib/rook"}, container.Args)
+	assert.True(t, *container.SecurityContext.Privileged)
+	assert.Equal(t, 1, len(podSpec.Volumes))
+	assert.Equal(t, "/var/lib/mydata", podSpec.Volumes[0].HostPath.Path)
+	assert.Equal(t, "/var/lib/rook", container.VolumeMounts[0].MountPath)
+
+	// the devices are mounted to wipe them
+	cluster.Spec.CleanupPolicy = cephv1beta1.CleanupPolicySpec{WipeDevices: true}
+	job = controller.cleanupJob(cluster, "node1", []string{"uuid1", "uuid2"}, "myfsid")
+	container = job.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, []string{"ceph", "cleanup", "--wipe-devices=true", "--disk-uuids=uuid1,uuid2", "--cluster-fsid=myfsid"}, container.Args)
+	assert.Equal(t, "/dev", container.VolumeMounts[0].MountPath)
+	assert.Equal(t, "/run/udev", container.VolumeMounts[1].MountPath)
+}
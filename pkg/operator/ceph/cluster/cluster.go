@@ -19,9 +19,11 @@ package cluster
 
 import (
 	"fmt"
+	"net"
 	"reflect"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
@@ -31,6 +33,9 @@ import (
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mgr"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/rbd"
+	"github.com/rook/rook/pkg/operator/ceph/file"
+	"github.com/rook/rook/pkg/operator/ceph/object"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	batch "k8s.io/api/batch/v1"
 	"k8s.io/api/core/v1"
@@ -50,14 +55,22 @@ var (
 )
 
 type cluster struct {
-	context   *clusterd.Context
-	Namespace string
-	Spec      *cephv1beta1.ClusterSpec
-	mons      *mon.Cluster
-	mgrs      *mgr.Cluster
-	osds      *osd.Cluster
-	stopCh    chan struct{}
-	ownerRef  metav1.OwnerReference
+	context           *clusterd.Context
+	Namespace         string
+	Spec              *cephv1beta1.ClusterSpec
+	mons              *mon.Cluster
+	mgrs              *mgr.Cluster
+	rbdMirrors        *rbd.Mirroring
+	osds              *osd.Cluster
+	osdMonitor        *osd.Monitor
+	disruptionChecker *disruptionChecker
+	fileController    *file.FilesystemController
+	objectController  *object.ObjectStoreController
+	stopCh            chan struct{}
+	ownerRef          metav1.OwnerReference
+	// orchestrationLock serializes the orchestration of the daemons with the osd remediation and topology checks running
+	// in the background
+	orchestrationLock sync.Mutex
 }
 
 func newCluster(c *cephv1beta1.Cluster, context *clusterd.Context) *cluster {
@@ -140,6 +153,8 @@ func (c *cluster) detectCephMajorVersion(timeout time.Duration) (string, error)
 }
 
 func (c *cluster) createInstance(rookImage string) error {
+	c.orchestrationLock.Lock()
+	defer c.orchestrationLock.Unlock()
 
 	// Create a configmap for overriding ceph config settings
 	// These settings should only be modified by a user after they are initialized
@@ -159,9 +174,14 @@ func (c *cluster) createInstance(rookImage string) error {
 		return fmt.Errorf("failed to create override configmap %s. %+v", c.Namespace, err)
 	}
 
+	// Save the ceph config settings from the cluster CRD that the daemons load when they start
+	if err := c.saveCephConfig(); err != nil {
+		return fmt.Errorf("failed to save the ceph config settings. %+v", err)
+	}
+
 	// Start the mon pods
 	c.mons = mon.New(c.context, c.Namespace, c.Spec.DataDirHostPath, rookImage, c.Spec.CephVersion, c.Spec.Mon, cephv1beta1.GetMonPlacement(c.Spec.Placement),
-		c.Spec.Network.HostNetwork, cephv1beta1.GetMonResources(c.Spec.Resources), c.ownerRef)
+		c.Spec.Network, cephv1beta1.GetMonResources(c.Spec.Resources), c.ownerRef)
 	err = c.mons.Start()
 	if err != nil {
 		return fmt.Errorf("failed to start the mons. %+v", err)
@@ -173,7 +193,7 @@ func (c *cluster) createInstance(rookImage string) error {
 	}
 
 	c.mgrs = mgr.New(c.context, c.Namespace, rookImage, c.Spec.CephVersion, cephv1beta1.GetMgrPlacement(c.Spec.Placement),
-		c.Spec.Network.HostNetwork, c.Spec.Dashboard, cephv1beta1.GetMgrResources(c.Spec.Resources), c.ownerRef)
+		c.Spec.Network, c.Spec.Dashboard, cephv1beta1.GetMgrResources(c.Spec.Resources), c.ownerRef)
 	err = c.mgrs.Start()
 	if err != nil {
 		return fmt.Errorf("failed to start the ceph mgr. %+v", err)
@@ -181,16 +201,40 @@ func (c *cluster) createInstance(rookImage string) error {
 
 	// Start the OSDs
 	c.osds = osd.New(c.context, c.Namespace, rookImage, c.Spec.CephVersion, c.Spec.ServiceAccount, c.Spec.Storage, c.Spec.DataDirHostPath,
-		cephv1beta1.GetOSDPlacement(c.Spec.Placement), c.Spec.Network.HostNetwork, cephv1beta1.GetOSDResources(c.Spec.Resources), c.ownerRef)
+		cephv1beta1.GetOSDPlacement(c.Spec.Placement), c.Spec.Network, cephv1beta1.GetOSDResources(c.Spec.Resources), c.ownerRef)
 	err = c.osds.Start()
 	if err != nil {
 		return fmt.Errorf("failed to start the osds. %+v", err)
 	}
 
+	// Start the rbd-mirror daemons if the pools are mirrored to peer clusters
+	c.rbdMirrors = rbd.New(c.context, c.Namespace, rookImage, c.Spec.CephVersion, cephv1beta1.GetRBDMirrorPlacement(c.Spec.Placement),
+		c.Spec.Network, c.Spec.RBDMirroring, cephv1beta1.GetRBDMirrorResources(c.Spec.Resources), c.ownerRef)
+	err = c.rbdMirrors.Start()
+	if err != nil {
+		return fmt.Errorf("failed to start the rbd-mirror daemons. %+v", err)
+	}
+
+	// Apply changes of the ceph config settings to the running daemons
+	if err := c.applyCephConfig(); err != nil {
+		return fmt.Errorf("failed to apply the ceph config settings. %+v", err)
+	}
+
 	logger.Infof("Done creating rook instance in namespace %s", c.Namespace)
 	return nil
 }
 
+// replaceOSD replaces an osd when no orchestration is in progress, since the osds of its node are provisioned again
+func (c *cluster) replaceOSD(id int) error {
+	c.orchestrationLock.Lock()
+	defer c.orchestrationLock.Unlock()
+
+	if c.osds == nil {
+		return fmt.Errorf("the osds of cluster %s are not created yet", c.Namespace)
+	}
+	return c.osds.ReplaceOSD(id)
+}
+
 func (c *cluster) createInitialCrushMap() error {
 	configMapExists := false
 	createCrushMap := false
@@ -286,11 +330,40 @@ func clusterChanged(oldCluster, newCluster cephv1beta1.ClusterSpec, clusterRef *
 		clusterRef.mons.MonCountMutex.Unlock()
 	}
 
+	if oldCluster.OSDRemediation != newCluster.OSDRemediation {
+		logger.Infof("osd remediation has changed from %+v to %+v. The osd health check will use the new policy...", oldCluster.OSDRemediation, newCluster.OSDRemediation)
+		if clusterRef.osdMonitor != nil {
+			clusterRef.osdMonitor.SetRemediation(newCluster.OSDRemediation)
+		}
+	}
+
+	if oldCluster.DisruptionManagement != newCluster.DisruptionManagement {
+		logger.Infof("disruption management has changed from %+v to %+v. The disruption check will update the budgets...", oldCluster.DisruptionManagement, newCluster.DisruptionManagement)
+		if clusterRef.disruptionChecker != nil {
+			clusterRef.disruptionChecker.setSpec(newCluster.DisruptionManagement)
+		}
+	}
+
+	if !reflect.DeepEqual(oldCluster.RBDMirroring, newCluster.RBDMirroring) {
+		logger.Infof("rbd mirroring has changed from %+v to %+v", oldCluster.RBDMirroring, newCluster.RBDMirroring)
+		changeFound = true
+	}
+
+	if oldCluster.CephVersion.Image != newCluster.CephVersion.Image {
+		logger.Infof("ceph image has changed from %s to %s. The ceph daemons will be upgraded...", oldCluster.CephVersion.Image, newCluster.CephVersion.Image)
+		changeFound = true
+	}
+
 	if oldCluster.CephVersion.AllowUnsupported != newCluster.CephVersion.AllowUnsupported {
 		logger.Infof("ceph version allowUnsupported has changed from %t to %t", oldCluster.CephVersion.AllowUnsupported, newCluster.CephVersion.AllowUnsupported)
 		changeFound = true
 	}
 
+	if !reflect.DeepEqual(oldCluster.CephConfig, newCluster.CephConfig) {
+		logger.Infof("ceph config settings have changed. The settings will be applied to the daemons...")
+		changeFound = true
+	}
+
 	return changeFound
 }
 
@@ -311,3 +384,26 @@ func versionSupported(version string) bool {
 	}
 	return false
 }
+
+// validateNetworkSpec checks that the daemons can be attached to the public and cluster networks
+func validateNetworkSpec(network rookv1alpha2.NetworkSpec) error {
+	networks := map[string]rookv1alpha2.NetworkSelection{"public": network.PublicNetwork, "cluster": network.ClusterNetwork}
+	for name, selection := range networks {
+		if selection.CIDR != "" {
+			if _, _, err := net.ParseCIDR(selection.CIDR); err != nil {
+				return fmt.Errorf("invalid cidr of the %s network. %+v", name, err)
+			}
+		}
+		if selection.Attachment == "" {
+			continue
+		}
+		// the daemons find their addresses in an attached network by its cidr
+		if selection.CIDR == "" {
+			return fmt.Errorf("the cidr of the %s network is required with the network attachment %s", name, selection.Attachment)
+		}
+		if network.HostNetwork {
+			return fmt.Errorf("the %s network cannot be attached to pods in the host network", name)
+		}
+	}
+	return nil
+}
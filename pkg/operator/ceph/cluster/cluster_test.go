@@ -21,6 +21,7 @@ import (
 	"time"
 
 	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	rookv1alpha2 "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	"github.com/rook/rook/pkg/clusterd"
 	testop "github.com/rook/rook/pkg/operator/test"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
@@ -46,3 +47,52 @@ func TestSetCephVersion(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, "mimic", cluster.Spec.CephVersion.Name)
 }
+
+func TestValidateNetworkSpec(t *testing.T) {
+	assert.Nil(t, validateNetworkSpec(rookv1alpha2.NetworkSpec{}))
+
+	network := rookv1alpha2.NetworkSpec{
+		PublicNetwork:  rookv1alpha2.NetworkSelection{CIDR: "10.1.1.0/24", Attachment: "public"},
+		ClusterNetwork: rookv1alpha2.NetworkSelection{CIDR: "10.1.2.0/24", Attachment: "rook-ceph/cluster"},
+	}
+	assert.Nil(t, validateNetworkSpec(network))
+
+	// the host network can be split by cidr without attachments
+	hostNetwork := rookv1alpha2.NetworkSpec{
+		HostNetwork:    true,
+		PublicNetwork:  rookv1alpha2.NetworkSelection{CIDR: "10.1.1.0/24"},
+		ClusterNetwork: rookv1alpha2.NetworkSelection{CIDR: "10.1.2.0/24"},
+	}
+	assert.Nil(t, validateNetworkSpec(hostNetwork))
+	hostNetwork.ClusterNetwork.Attachment = "cluster"
+	assert.NotNil(t, validateNetworkSpec(hostNetwork))
+
+	// the cidrs must be valid
+	invalid := network
+	invalid.PublicNetwork.CIDR = "10.1.1.0/33"
+	assert.NotNil(t, validateNetworkSpec(invalid))
+
+	// an attachment requires the cidr of the network
+	invalid = network
+	invalid.ClusterNetwork.CIDR = ""
+	assert.NotNil(t, validateNetworkSpec(invalid))
+}
+
+func TestReplaceOSDWaitsForOrchestration(t *testing.T) {
+	c := &cluster{Namespace: "ns"}
+
+	// the osd is not replaced while the cluster is orchestrated
+	c.orchestrationLock.Lock()
+	done := make(chan error)
+	go func() { done <- c.replaceOSD(1) }()
+	select {
+	case <-done:
+		assert.Fail(t, "the osd was replaced during the orchestration")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// the osds are not created yet when the orchestration is done
+	c.orchestrationLock.Unlock()
+	err := <-done
+	assert.NotNil(t, err)
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"sync"
 	"time"
 
 	"github.com/coreos/pkg/capnslog"
@@ -38,6 +39,7 @@ import (
 	"github.com/rook/rook/pkg/operator/discover"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -53,6 +55,7 @@ const (
 	clusterCreateTimeout     = 60 * time.Minute
 	updateClusterInterval    = 30 * time.Second
 	updateClusterTimeout     = 1 * time.Hour
+	upgradeClusterTimeout    = 24 * time.Hour
 )
 
 const (
@@ -93,6 +96,9 @@ type ClusterController struct {
 	devicesInUse     bool
 	rookImage        string
 	clusterMap       map[string]*cluster
+	// the namespaces of the clusters whose deletion is in progress
+	deletions    map[string]bool
+	deletionLock sync.Mutex
 }
 
 // NewClusterController create controller for watching cluster custom resources created
@@ -102,6 +108,7 @@ func NewClusterController(context *clusterd.Context, rookImage string, volumeAtt
 		volumeAttachment: volumeAttachment,
 		rookImage:        rookImage,
 		clusterMap:       make(map[string]*cluster),
+		deletions:        make(map[string]bool),
 	}
 }
 
@@ -165,6 +172,15 @@ func (c *ClusterController) onAdd(obj interface{}) {
 		return
 	}
 
+	if err := validateNetworkSpec(cluster.Spec.Network); err != nil {
+		message := fmt.Sprintf("invalid network settings. %+v", err)
+		logger.Error(message)
+		if err := c.updateClusterStatus(clusterObj.Namespace, clusterObj.Name, cephv1beta1.ClusterStateError, message); err != nil {
+			logger.Errorf("failed to update cluster status in namespace %s: %+v", cluster.Namespace, err)
+		}
+		return
+	}
+
 	if cluster.Spec.Storage.AnyUseAllDevices() {
 		c.devicesInUse = true
 	}
@@ -209,7 +225,11 @@ func (c *ClusterController) onAdd(obj interface{}) {
 		}
 
 		// cluster is created, update the cluster CRD status now
-		if err := c.updateClusterStatus(clusterObj.Namespace, clusterObj.Name, cephv1beta1.ClusterStateCreated, ""); err != nil {
+		if err := c.modifyClusterStatus(clusterObj.Namespace, clusterObj.Name, func(status *cephv1beta1.ClusterStatus) {
+			status.State = cephv1beta1.ClusterStateCreated
+			status.Message = ""
+			status.CephVersion = cephv1beta1.CephVersionStatus{Image: cluster.Spec.CephVersion.Image, Name: cluster.Spec.CephVersion.Name}
+		}); err != nil {
 			logger.Errorf("failed to update cluster status in namespace %s: %+v", cluster.Namespace, err)
 			return false, nil
 		}
@@ -230,20 +250,39 @@ func (c *ClusterController) onAdd(obj interface{}) {
 	poolController.StartWatch(cluster.Namespace, cluster.stopCh)
 
 	// Start object store CRD watcher
-	objectStoreController := object.NewObjectStoreController(c.context, c.rookImage, cluster.Spec.CephVersion, cluster.Spec.Network.HostNetwork, cluster.ownerRef)
+	objectStoreController := object.NewObjectStoreController(c.context, c.rookImage, cluster.Spec.CephVersion, cluster.Spec.Network, cluster.ownerRef)
 	objectStoreController.StartWatch(cluster.Namespace, cluster.stopCh)
+	cluster.objectController = objectStoreController
+
+	// Start object store user CRD watcher
+	objectStoreUserController := object.NewObjectStoreUserController(c.context)
+	objectStoreUserController.StartWatch(cluster.Namespace, cluster.stopCh)
 
 	// Start file system CRD watcher
-	fileController := file.NewFilesystemController(c.context, c.rookImage, cluster.Spec.CephVersion, cluster.Spec.Network.HostNetwork, cluster.ownerRef)
+	fileController := file.NewFilesystemController(c.context, c.rookImage, cluster.Spec.CephVersion, cluster.Spec.Network, cluster.ownerRef)
 	fileController.StartWatch(cluster.Namespace, cluster.stopCh)
+	cluster.fileController = fileController
 
 	// Start mon health checker
 	healthChecker := mon.NewHealthChecker(cluster.mons)
 	go healthChecker.Check(cluster.stopCh)
 
 	// Start the osd health checker
-	osdChecker := osd.NewMonitor(c.context, cluster.Namespace)
+	osdChecker := osd.NewMonitor(c.context, cluster.Namespace, cluster.ownerRef, cluster.Spec.OSDRemediation, cluster.replaceOSD)
 	go osdChecker.Start(cluster.stopCh)
+	cluster.osdMonitor = osdChecker
+
+	// Start moving the osds when the topology labels of their nodes change
+	go osd.CheckTopology(func() *osd.Cluster { return cluster.osds }, &cluster.orchestrationLock, cluster.stopCh)
+
+	// Start updating the disruption budgets of the daemons
+	disruptionChecker := newDisruptionChecker(c.context, cluster.Namespace, cluster.ownerRef, cluster.Spec.DisruptionManagement)
+	go disruptionChecker.check(cluster.stopCh)
+	cluster.disruptionChecker = disruptionChecker
+
+	// Start publishing the health and capacity of the cluster in the status of the crd
+	statusChecker := newCephStatusChecker(c, cluster.Namespace, clusterObj.Name, cluster.ownerRef)
+	go statusChecker.checkCephStatus(cluster.stopCh)
 
 	// add the finalizer to the crd
 	err = c.addFinalizer(clusterObj)
@@ -286,20 +325,27 @@ func (c *ClusterController) onUpdate(oldObj, newObj interface{}) {
 		return
 	}
 
+	// the status of the cluster is updated periodically without changes to the spec
+	if newClust.DeletionTimestamp == nil && reflect.DeepEqual(oldClust.Spec, newClust.Spec) {
+		logger.Debugf("skipping update event for cluster %s without changes to the spec", newClust.Namespace)
+		return
+	}
+
 	logger.Infof("update event for cluster %s", newClust.Namespace)
 
 	// Check if the cluster is being deleted. This code path is called when a finalizer is specified in the crd.
 	// When a cluster is requested for deletion, K8s will only set the deletion timestamp if there are any finalizers in the list.
 	// K8s will only delete the crd and child resources when the finalizers have been removed from the crd.
 	if newClust.DeletionTimestamp != nil {
-		logger.Infof("cluster %s has a deletion timestamp", newClust.Namespace)
-		err := c.handleDelete(newClust, time.Duration(clusterDeleteRetryInterval)*time.Second)
-		if err != nil {
-			logger.Errorf("failed finalizer for cluster. %+v", err)
+		// the status of the deletion is updated without changes to the spec or annotations, which would start the
+		// deletion again after it failed
+		if oldClust.DeletionTimestamp != nil && reflect.DeepEqual(oldClust.Spec, newClust.Spec) &&
+			reflect.DeepEqual(oldClust.Annotations, newClust.Annotations) {
+			logger.Debugf("skipping update event for deleted cluster %s without changes", newClust.Namespace)
 			return
 		}
-		// remove the finalizer from the crd, which indicates to k8s that the resource can safely be deleted
-		c.removeFinalizer(newClust)
+		logger.Infof("cluster %s has a deletion timestamp", newClust.Namespace)
+		c.startDelete(newClust)
 		return
 	}
 	cluster, ok := c.clusterMap[newClust.Namespace]
@@ -308,6 +354,16 @@ func (c *ClusterController) onUpdate(oldObj, newObj interface{}) {
 		return
 	}
 
+	// the changes are not applied if the new network settings are invalid
+	if err := validateNetworkSpec(newClust.Spec.Network); err != nil {
+		message := fmt.Sprintf("invalid network settings. %+v", err)
+		logger.Error(message)
+		if err := c.updateClusterStatus(newClust.Namespace, newClust.Name, cephv1beta1.ClusterStateError, message); err != nil {
+			logger.Errorf("failed to update cluster status in namespace %s: %+v", newClust.Namespace, err)
+		}
+		return
+	}
+
 	if !clusterChanged(oldClust.Spec, newClust.Spec, cluster) {
 		logger.Infof("update event for cluster %s is not supported", newClust.Namespace)
 		return
@@ -317,20 +373,34 @@ func (c *ClusterController) onUpdate(oldObj, newObj interface{}) {
 	logger.Debugf("old cluster: %+v", oldClust.Spec)
 	logger.Debugf("new cluster: %+v", newClust.Spec)
 
+	runningVersion := cluster.Spec.CephVersion.Name
 	cluster.Spec = &newClust.Spec
 
+	upgrade := oldClust.Spec.CephVersion.Image != newClust.Spec.CephVersion.Image
+	timeout := updateClusterTimeout
+	if upgrade {
+		if err := cluster.validateUpgrade(runningVersion); err != nil {
+			logger.Errorf("cannot upgrade cluster in namespace %s. %+v", newClust.Namespace, err)
+			if err := c.updateClusterStatus(newClust.Namespace, newClust.Name, cephv1beta1.ClusterStateError, err.Error()); err != nil {
+				logger.Errorf("failed to update cluster status in namespace %s: %+v", newClust.Namespace, err)
+			}
+			return
+		}
+		timeout = upgradeClusterTimeout
+	}
+
 	// attempt to update the cluster.  note this is done outside of wait.Poll because that function
 	// will wait for the retry interval before trying for the first time.
-	done, _ := c.handleUpdate(newClust, cluster)
+	done, _ := c.handleUpdate(newClust, cluster, upgrade)
 	if done {
 		return
 	}
 
-	err = wait.Poll(updateClusterInterval, updateClusterTimeout, func() (bool, error) {
-		return c.handleUpdate(newClust, cluster)
+	err = wait.Poll(updateClusterInterval, timeout, func() (bool, error) {
+		return c.handleUpdate(newClust, cluster, upgrade)
 	})
 	if err != nil {
-		message := fmt.Sprintf("giving up trying to update cluster in namespace %s after %s", cluster.Namespace, updateClusterTimeout)
+		message := fmt.Sprintf("giving up trying to update cluster in namespace %s after %s", cluster.Namespace, timeout)
 		logger.Error(message)
 		if err := c.updateClusterStatus(newClust.Namespace, newClust.Name, cephv1beta1.ClusterStateError, message); err != nil {
 			logger.Errorf("failed to update cluster status in namespace %s: %+v", newClust.Namespace, err)
@@ -339,12 +409,20 @@ func (c *ClusterController) onUpdate(oldObj, newObj interface{}) {
 	}
 }
 
-func (c *ClusterController) handleUpdate(newClust *cephv1beta1.Cluster, cluster *cluster) (bool, error) {
+func (c *ClusterController) handleUpdate(newClust *cephv1beta1.Cluster, cluster *cluster, upgrade bool) (bool, error) {
 	if err := c.updateClusterStatus(newClust.Namespace, newClust.Name, cephv1beta1.ClusterStateUpdating, ""); err != nil {
 		logger.Errorf("failed to update cluster status in namespace %s: %+v", newClust.Namespace, err)
 		return false, nil
 	}
 
+	// the daemons must be upgraded in order before the rest of the cluster is updated with the new image
+	if upgrade {
+		if err := c.upgradeCluster(newClust, cluster); err != nil {
+			logger.Errorf("failed to upgrade cluster in namespace %s. %+v", newClust.Namespace, err)
+			return false, nil
+		}
+	}
+
 	if err := cluster.createInstance(c.rookImage); err != nil {
 		logger.Errorf("failed to update cluster in namespace %s. %+v", newClust.Namespace, err)
 		return false, nil
@@ -378,14 +456,12 @@ func (c *ClusterController) onDelete(obj interface{}) {
 
 	logger.Infof("delete event for cluster %s in namespace %s", clust.Name, clust.Namespace)
 
-	err = c.handleDelete(clust, time.Duration(clusterDeleteRetryInterval)*time.Second)
-	if err != nil {
+	c.stopCluster(clust.Namespace)
+	if c.deletionInProgress(clust.Namespace) {
+		logger.Infof("deletion of cluster %s is already in progress", clust.Namespace)
+	} else if err := c.handleDelete(clust, time.Duration(clusterDeleteRetryInterval)*time.Second); err != nil {
 		logger.Errorf("failed to delete cluster. %+v", err)
 	}
-	if cluster, ok := c.clusterMap[clust.Namespace]; ok {
-		close(cluster.stopCh)
-		delete(c.clusterMap, clust.Namespace)
-	}
 	if clust.Spec.Storage.AnyUseAllDevices() {
 		c.devicesInUse = false
 	}
@@ -435,7 +511,8 @@ func (c *ClusterController) handleDelete(cluster *cephv1beta1.Cluster, retryInte
 		<-time.After(retryInterval)
 	}
 
-	return nil
+	// the finalizer is not removed until the hosts are cleaned up
+	return c.cleanupHosts(cluster)
 }
 
 func isLegacyClusterObjectDeleted(obj interface{}) bool {
@@ -536,6 +613,15 @@ func (c *ClusterController) removeFinalizer(obj interface{}) {
 }
 
 func (c *ClusterController) updateClusterStatus(namespace, name string, state cephv1beta1.ClusterState, message string) error {
+	return c.modifyClusterStatus(namespace, name, func(status *cephv1beta1.ClusterStatus) {
+		status.State = state
+		status.Message = message
+	})
+}
+
+// modifyClusterStatus applies the changes to the status of the most recent cluster CRD object. Fields of the
+// status that are not changed by modify are preserved.
+func (c *ClusterController) modifyClusterStatus(namespace, name string, modify func(status *cephv1beta1.ClusterStatus)) error {
 	// get the most recent cluster CRD object
 	cluster, err := c.context.RookClientset.CephV1beta1().Clusters(namespace).Get(name, metav1.GetOptions{})
 	if err != nil {
@@ -543,8 +629,13 @@ func (c *ClusterController) updateClusterStatus(namespace, name string, state ce
 	}
 
 	// update the status on the retrieved cluster object
-	cluster.Status = cephv1beta1.ClusterStatus{State: state, Message: message}
-	if _, err := c.context.RookClientset.CephV1beta1().Clusters(cluster.Namespace).Update(cluster); err != nil {
+	modify(&cluster.Status)
+	_, err = c.context.RookClientset.CephV1beta1().Clusters(cluster.Namespace).UpdateStatus(cluster)
+	if errors.IsNotFound(err) {
+		// the status subresource is not enabled if the CRD was created from an older manifest
+		_, err = c.context.RookClientset.CephV1beta1().Clusters(cluster.Namespace).Update(cluster)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to update cluster %s status: %+v", cluster.Namespace, err)
 	}
 
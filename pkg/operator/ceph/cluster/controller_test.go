@@ -164,6 +164,11 @@ func TestClusterChanged(t *testing.T) {
 	assert.False(t, clusterChanged(old, new, c))
 	assert.Equal(t, 3, c.mons.Count)
 	assert.True(t, c.mons.AllowMultiplePerNode)
+
+	// a new ceph image should be a change so the daemons are upgraded
+	old.CephVersion.Image = "ceph/ceph:v12.2.9"
+	new.CephVersion.Image = "ceph/ceph:v13.2.2"
+	assert.True(t, clusterChanged(old, new, c))
 }
 
 func TestRemoveFinalizer(t *testing.T) {
@@ -224,3 +229,26 @@ func TestRemoveFinalizer(t *testing.T) {
 	assert.NotNil(t, legacyRookCluster)
 	assert.Len(t, legacyRookCluster.Finalizers, 0)
 }
+
+func TestUpdateInvalidNetwork(t *testing.T) {
+	oldCluster := &cephv1beta1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph", Namespace: "rook-ceph"}}
+	context := &clusterd.Context{Clientset: testop.New(3), RookClientset: rookfake.NewSimpleClientset(oldCluster)}
+	controller := NewClusterController(context, "", &attachment.MockAttachment{})
+	c := newCluster(oldCluster, context)
+	c.mons = &mon.Cluster{}
+	controller.clusterMap["rook-ceph"] = c
+
+	// the network attachment requires the cidr of the network
+	invalid := oldCluster.DeepCopy()
+	invalid.Spec.Mon.Count = 3
+	invalid.Spec.Network.PublicNetwork = rookalpha.NetworkSelection{Attachment: "public-net"}
+	controller.onUpdate(oldCluster, invalid)
+
+	// the change is rejected
+	assert.Equal(t, 0, c.Spec.Mon.Count)
+	assert.Equal(t, 0, c.mons.Count)
+	updated, err := context.RookClientset.CephV1beta1().Clusters("rook-ceph").Get("rook-ceph", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cephv1beta1.ClusterStateError, updated.Status.State)
+	assert.Contains(t, updated.Status.Message, "invalid network settings")
+}
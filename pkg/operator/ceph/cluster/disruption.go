@@ -0,0 +1,153 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mgr"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/rook/rook/pkg/operator/ceph/file"
+	"github.com/rook/rook/pkg/operator/ceph/object"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	pdbNameFmt = "%s-pdb"
+)
+
+var disruptionCheckInterval = 60 * time.Second
+
+// disruptionChecker periodically updates the PodDisruptionBudgets of the daemons of a cluster, so that a drain of
+// the nodes does not disrupt more daemons than the cluster can tolerate
+type disruptionChecker struct {
+	context   *clusterd.Context
+	namespace string
+	ownerRef  metav1.OwnerReference
+
+	spec      cephv1beta1.DisruptionManagementSpec
+	specMutex sync.Mutex
+}
+
+func newDisruptionChecker(context *clusterd.Context, namespace string, ownerRef metav1.OwnerReference,
+	spec cephv1beta1.DisruptionManagementSpec) *disruptionChecker {
+	return &disruptionChecker{
+		context:   context,
+		namespace: namespace,
+		ownerRef:  ownerRef,
+		spec:      spec,
+	}
+}
+
+// setSpec changes the management of the disruptions, which is applied on the next check
+func (d *disruptionChecker) setSpec(spec cephv1beta1.DisruptionManagementSpec) {
+	d.specMutex.Lock()
+	defer d.specMutex.Unlock()
+	d.spec = spec
+}
+
+func (d *disruptionChecker) check(stopCh chan struct{}) {
+	for {
+		select {
+		case <-time.After(disruptionCheckInterval):
+			if err := d.updateBudgets(); err != nil {
+				logger.Warningf("failed to update the disruption budgets in namespace %s. %+v", d.namespace, err)
+			}
+
+		case <-stopCh:
+			logger.Infof("stopping the disruption check of namespace %s", d.namespace)
+			return
+		}
+	}
+}
+
+// updateBudgets creates the budgets of the daemons, or deletes them when the disruptions are no longer managed
+func (d *disruptionChecker) updateBudgets() error {
+	d.specMutex.Lock()
+	spec := d.spec
+	d.specMutex.Unlock()
+
+	if !spec.ManagePodBudgets {
+		return d.deleteBudgets()
+	}
+
+	// the mons keep quorum when less than half of them are disrupted
+	mons, err := d.context.Clientset.Extensions().Deployments(d.namespace).List(
+		metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", k8sutil.AppAttr, mon.AppName)})
+	if err != nil {
+		return fmt.Errorf("failed to list mon deployments. %+v", err)
+	}
+	if len(mons.Items) > 0 {
+		if err := d.updateBudget(mon.AppName, (len(mons.Items)-1)/2); err != nil {
+			return err
+		}
+	}
+
+	// one daemon of the other types can be disrupted at a time, which leaves their standbys running
+	for _, appName := range []string{mgr.AppName, file.AppName, object.AppName} {
+		if err := d.updateBudget(appName, 1); err != nil {
+			return err
+		}
+	}
+
+	return osd.UpdateDisruptionBudgets(d.context, d.namespace, d.ownerRef)
+}
+
+func (d *disruptionChecker) updateBudget(appName string, maxUnavailable int) error {
+	max := intstr.FromInt(maxUnavailable)
+	labels := map[string]string{k8sutil.AppAttr: appName, k8sutil.ClusterAttr: d.namespace}
+	pdb := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf(pdbNameFmt, appName),
+			Namespace: d.namespace,
+			Labels:    labels,
+		},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			Selector:       &metav1.LabelSelector{MatchLabels: labels},
+			MaxUnavailable: &max,
+		},
+	}
+	k8sutil.SetOwnerRef(d.context.Clientset, d.namespace, &pdb.ObjectMeta, &d.ownerRef)
+	return k8sutil.CreateOrUpdatePodDisruptionBudget(d.context.Clientset, pdb)
+}
+
+// deleteBudgets deletes all the budgets of the cluster and clears noout on the osds of a drained failure domain
+func (d *disruptionChecker) deleteBudgets() error {
+	if err := osd.ClearDrain(d.context, d.namespace); err != nil {
+		return err
+	}
+
+	selector := fmt.Sprintf("%s=%s", k8sutil.ClusterAttr, d.namespace)
+	pdbs, err := d.context.Clientset.PolicyV1beta1().PodDisruptionBudgets(d.namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return fmt.Errorf("failed to list disruption budgets. %+v", err)
+	}
+	for _, pdb := range pdbs.Items {
+		if err := k8sutil.DeletePodDisruptionBudget(d.context.Clientset, d.namespace, pdb.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"testing"
+
+	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	"github.com/rook/rook/pkg/clusterd"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	extensions "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUpdateDisruptionBudgets(t *testing.T) {
+	clientset := testop.New(3)
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			return `{"types":[{"type_id":1,"name":"host"}],"rules":[{"steps":[{"op":"chooseleaf_firstn","num":0,"type":"host"}]}]}`, nil
+		},
+	}
+	context := &clusterd.Context{Clientset: clientset, Executor: executor}
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		clientset.Extensions().Deployments("ns").Create(&extensions.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("rook-ceph-mon-%s", name),
+			Namespace: "ns",
+			Labels:    map[string]string{"app": "rook-ceph-mon", "rook_cluster": "ns"},
+		}})
+	}
+
+	// no budgets are created unless the disruptions are managed
+	checker := newDisruptionChecker(context, "ns", metav1.OwnerReference{}, cephv1beta1.DisruptionManagementSpec{})
+	err := checker.updateBudgets()
+	assert.Nil(t, err)
+	pdbs, _ := clientset.PolicyV1beta1().PodDisruptionBudgets("ns").List(metav1.ListOptions{})
+	assert.Equal(t, 0, len(pdbs.Items))
+
+	checker.setSpec(cephv1beta1.DisruptionManagementSpec{ManagePodBudgets: true})
+	err = checker.updateBudgets()
+	assert.Nil(t, err)
+	pdbs, _ = clientset.PolicyV1beta1().PodDisruptionBudgets("ns").List(metav1.ListOptions{})
+	assert.Equal(t, 5, len(pdbs.Items))
+
+	// two of five mons can be disrupted without losing quorum
+	pdb, err := clientset.PolicyV1beta1().PodDisruptionBudgets("ns").Get("rook-ceph-mon-pdb", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, pdb.Spec.MaxUnavailable.IntValue())
+	assert.Equal(t, map[string]string{"app": "rook-ceph-mon", "rook_cluster": "ns"}, pdb.Spec.Selector.MatchLabels)
+	for _, name := range []string{"rook-ceph-mgr-pdb", "rook-ceph-mds-pdb", "rook-ceph-rgw-pdb", "rook-ceph-osd-pdb"} {
+		pdb, err := clientset.PolicyV1beta1().PodDisruptionBudgets("ns").Get(name, metav1.GetOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, pdb.Spec.MaxUnavailable.IntValue())
+	}
+
+	// the budget of the mons is replaced when the number of mons changes
+	clientset.Extensions().Deployments("ns").Delete("rook-ceph-mon-e", &metav1.DeleteOptions{})
+	clientset.Extensions().Deployments("ns").Delete("rook-ceph-mon-d", &metav1.DeleteOptions{})
+	err = checker.updateBudgets()
+	assert.Nil(t, err)
+	pdb, err = clientset.PolicyV1beta1().PodDisruptionBudgets("ns").Get("rook-ceph-mon-pdb", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, pdb.Spec.MaxUnavailable.IntValue())
+
+	// the budgets are deleted when the disruptions are no longer managed
+	checker.setSpec(cephv1beta1.DisruptionManagementSpec{})
+	err = checker.updateBudgets()
+	assert.Nil(t, err)
+	pdbs, _ = clientset.PolicyV1beta1().PodDisruptionBudgets("ns").List(metav1.ListOptions{})
+	assert.Equal(t, 0, len(pdbs.Items))
+}
@@ -57,7 +57,7 @@ func (c *Cluster) configureDashboard(port int) error {
 		return err
 	}
 
-	dashboardService := c.makeDashboardService(appName, port)
+	dashboardService := c.makeDashboardService(AppName, port)
 	if c.dashboard.Enabled {
 		// expose the dashboard service
 		if _, err := c.context.Clientset.CoreV1().Services(c.Namespace).Create(dashboardService); err != nil {
@@ -34,7 +34,8 @@ import (
 var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-mgr")
 
 const (
-	appName              = "rook-ceph-mgr"
+	// AppName is the name of Rook's Ceph mgr sub-app
+	AppName              = "rook-ceph-mgr"
 	keyringSecretKeyName = "keyring"
 	prometheusModuleName = "prometheus"
 	metricsPort          = 9283
@@ -50,6 +51,7 @@ type Cluster struct {
 	context     *clusterd.Context
 	dataDir     string
 	HostNetwork bool
+	network     rookalpha.NetworkSpec
 	resources   v1.ResourceRequirements
 	ownerRef    metav1.OwnerReference
 	dashboard   cephv1beta1.DashboardSpec
@@ -64,7 +66,7 @@ type mgrConfig struct {
 }
 
 // New creates an instance of the mgr
-func New(context *clusterd.Context, namespace, rookVersion string, cephVersion cephv1beta1.CephVersionSpec, placement rookalpha.Placement, hostNetwork bool, dashboard cephv1beta1.DashboardSpec,
+func New(context *clusterd.Context, namespace, rookVersion string, cephVersion cephv1beta1.CephVersionSpec, placement rookalpha.Placement, network rookalpha.NetworkSpec, dashboard cephv1beta1.DashboardSpec,
 	resources v1.ResourceRequirements, ownerRef metav1.OwnerReference) *Cluster {
 	return &Cluster{
 		context:     context,
@@ -75,7 +77,8 @@ func New(context *clusterd.Context, namespace, rookVersion string, cephVersion c
 		Replicas:    1,
 		dataDir:     k8sutil.DataDir,
 		dashboard:   dashboard,
-		HostNetwork: hostNetwork,
+		HostNetwork: network.HostNetwork,
+		network:     network,
 		resources:   resources,
 		ownerRef:    ownerRef,
 	}
@@ -97,7 +100,7 @@ func (c *Cluster) Start() error {
 		}
 
 		daemonName := mgrNames[i]
-		resourceName := fmt.Sprintf("%s-%s", appName, daemonName)
+		resourceName := fmt.Sprintf("%s-%s", AppName, daemonName)
 		if err := c.createKeyring(c.Namespace, resourceName, daemonName); err != nil {
 			return fmt.Errorf("failed to create %s keyring. %+v", resourceName, err)
 		}
@@ -132,7 +135,7 @@ func (c *Cluster) Start() error {
 	}
 
 	// create the metrics service
-	service := c.makeMetricsService(appName)
+	service := c.makeMetricsService(AppName)
 	if _, err := c.context.Clientset.CoreV1().Services(c.Namespace).Create(service); err != nil {
 		if !errors.IsAlreadyExists(err) {
 			return fmt.Errorf("failed to create mgr service. %+v", err)
@@ -145,6 +148,39 @@ func (c *Cluster) Start() error {
 	return nil
 }
 
+// UpdateCephVersion rolls the mgr deployments to a new version of ceph, one mgr at a time.
+func (c *Cluster) UpdateCephVersion(cephVersion cephv1beta1.CephVersionSpec) error {
+	c.cephVersion = cephVersion
+
+	dashboardPort := dashboardPortHttps
+	if c.cephVersion.Name == cephv1beta1.Luminous {
+		dashboardPort = dashboardPortHttp
+	}
+
+	for i := 0; i < c.Replicas && i < len(mgrNames); i++ {
+		mgrConfig := &mgrConfig{
+			DaemonName:   mgrNames[i],
+			ResourceName: fmt.Sprintf("%s-%s", AppName, mgrNames[i]),
+		}
+
+		image, err := k8sutil.GetDeploymentImage(c.context.Clientset, c.Namespace, mgrConfig.ResourceName, "mgr")
+		if err != nil {
+			return fmt.Errorf("failed to get the image of mgr %s. %+v", mgrConfig.DaemonName, err)
+		}
+		if image == cephVersion.Image {
+			logger.Infof("mgr %s is already running image %s", mgrConfig.DaemonName, image)
+			continue
+		}
+
+		logger.Infof("upgrading mgr %s from image %s to %s", mgrConfig.DaemonName, image, cephVersion.Image)
+		if err := k8sutil.UpdateDeploymentAndWait(c.context, c.makeDeployment(mgrConfig, dashboardPort), c.Namespace); err != nil {
+			return fmt.Errorf("failed to upgrade mgr %s. %+v", mgrConfig.DaemonName, err)
+		}
+	}
+
+	return nil
+}
+
 func (c *Cluster) createKeyring(clusterName, name, daemonName string) error {
 	_, err := c.context.Clientset.CoreV1().Secrets(c.Namespace).Get(name, metav1.GetOptions{})
 	if err == nil {
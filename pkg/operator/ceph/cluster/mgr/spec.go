@@ -57,6 +57,7 @@ func (c *Cluster) makeDeployment(mgrConfig *mgrConfig, port int) *extensions.Dep
 	if c.HostNetwork {
 		podSpec.Spec.DNSPolicy = v1.DNSClusterFirstWithHostNet
 	}
+	opspec.AddNetworkAnnotations(&podSpec.ObjectMeta, c.network, false)
 	c.placement.ApplyToPodSpec(&podSpec.Spec)
 
 	replicas := int32(1)
@@ -72,6 +73,24 @@ func (c *Cluster) makeDeployment(mgrConfig *mgrConfig, port int) *extensions.Dep
 }
 
 func (c *Cluster) makeConfigInitContainer(mgrConfig *mgrConfig) v1.Container {
+	envVars := []v1.EnvVar{
+		// Set '--mgr-keyring' flag with an env var sourced from the secret
+		{Name: "ROOK_MGR_KEYRING",
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{Name: mgrConfig.ResourceName},
+					Key:                  keyringSecretKeyName,
+				}}},
+		k8sutil.PodIPEnvVar(k8sutil.PrivateIPEnvVar),
+		k8sutil.PodIPEnvVar(k8sutil.PublicIPEnvVar),
+		opmon.EndpointEnvVar(),
+		opmon.SecretEnvVar(),
+		opmon.AdminSecretEnvVar(),
+		k8sutil.ConfigOverrideEnvVar(),
+		k8sutil.CephConfigEnvVar(),
+	}
+	envVars = append(envVars, opspec.NetworkEnvVars(c.network)...)
+
 	return v1.Container{
 		Name: opspec.ConfigInitContainerName,
 		Args: []string{
@@ -80,22 +99,8 @@ func (c *Cluster) makeConfigInitContainer(mgrConfig *mgrConfig) v1.Container {
 			fmt.Sprintf("--config-dir=%s", k8sutil.DataDir),
 			fmt.Sprintf("--mgr-name=%s", mgrConfig.DaemonName),
 		},
-		Image: k8sutil.MakeRookImage(c.rookVersion),
-		Env: []v1.EnvVar{
-			// Set '--mgr-keyring' flag with an env var sourced from the secret
-			{Name: "ROOK_MGR_KEYRING",
-				ValueFrom: &v1.EnvVarSource{
-					SecretKeyRef: &v1.SecretKeySelector{
-						LocalObjectReference: v1.LocalObjectReference{Name: mgrConfig.ResourceName},
-						Key:                  keyringSecretKeyName,
-					}}},
-			k8sutil.PodIPEnvVar(k8sutil.PrivateIPEnvVar),
-			k8sutil.PodIPEnvVar(k8sutil.PublicIPEnvVar),
-			opmon.EndpointEnvVar(),
-			opmon.SecretEnvVar(),
-			opmon.AdminSecretEnvVar(),
-			k8sutil.ConfigOverrideEnvVar(),
-		},
+		Image:        k8sutil.MakeRookImage(c.rookVersion),
+		Env:          envVars,
 		VolumeMounts: opspec.RookVolumeMounts(),
 		// config file creation does not require ports to be open
 		Resources: c.resources,
@@ -140,7 +145,7 @@ func (c *Cluster) makeMgrDaemonContainer(mgrConfig *mgrConfig, port int) v1.Cont
 }
 
 func (c *Cluster) makeMetricsService(name string) *v1.Service {
-	labels := opspec.AppLabels(appName, c.Namespace)
+	labels := opspec.AppLabels(AppName, c.Namespace)
 	svc := &v1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
@@ -165,7 +170,7 @@ func (c *Cluster) makeMetricsService(name string) *v1.Service {
 }
 
 func (c *Cluster) makeDashboardService(name string, port int) *v1.Service {
-	labels := opspec.AppLabels(appName, c.Namespace)
+	labels := opspec.AppLabels(AppName, c.Namespace)
 	svc := &v1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      fmt.Sprintf("%s-dashboard", name),
@@ -189,7 +194,7 @@ func (c *Cluster) makeDashboardService(name string, port int) *v1.Service {
 }
 
 func (c *Cluster) getPodLabels(daemonName string) map[string]string {
-	labels := opspec.PodLabels(appName, c.Namespace, "mgr", daemonName)
+	labels := opspec.PodLabels(AppName, c.Namespace, "mgr", daemonName)
 	// leave "instance" key for legacy usage
 	labels["instance"] = daemonName
 	return labels
@@ -43,7 +43,7 @@ func TestPodSpec(t *testing.T) {
 		"rook/rook:myversion",
 		cephv1beta1.CephVersionSpec{Image: "ceph/ceph:myceph"},
 		rookalpha.Placement{},
-		false,
+		rookalpha.NetworkSpec{},
 		cephv1beta1.DashboardSpec{},
 		v1.ResourceRequirements{
 			Limits: v1.ResourceList{
@@ -83,7 +83,7 @@ func TestPodSpec(t *testing.T) {
 	assert.Equal(t, 1, len(pod.Spec.Containers))
 
 	configImage := "rook/rook:myversion"
-	configEnvs := 7
+	configEnvs := 8
 	configContainerDefinition := cephtest.ContainerTestDefinition{
 		Image:   &configImage,
 		Command: []string{}, // no command
@@ -146,7 +146,7 @@ func TestPodSpec(t *testing.T) {
 }
 
 func TestServiceSpec(t *testing.T) {
-	c := New(&clusterd.Context{}, "ns", "myversion", cephv1beta1.CephVersionSpec{}, rookalpha.Placement{}, false, cephv1beta1.DashboardSpec{}, v1.ResourceRequirements{}, metav1.OwnerReference{})
+	c := New(&clusterd.Context{}, "ns", "myversion", cephv1beta1.CephVersionSpec{}, rookalpha.Placement{}, rookalpha.NetworkSpec{}, cephv1beta1.DashboardSpec{}, v1.ResourceRequirements{}, metav1.OwnerReference{})
 
 	s := c.makeMetricsService("rook-mgr")
 	assert.NotNil(t, s)
@@ -161,7 +161,7 @@ func TestHostNetwork(t *testing.T) {
 		"myversion",
 		cephv1beta1.CephVersionSpec{},
 		rookalpha.Placement{},
-		true,
+		rookalpha.NetworkSpec{HostNetwork: true},
 		cephv1beta1.DashboardSpec{},
 		v1.ResourceRequirements{},
 		metav1.OwnerReference{},
@@ -25,6 +25,7 @@ import (
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	mondaemon "github.com/rook/rook/pkg/daemon/ceph/mon"
 	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -36,6 +37,13 @@ var (
 	MonOutTimeout = 300 * time.Second
 )
 
+const (
+	monFailoverReason       = "MonFailover"
+	monFailoverFailedReason = "MonFailoverFailed"
+	monRemovedReason        = "MonRemoved"
+	monRemoveFailedReason   = "MonRemoveFailed"
+)
+
 // HealthChecker aggregates the mon/cluster info needed to check the health of the monitors
 type HealthChecker struct {
 	monCluster *Cluster
@@ -139,6 +147,12 @@ func (c *Cluster) checkHealth() error {
 				continue
 			}
 
+			if c.onPVC(mon.Name) {
+				logger.Warningf("mon %s NOT found in quorum and timeout exceeded, mon will be rescheduled", mon.Name)
+				c.rescheduleMon(len(status.MonMap.Mons), desiredMonCount, mon.Name)
+				return nil
+			}
+
 			logger.Warningf("mon %s NOT found in quorum and timeout exceeded, mon will be failed over", mon.Name)
 			c.failMon(len(status.MonMap.Mons), desiredMonCount, mon.Name)
 			// only deal with one unhealthy mon per health check
@@ -227,7 +241,10 @@ func (c *Cluster) checkMonsOnValidNodes() (bool, error) {
 			logger.Warning("failed to validate node %s %v", node.Name, err)
 		} else if !valid {
 			logger.Warningf("node %s isn't valid anymore, failover mon %s", nInfo.Name, mon)
-			c.failoverMon(mon)
+			if err := c.failoverMon(mon); err != nil {
+				logger.Errorf("failed to failover mon %s. %+v", mon, err)
+				c.recordEvent(v1.EventTypeWarning, monFailoverFailedReason, "failed to failover mon %s from invalid node %s. %+v", mon, nInfo.Name, err)
+			}
 			return true, nil
 		}
 		logger.Debugf("node %s with mon %s is still valid", nInfo.Name, mon)
@@ -241,11 +258,13 @@ func (c *Cluster) failMon(monCount, desiredMonCount int, name string) {
 		// no need to create a new mon since we have an extra
 		if err := c.removeMon(name); err != nil {
 			logger.Errorf("failed to remove mon %s. %+v", name, err)
+			c.recordEvent(v1.EventTypeWarning, monRemoveFailedReason, "failed to remove mon %s. %+v", name, err)
 		}
 	} else {
 		// bring up a new mon to replace the unhealthy mon
 		if err := c.failoverMon(name); err != nil {
 			logger.Errorf("failed to failover mon %s. %+v", name, err)
+			c.recordEvent(v1.EventTypeWarning, monFailoverFailedReason, "failed to failover mon %s. %+v", name, err)
 		}
 	}
 }
@@ -289,7 +308,11 @@ func (c *Cluster) failoverMon(name string) error {
 	// Only increment the max mon id if the new pod started successfully
 	c.maxMonID++
 
-	return c.removeMon(name)
+	if err := c.removeMon(name); err != nil {
+		return err
+	}
+	c.recordEvent(v1.EventTypeWarning, monFailoverReason, "mon %s was failed over to mon %s", name, m.DaemonName)
+	return nil
 }
 
 func (c *Cluster) removeMon(daemonName string) error {
@@ -331,6 +354,11 @@ func (c *Cluster) removeMon(daemonName string) error {
 		}
 	}
 
+	// the data of the mon is not needed anymore once it is removed from quorum
+	if err := c.deleteVolumeClaim(daemonName); err != nil {
+		return err
+	}
+
 	// Remove the service endpoint
 	if err := c.context.Clientset.CoreV1().Services(c.Namespace).Delete(resourceName, options); err != nil {
 		if errors.IsNotFound(err) {
@@ -349,9 +377,15 @@ func (c *Cluster) removeMon(daemonName string) error {
 		return fmt.Errorf("failed to write connection config after failing over mon %s. %+v", daemonName, err)
 	}
 
+	c.recordEvent(v1.EventTypeNormal, monRemovedReason, "mon %s was removed from the cluster", daemonName)
 	return nil
 }
 
+// recordEvent records an event about the mons on the cluster CRD
+func (c *Cluster) recordEvent(eventType, reason, messageFmt string, args ...interface{}) {
+	k8sutil.RecordEvent(c.context, k8sutil.OwnerObjectReference(c.Namespace, c.ownerRef), eventType, reason, messageFmt, args...)
+}
+
 func removeMonitorFromQuorum(context *clusterd.Context, clusterName, name string) error {
 	logger.Debugf("removing monitor %s", name)
 	args := []string{"mon", "remove", name}
@@ -35,6 +35,7 @@ import (
 	"k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/kubernetes/pkg/kubelet/apis"
 )
 
@@ -47,13 +48,15 @@ func TestCheckHealth(t *testing.T) {
 	clientset := test.New(1)
 	configDir, _ := ioutil.TempDir("", "")
 	defer os.RemoveAll(configDir)
+	recorder := record.NewFakeRecorder(10)
 	context := &clusterd.Context{
 		Clientset: clientset,
 		ConfigDir: configDir,
 		Executor:  executor,
+		Recorder:  recorder,
 	}
 	c := New(context, "ns", "", "myversion", cephv1beta1.CephVersionSpec{}, cephv1beta1.MonSpec{Count: 3, AllowMultiplePerNode: true},
-		rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		rookalpha.Placement{}, rookalpha.NetworkSpec{}, v1.ResourceRequirements{}, metav1.OwnerReference{})
 	c.clusterInfo = test.CreateConfigDir(1)
 	logger.Infof("initial mons: %v", c.clusterInfo.Monitors)
 	c.waitForStart = false
@@ -80,6 +83,14 @@ func TestCheckHealth(t *testing.T) {
 		_, ok := c.clusterInfo.Monitors[monName]
 		assert.True(t, ok, fmt.Sprintf("mon %s not found in monitor list. %v", monName, c.clusterInfo.Monitors))
 	}
+
+	// the removal and the failover of the mon are recorded on the cluster crd
+	var events []string
+	for len(recorder.Events) > 0 {
+		events = append(events, <-recorder.Events)
+	}
+	assert.Contains(t, events, "Normal MonRemoved mon f was removed from the cluster")
+	assert.Contains(t, events, "Warning MonFailover mon f was failed over to mon g")
 }
 
 func TestCheckHealthNotFound(t *testing.T) {
@@ -97,7 +108,7 @@ func TestCheckHealthNotFound(t *testing.T) {
 		Executor:  executor,
 	}
 	c := New(context, "ns", "", "myversion", cephv1beta1.CephVersionSpec{}, cephv1beta1.MonSpec{Count: 3, AllowMultiplePerNode: true},
-		rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		rookalpha.Placement{}, rookalpha.NetworkSpec{}, v1.ResourceRequirements{}, metav1.OwnerReference{})
 	c.clusterInfo = test.CreateConfigDir(2)
 	c.waitForStart = false
 	defer os.RemoveAll(c.context.ConfigDir)
@@ -166,7 +177,7 @@ func TestCheckHealthTwoMonsOneNode(t *testing.T) {
 		Executor:  executor,
 	}
 	c := New(context, "ns", "", "myversion", cephv1beta1.CephVersionSpec{}, cephv1beta1.MonSpec{Count: 3, AllowMultiplePerNode: true},
-		rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		rookalpha.Placement{}, rookalpha.NetworkSpec{}, v1.ResourceRequirements{}, metav1.OwnerReference{})
 	c.clusterInfo = test.CreateConfigDir(2)
 	c.waitForStart = false
 	defer os.RemoveAll(c.context.ConfigDir)
@@ -187,7 +198,7 @@ func TestCheckHealthTwoMonsOneNode(t *testing.T) {
 
 	monNames := []string{"a", "b"}
 	for i := 0; i < len(monNames); i++ {
-		prefix := appName + "-"
+		prefix := AppName + "-"
 		name := monNames[i]
 		d := c.makeDeployment(&monConfig{ResourceName: prefix + name, DaemonName: name}, "node0")
 		_, err := clientset.ExtensionsV1beta1().Deployments(c.Namespace).Create(d)
@@ -277,7 +288,7 @@ func TestCheckMonsValid(t *testing.T) {
 		Executor:  executor,
 	}
 	c := New(context, "ns", "", "myversion", cephv1beta1.CephVersionSpec{}, cephv1beta1.MonSpec{Count: 3, AllowMultiplePerNode: true},
-		rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		rookalpha.Placement{}, rookalpha.NetworkSpec{}, v1.ResourceRequirements{}, metav1.OwnerReference{})
 	c.clusterInfo = test.CreateConfigDir(1)
 	c.waitForStart = false
 	defer os.RemoveAll(c.context.ConfigDir)
@@ -362,7 +373,7 @@ func TestAddRemoveMons(t *testing.T) {
 		Executor:  executor,
 	}
 	c := New(context, "ns", "", "myversion", cephv1beta1.CephVersionSpec{}, cephv1beta1.MonSpec{Count: 5, AllowMultiplePerNode: true},
-		rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		rookalpha.Placement{}, rookalpha.NetworkSpec{}, v1.ResourceRequirements{}, metav1.OwnerReference{})
 	c.maxMonID = 0
 	c.clusterInfo = test.CreateConfigDir(0)
 	c.waitForStart = false
@@ -22,6 +22,8 @@ package mon
 import (
 	"encoding/json"
 	"fmt"
+	"net"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -55,7 +57,8 @@ const (
 	// MappingKey is the name of the mapping for the mon->node and node->port
 	MappingKey = "mapping"
 
-	appName           = "rook-ceph-mon"
+	// AppName is the name of Rook's Ceph mon sub-app
+	AppName           = "rook-ceph-mon"
 	monNodeAttr       = "mon_node"
 	monClusterAttr    = "mon_cluster"
 	tprName           = "mon.rook.io"
@@ -90,9 +93,11 @@ type Cluster struct {
 	monPodTimeout        time.Duration
 	monTimeoutList       map[string]time.Time
 	HostNetwork          bool
+	network              rookalpha.NetworkSpec
 	mapping              *Mapping
 	resources            v1.ResourceRequirements
 	ownerRef             metav1.OwnerReference
+	volumeClaimTemplate  *v1.PersistentVolumeClaim
 }
 
 // monConfig for a single monitor
@@ -122,7 +127,7 @@ type NodeInfo struct {
 
 // New creates an instance of a mon cluster
 func New(context *clusterd.Context, namespace, dataDirHostPath, rookVersion string, cephVersion cephv1beta1.CephVersionSpec, mon cephv1beta1.MonSpec,
-	placement rookalpha.Placement, hostNetwork bool, resources v1.ResourceRequirements, ownerRef metav1.OwnerReference) *Cluster {
+	placement rookalpha.Placement, network rookalpha.NetworkSpec, resources v1.ResourceRequirements, ownerRef metav1.OwnerReference) *Cluster {
 	return &Cluster{
 		context:              context,
 		placement:            placement,
@@ -137,13 +142,15 @@ func New(context *clusterd.Context, namespace, dataDirHostPath, rookVersion stri
 		monPodRetryInterval:  6 * time.Second,
 		monPodTimeout:        5 * time.Minute,
 		monTimeoutList:       map[string]time.Time{},
-		HostNetwork:          hostNetwork,
+		HostNetwork:          network.HostNetwork,
+		network:              network,
 		mapping: &Mapping{
 			Node: map[string]*NodeInfo{},
 			Port: map[string]int32{},
 		},
-		resources: resources,
-		ownerRef:  ownerRef,
+		resources:           resources,
+		ownerRef:            ownerRef,
+		volumeClaimTemplate: mon.VolumeClaimTemplate,
 	}
 }
 
@@ -151,6 +158,11 @@ func New(context *clusterd.Context, namespace, dataDirHostPath, rookVersion stri
 func (c *Cluster) Start() error {
 	logger.Infof("start running mons")
 
+	// the endpoints of the mons on the host network are the addresses of their nodes
+	if c.HostNetwork && c.volumeClaimTemplate != nil {
+		return fmt.Errorf("the mons cannot be stored on pvcs with the host network")
+	}
+
 	if err := c.initClusterInfo(); err != nil {
 		return fmt.Errorf("failed to initialize ceph cluster info. %+v", err)
 	}
@@ -245,10 +257,10 @@ func newMonConfig(monID int) *monConfig {
 
 // resourceName ensures the mon name has the rook-ceph-mon prefix
 func resourceName(name string) string {
-	if strings.HasPrefix(name, appName) {
+	if strings.HasPrefix(name, AppName) {
 		return name
 	}
-	return fmt.Sprintf("%s-%s", appName, name)
+	return fmt.Sprintf("%s-%s", AppName, name)
 }
 
 func (c *Cluster) initMonIPs(mons []*monConfig) error {
@@ -326,6 +338,11 @@ func (c *Cluster) assignMons(mons []*monConfig) error {
 
 	nodeIndex := 0
 	for _, m := range mons {
+		// the mons on pvcs are placed by the scheduler and move between the nodes with their pvc
+		if c.onPVC(m.DaemonName) {
+			logger.Debugf("mon %s is stored on a pvc, no need to assign it to a node", m.DaemonName)
+			continue
+		}
 		if _, ok := c.mapping.Node[m.DaemonName]; ok {
 			logger.Debugf("mon %s already assigned to a node, no need to assign", m.DaemonName)
 			continue
@@ -386,8 +403,11 @@ func (c *Cluster) startDeployments(mons []*monConfig, index int) error {
 
 	// Ensure each of the mons have been created. If already created, it will be a no-op.
 	for i := 0; i < len(mons); i++ {
-		node, _ := c.mapping.Node[mons[i].DaemonName]
-		err := c.startMon(mons[i], node.Hostname)
+		hostname, err := c.monHostname(mons[i].DaemonName)
+		if err != nil {
+			return err
+		}
+		err = c.startMon(mons[i], hostname)
 		if err != nil {
 			return fmt.Errorf("failed to create mon %s. %+v", mons[i].DaemonName, err)
 		}
@@ -516,14 +536,21 @@ func (c *Cluster) getAvailableMonNodes() ([]v1.Node, *v1.NodeList, error) {
 
 func (c *Cluster) getNodesWithMons(nodes *v1.NodeList) (*util.Set, error) {
 	// get the mon pods and their node affinity
-	options := metav1.ListOptions{LabelSelector: fmt.Sprintf("app=%s", appName)}
+	options := metav1.ListOptions{LabelSelector: fmt.Sprintf("app=%s", AppName)}
 	pods, err := c.context.Clientset.CoreV1().Pods(c.Namespace).List(options)
 	if err != nil {
 		return nil, err
 	}
 	nodesInUse := util.NewSet()
 	for _, pod := range pods.Items {
-		hostname := pod.Spec.NodeSelector[apis.LabelHostname]
+		hostname, ok := pod.Spec.NodeSelector[apis.LabelHostname]
+		if !ok {
+			// the pods of the mons on pvcs are placed by the scheduler
+			if pod.Spec.NodeName == "" {
+				continue
+			}
+			hostname = pod.Spec.NodeName
+		}
 		logger.Debugf("mon pod on node %s", hostname)
 		name, ok := getNodeNameFromHostname(nodes, hostname)
 		if !ok {
@@ -553,6 +580,12 @@ func (c *Cluster) startMon(m *monConfig, hostname string) error {
 		logger.Errorf("failed to delete legacy mon replicaset. %+v", err)
 	}
 
+	if hostname == "" {
+		if err := c.createVolumeClaim(m); err != nil {
+			return err
+		}
+	}
+
 	d := c.makeDeployment(m, hostname)
 	logger.Debugf("Starting mon: %+v", d.Name)
 	_, err := c.context.Clientset.Extensions().Deployments(c.Namespace).Create(d)
@@ -566,6 +599,56 @@ func (c *Cluster) startMon(m *monConfig, hostname string) error {
 	return nil
 }
 
+// UpdateCephVersion rolls the mons to a new version of ceph. The mons are restarted one at a time and all
+// the mons must be back in quorum before the next mon is restarted.
+func (c *Cluster) UpdateCephVersion(cephVersion cephv1beta1.CephVersionSpec) error {
+	c.cephVersion = cephVersion
+
+	mons := []*monConfig{}
+	monNames := []string{}
+	for _, monitor := range c.clusterInfo.Monitors {
+		host, port, err := net.SplitHostPort(monitor.Endpoint)
+		if err != nil {
+			return fmt.Errorf("failed to parse endpoint %s of mon %s. %+v", monitor.Endpoint, monitor.Name, err)
+		}
+		monPort, err := strconv.Atoi(port)
+		if err != nil {
+			return fmt.Errorf("failed to parse port of mon %s. %+v", monitor.Name, err)
+		}
+		mons = append(mons, &monConfig{ResourceName: resourceName(monitor.Name), DaemonName: monitor.Name, PublicIP: host, Port: int32(monPort)})
+		monNames = append(monNames, monitor.Name)
+	}
+	sort.Slice(mons, func(i, j int) bool { return mons[i].DaemonName < mons[j].DaemonName })
+
+	for _, m := range mons {
+		image, err := k8sutil.GetDeploymentImage(c.context.Clientset, c.Namespace, m.ResourceName, "mon")
+		if err != nil {
+			return fmt.Errorf("failed to get the image of mon %s. %+v", m.DaemonName, err)
+		}
+		if image == cephVersion.Image {
+			logger.Infof("mon %s is already running image %s", m.DaemonName, image)
+			continue
+		}
+
+		hostname, err := c.monHostname(m.DaemonName)
+		if err != nil {
+			return err
+		}
+
+		logger.Infof("upgrading mon %s from image %s to %s", m.DaemonName, image, cephVersion.Image)
+		if err := k8sutil.UpdateDeploymentAndWait(c.context, c.makeDeployment(m, hostname), c.Namespace); err != nil {
+			return fmt.Errorf("failed to upgrade mon %s. %+v", m.DaemonName, err)
+		}
+
+		// all the mons must be back in quorum before the next mon can be restarted
+		if err := waitForQuorumWithMons(c.context, c.clusterInfo.Name, monNames); err != nil {
+			return fmt.Errorf("mons did not reach quorum after upgrading mon %s. %+v", m.DaemonName, err)
+		}
+	}
+
+	return nil
+}
+
 func waitForQuorumWithMons(context *clusterd.Context, clusterName string, mons []string) error {
 	logger.Infof("waiting for mon quorum with %v", mons)
 
@@ -585,7 +668,7 @@ func waitForQuorumWithMons(context *clusterd.Context, clusterName string, mons [
 		}
 
 		// wait for the mon pods to be running
-		running, err := k8sutil.PodsRunningWithLabel(context.Clientset, clusterName, "app="+appName)
+		running, err := k8sutil.PodsRunningWithLabel(context.Clientset, clusterName, "app="+AppName)
 		if err != nil {
 			logger.Infof("failed to query mon pod status, trying again. %+v", err)
 			continue
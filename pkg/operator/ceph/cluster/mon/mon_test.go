@@ -153,7 +153,7 @@ func TestOperatorRestartHostNetwork(t *testing.T) {
 }
 
 func validateStart(t *testing.T, c *Cluster) {
-	s, err := c.context.Clientset.CoreV1().Secrets(c.Namespace).Get(appName, metav1.GetOptions{})
+	s, err := c.context.Clientset.CoreV1().Secrets(c.Namespace).Get(AppName, metav1.GetOptions{})
 	assert.Nil(t, err) // there shouldn't be an error due the secret existing
 	assert.Equal(t, 4, len(s.StringData))
 
@@ -167,7 +167,7 @@ func TestSaveMonEndpoints(t *testing.T) {
 	configDir, _ := ioutil.TempDir("", "")
 	defer os.RemoveAll(configDir)
 	c := New(&clusterd.Context{Clientset: clientset, ConfigDir: configDir}, "ns", "", "myversion", cephv1beta1.CephVersionSpec{},
-		cephv1beta1.MonSpec{Count: 3, AllowMultiplePerNode: true}, rookalpha.Placement{}, false,
+		cephv1beta1.MonSpec{Count: 3, AllowMultiplePerNode: true}, rookalpha.Placement{}, rookalpha.NetworkSpec{},
 		v1.ResourceRequirements{}, metav1.OwnerReference{})
 	c.clusterInfo = test.CreateConfigDir(1)
 
@@ -242,7 +242,7 @@ func TestAvailableMonNodes(t *testing.T) {
 	clientset := test.New(1)
 	c := New(&clusterd.Context{Clientset: clientset}, "ns", "", "myversion", cephv1beta1.CephVersionSpec{},
 		cephv1beta1.MonSpec{Count: 3, AllowMultiplePerNode: true}, rookalpha.Placement{},
-		false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		rookalpha.NetworkSpec{}, v1.ResourceRequirements{}, metav1.OwnerReference{})
 	c.clusterInfo = test.CreateConfigDir(0)
 	nodes, err := c.getMonNodes()
 	assert.Nil(t, err)
@@ -269,7 +269,7 @@ func TestAvailableNodesInUse(t *testing.T) {
 	clientset := test.New(3)
 	c := New(&clusterd.Context{Clientset: clientset}, "ns", "", "myversion", cephv1beta1.CephVersionSpec{},
 		cephv1beta1.MonSpec{Count: 3, AllowMultiplePerNode: true}, rookalpha.Placement{},
-		false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		rookalpha.NetworkSpec{}, v1.ResourceRequirements{}, metav1.OwnerReference{})
 	c.clusterInfo = test.CreateConfigDir(0)
 
 	// all three nodes are available by default
@@ -310,7 +310,7 @@ func TestTaintedNodes(t *testing.T) {
 	clientset := test.New(3)
 	c := New(&clusterd.Context{Clientset: clientset}, "ns", "", "myversion", cephv1beta1.CephVersionSpec{},
 		cephv1beta1.MonSpec{Count: 3, AllowMultiplePerNode: true}, rookalpha.Placement{},
-		false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		rookalpha.NetworkSpec{}, v1.ResourceRequirements{}, metav1.OwnerReference{})
 	c.clusterInfo = test.CreateConfigDir(0)
 
 	nodes, err := c.getMonNodes()
@@ -345,7 +345,7 @@ func TestNodeAffinity(t *testing.T) {
 	clientset := test.New(3)
 	c := New(&clusterd.Context{Clientset: clientset}, "ns", "", "myversion", cephv1beta1.CephVersionSpec{},
 		cephv1beta1.MonSpec{Count: 3, AllowMultiplePerNode: true}, rookalpha.Placement{},
-		false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		rookalpha.NetworkSpec{}, v1.ResourceRequirements{}, metav1.OwnerReference{})
 	c.clusterInfo = test.CreateConfigDir(0)
 
 	nodes, err := c.getMonNodes()
@@ -387,7 +387,7 @@ func TestHostNetwork(t *testing.T) {
 	clientset := test.New(3)
 	c := New(&clusterd.Context{Clientset: clientset}, "ns", "", "myversion", cephv1beta1.CephVersionSpec{},
 		cephv1beta1.MonSpec{Count: 3, AllowMultiplePerNode: true}, rookalpha.Placement{},
-		false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		rookalpha.NetworkSpec{}, v1.ResourceRequirements{}, metav1.OwnerReference{})
 	c.clusterInfo = test.CreateConfigDir(0)
 
 	c.HostNetwork = true
@@ -419,7 +419,7 @@ func TestGetNodeInfoFromNode(t *testing.T) {
 
 	c := New(&clusterd.Context{Clientset: clientset}, "ns", "", "myversion", cephv1beta1.CephVersionSpec{},
 		cephv1beta1.MonSpec{Count: 3, AllowMultiplePerNode: true}, rookalpha.Placement{},
-		true, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		rookalpha.NetworkSpec{HostNetwork: true}, v1.ResourceRequirements{}, metav1.OwnerReference{})
 	c.clusterInfo = test.CreateConfigDir(0)
 
 	var info *NodeInfo
@@ -447,7 +447,7 @@ func TestHostNetworkPortIncrease(t *testing.T) {
 	defer os.RemoveAll(configDir)
 	c := New(&clusterd.Context{Clientset: clientset, ConfigDir: configDir},
 		"ns", "", "myversion", cephv1beta1.CephVersionSpec{}, cephv1beta1.MonSpec{Count: 3, AllowMultiplePerNode: true},
-		rookalpha.Placement{}, true, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		rookalpha.Placement{}, rookalpha.NetworkSpec{HostNetwork: true}, v1.ResourceRequirements{}, metav1.OwnerReference{})
 	c.clusterInfo = test.CreateConfigDir(0)
 
 	mons := []*monConfig{
@@ -0,0 +1,175 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"fmt"
+	"time"
+
+	mondaemon "github.com/rook/rook/pkg/daemon/ceph/mon"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/kubelet/apis"
+)
+
+const (
+	monDataVolumeName   = "rook-ceph-mon-data"
+	monRescheduleReason = "MonRescheduled"
+)
+
+// onPVC returns whether the data of a mon is stored on a pvc. The mons that were assigned to a node before
+// the volume claim template was set keep their data under the dataDirHostPath.
+func (c *Cluster) onPVC(daemonName string) bool {
+	if c.volumeClaimTemplate == nil {
+		return false
+	}
+	_, ok := c.mapping.Node[daemonName]
+	return !ok
+}
+
+// monHostname returns the hostname of the node the mon is assigned to, or an empty hostname when the mon
+// is on a pvc and is placed by the scheduler
+func (c *Cluster) monHostname(daemonName string) (string, error) {
+	if node, ok := c.mapping.Node[daemonName]; ok {
+		return node.Hostname, nil
+	}
+	if c.volumeClaimTemplate != nil {
+		return "", nil
+	}
+	return "", fmt.Errorf("mon %s doesn't exist in assignment map", daemonName)
+}
+
+func (c *Cluster) makeVolumeClaim(m *monConfig) *v1.PersistentVolumeClaim {
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        m.ResourceName,
+			Namespace:   c.Namespace,
+			Labels:      c.getLabels(m.DaemonName),
+			Annotations: map[string]string{},
+		},
+		Spec: *c.volumeClaimTemplate.Spec.DeepCopy(),
+	}
+	for k, v := range c.volumeClaimTemplate.Labels {
+		pvc.Labels[k] = v
+	}
+	for k, v := range c.volumeClaimTemplate.Annotations {
+		pvc.Annotations[k] = v
+	}
+	k8sutil.SetOwnerRef(c.context.Clientset, c.Namespace, &pvc.ObjectMeta, &c.ownerRef)
+	return pvc
+}
+
+// createVolumeClaim creates the pvc of the mon if it does not exist yet. An existing pvc keeps the data of
+// the mon when its pod is rescheduled to another node.
+func (c *Cluster) createVolumeClaim(m *monConfig) error {
+	pvc := c.makeVolumeClaim(m)
+	if _, err := c.context.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Create(pvc); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create pvc %s. %+v", pvc.Name, err)
+		}
+		logger.Debugf("pvc %s of mon %s already exists", pvc.Name, m.DaemonName)
+		return nil
+	}
+	logger.Infof("created pvc %s for mon %s", pvc.Name, m.DaemonName)
+	return nil
+}
+
+func (c *Cluster) deleteVolumeClaim(daemonName string) error {
+	name := resourceName(daemonName)
+	if err := c.context.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Delete(name, &metav1.DeleteOptions{}); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to delete pvc %s. %+v", name, err)
+	}
+	logger.Infof("deleted pvc %s of mon %s", name, daemonName)
+	return nil
+}
+
+// applyVolumeClaim mounts the pvc of the mon over the directory of the mon in all the containers of the pod.
+// The rest of the data dir is not kept since it is generated again when the pod starts.
+func (c *Cluster) applyVolumeClaim(m *monConfig, podSpec *v1.PodSpec) {
+	podSpec.Volumes = append(podSpec.Volumes, v1.Volume{
+		Name: monDataVolumeName,
+		VolumeSource: v1.VolumeSource{
+			PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: m.ResourceName},
+		},
+	})
+	mount := v1.VolumeMount{Name: monDataVolumeName, MountPath: mondaemon.GetMonRunDirPath(c.context.ConfigDir, m.DaemonName)}
+	for i := range podSpec.InitContainers {
+		podSpec.InitContainers[i].VolumeMounts = append(podSpec.InitContainers[i].VolumeMounts, mount)
+	}
+	for i := range podSpec.Containers {
+		podSpec.Containers[i].VolumeMounts = append(podSpec.Containers[i].VolumeMounts, mount)
+	}
+
+	// the scheduler keeps the mons on different nodes since they are not assigned to nodes by the operator
+	if !c.AllowMultiplePerNode {
+		if podSpec.Affinity == nil {
+			podSpec.Affinity = &v1.Affinity{}
+		}
+		podSpec.Affinity.PodAntiAffinity = &v1.PodAntiAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []v1.PodAffinityTerm{
+				{
+					LabelSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{k8sutil.AppAttr: AppName, k8sutil.ClusterAttr: c.Namespace},
+					},
+					TopologyKey: apis.LabelHostname,
+				},
+			},
+		}
+	}
+}
+
+// rescheduleMon deletes the pod of a mon on a pvc that is out of quorum so the pod is started again with its
+// data, on another node if its node is gone. The mon is only failed over when its pvc is lost.
+func (c *Cluster) rescheduleMon(monCount, desiredMonCount int, daemonName string) {
+	name := resourceName(daemonName)
+	pvc, err := c.context.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Get(name, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		logger.Errorf("failed to get pvc of mon %s. %+v", daemonName, err)
+		return
+	}
+	if errors.IsNotFound(err) || pvc.Status.Phase == v1.ClaimLost {
+		logger.Warningf("pvc of mon %s is lost, mon will be failed over", daemonName)
+		c.failMon(monCount, desiredMonCount, daemonName)
+		return
+	}
+
+	// the pods on a node that is gone are only removed when they are deleted without a grace period
+	var gracePeriod int64
+	options := &metav1.DeleteOptions{GracePeriodSeconds: &gracePeriod}
+	listOptions := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s,mon=%s", k8sutil.AppAttr, AppName, daemonName)}
+	pods, err := c.context.Clientset.CoreV1().Pods(c.Namespace).List(listOptions)
+	if err != nil {
+		logger.Errorf("failed to list pods of mon %s. %+v", daemonName, err)
+		return
+	}
+	for _, pod := range pods.Items {
+		if err := c.context.Clientset.CoreV1().Pods(c.Namespace).Delete(pod.Name, options); err != nil && !errors.IsNotFound(err) {
+			logger.Errorf("failed to delete pod %s of mon %s. %+v", pod.Name, daemonName, err)
+			c.recordEvent(v1.EventTypeWarning, monFailoverFailedReason, "failed to reschedule mon %s. %+v", daemonName, err)
+			return
+		}
+	}
+
+	// give the mon another timeout to start with its data before it is rescheduled again
+	c.monTimeoutList[daemonName] = time.Now()
+	c.recordEvent(v1.EventTypeWarning, monRescheduleReason, "mon %s was out of quorum and was rescheduled with its pvc", daemonName)
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"testing"
+
+	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	testop "github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func newPVCCluster(context *clusterd.Context) *Cluster {
+	storageClass := "gp2"
+	template := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"tier": "mon"}},
+		Spec: v1.PersistentVolumeClaimSpec{
+			StorageClassName: &storageClass,
+			AccessModes:      []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse("10Gi")},
+			},
+		},
+	}
+	c := New(context, "ns", "/var/lib/rook", "myversion", cephv1beta1.CephVersionSpec{Image: "ceph/ceph:myceph"},
+		cephv1beta1.MonSpec{Count: 3, VolumeClaimTemplate: template},
+		rookalpha.Placement{}, rookalpha.NetworkSpec{}, v1.ResourceRequirements{}, metav1.OwnerReference{})
+	c.clusterInfo = testop.CreateConfigDir(0)
+	return c
+}
+
+func TestMonOnPVC(t *testing.T) {
+	clientset := testop.New(3)
+	c := newPVCCluster(&clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook"})
+	config := &monConfig{ResourceName: "rook-ceph-mon-a", DaemonName: "a", Port: 6790, PublicIP: "2.4.6.1"}
+
+	// the mon on a pvc is not assigned to a node
+	assert.True(t, c.onPVC("a"))
+	assert.Nil(t, c.assignMons([]*monConfig{config}))
+	_, ok := c.mapping.Node["a"]
+	assert.False(t, ok)
+	hostname, err := c.monHostname("a")
+	assert.Nil(t, err)
+	assert.Equal(t, "", hostname)
+
+	// a mon assigned to a node before the template was set keeps its node
+	c.mapping.Node["b"] = &NodeInfo{Name: "node1", Hostname: "node1"}
+	assert.False(t, c.onPVC("b"))
+
+	pod := c.makeMonPod(config, "")
+	assert.Equal(t, 0, len(pod.Spec.NodeSelector))
+	assert.Equal(t, 4, len(pod.Spec.Volumes))
+	assert.Nil(t, testop.VolumeIsEmptyDir(k8sutil.DataDirVolume, pod.Spec.Volumes))
+	assert.Equal(t, "rook-ceph-mon-a", pod.Spec.Volumes[3].PersistentVolumeClaim.ClaimName)
+	for _, container := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+		assert.Contains(t, container.VolumeMounts, v1.VolumeMount{Name: monDataVolumeName, MountPath: "/var/lib/rook/mon-a"})
+	}
+	// the scheduler keeps the mons on different nodes
+	antiAffinity := pod.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	assert.Equal(t, 1, len(antiAffinity))
+	assert.Equal(t, AppName, antiAffinity[0].LabelSelector.MatchLabels[k8sutil.AppAttr])
+
+	// the pvc is created with the mon
+	c.waitForStart = false
+	assert.Nil(t, c.startMon(config, ""))
+	pvc, err := clientset.CoreV1().PersistentVolumeClaims("ns").Get("rook-ceph-mon-a", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "gp2", *pvc.Spec.StorageClassName)
+	assert.Equal(t, "mon", pvc.Labels["tier"])
+	assert.Equal(t, "a", pvc.Labels["mon"])
+
+	// starting the mon again keeps the pvc
+	assert.Nil(t, c.startMon(config, ""))
+
+	// mons on pvcs cannot use the host network
+	c.HostNetwork = true
+	assert.NotNil(t, c.Start())
+}
+
+func TestRescheduleMonOnPVC(t *testing.T) {
+	clientset := testop.New(3)
+	recorder := record.NewFakeRecorder(10)
+	c := newPVCCluster(&clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Recorder: recorder})
+	config := &monConfig{ResourceName: "rook-ceph-mon-a", DaemonName: "a", Port: 6790}
+	assert.Nil(t, c.createVolumeClaim(config))
+
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon-a-1234", Namespace: "ns", Labels: c.getLabels("a")}}
+	_, err := clientset.CoreV1().Pods("ns").Create(pod)
+	assert.Nil(t, err)
+
+	// the pod of the mon is deleted so it is started again with its pvc
+	c.rescheduleMon(3, 3, "a")
+	_, err = clientset.CoreV1().Pods("ns").Get(pod.Name, metav1.GetOptions{})
+	assert.True(t, errors.IsNotFound(err))
+	_, err = clientset.CoreV1().PersistentVolumeClaims("ns").Get("rook-ceph-mon-a", metav1.GetOptions{})
+	assert.Nil(t, err)
+	_, ok := c.monTimeoutList["a"]
+	assert.True(t, ok)
+	assert.Equal(t, "Warning MonRescheduled mon a was out of quorum and was rescheduled with its pvc", <-recorder.Events)
+}
@@ -43,7 +43,7 @@ const (
 func (c *Cluster) getLabels(daemonName string) map[string]string {
 	// Mons have a service for each mon, so the additional pod data is relevant for its services
 	// Use pod labels to keep "mon: id" for legacy
-	labels := opspec.PodLabels(appName, c.Namespace, "mon", daemonName)
+	labels := opspec.PodLabels(AppName, c.Namespace, "mon", daemonName)
 	// Add "mon_cluster: <namespace>" for legacy
 	labels[monClusterAttr] = c.Namespace
 	return labels
@@ -79,6 +79,8 @@ func (c *Cluster) makeDeployment(monConfig *monConfig, hostname string) *extensi
  * Pod spec
  */
 
+// makeMonPod makes the pod of a mon on the node with the hostname, or the pod of a mon on a pvc that
+// is placed by the scheduler when the hostname is empty
 func (c *Cluster) makeMonPod(monConfig *monConfig, hostname string) *v1.Pod {
 	podSpec := v1.PodSpec{
 		InitContainers: []v1.Container{
@@ -93,8 +95,6 @@ func (c *Cluster) makeMonPod(monConfig *monConfig, hostname string) *v1.Pod {
 			c.makeMonDaemonContainer(monConfig),
 		},
 		RestartPolicy: v1.RestartPolicyAlways,
-		NodeSelector:  map[string]string{apis.LabelHostname: hostname},
-		Volumes:       opspec.PodVolumes(c.dataDirHostPath),
 		HostNetwork:   c.HostNetwork,
 	}
 	if c.HostNetwork {
@@ -105,6 +105,16 @@ func (c *Cluster) makeMonPod(monConfig *monConfig, hostname string) *v1.Pod {
 	c.placement.PodAffinity = nil
 	c.placement.PodAntiAffinity = nil
 
+	if hostname != "" {
+		podSpec.NodeSelector = map[string]string{apis.LabelHostname: hostname}
+		podSpec.Volumes = opspec.PodVolumes(c.dataDirHostPath)
+	} else {
+		podSpec.Volumes = opspec.PodVolumes("")
+		c.applyVolumeClaim(monConfig, &podSpec)
+	}
+
+	// the mons are not attached to the public network since clients reach them at the addresses of their
+	// services, which the mons bind to in the pod network
 	pod := &v1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        monConfig.ResourceName,
@@ -132,6 +142,18 @@ func podSecurityContext() *v1.SecurityContext {
 }
 
 func (c *Cluster) makeConfigInitContainer(monConfig *monConfig) v1.Container {
+	envVars := []v1.EnvVar{
+		k8sutil.PodIPEnvVar(k8sutil.PrivateIPEnvVar),
+		{Name: k8sutil.PublicIPEnvVar, Value: monConfig.PublicIP},
+		ClusterNameEnvVar(c.Namespace),
+		EndpointEnvVar(),
+		SecretEnvVar(),
+		AdminSecretEnvVar(),
+		k8sutil.ConfigOverrideEnvVar(),
+		k8sutil.CephConfigEnvVar(),
+	}
+	envVars = append(envVars, opspec.NetworkEnvVars(c.network)...)
+
 	return v1.Container{
 		Name: opspec.ConfigInitContainerName,
 		Args: []string{
@@ -142,16 +164,8 @@ func (c *Cluster) makeConfigInitContainer(monConfig *monConfig) v1.Container {
 			fmt.Sprintf("--port=%d", monConfig.Port),
 			fmt.Sprintf("--fsid=%s", c.clusterInfo.FSID),
 		},
-		Image: k8sutil.MakeRookImage(c.rookVersion),
-		Env: []v1.EnvVar{
-			k8sutil.PodIPEnvVar(k8sutil.PrivateIPEnvVar),
-			{Name: k8sutil.PublicIPEnvVar, Value: monConfig.PublicIP},
-			ClusterNameEnvVar(c.Namespace),
-			EndpointEnvVar(),
-			SecretEnvVar(),
-			AdminSecretEnvVar(),
-			k8sutil.ConfigOverrideEnvVar(),
-		},
+		Image:           k8sutil.MakeRookImage(c.rookVersion),
+		Env:             envVars,
 		VolumeMounts:    opspec.RookVolumeMounts(),
 		SecurityContext: podSecurityContext(),
 		Resources:       c.resources,
@@ -56,7 +56,7 @@ func testPodSpec(t *testing.T, dataDir string) {
 		cephv1beta1.CephVersionSpec{Image: "ceph/ceph:myceph"},
 		cephv1beta1.MonSpec{Count: 3, AllowMultiplePerNode: true},
 		rookalpha.Placement{},
-		false,
+		rookalpha.NetworkSpec{},
 		v1.ResourceRequirements{
 			Limits: v1.ResourceList{
 				v1.ResourceCPU: *resource.NewQuantity(100.0, resource.BinarySI),
@@ -96,7 +96,7 @@ func testPodSpec(t *testing.T, dataDir string) {
 
 	// config w/ rook binary init container
 	configImage := "rook/rook:myversion"
-	configEnvs := 7
+	configEnvs := 8
 	configContDev := test_opceph.ContainerTestDefinition{
 		Image:   &configImage,
 		Command: []string{}, // no command
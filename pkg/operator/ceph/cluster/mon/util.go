@@ -58,7 +58,7 @@ func CreateOrLoadClusterInfo(context *clusterd.Context, namespace string, ownerR
 		Port: map[string]int32{},
 	}
 
-	secrets, err := context.Clientset.CoreV1().Secrets(namespace).Get(appName, metav1.GetOptions{})
+	secrets, err := context.Clientset.CoreV1().Secrets(namespace).Get(AppName, metav1.GetOptions{})
 	if err != nil {
 		if !errors.IsNotFound(err) {
 			return nil, maxMonID, monMapping, fmt.Errorf("failed to get mon secrets. %+v", err)
@@ -167,7 +167,7 @@ func createClusterAccessSecret(clientset kubernetes.Interface, namespace string,
 	}
 	secret := &v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      appName,
+			Name:      AppName,
 			Namespace: namespace,
 		},
 		StringData: secrets,
@@ -260,14 +260,14 @@ func extractKey(contents string) (string, error) {
 
 // convert the mon name to the numeric mon ID
 func fullNameToIndex(name string) (int, error) {
-	prefix := appName + "-"
+	prefix := AppName + "-"
 	if strings.Index(name, prefix) != -1 && len(prefix) < len(name) {
 		return k8sutil.NameToIndex(name[len(prefix)+1:])
 	}
 
 	// attempt to parse the legacy mon name
-	legacyPrefix := appName
-	if strings.Index(name, legacyPrefix) == -1 || len(name) < len(appName) {
+	legacyPrefix := AppName
+	if strings.Index(name, legacyPrefix) == -1 || len(name) < len(AppName) {
 		return -1, fmt.Errorf("unexpected mon name")
 	}
 	id, err := strconv.Atoi(name[len(legacyPrefix):])
@@ -18,9 +18,11 @@ limitations under the License.
 package config
 
 import (
+	"fmt"
 	"strconv"
 
 	"github.com/coreos/pkg/capnslog"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 )
 
@@ -28,6 +30,16 @@ const (
 	OSDFSStoreNameFmt  = "rook-ceph-osd-%d-fs-backup"
 	configStoreNameFmt = "rook-ceph-osd-%s-config"
 	osdDirsKeyName     = "osd-dirs"
+
+	// EncryptionKeySecretNameFmt is the name of the secret with the dm-crypt key of an encrypted osd
+	EncryptionKeySecretNameFmt = "rook-ceph-osd-%d-encryption-key"
+	// EncryptionKeySecretKey is the key of the dm-crypt key in the secret of an encrypted osd
+	EncryptionKeySecretKey = "dmcrypt-key"
+
+	// RookBackend provisions the osds on devices with the partitions of rook. This is the default backend.
+	RookBackend = "rook"
+	// CephVolumeBackend provisions the osds on devices with ceph-volume on lvm volumes
+	CephVolumeBackend = "ceph-volume"
 )
 
 var logger = capnslog.NewPackageLogger("github.com/rook/rook", "osd-config")
@@ -37,18 +49,23 @@ func GetConfigStoreName(nodeName string) string {
 }
 
 const (
-	StoreTypeKey      = "storeType"
-	WalSizeMBKey      = "walSizeMB"
-	DatabaseSizeMBKey = "databaseSizeMB"
-	JournalSizeMBKey  = "journalSizeMB"
-	MetadataDeviceKey = "metadataDevice"
+	StoreTypeKey       = "storeType"
+	WalSizeMBKey       = "walSizeMB"
+	DatabaseSizeMBKey  = "databaseSizeMB"
+	JournalSizeMBKey   = "journalSizeMB"
+	MetadataDeviceKey  = "metadataDevice"
+	DeviceClassKey     = "deviceClass"
+	EncryptedDeviceKey = "encryptedDevice"
+	BackendKey         = "backend"
 )
 
 type StoreConfig struct {
-	StoreType      string `json:"storeType,omitempty"`
-	WalSizeMB      int    `json:"walSizeMB,omitempty"`
-	DatabaseSizeMB int    `json:"databaseSizeMB,omitempty"`
-	JournalSizeMB  int    `json:"journalSizeMB,omitempty"`
+	StoreType       string `json:"storeType,omitempty"`
+	WalSizeMB       int    `json:"walSizeMB,omitempty"`
+	DatabaseSizeMB  int    `json:"databaseSizeMB,omitempty"`
+	JournalSizeMB   int    `json:"journalSizeMB,omitempty"`
+	EncryptedDevice bool   `json:"encryptedDevice,omitempty"`
+	Backend         string `json:"backend,omitempty"`
 }
 
 func ToStoreConfig(config map[string]string) StoreConfig {
@@ -63,6 +80,10 @@ func ToStoreConfig(config map[string]string) StoreConfig {
 			storeConfig.DatabaseSizeMB = convertToIntIgnoreErr(v)
 		case JournalSizeMBKey:
 			storeConfig.JournalSizeMB = convertToIntIgnoreErr(v)
+		case EncryptedDeviceKey:
+			storeConfig.EncryptedDevice, _ = strconv.ParseBool(v)
+		case BackendKey:
+			storeConfig.Backend = v
 		}
 	}
 
@@ -80,6 +101,35 @@ func MetadataDevice(config map[string]string) string {
 	return ""
 }
 
+// DeviceClasses returns the crush device classes set in the config of the devices, keyed by device name
+func DeviceClasses(devices []rookalpha.Device) map[string]string {
+	classes := map[string]string{}
+	for _, device := range devices {
+		if class := device.Config[DeviceClassKey]; class != "" {
+			classes[device.Name] = class
+		}
+	}
+
+	return classes
+}
+
+// EncryptedDevices returns whether the devices are encrypted for the devices that set it in their config, keyed by device name
+func EncryptedDevices(devices []rookalpha.Device) map[string]bool {
+	encrypted := map[string]bool{}
+	for _, device := range devices {
+		if value, ok := device.Config[EncryptedDeviceKey]; ok {
+			encrypted[device.Name], _ = strconv.ParseBool(value)
+		}
+	}
+
+	return encrypted
+}
+
+// EncryptionKeySecretName returns the name of the secret with the dm-crypt key of the osd
+func EncryptionKeySecretName(osdID int) string {
+	return fmt.Sprintf(EncryptionKeySecretNameFmt, osdID)
+}
+
 func convertToIntIgnoreErr(raw string) int {
 	val, err := strconv.Atoi(raw)
 	if err != nil {
@@ -61,6 +61,8 @@ type PerfSchemeEntry struct {
 	Partitions map[PartitionType]*PerfSchemePartitionDetails `json:"partitions"` // mapping of partition name to its details
 	StoreType  string                                        `json:"storeType,omitempty"`
 	FSCreated  bool                                          `json:"fsCreated"`
+	// whether the partitions of the OSD are encrypted with dm-crypt
+	Encrypted bool `json:"encrypted,omitempty"`
 }
 
 // details for 1 OSD partition
@@ -0,0 +1,254 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"path"
+
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	osdconfig "github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	opspec "github.com/rook/rook/pkg/operator/ceph/spec"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	batch "k8s.io/api/batch/v1"
+	"k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	deviceSetLabelKey       = "ceph.rook.io/DeviceSet"
+	pvcLabelKey             = "ceph.rook.io/pvc"
+	pvcDevicePathEnvVarName = "ROOK_PVC_DEVICE_PATH"
+	pvcDeviceVolumeName     = "rook-ceph-osd-pvc"
+	pvcDeviceDir            = "/mnt"
+	provisionContainerName  = "provision"
+	deviceSetPVCNameFmt     = "%s-%d"
+	devicesVolumeName       = "devices"
+	udevVolumeName          = "udev"
+	devicesVolumeMountPath  = "/dev"
+	udevVolumeMountPath     = "/run/udev"
+)
+
+func deviceSetPVCName(set rookalpha.StorageClassDeviceSet, index int) string {
+	return fmt.Sprintf(deviceSetPVCNameFmt, set.Name, index)
+}
+
+// resolveDeviceSet returns the device set of the given pvc, or nil if the name is not the name of a pvc
+// of a device set. The pvc name takes the place of the node name to track the provisioning of its osd.
+func (c *Cluster) resolveDeviceSet(pvcName string) *rookalpha.StorageClassDeviceSet {
+	for i := range c.Storage.StorageClassDeviceSets {
+		set := &c.Storage.StorageClassDeviceSets[i]
+		for j := 0; j < set.Count; j++ {
+			if deviceSetPVCName(*set, j) == pvcName {
+				return set
+			}
+		}
+	}
+	return nil
+}
+
+// deviceSetNode resolves the storage config and resources of the osd on a pvc like those of a node
+func (c *Cluster) deviceSetNode(pvcName string, set *rookalpha.StorageClassDeviceSet) *rookalpha.Node {
+	return &rookalpha.Node{
+		Name:      pvcName,
+		Location:  c.pvcTopologyLocation(pvcName, c.Storage.Location),
+		Resources: k8sutil.MergeResourceRequirements(set.Resources, c.resources),
+		Config:    set.Config,
+	}
+}
+
+func (c *Cluster) makeDeviceSetPVC(set rookalpha.StorageClassDeviceSet, index int) *v1.PersistentVolumeClaim {
+	template := set.VolumeClaimTemplate
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        deviceSetPVCName(set, index),
+			Namespace:   c.Namespace,
+			Labels:      map[string]string{k8sutil.AppAttr: AppName, k8sutil.ClusterAttr: c.Namespace, deviceSetLabelKey: set.Name},
+			Annotations: map[string]string{},
+		},
+		Spec: *template.Spec.DeepCopy(),
+	}
+	for k, v := range template.Labels {
+		pvc.Labels[k] = v
+	}
+	for k, v := range template.Annotations {
+		pvc.Annotations[k] = v
+	}
+	// the osd consumes the pvc as a raw device
+	volumeMode := v1.PersistentVolumeBlock
+	pvc.Spec.VolumeMode = &volumeMode
+	k8sutil.SetOwnerRef(c.context.Clientset, c.Namespace, &pvc.ObjectMeta, &c.ownerRef)
+	return pvc
+}
+
+// createDeviceSetPVC creates the pvc if it does not exist yet. An existing pvc keeps the data of its osd.
+func (c *Cluster) createDeviceSetPVC(set rookalpha.StorageClassDeviceSet, index int) (string, error) {
+	pvc := c.makeDeviceSetPVC(set, index)
+	if _, err := c.context.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Create(pvc); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return "", fmt.Errorf("failed to create pvc %s. %+v", pvc.Name, err)
+		}
+		logger.Debugf("pvc %s of device set %s already exists", pvc.Name, set.Name)
+		return pvc.Name, nil
+	}
+	logger.Infof("created pvc %s of device set %s", pvc.Name, set.Name)
+	return pvc.Name, nil
+}
+
+func (c *Cluster) startProvisioningDeviceSets(config *provisionConfig) {
+	for _, set := range c.Storage.StorageClassDeviceSets {
+		for i := 0; i < set.Count; i++ {
+			pvcName, err := c.createDeviceSetPVC(set, i)
+			if err != nil {
+				config.addError("failed to create pvc %d of device set %s. %+v", i, set.Name, err)
+				continue
+			}
+			c.startProvisioningPVC(pvcName, &set, config)
+		}
+	}
+}
+
+func (c *Cluster) startProvisioningPVC(pvcName string, set *rookalpha.StorageClassDeviceSet, config *provisionConfig) {
+	// update the orchestration status of this pvc to the starting state
+	status := OrchestrationStatus{Status: OrchestrationStatusStarting}
+	if err := c.updateNodeStatus(pvcName, status); err != nil {
+		config.addError("failed to set orchestration starting status for pvc %s: %+v", pvcName, err)
+		return
+	}
+
+	job, err := c.makeDeviceSetJob(pvcName, set)
+	if err != nil {
+		message := fmt.Sprintf("failed to create prepare job for pvc %s: %v", pvcName, err)
+		c.handleOrchestrationFailure(config, pvcName, message)
+		return
+	}
+
+	c.runJob(job, pvcName, config, "provision")
+}
+
+// makeDeviceSetJob makes the job that prepares the osd on the raw device of the pvc
+func (c *Cluster) makeDeviceSetJob(pvcName string, set *rookalpha.StorageClassDeviceSet) (*batch.Job, error) {
+	n := c.deviceSetNode(pvcName, set)
+	storeConfig := osdconfig.ToStoreConfig(n.Config)
+	job, err := c.makeJob(n.Name, nil, rookalpha.Selection{}, n.Resources, storeConfig, "", n.Location)
+	if err != nil {
+		return nil, err
+	}
+
+	podSpec := &job.Spec.Template.Spec
+	applyDeviceSetPVC(podSpec, set, pvcName, provisionContainerName)
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name == provisionContainerName {
+			podSpec.Containers[i].Env = append(podSpec.Containers[i].Env, pvcDevicePathEnvVar(pvcName))
+		}
+	}
+	job.Labels[pvcLabelKey] = pvcName
+	job.Spec.Template.Labels[pvcLabelKey] = pvcName
+	return job, nil
+}
+
+// makeDeviceSetDeployment makes the deployment of an osd on the raw device of the pvc
+func (c *Cluster) makeDeviceSetDeployment(pvcName string, set *rookalpha.StorageClassDeviceSet, osd OSDInfo) (*extensions.Deployment, error) {
+	n := c.deviceSetNode(pvcName, set)
+	storeConfig := osdconfig.ToStoreConfig(n.Config)
+	dp, err := c.makeDeployment(n.Name, nil, rookalpha.Selection{}, n.Resources, storeConfig, "", n.Location, osd)
+	if err != nil {
+		return nil, err
+	}
+
+	applyDeviceSetPVC(&dp.Spec.Template.Spec, set, pvcName, opspec.ConfigInitContainerName, osdContainerName)
+	dp.Labels[pvcLabelKey] = pvcName
+	dp.Spec.Template.Labels[pvcLabelKey] = pvcName
+	return dp, nil
+}
+
+// applyDeviceSetPVC attaches the pvc as a raw block device to the given containers of the pod. The pod is not
+// bound to a node so the osd follows its pvc when it is rescheduled. The osd dirs are not kept on the node since
+// they are restored on the node where the pod runs from the backup of the osd filesystem.
+func applyDeviceSetPVC(podSpec *v1.PodSpec, set *rookalpha.StorageClassDeviceSet, pvcName string, containerNames ...string) {
+	podSpec.NodeSelector = nil
+	for i := range podSpec.Volumes {
+		if podSpec.Volumes[i].Name == k8sutil.DataDirVolume {
+			podSpec.Volumes[i].VolumeSource = v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}
+		}
+	}
+	podSpec.Volumes = append(podSpec.Volumes, v1.Volume{
+		Name: pvcDeviceVolumeName,
+		VolumeSource: v1.VolumeSource{
+			PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: pvcName},
+		},
+	})
+
+	// the partitions of the device are found by their partuuid in the /dev of the host
+	addHostPathVolume(podSpec, devicesVolumeName, devicesVolumeMountPath)
+	addHostPathVolume(podSpec, udevVolumeName, udevVolumeMountPath)
+
+	device := v1.VolumeDevice{Name: pvcDeviceVolumeName, DevicePath: pvcDevicePath(pvcName)}
+	privileged := true
+	for _, name := range containerNames {
+		for _, containers := range [][]v1.Container{podSpec.InitContainers, podSpec.Containers} {
+			for i := range containers {
+				container := &containers[i]
+				if container.Name != name {
+					continue
+				}
+				container.VolumeDevices = append(container.VolumeDevices, device)
+				addVolumeMount(container, devicesVolumeName, devicesVolumeMountPath)
+				addVolumeMount(container, udevVolumeName, udevVolumeMountPath)
+				if container.SecurityContext == nil {
+					container.SecurityContext = &v1.SecurityContext{}
+				}
+				container.SecurityContext.Privileged = &privileged
+			}
+		}
+	}
+
+	set.Placement.ApplyToPodSpec(podSpec)
+}
+
+func addHostPathVolume(podSpec *v1.PodSpec, name, hostPath string) {
+	for _, volume := range podSpec.Volumes {
+		if volume.Name == name {
+			return
+		}
+	}
+	podSpec.Volumes = append(podSpec.Volumes, v1.Volume{Name: name, VolumeSource: v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: hostPath}}})
+}
+
+func addVolumeMount(container *v1.Container, name, mountPath string) {
+	for _, mount := range container.VolumeMounts {
+		if mount.Name == name {
+			return
+		}
+	}
+	container.VolumeMounts = append(container.VolumeMounts, v1.VolumeMount{Name: name, MountPath: mountPath})
+}
+
+func pvcDevicePath(pvcName string) string {
+	return path.Join(pvcDeviceDir, pvcName)
+}
+
+func pvcDevicePathEnvVar(pvcName string) v1.EnvVar {
+	return v1.EnvVar{Name: pvcDevicePathEnvVarName, Value: pvcDevicePath(pvcName)}
+}
+
+func isDeviceSetDeployment(dp *extensions.Deployment) bool {
+	_, ok := dp.Labels[pvcLabelKey]
+	return ok
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"testing"
+
+	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	opspec "github.com/rook/rook/pkg/operator/ceph/spec"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newDeviceSetCluster() *Cluster {
+	storageSpec := rookalpha.StorageScopeSpec{
+		StorageClassDeviceSets: []rookalpha.StorageClassDeviceSet{
+			{
+				Name:  "set1",
+				Count: 2,
+				VolumeClaimTemplate: v1.PersistentVolumeClaim{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"foo": "bar"}},
+					Spec: v1.PersistentVolumeClaimSpec{
+						Resources: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse("10Gi")}},
+					},
+				},
+			},
+		},
+	}
+	context := &clusterd.Context{Clientset: testop.New(1), ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}
+	return New(context, "ns", "rook/rook:myversion", cephv1beta1.CephVersionSpec{Image: "ceph/ceph:v12.2.8"}, "",
+		storageSpec, "/var/lib/rook", rookalpha.Placement{}, rookalpha.NetworkSpec{}, v1.ResourceRequirements{}, metav1.OwnerReference{})
+}
+
+func TestResolveDeviceSet(t *testing.T) {
+	c := newDeviceSetCluster()
+
+	set := c.resolveDeviceSet("set1-1")
+	require.NotNil(t, set)
+	assert.Equal(t, "set1", set.Name)
+	assert.Nil(t, c.resolveDeviceSet("set1-2"))
+	assert.Nil(t, c.resolveDeviceSet("node1"))
+}
+
+func TestCreateDeviceSetPVC(t *testing.T) {
+	c := newDeviceSetCluster()
+
+	name, err := c.createDeviceSetPVC(c.Storage.StorageClassDeviceSets[0], 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "set1-0", name)
+	pvc, err := c.context.Clientset.CoreV1().PersistentVolumeClaims("ns").Get(name, metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, v1.PersistentVolumeBlock, *pvc.Spec.VolumeMode)
+	assert.Equal(t, "bar", pvc.Labels["foo"])
+	assert.Equal(t, "set1", pvc.Labels[deviceSetLabelKey])
+
+	// the existing pvc is kept
+	name, err = c.createDeviceSetPVC(c.Storage.StorageClassDeviceSets[0], 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "set1-0", name)
+}
+
+func TestDeviceSetJob(t *testing.T) {
+	c := newDeviceSetCluster()
+
+	job, err := c.makeDeviceSetJob("set1-0", &c.Storage.StorageClassDeviceSets[0])
+	require.Nil(t, err)
+	assert.Equal(t, "rook-ceph-osd-prepare-set1-0", job.Name)
+	assert.Equal(t, "set1-0", job.Spec.Template.Labels[pvcLabelKey])
+	podSpec := job.Spec.Template.Spec
+	assert.Nil(t, podSpec.NodeSelector)
+	assertDeviceSetVolumes(t, podSpec.Volumes, "set1-0")
+
+	container := podSpec.Containers[1]
+	assert.Equal(t, provisionContainerName, container.Name)
+	assert.Equal(t, []v1.VolumeDevice{{Name: pvcDeviceVolumeName, DevicePath: "/mnt/set1-0"}}, container.VolumeDevices)
+	assert.True(t, *container.SecurityContext.Privileged)
+	assert.Contains(t, container.Env, v1.EnvVar{Name: "ROOK_PVC_DEVICE_PATH", Value: "/mnt/set1-0"})
+	assert.Contains(t, container.Env, v1.EnvVar{Name: "ROOK_NODE_NAME", Value: "set1-0"})
+	assert.Contains(t, container.VolumeMounts, v1.VolumeMount{Name: devicesVolumeName, MountPath: "/dev"})
+	assert.Contains(t, container.VolumeMounts, v1.VolumeMount{Name: udevVolumeName, MountPath: "/run/udev"})
+}
+
+func TestDeviceSetDeployment(t *testing.T) {
+	c := newDeviceSetCluster()
+
+	osd := OSDInfo{ID: 3, DataPath: "/var/lib/rook/osd3"}
+	dp, err := c.makeDeviceSetDeployment("set1-1", &c.Storage.StorageClassDeviceSets[0], osd)
+	require.Nil(t, err)
+	assert.Equal(t, "rook-ceph-osd-3", dp.Name)
+	assert.True(t, isDeviceSetDeployment(dp))
+	podSpec := dp.Spec.Template.Spec
+	assert.Nil(t, podSpec.NodeSelector)
+	assertDeviceSetVolumes(t, podSpec.Volumes, "set1-1")
+
+	device := v1.VolumeDevice{Name: pvcDeviceVolumeName, DevicePath: "/mnt/set1-1"}
+	initContainer := podSpec.InitContainers[0]
+	assert.Equal(t, opspec.ConfigInitContainerName, initContainer.Name)
+	assert.Equal(t, []v1.VolumeDevice{device}, initContainer.VolumeDevices)
+	assert.Contains(t, initContainer.VolumeMounts, v1.VolumeMount{Name: devicesVolumeName, MountPath: "/dev"})
+	assert.Equal(t, []v1.VolumeDevice{device}, podSpec.Containers[0].VolumeDevices)
+
+	// the osds on pvcs are grouped by their pvc
+	_, err = c.context.Clientset.Extensions().Deployments("ns").Create(dp)
+	require.Nil(t, err)
+	discovered, err := c.discoverStorageNodes()
+	require.Nil(t, err)
+	assert.Equal(t, 1, len(discovered["set1-1"]))
+
+	// the osds on pvcs are not removed with the nodes
+	removed, err := c.findRemovedNodes()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(removed))
+}
+
+func assertDeviceSetVolumes(t *testing.T, volumes []v1.Volume, pvcName string) {
+	names := map[string]v1.VolumeSource{}
+	for _, volume := range volumes {
+		names[volume.Name] = volume.VolumeSource
+	}
+	// the osd dirs are not kept on the node
+	assert.NotNil(t, names[k8sutil.DataDirVolume].EmptyDir)
+	assert.Equal(t, pvcName, names[pvcDeviceVolumeName].PersistentVolumeClaim.ClaimName)
+	assert.Equal(t, "/dev", names[devicesVolumeName].HostPath.Path)
+	assert.Equal(t, "/run/udev", names[udevVolumeName].HostPath.Path)
+}
@@ -0,0 +1,429 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	opspec "github.com/rook/rook/pkg/operator/ceph/spec"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/kubernetes/pkg/kubelet/apis"
+)
+
+const (
+	osdPDBName                 = "rook-ceph-osd-pdb"
+	osdFailureDomainPDBNameFmt = "rook-ceph-osd-%s"
+	// the label of the budgets of the osds of a failure domain, with the failure domain
+	osdFailureDomainPDBLabelKey = "ceph-osd-failure-domain"
+
+	// the config map with the failure domain whose osds can be disrupted while its nodes are drained
+	drainConfigMapName    = "rook-ceph-osd-drain"
+	drainFailureDomainKey = "failureDomain"
+	drainOSDsKey          = "osds"
+
+	hostFailureDomain = "host"
+)
+
+// UpdateDisruptionBudgets updates the PodDisruptionBudgets of the osds. The osds are grouped by the failure domain
+// of the pools, which is the narrowest crush bucket type that the crush rules choose the replicas from, or the host.
+// Normally one osd in the cluster can be disrupted at a time. When a node with osds is cordoned to be drained, noout
+// is set on the osds of its failure domain so their data is not rebalanced, and all of them can be disrupted while
+// the osds of the other failure domains cannot. Only one failure domain is drained at a time. When its nodes are
+// uncordoned and its osds are up again, noout is cleared and the budget of the cluster is restored.
+func UpdateDisruptionBudgets(context *clusterd.Context, namespace string, ownerRef metav1.OwnerReference) error {
+	cordoned, nodeHostnames, err := getCordonedHosts(context)
+	if err != nil {
+		return err
+	}
+	domainType, err := getOSDFailureDomainType(context, namespace)
+	if err != nil {
+		return err
+	}
+	osdDomains, domainHosts, err := getOSDFailureDomains(context, namespace, nodeHostnames, domainType)
+	if err != nil {
+		return err
+	}
+	drainingDomain, drainingOSDs, err := loadDrainState(context, namespace)
+	if err != nil {
+		return err
+	}
+
+	isCordoned := func(domain string) bool {
+		for _, host := range domainHosts[domain] {
+			if cordoned[host] {
+				return true
+			}
+		}
+		return false
+	}
+
+	if drainingDomain != "" && !isCordoned(drainingDomain) {
+		// the nodes are back, but the osds must be up before their data can be rebalanced again
+		up, err := osdsUp(context, namespace, drainingOSDs)
+		if err != nil {
+			return err
+		}
+		if !up {
+			logger.Infof("waiting for osds %v in uncordoned failure domain %s to be up", drainingOSDs, drainingDomain)
+		} else {
+			if err := client.OSDRemoveNoOut(context, namespace, drainingOSDs); err != nil {
+				return err
+			}
+			logger.Infof("cleared noout on osds %v in uncordoned failure domain %s", drainingOSDs, drainingDomain)
+			drainingDomain = ""
+			if err := saveDrainState(context, namespace, ownerRef, "", nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	if drainingDomain == "" {
+		var domains []string
+		for domain := range osdDomains {
+			domains = append(domains, domain)
+		}
+		sort.Strings(domains)
+		for _, domain := range domains {
+			if !isCordoned(domain) {
+				continue
+			}
+			drainingDomain = domain
+			drainingOSDs = osdDomains[domain]
+			// the state is saved first so that noout is cleared even if setting it partially failed
+			if err := saveDrainState(context, namespace, ownerRef, drainingDomain, drainingOSDs); err != nil {
+				return err
+			}
+			if err := client.OSDAddNoOut(context, namespace, drainingOSDs); err != nil {
+				return err
+			}
+			logger.Infof("set noout on osds %v in cordoned failure domain %s", drainingOSDs, drainingDomain)
+			break
+		}
+	}
+
+	if drainingDomain == "" {
+		if err := k8sutil.CreateOrUpdatePodDisruptionBudget(context.Clientset, osdPDB(context, namespace, ownerRef)); err != nil {
+			return err
+		}
+		return deleteFailureDomainPDBs(context, namespace, nil)
+	}
+
+	// the budgets of the other failure domains are created before the budget of the cluster is removed
+	expected := map[string]bool{}
+	for domain, ids := range osdDomains {
+		if domain == drainingDomain {
+			continue
+		}
+		pdb := osdFailureDomainPDB(context, namespace, ownerRef, domain, ids)
+		if err := k8sutil.CreateOrUpdatePodDisruptionBudget(context.Clientset, pdb); err != nil {
+			return err
+		}
+		expected[pdb.Name] = true
+	}
+	if err := k8sutil.DeletePodDisruptionBudget(context.Clientset, namespace, osdPDBName); err != nil {
+		return err
+	}
+	return deleteFailureDomainPDBs(context, namespace, expected)
+}
+
+// ClearDrain clears noout on the osds of the drained failure domain when the disruptions are no longer managed.
+// The budgets of the osds are deleted with the other budgets of the cluster.
+func ClearDrain(context *clusterd.Context, namespace string) error {
+	drainingDomain, drainingOSDs, err := loadDrainState(context, namespace)
+	if err != nil || drainingDomain == "" {
+		return err
+	}
+	if err := client.OSDRemoveNoOut(context, namespace, drainingOSDs); err != nil {
+		return err
+	}
+	logger.Infof("cleared noout on osds %v in failure domain %s", drainingOSDs, drainingDomain)
+	return saveDrainState(context, namespace, metav1.OwnerReference{}, "", nil)
+}
+
+// getCordonedHosts returns the hostnames of the cordoned nodes, and the hostnames of the nodes by node name
+func getCordonedHosts(context *clusterd.Context) (map[string]bool, map[string]string, error) {
+	nodes, err := context.Clientset.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list nodes. %+v", err)
+	}
+
+	cordoned := map[string]bool{}
+	hostnames := map[string]string{}
+	for _, node := range nodes.Items {
+		hostname := node.Labels[apis.LabelHostname]
+		hostnames[node.Name] = hostname
+		if node.Spec.Unschedulable {
+			cordoned[hostname] = true
+		}
+	}
+	return cordoned, hostnames, nil
+}
+
+// getOSDFailureDomainType returns the narrowest crush bucket type that the crush rules choose the replicas of the
+// pools from. The pgs of all the pools stay active when the osds of a single bucket of this type are down.
+// The types below the host fall back to the host since the nodes are drained as a whole.
+func getOSDFailureDomainType(context *clusterd.Context, namespace string) (string, error) {
+	crushMap, err := client.GetCrushMap(context, namespace)
+	if err != nil {
+		return "", err
+	}
+	typeIDs := map[string]int{}
+	for _, t := range crushMap.Types {
+		typeIDs[t.Name] = t.ID
+	}
+
+	domainType := ""
+	for _, rule := range crushMap.Rules {
+		for _, step := range rule.Steps {
+			if !strings.HasPrefix(step.Operation, "choose") || step.Type == "" {
+				continue
+			}
+			if domainType == "" || typeIDs[step.Type] < typeIDs[domainType] {
+				domainType = step.Type
+			}
+		}
+	}
+	if domainType == "" || typeIDs[domainType] < typeIDs[hostFailureDomain] {
+		return hostFailureDomain, nil
+	}
+	return domainType, nil
+}
+
+// getOSDFailureDomains returns the ids of the osds, and the hostnames of their nodes, by the failure domain of the
+// osds, such as zone=a. The failure domain is read from the crush location of the osd, or is the host of the osd if
+// the location doesn't have the bucket type. The osds on PVCs are on the node of their pod.
+func getOSDFailureDomains(context *clusterd.Context, namespace string, nodeHostnames map[string]string, domainType string) (map[string][]int, map[string][]string, error) {
+	listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", k8sutil.AppAttr, AppName)}
+	deployments, err := context.Clientset.Extensions().Deployments(namespace).List(listOpts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list osd deployments. %+v", err)
+	}
+	pods, err := context.Clientset.CoreV1().Pods(namespace).List(listOpts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list osd pods. %+v", err)
+	}
+	podHosts := map[string]string{}
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName != "" {
+			podHosts[pod.Labels[osdLabelKey]] = nodeHostnames[pod.Spec.NodeName]
+		}
+	}
+
+	osdDomains := map[string][]int{}
+	domainHosts := map[string][]string{}
+	for i := range deployments.Items {
+		dp := &deployments.Items[i]
+		id, err := strconv.Atoi(dp.Labels[osdLabelKey])
+		if err != nil {
+			logger.Warningf("skipping osd deployment %s without an osd id. %+v", dp.Name, err)
+			continue
+		}
+		host := dp.Spec.Template.Spec.NodeSelector[apis.LabelHostname]
+		if isDeviceSetDeployment(dp) {
+			host = podHosts[dp.Labels[osdLabelKey]]
+		}
+		if host == "" {
+			continue
+		}
+
+		domain := fmt.Sprintf("%s=%s", hostFailureDomain, host)
+		if domainType != hostFailureDomain {
+			if value := locationValue(deploymentLocation(dp), domainType); value != "" {
+				domain = fmt.Sprintf("%s=%s", domainType, value)
+			}
+		}
+		osdDomains[domain] = append(osdDomains[domain], id)
+		if !containsString(domainHosts[domain], host) {
+			domainHosts[domain] = append(domainHosts[domain], host)
+		}
+	}
+	for domain := range osdDomains {
+		sort.Ints(osdDomains[domain])
+	}
+	return osdDomains, domainHosts, nil
+}
+
+// deploymentLocation returns the crush location of the osd of the deployment
+func deploymentLocation(dp *extensions.Deployment) string {
+	for _, c := range dp.Spec.Template.Spec.InitContainers {
+		if c.Name == opspec.ConfigInitContainerName {
+			return rookalpha.GetLocationFromContainer(c)
+		}
+	}
+	return ""
+}
+
+// locationValue returns the name of the bucket of the type in a crush location such as zone=a,rack=b
+func locationValue(location, bucketType string) string {
+	for _, pair := range strings.Split(location, ",") {
+		if strings.HasPrefix(pair, bucketType+"=") {
+			return strings.TrimPrefix(pair, bucketType+"=")
+		}
+	}
+	return ""
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func osdsUp(context *clusterd.Context, namespace string, osdIDs []int) (bool, error) {
+	osdDump, err := client.GetOSDDump(context, namespace)
+	if err != nil {
+		return false, err
+	}
+	for _, id := range osdIDs {
+		up, _, err := osdDump.StatusByID(int64(id))
+		if err != nil || up != upStatus {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func osdPDB(context *clusterd.Context, namespace string, ownerRef metav1.OwnerReference) *policyv1beta1.PodDisruptionBudget {
+	maxUnavailable := intstr.FromInt(1)
+	labels := map[string]string{k8sutil.AppAttr: AppName, k8sutil.ClusterAttr: namespace}
+	pdb := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      osdPDBName,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			Selector:       &metav1.LabelSelector{MatchLabels: labels},
+			MaxUnavailable: &maxUnavailable,
+		},
+	}
+	k8sutil.SetOwnerRef(context.Clientset, namespace, &pdb.ObjectMeta, &ownerRef)
+	return pdb
+}
+
+// osdFailureDomainPDB returns the budget that prevents the disruption of the osds of a failure domain while another
+// failure domain is drained
+func osdFailureDomainPDB(context *clusterd.Context, namespace string, ownerRef metav1.OwnerReference, domain string, osdIDs []int) *policyv1beta1.PodDisruptionBudget {
+	maxUnavailable := intstr.FromInt(0)
+	// the budget of zone=a is named rook-ceph-osd-zone-a
+	name := strings.Replace(domain, "=", "-", 1)
+	var ids []string
+	for _, id := range osdIDs {
+		ids = append(ids, strconv.Itoa(id))
+	}
+	pdb := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      k8sutil.TruncateNodeName(osdFailureDomainPDBNameFmt, name),
+			Namespace: namespace,
+			Labels:    map[string]string{k8sutil.AppAttr: AppName, k8sutil.ClusterAttr: namespace, osdFailureDomainPDBLabelKey: name},
+		},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{k8sutil.AppAttr: AppName, k8sutil.ClusterAttr: namespace},
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: osdLabelKey, Operator: metav1.LabelSelectorOpIn, Values: ids},
+				},
+			},
+			MaxUnavailable: &maxUnavailable,
+		},
+	}
+	k8sutil.SetOwnerRef(context.Clientset, namespace, &pdb.ObjectMeta, &ownerRef)
+	return pdb
+}
+
+// deleteFailureDomainPDBs deletes the budgets of the osds of the failure domains that are not expected
+func deleteFailureDomainPDBs(context *clusterd.Context, namespace string, expected map[string]bool) error {
+	pdbs, err := context.Clientset.PolicyV1beta1().PodDisruptionBudgets(namespace).List(metav1.ListOptions{LabelSelector: osdFailureDomainPDBLabelKey})
+	if err != nil {
+		return fmt.Errorf("failed to list osd budgets. %+v", err)
+	}
+	for _, pdb := range pdbs.Items {
+		if expected[pdb.Name] {
+			continue
+		}
+		if err := k8sutil.DeletePodDisruptionBudget(context.Clientset, namespace, pdb.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func loadDrainState(context *clusterd.Context, namespace string) (string, []int, error) {
+	cm, err := context.Clientset.CoreV1().ConfigMaps(namespace).Get(drainConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return "", nil, nil
+		}
+		return "", nil, fmt.Errorf("failed to get config map %s. %+v", drainConfigMapName, err)
+	}
+
+	var osdIDs []int
+	for _, s := range strings.Split(cm.Data[drainOSDsKey], ",") {
+		if id, err := strconv.Atoi(s); err == nil {
+			osdIDs = append(osdIDs, id)
+		}
+	}
+	return cm.Data[drainFailureDomainKey], osdIDs, nil
+}
+
+// saveDrainState saves the failure domain being drained and its osds, or deletes the state when none is drained
+func saveDrainState(context *clusterd.Context, namespace string, ownerRef metav1.OwnerReference, domain string, osdIDs []int) error {
+	if domain == "" {
+		err := context.Clientset.CoreV1().ConfigMaps(namespace).Delete(drainConfigMapName, &metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete config map %s. %+v", drainConfigMapName, err)
+		}
+		return nil
+	}
+
+	var ids []string
+	for _, id := range osdIDs {
+		ids = append(ids, strconv.Itoa(id))
+	}
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      drainConfigMapName,
+			Namespace: namespace,
+		},
+		Data: map[string]string{drainFailureDomainKey: domain, drainOSDsKey: strings.Join(ids, ",")},
+	}
+	k8sutil.SetOwnerRef(context.Clientset, namespace, &cm.ObjectMeta, &ownerRef)
+	if _, err := context.Clientset.CoreV1().ConfigMaps(namespace).Create(cm); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create config map %s. %+v", drainConfigMapName, err)
+		}
+		if _, err := context.Clientset.CoreV1().ConfigMaps(namespace).Update(cm); err != nil {
+			return fmt.Errorf("failed to update config map %s. %+v", drainConfigMapName, err)
+		}
+	}
+	return nil
+}
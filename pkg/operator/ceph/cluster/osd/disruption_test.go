@@ -0,0 +1,241 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	opspec "github.com/rook/rook/pkg/operator/ceph/spec"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/kubernetes/pkg/kubelet/apis"
+)
+
+func TestUpdateDisruptionBudgets(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	for _, name := range []string{"node0", "node1"} {
+		clientset.CoreV1().Nodes().Create(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{apis.LabelHostname: name}}})
+	}
+	for id, host := range []string{"node0", "node0", "node1"} {
+		d := &extensions.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("rook-ceph-osd-%d", id),
+			Namespace: "ns",
+			Labels:    map[string]string{k8sutil.AppAttr: AppName, osdLabelKey: fmt.Sprintf("%d", id)},
+		}}
+		d.Spec.Template.Spec.NodeSelector = map[string]string{apis.LabelHostname: host}
+		clientset.Extensions().Deployments("ns").Create(d)
+	}
+
+	osdsUp := true
+	var nooutArgs [][]string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if args[1] == "crush" {
+				return crushMapWithRules("host"), nil
+			}
+			if args[1] == "dump" {
+				if osdsUp {
+					return `{"osds":[{"osd":0,"up":1,"in":1},{"osd":1,"up":1,"in":1},{"osd":2,"up":1,"in":1}]}`, nil
+				}
+				return `{"osds":[{"osd":0,"up":0,"in":1},{"osd":1,"up":0,"in":1},{"osd":2,"up":1,"in":1}]}`, nil
+			}
+			// the osds are followed by the flags of the cluster
+			var cmd []string
+			for _, arg := range args[1:] {
+				if strings.HasPrefix(arg, "--") {
+					break
+				}
+				cmd = append(cmd, arg)
+			}
+			nooutArgs = append(nooutArgs, cmd)
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Clientset: clientset, Executor: executor}
+	cordon := func(name string, unschedulable bool) {
+		node, _ := clientset.CoreV1().Nodes().Get(name, metav1.GetOptions{})
+		node.Spec.Unschedulable = unschedulable
+		clientset.CoreV1().Nodes().Update(node)
+	}
+	pdbExists := func(name string) bool {
+		_, err := clientset.PolicyV1beta1().PodDisruptionBudgets("ns").Get(name, metav1.GetOptions{})
+		return !errors.IsNotFound(err)
+	}
+
+	// one osd in the cluster can be disrupted when no node is drained
+	err := UpdateDisruptionBudgets(context, "ns", metav1.OwnerReference{})
+	assert.Nil(t, err)
+	pdb, err := clientset.PolicyV1beta1().PodDisruptionBudgets("ns").Get("rook-ceph-osd-pdb", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, pdb.Spec.MaxUnavailable.IntValue())
+	assert.Equal(t, 0, len(nooutArgs))
+
+	// the osds of a cordoned node can all be disrupted, but not the osds of the other nodes
+	cordon("node0", true)
+	err = UpdateDisruptionBudgets(context, "ns", metav1.OwnerReference{})
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"add-noout", "osd.0", "osd.1"}}, nooutArgs)
+	assert.False(t, pdbExists("rook-ceph-osd-pdb"))
+	assert.False(t, pdbExists("rook-ceph-osd-host-node0"))
+	pdb, err = clientset.PolicyV1beta1().PodDisruptionBudgets("ns").Get("rook-ceph-osd-host-node1", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, pdb.Spec.MaxUnavailable.IntValue())
+	assert.Equal(t, []string{"2"}, pdb.Spec.Selector.MatchExpressions[0].Values)
+
+	// only one node is drained at a time
+	cordon("node1", true)
+	err = UpdateDisruptionBudgets(context, "ns", metav1.OwnerReference{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(nooutArgs))
+	assert.True(t, pdbExists("rook-ceph-osd-host-node1"))
+
+	// noout is not cleared until the osds of the uncordoned node are up
+	osdsUp = false
+	cordon("node0", false)
+	err = UpdateDisruptionBudgets(context, "ns", metav1.OwnerReference{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(nooutArgs))
+
+	// the next cordoned node is drained after the osds are up
+	osdsUp = true
+	err = UpdateDisruptionBudgets(context, "ns", metav1.OwnerReference{})
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"add-noout", "osd.0", "osd.1"}, {"rm-noout", "osd.0", "osd.1"}, {"add-noout", "osd.2"}}, nooutArgs)
+	assert.False(t, pdbExists("rook-ceph-osd-host-node1"))
+	pdb, err = clientset.PolicyV1beta1().PodDisruptionBudgets("ns").Get("rook-ceph-osd-host-node0", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"0", "1"}, pdb.Spec.Selector.MatchExpressions[0].Values)
+
+	// noout is cleared when the disruptions are no longer managed
+	err = ClearDrain(context, "ns")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"rm-noout", "osd.2"}, nooutArgs[3])
+	_, err = clientset.CoreV1().ConfigMaps("ns").Get(drainConfigMapName, metav1.GetOptions{})
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestUpdateDisruptionBudgetsByZone(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	for _, name := range []string{"node0", "node1", "node2"} {
+		clientset.CoreV1().Nodes().Create(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{apis.LabelHostname: name}}})
+	}
+	zones := []string{"a", "a", "b"}
+	for id, host := range []string{"node0", "node1", "node2"} {
+		d := &extensions.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("rook-ceph-osd-%d", id),
+			Namespace: "ns",
+			Labels:    map[string]string{k8sutil.AppAttr: AppName, osdLabelKey: fmt.Sprintf("%d", id)},
+		}}
+		d.Spec.Template.Spec.NodeSelector = map[string]string{apis.LabelHostname: host}
+		d.Spec.Template.Spec.InitContainers = []v1.Container{
+			{Name: opspec.ConfigInitContainerName, Env: []v1.EnvVar{rookalpha.LocationEnvVar("region=r1,zone=" + zones[id])}},
+		}
+		clientset.Extensions().Deployments("ns").Create(d)
+	}
+
+	crushMap := crushMapWithRules("zone")
+	var nooutArgs [][]string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if args[1] == "crush" {
+				return crushMap, nil
+			}
+			var cmd []string
+			for _, arg := range args[1:] {
+				if strings.HasPrefix(arg, "--") {
+					break
+				}
+				cmd = append(cmd, arg)
+			}
+			nooutArgs = append(nooutArgs, cmd)
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Clientset: clientset, Executor: executor}
+	node, _ := clientset.CoreV1().Nodes().Get("node0", metav1.GetOptions{})
+	node.Spec.Unschedulable = true
+	clientset.CoreV1().Nodes().Update(node)
+
+	// the osds of all the nodes of the zone of the cordoned node can be disrupted
+	err := UpdateDisruptionBudgets(context, "ns", metav1.OwnerReference{})
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"add-noout", "osd.0", "osd.1"}}, nooutArgs)
+	pdbs, _ := clientset.PolicyV1beta1().PodDisruptionBudgets("ns").List(metav1.ListOptions{})
+	assert.Equal(t, 1, len(pdbs.Items))
+	assert.Equal(t, "rook-ceph-osd-zone-b", pdbs.Items[0].Name)
+	assert.Equal(t, []string{"2"}, pdbs.Items[0].Spec.Selector.MatchExpressions[0].Values)
+
+	// the osds are grouped by host when a pool cannot lose a zone
+	ClearDrain(context, "ns")
+	crushMap = crushMapWithRules("zone", "host")
+	nooutArgs = nil
+	err = UpdateDisruptionBudgets(context, "ns", metav1.OwnerReference{})
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"add-noout", "osd.0"}}, nooutArgs)
+	pdbs, _ = clientset.PolicyV1beta1().PodDisruptionBudgets("ns").List(metav1.ListOptions{})
+	assert.Equal(t, 2, len(pdbs.Items))
+	_, err = clientset.PolicyV1beta1().PodDisruptionBudgets("ns").Get("rook-ceph-osd-host-node1", metav1.GetOptions{})
+	assert.Nil(t, err)
+	_, err = clientset.PolicyV1beta1().PodDisruptionBudgets("ns").Get("rook-ceph-osd-host-node2", metav1.GetOptions{})
+	assert.Nil(t, err)
+}
+
+func TestGetOSDFailureDomainType(t *testing.T) {
+	crushMap := ""
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			return crushMap, nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	for _, test := range []struct {
+		ruleTypes []string
+		expected  string
+	}{
+		{[]string{"host"}, "host"},
+		{[]string{"rack", "zone"}, "rack"},
+		{[]string{"zone", "osd"}, "host"},
+		{nil, "host"},
+	} {
+		crushMap = crushMapWithRules(test.ruleTypes...)
+		domainType, err := getOSDFailureDomainType(context, "ns")
+		assert.Nil(t, err)
+		assert.Equal(t, test.expected, domainType)
+	}
+}
+
+// crushMapWithRules returns a crush map with a rule that chooses the replicas from each of the bucket types
+func crushMapWithRules(ruleTypes ...string) string {
+	var rules []string
+	for _, ruleType := range ruleTypes {
+		rules = append(rules, fmt.Sprintf(`{"rule_name":"%s","steps":[{"op":"take","item":-1,"item_name":"default"},`+
+			`{"op":"chooseleaf_firstn","num":0,"type":"%s"},{"op":"emit"}]}`, ruleType, ruleType))
+	}
+	return `{"types":[{"type_id":0,"name":"osd"},{"type_id":1,"name":"host"},{"type_id":3,"name":"rack"},` +
+		`{"type_id":9,"name":"zone"},{"type_id":10,"name":"region"}],"rules":[` + strings.Join(rules, ",") + `]}`
+}
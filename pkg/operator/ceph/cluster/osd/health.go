@@ -16,13 +16,22 @@ limitations under the License.
 package osd
 
 import (
+	"sync"
 	"time"
 
+	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-const upStatus = 1
+const (
+	upStatus = 1
+
+	osdDownReason = "OSDDown"
+)
 
 var (
 	healthCheckInterval = 60 * time.Second
@@ -33,15 +42,42 @@ var (
 type Monitor struct {
 	context     *clusterd.Context
 	clusterName string
+	ownerRef    metav1.OwnerReference
 
 	// lastStatus keeps track of OSDs status
 	// key - OSD id; value: time of the status change.
 	lastStatus map[int]time.Time
+
+	// remediation is the policy of the actions taken on the OSDs that are down for longer than the grace period
+	remediation      cephv1beta1.OSDRemediationSpec
+	remediationMutex sync.Mutex
+	// lastAction keeps track of the last remediation action taken on the OSDs that are still down
+	lastAction map[int]remediationAction
+	// lastRemediation is the time of the last remediation action taken in the cluster
+	lastRemediation time.Time
+	// replaceOSD purges an OSD and provisions the OSDs of its node again
+	replaceOSD func(id int) error
 }
 
 // newMonitor instantiates OSD monitoring
-func NewMonitor(context *clusterd.Context, clusterName string) *Monitor {
-	return &Monitor{context, clusterName, make(map[int]time.Time)}
+func NewMonitor(context *clusterd.Context, clusterName string, ownerRef metav1.OwnerReference,
+	remediation cephv1beta1.OSDRemediationSpec, replaceOSD func(id int) error) *Monitor {
+	return &Monitor{
+		context:     context,
+		clusterName: clusterName,
+		ownerRef:    ownerRef,
+		lastStatus:  make(map[int]time.Time),
+		remediation: remediation,
+		lastAction:  make(map[int]remediationAction),
+		replaceOSD:  replaceOSD,
+	}
+}
+
+// SetRemediation changes the policy of the actions taken on the OSDs that are down for longer than the grace period
+func (m *Monitor) SetRemediation(remediation cephv1beta1.OSDRemediationSpec) {
+	m.remediationMutex.Lock()
+	defer m.remediationMutex.Unlock()
+	m.remediation = remediation
 }
 
 // Run runs monitoring logic for osds status at set intervals
@@ -72,10 +108,20 @@ func (m *Monitor) osdStatus() error {
 	}
 	logger.Debugf("osd dump %v", osdDump)
 
-	evalDownStatus := func(id int) {
+	// the remediation actions are blocked when too many osds are down
+	downOSDs := 0
+	for _, osdStatus := range osdDump.OSDs {
+		if up, err := osdStatus.Up.Int64(); err == nil && up != upStatus {
+			downOSDs++
+		}
+	}
+
+	evalDownStatus := func(id int, in bool) {
 		if now := time.Now(); now.Sub(m.lastStatus[id]) > osdGracePeriod {
 			logger.Warningf("osd.%d has been down for longer than the grace period (down since %+v)", id, m.lastStatus[id])
+			m.recordEvent(v1.EventTypeWarning, osdDownReason, "osd.%d has been down for longer than the grace period of %s", id, osdGracePeriod)
 			m.lastStatus[id] = time.Now()
+			m.remediate(id, in, downOSDs)
 		} else {
 			logger.Warningf("waiting for the osd.%d to exceed the grace period", id)
 		}
@@ -91,8 +137,7 @@ func (m *Monitor) osdStatus() error {
 		logger.Debugf("validating status of osd.%d", id)
 		_, tracked := m.lastStatus[id]
 
-		// No action on in/out cluster state is taken at this time.
-		status, _, err := osdDump.StatusByID(int64(id))
+		status, in, err := osdDump.StatusByID(int64(id))
 		if err != nil {
 			return err
 		}
@@ -100,7 +145,7 @@ func (m *Monitor) osdStatus() error {
 		if status != upStatus {
 			logger.Infof("osd.%d is marked 'DOWN'", id)
 			if tracked {
-				evalDownStatus(id)
+				evalDownStatus(id, in == 1)
 			} else {
 				m.lastStatus[id] = time.Now()
 			}
@@ -109,9 +154,15 @@ func (m *Monitor) osdStatus() error {
 			if tracked {
 				logger.Debugf("osd.%d recovered, stopping tracking.", id)
 				delete(m.lastStatus, id)
+				delete(m.lastAction, id)
 			}
 		}
 	}
 
 	return nil
 }
+
+// recordEvent records an event about the osds on the cluster CRD
+func (m *Monitor) recordEvent(eventType, reason, messageFmt string, args ...interface{}) {
+	k8sutil.RecordEvent(m.context, k8sutil.OwnerObjectReference(m.clusterName, m.ownerRef), eventType, reason, messageFmt, args...)
+}
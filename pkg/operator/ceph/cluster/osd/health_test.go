@@ -19,10 +19,13 @@ import (
 	"testing"
 	"time"
 
+	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
 	"github.com/rook/rook/pkg/clusterd"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
 
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 )
 
 func TestOSDStatus(t *testing.T) {
@@ -53,11 +56,13 @@ func TestOSDStatus(t *testing.T) {
 	}
 
 	// Setting up objects needed to create OSD
+	recorder := record.NewFakeRecorder(10)
 	context := &clusterd.Context{
 		Executor: executor,
+		Recorder: recorder,
 	}
 	// Initializing an OSD monitoring
-	osdMon := NewMonitor(context, cluster)
+	osdMon := NewMonitor(context, cluster, metav1.OwnerReference{Name: cluster}, cephv1beta1.OSDRemediationSpec{}, nil)
 	// Run OSD monitoring routine
 	err := osdMon.osdStatus()
 	assert.Nil(t, err)
@@ -74,11 +79,14 @@ func TestOSDStatus(t *testing.T) {
 	assert.Equal(t, 2, execCount)
 	// OSD monitor should stop tracking that process once the action is triggered
 	assert.Equal(t, 1, len(osdMon.lastStatus))
+	// The OSD down past the grace period is recorded on the cluster crd
+	assert.Equal(t, 1, len(recorder.Events))
+	assert.Equal(t, "Warning OSDDown osd.0 has been down for longer than the grace period of 1µs", <-recorder.Events)
 }
 
 func TestMonitorStart(t *testing.T) {
 	stopCh := make(chan struct{})
-	osdMon := NewMonitor(&clusterd.Context{}, "cluster")
+	osdMon := NewMonitor(&clusterd.Context{}, "cluster", metav1.OwnerReference{}, cephv1beta1.OSDRemediationSpec{}, nil)
 	logger.Infof("starting osd monitor")
 	go osdMon.Start(stopCh)
 	close(stopCh)
@@ -43,7 +43,8 @@ import (
 var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-osd")
 
 const (
-	appName                      = "rook-ceph-osd"
+	// AppName is the name of Rook's Ceph osd sub-app
+	AppName                      = "rook-ceph-osd"
 	prepareAppName               = "rook-ceph-osd-prepare"
 	prepareAppNameFmt            = "rook-ceph-osd-prepare-%s"
 	legacyAppNameFmt             = "rook-ceph-osd-id-%d"
@@ -65,6 +66,7 @@ type Cluster struct {
 	Storage         rookalpha.StorageScopeSpec
 	dataDirHostPath string
 	HostNetwork     bool
+	network         rookalpha.NetworkSpec
 	resources       v1.ResourceRequirements
 	ownerRef        metav1.OwnerReference
 	serviceAccount  string
@@ -81,7 +83,7 @@ func New(
 	storageSpec rookalpha.StorageScopeSpec,
 	dataDirHostPath string,
 	placement rookalpha.Placement,
-	hostNetwork bool,
+	network rookalpha.NetworkSpec,
 	resources v1.ResourceRequirements,
 	ownerRef metav1.OwnerReference,
 ) *Cluster {
@@ -101,7 +103,8 @@ func New(
 		cephVersion:     cephVersion,
 		Storage:         storageSpec,
 		dataDirHostPath: dataDirHostPath,
-		HostNetwork:     hostNetwork,
+		HostNetwork:     network.HostNetwork,
+		network:         network,
 		resources:       resources,
 		ownerRef:        ownerRef,
 		kv:              k8sutil.NewConfigMapKVStore(namespace, context.Clientset, ownerRef),
@@ -119,6 +122,8 @@ type OSDInfo struct {
 	IsFileStore    bool   `json:"is-file-store"`
 	IsDirectory    bool   `json:"is-directory"`
 	DevicePartUUID string `json:"device-part-uuid"`
+	IsEncrypted    bool   `json:"is-encrypted"`
+	IsCephVolume   bool   `json:"is-ceph-volume"`
 }
 
 type OrchestrationStatus struct {
@@ -131,7 +136,7 @@ type OrchestrationStatus struct {
 func (c *Cluster) Start() error {
 	logger.Infof("start running osds in namespace %s", c.Namespace)
 
-	if c.Storage.UseAllNodes == false && len(c.Storage.Nodes) == 0 {
+	if c.Storage.UseAllNodes == false && len(c.Storage.Nodes) == 0 && len(c.Storage.StorageClassDeviceSets) == 0 {
 		logger.Warningf("useAllNodes is set to false and no nodes are specified, no OSD pods are going to be created")
 	}
 
@@ -174,8 +179,8 @@ func (c *Cluster) Start() error {
 		logger.Debugf("storage nodes: %+v", c.Storage.Nodes)
 	}
 	validNodes := k8sutil.GetValidNodes(c.Storage.Nodes, c.context.Clientset, c.placement)
-	// no valid node is ready to run an osd
-	if len(validNodes) == 0 {
+	// no valid node is ready to run an osd, and there are no pvcs for osds that can run on any node
+	if len(validNodes) == 0 && len(c.Storage.StorageClassDeviceSets) == 0 {
 		logger.Warningf("no valid node available to run an osd in namespace %s", c.Namespace)
 		return nil
 	}
@@ -191,13 +196,20 @@ func (c *Cluster) Start() error {
 	logger.Infof("start provisioning the osds on nodes, if needed")
 	c.startProvisioning(config)
 
+	// start the jobs to provision the OSDs on the pvcs of the device sets
+	logger.Infof("start provisioning the osds on pvcs, if needed")
+	c.startProvisioningDeviceSets(config)
+
 	// start the OSD pods, waiting for the provisioning to be completed
 	logger.Infof("start osds after provisioning is completed, if needed")
 	c.completeProvision(config)
 
-	// handle the removed nodes and rebalance the PGs
-	logger.Infof("checking if any nodes were removed")
-	c.handleRemovedNodes(config)
+	// handle the removed nodes and rebalance the PGs. when no node is valid, the osds of the nodes are not
+	// removed since the nodes are likely only temporarily unavailable.
+	if len(validNodes) > 0 {
+		logger.Infof("checking if any nodes were removed")
+		c.handleRemovedNodes(config)
+	}
 
 	if len(config.errorMessages) > 0 {
 		return fmt.Errorf("%d failures encountered while running osds in namespace %s: %+v",
@@ -213,55 +225,72 @@ func (c *Cluster) startProvisioning(config *provisionConfig) {
 
 	// start with nodes currently in the storage spec
 	for _, node := range c.Storage.Nodes {
-		// fully resolve the storage config and resources for this node
-		n := c.resolveNode(node.Name)
-		if n == nil {
-			logger.Warningf("node %s did not resolve", node.Name)
-			continue
-		}
+		c.startProvisioningNode(node.Name, config)
+	}
+}
 
-		if n.Name == "" {
-			logger.Warningf("skipping node with a blank name! %+v", n)
-			continue
-		}
+// provisionNode provisions the osds of a single node and starts their daemons, such as after a disk of the node was replaced
+func (c *Cluster) provisionNode(nodeName string) error {
+	config := newProvisionConfig()
+	config.devicesToUse = map[string][]rookalpha.Device{}
+	c.startProvisioningNode(nodeName, config)
+	c.completeProvision(config)
 
-		// update the orchestration status of this node to the starting state
-		status := OrchestrationStatus{Status: OrchestrationStatusStarting}
-		if err := c.updateNodeStatus(n.Name, status); err != nil {
-			config.addError("failed to set orchestration starting status for node %s: %+v", n.Name, err)
-			continue
-		}
-		config.devicesToUse[n.Name] = n.Devices
-		availDev, deviceErr := discover.GetAvailableDevices(c.context, n.Name, c.Namespace, n.Devices, n.Selection.DeviceFilter, n.Selection.GetUseAllDevices())
-		if deviceErr != nil {
-			logger.Warningf("failed to get devices for node %s cluster %s: %v", n.Name, c.Namespace, deviceErr)
-		} else {
-			config.devicesToUse[n.Name] = availDev
-			logger.Infof("avail devices for node %s: %+v", n.Name, availDev)
-		}
-		if len(availDev) == 0 && len(c.dataDirHostPath) == 0 {
-			config.addError("empty volumes for node %s", n.Name)
-			continue
-		}
+	if len(config.errorMessages) > 0 {
+		return fmt.Errorf("failed to provision osds on node %s: %+v", nodeName, strings.Join(config.errorMessages, "\n"))
+	}
+	return nil
+}
 
-		// create the job that prepares osds on the node
-		storeConfig := osdconfig.ToStoreConfig(n.Config)
-		metadataDevice := osdconfig.MetadataDevice(n.Config)
-		job, err := c.makeJob(n.Name, config.devicesToUse[n.Name], n.Selection, n.Resources, storeConfig, metadataDevice, n.Location)
-		if err != nil {
-			message := fmt.Sprintf("failed to create prepare job node %s: %v", n.Name, err)
-			config.addError(message)
-			status := OrchestrationStatus{Status: OrchestrationStatusCompleted, Message: message}
-			if err := c.updateNodeStatus(n.Name, status); err != nil {
-				config.addError("failed to update node %s status. %+v", n.Name, err)
-				continue
-			}
+func (c *Cluster) startProvisioningNode(nodeName string, config *provisionConfig) {
+	// fully resolve the storage config and resources for this node
+	n := c.resolveNode(nodeName)
+	if n == nil {
+		logger.Warningf("node %s did not resolve", nodeName)
+		return
+	}
+
+	if n.Name == "" {
+		logger.Warningf("skipping node with a blank name! %+v", n)
+		return
+	}
+
+	// update the orchestration status of this node to the starting state
+	status := OrchestrationStatus{Status: OrchestrationStatusStarting}
+	if err := c.updateNodeStatus(n.Name, status); err != nil {
+		config.addError("failed to set orchestration starting status for node %s: %+v", n.Name, err)
+		return
+	}
+	config.devicesToUse[n.Name] = n.Devices
+	availDev, deviceErr := discover.GetAvailableDevices(c.context, n.Name, c.Namespace, n.Devices, n.Selection.DeviceFilter, n.Selection.GetUseAllDevices())
+	if deviceErr != nil {
+		logger.Warningf("failed to get devices for node %s cluster %s: %v", n.Name, c.Namespace, deviceErr)
+	} else {
+		config.devicesToUse[n.Name] = availDev
+		logger.Infof("avail devices for node %s: %+v", n.Name, availDev)
+	}
+	if len(availDev) == 0 && len(c.dataDirHostPath) == 0 {
+		config.addError("empty volumes for node %s", n.Name)
+		return
+	}
+
+	// create the job that prepares osds on the node
+	storeConfig := osdconfig.ToStoreConfig(n.Config)
+	metadataDevice := osdconfig.MetadataDevice(n.Config)
+	job, err := c.makeJob(n.Name, config.devicesToUse[n.Name], n.Selection, n.Resources, storeConfig, metadataDevice, n.Location)
+	if err != nil {
+		message := fmt.Sprintf("failed to create prepare job node %s: %v", n.Name, err)
+		config.addError(message)
+		status := OrchestrationStatus{Status: OrchestrationStatusCompleted, Message: message}
+		if err := c.updateNodeStatus(n.Name, status); err != nil {
+			config.addError("failed to update node %s status. %+v", n.Name, err)
+			return
 		}
+	}
 
-		if !c.runJob(job, n.Name, config, "provision") {
-			if err = discover.FreeDevices(c.context, n.Name, c.Namespace); err != nil {
-				logger.Warningf("failed to free devices: %s", err)
-			}
+	if !c.runJob(job, n.Name, config, "provision") {
+		if err = discover.FreeDevices(c.context, n.Name, c.Namespace); err != nil {
+			logger.Warningf("failed to free devices: %s", err)
 		}
 	}
 }
@@ -287,8 +316,14 @@ func (c *Cluster) startOSDDaemonsOnNode(nodeName string, config *provisionConfig
 	osds := status.OSDs
 	logger.Infof("starting %d osd daemons on node %s", len(osds), nodeName)
 
-	// fully resolve the storage config and resources for this node
-	n := c.resolveNode(nodeName)
+	// fully resolve the storage config and resources for this node, or for the pvc of a device set
+	set := c.resolveDeviceSet(nodeName)
+	var n *rookalpha.Node
+	if set != nil {
+		n = c.deviceSetNode(nodeName, set)
+	} else {
+		n = c.resolveNode(nodeName)
+	}
 	if n == nil {
 		config.addError("node %s did not resolve to start osds", nodeName)
 		return
@@ -300,7 +335,13 @@ func (c *Cluster) startOSDDaemonsOnNode(nodeName string, config *provisionConfig
 	// start osds
 	for _, osd := range osds {
 		logger.Debugf("start osd %v", osd)
-		dp, err := c.makeDeployment(n.Name, config.devicesToUse[n.Name], n.Selection, n.Resources, storeConfig, metadataDevice, n.Location, osd)
+		var dp *extensions.Deployment
+		var err error
+		if set != nil {
+			dp, err = c.makeDeviceSetDeployment(n.Name, set, osd)
+		} else {
+			dp, err = c.makeDeployment(n.Name, config.devicesToUse[n.Name], n.Selection, n.Resources, storeConfig, metadataDevice, n.Location, osd)
+		}
 		if err != nil {
 			errMsg := fmt.Sprintf("nil deployment for node %s: %v", n.Name, err)
 			config.addError(errMsg)
@@ -441,7 +482,7 @@ func (c *Cluster) cleanupRemovedNode(config *provisionConfig, nodeName, crushNam
 
 func (c *Cluster) discoverStorageNodes() (map[string][]*extensions.Deployment, error) {
 
-	listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("app=%s", appName)}
+	listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("app=%s", AppName)}
 	osdDeployments, err := c.context.Clientset.Extensions().Deployments(c.Namespace).List(listOpts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list osd deployment: %+v", err)
@@ -450,8 +491,12 @@ func (c *Cluster) discoverStorageNodes() (map[string][]*extensions.Deployment, e
 	for _, osdDeployment := range osdDeployments.Items {
 		osdPodSpec := osdDeployment.Spec.Template.Spec
 
-		// get the node name from the node selector
+		// get the node name from the node selector. the osds on the pvcs of device sets are not bound
+		// to a node, so they are grouped by their pvc instead.
 		nodeName, ok := osdPodSpec.NodeSelector[apis.LabelHostname]
+		if pvcName, isPVC := osdDeployment.Labels[pvcLabelKey]; isPVC {
+			nodeName, ok = pvcName, true
+		}
 		if !ok || nodeName == "" {
 			return nil, fmt.Errorf("osd deployment %s doesn't have a node name on its node selector: %+v", osdDeployment.Name, osdPodSpec.NodeSelector)
 		}
@@ -545,6 +590,7 @@ func (c *Cluster) resolveNode(nodeName string) *rookalpha.Node {
 		return nil
 	}
 	rookNode.Resources = k8sutil.MergeResourceRequirements(rookNode.Resources, c.resources)
+	rookNode.Location = c.nodeTopologyLocation(nodeName, rookNode.Location)
 
 	// ensure no invalid dirs are specified
 	var validDirs []rookalpha.Directory
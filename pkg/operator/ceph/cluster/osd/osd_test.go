@@ -42,7 +42,7 @@ import (
 func TestStart(t *testing.T) {
 	clientset := fake.NewSimpleClientset()
 	c := New(&clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "myversion", cephv1beta1.CephVersionSpec{}, "",
-		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, rookalpha.NetworkSpec{}, v1.ResourceRequirements{}, metav1.OwnerReference{})
 
 	// Start the first time
 	err := c.Start()
@@ -91,7 +91,7 @@ func createNode(nodeName string, condition v1.NodeConditionType, clientset *fake
 func TestLegacyDeployment(t *testing.T) {
 	clientset := fake.NewSimpleClientset()
 	c := New(&clusterd.Context{Clientset: clientset}, "ns", "myversion", cephv1beta1.CephVersionSpec{}, "",
-		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, rookalpha.NetworkSpec{}, v1.ResourceRequirements{}, metav1.OwnerReference{})
 
 	osdID := 23
 	d := &extensions.Deployment{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf(legacyAppNameFmt, osdID), Namespace: c.Namespace}}
@@ -146,7 +146,7 @@ func TestAddRemoveNode(t *testing.T) {
 	clientset.PrependWatchReactor("configmaps", k8stesting.DefaultWatchReactor(statusMapWatcher, nil))
 
 	c := New(&clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns-add-remove", "myversion", cephv1beta1.CephVersionSpec{}, "",
-		storageSpec, "", rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		storageSpec, "", rookalpha.Placement{}, rookalpha.NetworkSpec{}, v1.ResourceRequirements{}, metav1.OwnerReference{})
 
 	// kick off the start of the orchestration in a goroutine
 	var startErr error
@@ -175,7 +175,7 @@ func TestAddRemoveNode(t *testing.T) {
 	// simulate the OSD pod having been created
 	osdPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
 		Name:   "osdPod",
-		Labels: map[string]string{k8sutil.AppAttr: appName}}}
+		Labels: map[string]string{k8sutil.AppAttr: AppName}}}
 	c.context.Clientset.CoreV1().Pods(c.Namespace).Create(osdPod)
 
 	// mock the ceph calls that will be called during remove node
@@ -231,7 +231,7 @@ func TestAddRemoveNode(t *testing.T) {
 	// modify the storage spec to remove the node from the cluster
 	storageSpec.Nodes = []rookalpha.Node{}
 	c = New(&clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: mockExec}, "ns-add-remove", "myversion", cephv1beta1.CephVersionSpec{}, "",
-		storageSpec, "", rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		storageSpec, "", rookalpha.Placement{}, rookalpha.NetworkSpec{}, v1.ResourceRequirements{}, metav1.OwnerReference{})
 
 	// reset the orchestration status watcher
 	statusMapWatcher = watch.NewFake()
@@ -270,7 +270,7 @@ func TestGetIDFromDeployment(t *testing.T) {
 
 func TestDiscoverOSDs(t *testing.T) {
 	c := New(&clusterd.Context{}, "ns", "myversion", cephv1beta1.CephVersionSpec{}, "",
-		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, rookalpha.NetworkSpec{}, v1.ResourceRequirements{}, metav1.OwnerReference{})
 	node1 := "n1"
 	node2 := "n2"
 
@@ -338,7 +338,7 @@ func TestAddNodeFailure(t *testing.T) {
 	assert.Nil(t, cmErr)
 
 	c := New(&clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns-add-remove", "myversion", cephv1beta1.CephVersionSpec{}, "",
-		storageSpec, "", rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		storageSpec, "", rookalpha.Placement{}, rookalpha.NetworkSpec{}, v1.ResourceRequirements{}, metav1.OwnerReference{})
 
 	// kick off the start of the orchestration in a goroutine
 	var startErr error
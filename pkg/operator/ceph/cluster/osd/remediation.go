@@ -0,0 +1,220 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	osdconfig "github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/kubernetes/pkg/kubelet/apis"
+)
+
+const (
+	osdRemediatedReason         = "OSDRemediated"
+	osdRemediationFailedReason  = "OSDRemediationFailed"
+	osdRemediationBlockedReason = "OSDRemediationBlocked"
+
+	defaultRemediationInterval = 10 * time.Minute
+	defaultMaxDownOSDs         = 1
+)
+
+// remediationAction is an action taken on an osd that is down for longer than the grace period
+type remediationAction int
+
+const (
+	remediationNone remediationAction = iota
+	remediationRestart
+	remediationMarkOut
+	remediationReplace
+)
+
+func (a remediationAction) String() string {
+	switch a {
+	case remediationRestart:
+		return "restart"
+	case remediationMarkOut:
+		return "mark out"
+	case remediationReplace:
+		return "replace"
+	}
+	return "none"
+}
+
+// nextRemediationAction returns the next action of the policy that is enabled after the last action taken on an osd
+func nextRemediationAction(last remediationAction, restart, markOut, replace bool) remediationAction {
+	if last < remediationRestart && restart {
+		return remediationRestart
+	}
+	if last < remediationMarkOut && markOut {
+		return remediationMarkOut
+	}
+	if last < remediationReplace && replace {
+		return remediationReplace
+	}
+	return remediationNone
+}
+
+// remediate takes the next action of the remediation policy on an osd that is down for longer than the grace period.
+// A single action is taken in the cluster per interval, and no action is taken when too many osds are down.
+func (m *Monitor) remediate(id int, in bool, downOSDs int) {
+	m.remediationMutex.Lock()
+	policy := m.remediation
+	m.remediationMutex.Unlock()
+
+	action := nextRemediationAction(m.lastAction[id], policy.Restart, policy.MarkOut, policy.Replace)
+	if action == remediationNone {
+		return
+	}
+
+	maxDownOSDs := policy.MaxDownOSDs
+	if maxDownOSDs <= 0 {
+		maxDownOSDs = defaultMaxDownOSDs
+	}
+	if downOSDs > maxDownOSDs {
+		logger.Warningf("not remediating osd.%d since %d osds are down, more than the maximum of %d", id, downOSDs, maxDownOSDs)
+		m.recordEvent(v1.EventTypeWarning, osdRemediationBlockedReason, "osd.%d was not remediated since %d osds are down, more than the maximum of %d",
+			id, downOSDs, maxDownOSDs)
+		return
+	}
+
+	interval := time.Duration(policy.IntervalMinutes) * time.Minute
+	if interval <= 0 {
+		interval = defaultRemediationInterval
+	}
+	if elapsed := time.Since(m.lastRemediation); elapsed < interval {
+		logger.Infof("waiting %s to remediate osd.%d after the last remediation in the cluster", interval-elapsed, id)
+		return
+	}
+
+	if action == remediationReplace {
+		// the data of the osd must be rebalanced to the other osds before it is purged
+		if in {
+			logger.Infof("waiting for osd.%d to be marked out before it is replaced", id)
+			return
+		}
+		if err := client.IsClusterClean(m.context, m.clusterName); err != nil {
+			logger.Infof("waiting for the data to rebalance before osd.%d is replaced. %+v", id, err)
+			return
+		}
+	}
+
+	logger.Infof("remediating osd.%d with action %s", id, action)
+	m.lastRemediation = time.Now()
+	if err := m.takeRemediationAction(id, action); err != nil {
+		logger.Errorf("failed to %s osd.%d. %+v", action, id, err)
+		m.recordEvent(v1.EventTypeWarning, osdRemediationFailedReason, "failed to %s osd.%d. %+v", action, id, err)
+		return
+	}
+
+	m.lastAction[id] = action
+	m.recordEvent(v1.EventTypeNormal, osdRemediatedReason, "osd.%d was remediated with action %s", id, action)
+	if action == remediationReplace {
+		// the osd does not exist anymore
+		delete(m.lastStatus, id)
+		delete(m.lastAction, id)
+	}
+}
+
+func (m *Monitor) takeRemediationAction(id int, action remediationAction) error {
+	switch action {
+	case remediationRestart:
+		return restartOSD(m.context.Clientset, m.clusterName, id)
+	case remediationMarkOut:
+		return markOSDOut(m.context, m.clusterName, id)
+	case remediationReplace:
+		if m.replaceOSD == nil {
+			return fmt.Errorf("osds cannot be replaced in cluster %s", m.clusterName)
+		}
+		return m.replaceOSD(id)
+	}
+	return nil
+}
+
+// restartOSD deletes the pods of an osd so they are started again by its deployment
+func restartOSD(clientset kubernetes.Interface, namespace string, id int) error {
+	listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%d", osdLabelKey, id)}
+	pods, err := clientset.CoreV1().Pods(namespace).List(listOpts)
+	if err != nil {
+		return fmt.Errorf("failed to list pods of osd.%d. %+v", id, err)
+	}
+	for _, pod := range pods.Items {
+		logger.Infof("deleting pod %s to restart osd.%d", pod.Name, id)
+		if err := clientset.CoreV1().Pods(namespace).Delete(pod.Name, &metav1.DeleteOptions{}); err != nil {
+			return fmt.Errorf("failed to delete pod %s. %+v", pod.Name, err)
+		}
+	}
+	return nil
+}
+
+// ReplaceOSD purges an osd that is out of the cluster and provisions the osds of its node again, which creates
+// a new osd on the disk that replaced the disk of the purged osd
+func (c *Cluster) ReplaceOSD(id int) error {
+	deploymentName := fmt.Sprintf(osdAppNameFmt, id)
+	dp, err := c.context.Clientset.Extensions().Deployments(c.Namespace).Get(deploymentName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get deployment of osd.%d. %+v", id, err)
+	}
+	if isDeviceSetDeployment(dp) {
+		return fmt.Errorf("osd.%d is on pvc %s of a device set, which cannot be replaced", id, dp.Labels[pvcLabelKey])
+	}
+	nodeName := dp.Spec.Template.Spec.NodeSelector[apis.LabelHostname]
+	if nodeName == "" {
+		return fmt.Errorf("osd deployment %s doesn't have a node name on its node selector", deploymentName)
+	}
+
+	logger.Infof("replacing osd.%d on node %s", id, nodeName)
+	if err := k8sutil.DeleteDeployment(c.context.Clientset, c.Namespace, deploymentName); err != nil {
+		return fmt.Errorf("failed to delete deployment %s. %+v", deploymentName, err)
+	}
+	if err := purgeOSD(c.context, c.Namespace, id); err != nil {
+		return fmt.Errorf("failed to purge osd.%d from the cluster. %+v", id, err)
+	}
+	if err := deleteOSDFileSystem(c.context.Clientset, c.Namespace, id); err != nil {
+		logger.Warningf("failed to delete osd.%d filesystem, it may need to be cleaned up manually: %+v", id, err)
+	}
+	// the new osd on the replaced disk is encrypted with a new key
+	if err := deleteOSDEncryptionKey(c.context.Clientset, c.Namespace, id); err != nil {
+		logger.Warningf("failed to delete osd.%d encryption key, it may need to be cleaned up manually: %+v", id, err)
+	}
+
+	// forget the partitions of the purged osd so the replaced disk is provisioned as a new osd
+	if err := c.removeFromScheme(nodeName, id); err != nil {
+		logger.Warningf("failed to remove osd.%d from the partition scheme of node %s. %+v", id, nodeName, err)
+	}
+
+	return c.provisionNode(nodeName)
+}
+
+func (c *Cluster) removeFromScheme(nodeName string, id int) error {
+	storeName := osdconfig.GetConfigStoreName(nodeName)
+	scheme, err := osdconfig.LoadScheme(c.kv, storeName)
+	if err != nil {
+		return fmt.Errorf("failed to load the partition scheme. %+v", err)
+	}
+	for _, entry := range scheme.Entries {
+		if entry.ID == id {
+			return osdconfig.RemoveFromScheme(entry, c.kv, storeName)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/kubernetes/pkg/kubelet/apis"
+)
+
+func TestNextRemediationAction(t *testing.T) {
+	assert.Equal(t, remediationNone, nextRemediationAction(remediationNone, false, false, false))
+	assert.Equal(t, remediationRestart, nextRemediationAction(remediationNone, true, true, true))
+	assert.Equal(t, remediationMarkOut, nextRemediationAction(remediationRestart, true, true, true))
+	assert.Equal(t, remediationReplace, nextRemediationAction(remediationMarkOut, true, true, true))
+	assert.Equal(t, remediationNone, nextRemediationAction(remediationReplace, true, true, true))
+
+	// the actions that are not enabled are skipped
+	assert.Equal(t, remediationMarkOut, nextRemediationAction(remediationNone, false, true, false))
+	assert.Equal(t, remediationReplace, nextRemediationAction(remediationNone, false, false, true))
+	assert.Equal(t, remediationNone, nextRemediationAction(remediationRestart, true, false, false))
+}
+
+func TestRemediateOSD(t *testing.T) {
+	var outArgs []string
+	clean := false
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "osd" && args[1] == "out" {
+				outArgs = args
+			}
+			if args[0] == "status" {
+				if clean {
+					return `{"pgmap":{"num_pgs":10,"pgs_by_state":[{"state_name":"active+clean","count":10}]}}`, nil
+				}
+				return `{"pgmap":{"num_pgs":10,"pgs_by_state":[{"state_name":"active+clean","count":5},{"state_name":"active+undersized","count":5}]}}`, nil
+			}
+			return "", nil
+		},
+	}
+	clientset := fake.NewSimpleClientset()
+	context := &clusterd.Context{Clientset: clientset, Executor: executor}
+	clientset.CoreV1().Pods("ns").Create(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "osd3", Namespace: "ns", Labels: map[string]string{osdLabelKey: "3"}}})
+	clientset.CoreV1().Pods("ns").Create(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "osd4", Namespace: "ns", Labels: map[string]string{osdLabelKey: "4"}}})
+
+	var replaced []int
+	policy := cephv1beta1.OSDRemediationSpec{Restart: true, MarkOut: true, Replace: true, MaxDownOSDs: 2}
+	m := NewMonitor(context, "ns", metav1.OwnerReference{}, policy, func(id int) error {
+		replaced = append(replaced, id)
+		return nil
+	})
+
+	// no action is taken when too many osds are down
+	m.remediate(3, true, 3)
+	assert.Equal(t, remediationNone, m.lastAction[3])
+
+	// the pod of the osd is restarted first
+	m.remediate(3, true, 2)
+	assert.Equal(t, remediationRestart, m.lastAction[3])
+	pods, _ := clientset.CoreV1().Pods("ns").List(metav1.ListOptions{})
+	assert.Equal(t, 1, len(pods.Items))
+	assert.Equal(t, "osd4", pods.Items[0].Name)
+
+	// the next action is rate limited
+	m.remediate(3, true, 1)
+	assert.Equal(t, remediationRestart, m.lastAction[3])
+
+	// the osd is marked out after the interval
+	m.lastRemediation = time.Now().Add(-11 * time.Minute)
+	m.remediate(3, true, 1)
+	assert.Equal(t, remediationMarkOut, m.lastAction[3])
+	assert.Equal(t, []string{"osd", "out", "3"}, outArgs[:3])
+
+	// the osd is not replaced until it is out and the data is rebalanced
+	m.lastRemediation = time.Time{}
+	m.remediate(3, true, 1)
+	m.remediate(3, false, 1)
+	assert.Equal(t, 0, len(replaced))
+	clean = true
+	m.remediate(3, false, 1)
+	assert.Equal(t, []int{3}, replaced)
+	_, tracked := m.lastAction[3]
+	assert.False(t, tracked)
+
+	// a failed action is taken again after the interval
+	m.lastRemediation = time.Time{}
+	m.replaceOSD = func(id int) error { return fmt.Errorf("mock failure") }
+	m.SetRemediation(cephv1beta1.OSDRemediationSpec{Replace: true})
+	m.remediate(4, false, 1)
+	assert.Equal(t, remediationNone, m.lastAction[4])
+	assert.NotEqual(t, time.Time{}, m.lastRemediation)
+}
+
+func TestReplaceOSD(t *testing.T) {
+	var purged []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			purged = append(purged, args[0]+" "+args[1])
+			return "", nil
+		},
+	}
+	clientset := fake.NewSimpleClientset()
+	c := New(&clusterd.Context{Clientset: clientset, Executor: executor}, "ns", "myversion", cephv1beta1.CephVersionSpec{}, "",
+		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, rookalpha.NetworkSpec{}, v1.ResourceRequirements{}, metav1.OwnerReference{})
+
+	dp := &extensions.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-2", Namespace: "ns"}}
+	dp.Spec.Template.Spec.NodeSelector = map[string]string{apis.LabelHostname: "node1"}
+	clientset.Extensions().Deployments("ns").Create(dp)
+	clientset.CoreV1().Secrets("ns").Create(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: config.EncryptionKeySecretName(2), Namespace: "ns"}})
+	clientset.CoreV1().Secrets("ns").Create(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: config.EncryptionKeySecretName(3), Namespace: "ns"}})
+
+	// the osd is purged and its encryption key is deleted
+	err := c.ReplaceOSD(2)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"osd crush", "auth del", "osd rm"}, purged)
+	_, err = clientset.Extensions().Deployments("ns").Get("rook-ceph-osd-2", metav1.GetOptions{})
+	assert.True(t, errors.IsNotFound(err))
+	_, err = clientset.CoreV1().Secrets("ns").Get(config.EncryptionKeySecretName(2), metav1.GetOptions{})
+	assert.True(t, errors.IsNotFound(err))
+	_, err = clientset.CoreV1().Secrets("ns").Get(config.EncryptionKeySecretName(3), metav1.GetOptions{})
+	assert.Nil(t, err)
+}
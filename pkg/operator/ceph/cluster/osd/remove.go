@@ -82,6 +82,11 @@ func removeOSD(context *clusterd.Context, namespace, deploymentName string, id i
 		logger.Warningf("failed to delete osd.%d filesystem, it may need to be cleaned up manually: %+v", id, err)
 	}
 
+	// delete the encryption key of the OSD, if it was encrypted
+	if err := deleteOSDEncryptionKey(context.Clientset, namespace, id); err != nil {
+		logger.Warningf("failed to delete osd.%d encryption key, it may need to be cleaned up manually: %+v", id, err)
+	}
+
 	return nil
 }
 
@@ -227,6 +232,14 @@ func deleteOSDFileSystem(clientset kubernetes.Interface, namespace string, id in
 	return nil
 }
 
+func deleteOSDEncryptionKey(clientset kubernetes.Interface, namespace string, id int) error {
+	err := clientset.CoreV1().Secrets(namespace).Delete(config.EncryptionKeySecretName(id), &metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
 func (c *Cluster) cleanUpNodeResources(nodeName, nodeCrushName string) error {
 
 	if nodeCrushName != "" {
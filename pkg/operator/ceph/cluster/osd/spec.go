@@ -22,6 +22,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -38,14 +39,19 @@ import (
 )
 
 const (
-	dataDirsEnvVarName          = "ROOK_DATA_DIRECTORIES"
-	osdStoreEnvVarName          = "ROOK_OSD_STORE"
-	osdDatabaseSizeEnvVarName   = "ROOK_OSD_DATABASE_SIZE"
-	osdWalSizeEnvVarName        = "ROOK_OSD_WAL_SIZE"
-	osdJournalSizeEnvVarName    = "ROOK_OSD_JOURNAL_SIZE"
-	osdMetadataDeviceEnvVarName = "ROOK_METADATA_DEVICE"
-	rookBinariesMountPath       = "/rook"
-	rookBinariesVolumeName      = "rook-binaries"
+	dataDirsEnvVarName           = "ROOK_DATA_DIRECTORIES"
+	osdStoreEnvVarName           = "ROOK_OSD_STORE"
+	osdDatabaseSizeEnvVarName    = "ROOK_OSD_DATABASE_SIZE"
+	osdWalSizeEnvVarName         = "ROOK_OSD_WAL_SIZE"
+	osdJournalSizeEnvVarName     = "ROOK_OSD_JOURNAL_SIZE"
+	osdMetadataDeviceEnvVarName  = "ROOK_METADATA_DEVICE"
+	deviceClassesEnvVarName      = "ROOK_DEVICE_CLASSES"
+	osdEncryptedDeviceEnvVarName = "ROOK_OSD_ENCRYPTED_DEVICE"
+	encryptedDevicesEnvVarName   = "ROOK_ENCRYPTED_DEVICES"
+	osdBackendEnvVarName         = "ROOK_OSD_BACKEND"
+	osdCephVolumeEnvVarName      = "ROOK_OSD_CEPH_VOLUME"
+	rookBinariesMountPath        = "/rook"
+	rookBinariesVolumeName       = "rook-binaries"
 )
 
 func (c *Cluster) makeJob(nodeName string, devices []rookalpha.Device,
@@ -105,6 +111,16 @@ func (c *Cluster) makeDeployment(nodeName string, devices []rookalpha.Device, se
 		volumes = append(volumes, devVolume)
 		devMount := v1.VolumeMount{Name: "devices", MountPath: "/dev"}
 		volumeMounts = append(volumeMounts, devMount)
+		if osd.IsEncrypted {
+			// the config init container opens the encrypted partitions before the osd starts
+			configVolumeMounts = append(configVolumeMounts, devMount)
+		}
+		if osd.IsCephVolume {
+			// ceph-volume finds the logical volumes of the osd when it activates the osd
+			udevVolume := v1.Volume{Name: "udev", VolumeSource: v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: "/run/udev"}}}
+			volumes = append(volumes, udevVolume)
+			volumeMounts = append(volumeMounts, v1.VolumeMount{Name: "udev", MountPath: "/run/udev"})
+		}
 	}
 
 	if len(volumes) == 0 {
@@ -120,10 +136,15 @@ func (c *Cluster) makeDeployment(nodeName string, devices []rookalpha.Device, se
 		tiniEnvVar,
 	}
 	envVars = append(envVars, k8sutil.ClusterDaemonEnvVars()...)
+	// the filestore device command selects the addresses of the osd in the networks
+	envVars = append(envVars, opspec.NetworkEnvVars(c.network)...)
 	configEnvVars := append(c.getConfigEnvVars(storeConfig, dataDir, nodeName, location), []v1.EnvVar{
 		tiniEnvVar,
 		{Name: "ROOK_OSD_ID", Value: osdID},
 	}...)
+	if osd.IsCephVolume {
+		configEnvVars = append(configEnvVars, v1.EnvVar{Name: osdCephVolumeEnvVarName, Value: "true"})
+	}
 
 	commonArgs := []string{
 		"--foreground",
@@ -141,13 +162,37 @@ func (c *Cluster) makeDeployment(nodeName string, devices []rookalpha.Device, se
 	var command []string
 	var args []string
 	var copyBinariesContainer *v1.Container
-	if !osd.IsDirectory && osd.IsFileStore {
+	if osd.IsCephVolume {
+		// ceph-volume mounts the data dir of the osd when it activates the osd, so rook activates the osd and
+		// runs the ceph-osd daemon in the same container
+		command = []string{path.Join(rookBinariesMountPath, "tini")}
+		args = []string{
+			"--", path.Join(rookBinariesMountPath, "rook"),
+			"ceph", "osd", "activate",
+			"--osd-id", osdID,
+			"--osd-uuid", osd.UUID,
+		}
+		if osd.IsFileStore {
+			args = append(args, "--filestore")
+		}
+		args = append(append(args, "--"), commonArgs...)
+
+		var copyBinariesVolume v1.Volume
+		copyBinariesVolume, copyBinariesContainer = c.getCopyBinariesContainer()
+		volumes = append(volumes, copyBinariesVolume)
+		volumeMounts = append(volumeMounts, copyBinariesContainer.VolumeMounts[0])
+
+	} else if !osd.IsDirectory && osd.IsFileStore {
 		// All scenarios except one can call the ceph-osd daemon directly. The one different scenario is when
 		// filestore is running on a device. Rook needs to mount the device, run the ceph-osd daemon, and then
 		// when the daemon exits, rook needs to unmount the device. Since rook needs to be in the container
 		// for this scenario, we will copy the binaries necessary to a mount, which will then be mounted
 		// to the daemon container.
 		sourcePath := path.Join("/dev/disk/by-partuuid", osd.DevicePartUUID)
+		if osd.IsEncrypted {
+			// the encrypted partition is opened as a device named after the partition
+			sourcePath = path.Join("/dev/mapper", osd.DevicePartUUID)
+		}
 		command = []string{path.Join(rookBinariesMountPath, "tini")}
 		args = append([]string{
 			"--", path.Join(rookBinariesMountPath, "rook"),
@@ -187,7 +232,7 @@ func (c *Cluster) makeDeployment(nodeName string, devices []rookalpha.Device, se
 			Name:      fmt.Sprintf(osdAppNameFmt, osd.ID),
 			Namespace: c.Namespace,
 			Labels: map[string]string{
-				k8sutil.AppAttr:     appName,
+				k8sutil.AppAttr:     AppName,
 				k8sutil.ClusterAttr: c.Namespace,
 				osdLabelKey:         fmt.Sprintf("%d", osd.ID),
 			},
@@ -198,9 +243,9 @@ func (c *Cluster) makeDeployment(nodeName string, devices []rookalpha.Device, se
 			},
 			Template: v1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Name: appName,
+					Name: AppName,
 					Labels: map[string]string{
-						k8sutil.AppAttr:     appName,
+						k8sutil.AppAttr:     AppName,
 						k8sutil.ClusterAttr: c.Namespace,
 						osdLabelKey:         fmt.Sprintf("%d", osd.ID),
 					},
@@ -245,6 +290,7 @@ func (c *Cluster) makeDeployment(nodeName string, devices []rookalpha.Device, se
 		deployment.Spec.Template.Spec.InitContainers = append(deployment.Spec.Template.Spec.InitContainers, *copyBinariesContainer)
 	}
 	k8sutil.SetOwnerRef(c.context.Clientset, c.Namespace, &deployment.ObjectMeta, &c.ownerRef)
+	opspec.AddNetworkAnnotations(&deployment.Spec.Template.ObjectMeta, c.network, true)
 	c.placement.ApplyToPodSpec(&deployment.Spec.Template.Spec)
 	return deployment, nil
 }
@@ -309,9 +355,9 @@ func (c *Cluster) provisionPodTemplateSpec(devices []rookalpha.Device, selection
 	}
 	c.placement.ApplyToPodSpec(&podSpec)
 
-	return &v1.PodTemplateSpec{
+	podTemplateSpec := &v1.PodTemplateSpec{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: appName,
+			Name: AppName,
 			Labels: map[string]string{
 				k8sutil.AppAttr:     prepareAppName,
 				k8sutil.ClusterAttr: c.Namespace,
@@ -319,7 +365,10 @@ func (c *Cluster) provisionPodTemplateSpec(devices []rookalpha.Device, selection
 			Annotations: map[string]string{},
 		},
 		Spec: podSpec,
-	}, nil
+	}
+	// the osd is prepared with its addresses in the networks
+	opspec.AddNetworkAnnotations(&podTemplateSpec.ObjectMeta, c.network, true)
+	return podTemplateSpec, nil
 }
 
 func (c *Cluster) getConfigEnvVars(storeConfig config.StoreConfig, dataDir, nodeName, location string) []v1.EnvVar {
@@ -332,9 +381,12 @@ func (c *Cluster) getConfigEnvVars(storeConfig config.StoreConfig, dataDir, node
 		opmon.EndpointEnvVar(),
 		opmon.SecretEnvVar(),
 		opmon.AdminSecretEnvVar(),
+		opmon.FSIDEnvVar(),
 		k8sutil.ConfigDirEnvVar(dataDir),
 		k8sutil.ConfigOverrideEnvVar(),
+		k8sutil.CephConfigEnvVar(),
 	}
+	envVars = append(envVars, opspec.NetworkEnvVars(c.network)...)
 
 	if storeConfig.StoreType != "" {
 		envVars = append(envVars, osdStoreEnvVar(storeConfig.StoreType))
@@ -352,6 +404,14 @@ func (c *Cluster) getConfigEnvVars(storeConfig config.StoreConfig, dataDir, node
 		envVars = append(envVars, osdJournalSizeEnvVar(storeConfig.JournalSizeMB))
 	}
 
+	if storeConfig.EncryptedDevice {
+		envVars = append(envVars, osdEncryptedDeviceEnvVar(storeConfig.EncryptedDevice))
+	}
+
+	if storeConfig.Backend != "" {
+		envVars = append(envVars, osdBackendEnvVar(storeConfig.Backend))
+	}
+
 	if location != "" {
 		envVars = append(envVars, rookalpha.LocationEnvVar(location))
 	}
@@ -373,6 +433,12 @@ func (c *Cluster) provisionOSDContainer(devices []rookalpha.Device, selection ro
 			deviceNames[i] = devices[i].Name
 		}
 		envVars = append(envVars, dataDevicesEnvVar(strings.Join(deviceNames, ",")))
+		if classes := config.DeviceClasses(devices); len(classes) > 0 {
+			envVars = append(envVars, deviceClassesEnvVar(classes))
+		}
+		if encrypted := config.EncryptedDevices(devices); len(encrypted) > 0 {
+			envVars = append(envVars, encryptedDevicesEnvVar(encrypted))
+		}
 		devMountNeeded = true
 	} else if selection.DeviceFilter != "" {
 		envVars = append(envVars, deviceFilterEnvVar(selection.DeviceFilter))
@@ -420,7 +486,7 @@ func (c *Cluster) provisionOSDContainer(devices []rookalpha.Device, selection ro
 	return v1.Container{
 		Command:      []string{path.Join(rookBinariesMountPath, "tini")},
 		Args:         []string{"--", path.Join(rookBinariesMountPath, "rook"), "ceph", "osd", "provision"},
-		Name:         "provision",
+		Name:         provisionContainerName,
 		Image:        c.cephVersion.Image,
 		VolumeMounts: volumeMounts,
 		Env:          envVars,
@@ -442,6 +508,26 @@ func dataDevicesEnvVar(dataDevices string) v1.EnvVar {
 	return v1.EnvVar{Name: "ROOK_DATA_DEVICES", Value: dataDevices}
 }
 
+// the device classes are passed as a list of device:class pairs
+func deviceClassesEnvVar(classes map[string]string) v1.EnvVar {
+	var pairs []string
+	for device, class := range classes {
+		pairs = append(pairs, fmt.Sprintf("%s:%s", device, class))
+	}
+	sort.Strings(pairs)
+	return v1.EnvVar{Name: deviceClassesEnvVarName, Value: strings.Join(pairs, ",")}
+}
+
+// whether the osds on the devices are encrypted is passed as a list of device:bool pairs
+func encryptedDevicesEnvVar(encrypted map[string]bool) v1.EnvVar {
+	var pairs []string
+	for device, value := range encrypted {
+		pairs = append(pairs, fmt.Sprintf("%s:%t", device, value))
+	}
+	sort.Strings(pairs)
+	return v1.EnvVar{Name: encryptedDevicesEnvVarName, Value: strings.Join(pairs, ",")}
+}
+
 func deviceFilterEnvVar(filter string) v1.EnvVar {
 	return v1.EnvVar{Name: "ROOK_DATA_DEVICE_FILTER", Value: filter}
 }
@@ -470,6 +556,14 @@ func osdJournalSizeEnvVar(journalSize int) v1.EnvVar {
 	return v1.EnvVar{Name: osdJournalSizeEnvVarName, Value: strconv.Itoa(journalSize)}
 }
 
+func osdEncryptedDeviceEnvVar(encrypted bool) v1.EnvVar {
+	return v1.EnvVar{Name: osdEncryptedDeviceEnvVarName, Value: strconv.FormatBool(encrypted)}
+}
+
+func osdBackendEnvVar(backend string) v1.EnvVar {
+	return v1.EnvVar{Name: osdBackendEnvVarName, Value: backend}
+}
+
 func getDirectoriesFromContainer(osdContainer v1.Container) []rookalpha.Directory {
 	var dirsArg string
 	for _, envVar := range osdContainer.Env {
@@ -506,6 +600,10 @@ func getConfigFromContainer(osdContainer v1.Container) map[string]string {
 			cfg[config.JournalSizeMBKey] = envVar.Value
 		case osdMetadataDeviceEnvVarName:
 			cfg[config.MetadataDeviceKey] = envVar.Value
+		case osdEncryptedDeviceEnvVarName:
+			cfg[config.EncryptedDeviceKey] = envVar.Value
+		case osdBackendEnvVarName:
+			cfg[config.BackendKey] = envVar.Value
 		}
 	}
 
@@ -74,7 +74,7 @@ func testPodDevices(t *testing.T, dataDir, deviceName string, allDevices bool) {
 	clientset := fake.NewSimpleClientset()
 	cephVersion := cephv1beta1.CephVersionSpec{Image: "ceph/ceph:v12.2.8"}
 	c := New(&clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "rook/rook:myversion", cephVersion, "mysa",
-		storageSpec, dataDir, rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		storageSpec, dataDir, rookalpha.Placement{}, rookalpha.NetworkSpec{}, v1.ResourceRequirements{}, metav1.OwnerReference{})
 
 	devMountNeeded := deviceName != "" || allDevices
 
@@ -108,8 +108,8 @@ func testPodDevices(t *testing.T, dataDir, deviceName string, allDevices bool) {
 	assert.Equal(t, "rook-data", deployment.Spec.Template.Spec.Volumes[0].Name)
 	assert.Equal(t, "ceph-default-config-dir", deployment.Spec.Template.Spec.Volumes[1].Name)
 
-	assert.Equal(t, appName, deployment.Spec.Template.ObjectMeta.Name)
-	assert.Equal(t, appName, deployment.Spec.Template.ObjectMeta.Labels["app"])
+	assert.Equal(t, AppName, deployment.Spec.Template.ObjectMeta.Name)
+	assert.Equal(t, AppName, deployment.Spec.Template.ObjectMeta.Labels["app"])
 	assert.Equal(t, c.Namespace, deployment.Spec.Template.ObjectMeta.Labels["rook_cluster"])
 	assert.Equal(t, 0, len(deployment.Spec.Template.ObjectMeta.Annotations))
 
@@ -161,7 +161,7 @@ func TestStorageSpecDevicesAndDirectories(t *testing.T) {
 
 	clientset := fake.NewSimpleClientset()
 	c := New(&clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "rook/rook:myversion", cephv1beta1.CephVersionSpec{}, "",
-		storageSpec, "/var/lib/rook", rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		storageSpec, "/var/lib/rook", rookalpha.Placement{}, rookalpha.NetworkSpec{}, v1.ResourceRequirements{}, metav1.OwnerReference{})
 
 	n := c.Storage.ResolveNode(storageSpec.Nodes[0].Name)
 	osd := OSDInfo{
@@ -248,7 +248,7 @@ func TestStorageSpecConfig(t *testing.T) {
 
 	clientset := fake.NewSimpleClientset()
 	c := New(&clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "rook/rook:myversion", cephv1beta1.CephVersionSpec{}, "",
-		storageSpec, "", rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		storageSpec, "", rookalpha.Placement{}, rookalpha.NetworkSpec{}, v1.ResourceRequirements{}, metav1.OwnerReference{})
 
 	n := c.Storage.ResolveNode(storageSpec.Nodes[0].Name)
 	storeConfig := config.ToStoreConfig(storageSpec.Nodes[0].Config)
@@ -280,6 +280,121 @@ func TestStorageSpecConfig(t *testing.T) {
 	assert.Equal(t, n.Directories, discoveredDirs)
 }
 
+func TestStorageSpecDeviceClasses(t *testing.T) {
+	cluster := &Cluster{Namespace: "myosd", rookVersion: "23"}
+	devices := []rookalpha.Device{
+		{Name: "sda", Config: map[string]string{"deviceClass": "ssd"}},
+		{Name: "sdb"},
+		{Name: "nvme0n1", Config: map[string]string{"deviceClass": "fast"}},
+	}
+	c, err := cluster.provisionPodTemplateSpec(devices, rookalpha.Selection{}, v1.ResourceRequirements{}, config.StoreConfig{}, "", "node", "", v1.RestartPolicyAlways)
+	assert.Nil(t, err)
+	container := c.Spec.Containers[1]
+	verifyEnvVar(t, container.Env, "ROOK_DATA_DEVICES", "sda,sdb,nvme0n1", true)
+	verifyEnvVar(t, container.Env, "ROOK_DEVICE_CLASSES", "nvme0n1:fast,sda:ssd", true)
+
+	// the classes are detected by the osds when none is set in the device config
+	c, err = cluster.provisionPodTemplateSpec(devices[1:2], rookalpha.Selection{}, v1.ResourceRequirements{}, config.StoreConfig{}, "", "node", "", v1.RestartPolicyAlways)
+	assert.Nil(t, err)
+	verifyEnvVar(t, c.Spec.Containers[1].Env, "ROOK_DEVICE_CLASSES", "", false)
+}
+
+func TestStorageSpecEncryptedDevices(t *testing.T) {
+	cluster := &Cluster{Namespace: "myosd", rookVersion: "23"}
+	devices := []rookalpha.Device{
+		{Name: "sda", Config: map[string]string{"encryptedDevice": "false"}},
+		{Name: "sdb"},
+		{Name: "sdc", Config: map[string]string{"encryptedDevice": "true"}},
+	}
+	storeConfig := config.ToStoreConfig(map[string]string{"encryptedDevice": "true"})
+	assert.True(t, storeConfig.EncryptedDevice)
+	c, err := cluster.provisionPodTemplateSpec(devices, rookalpha.Selection{}, v1.ResourceRequirements{}, storeConfig, "", "node", "", v1.RestartPolicyAlways)
+	assert.Nil(t, err)
+	container := c.Spec.Containers[1]
+	verifyEnvVar(t, container.Env, "ROOK_OSD_ENCRYPTED_DEVICE", "true", true)
+	verifyEnvVar(t, container.Env, "ROOK_ENCRYPTED_DEVICES", "sda:false,sdc:true", true)
+	assert.Equal(t, map[string]string{"encryptedDevice": "true"}, getConfigFromContainer(container))
+
+	// the devices are not encrypted by default
+	c, err = cluster.provisionPodTemplateSpec(devices[1:2], rookalpha.Selection{}, v1.ResourceRequirements{}, config.StoreConfig{}, "", "node", "", v1.RestartPolicyAlways)
+	assert.Nil(t, err)
+	verifyEnvVar(t, c.Spec.Containers[1].Env, "ROOK_OSD_ENCRYPTED_DEVICE", "", false)
+	verifyEnvVar(t, c.Spec.Containers[1].Env, "ROOK_ENCRYPTED_DEVICES", "", false)
+}
+
+func TestEncryptedOSDDeployment(t *testing.T) {
+	storageSpec := rookalpha.StorageScopeSpec{Nodes: []rookalpha.Node{{Name: "node1"}}}
+	clientset := fake.NewSimpleClientset()
+	c := New(&clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "rook/rook:myversion", cephv1beta1.CephVersionSpec{}, "",
+		storageSpec, "", rookalpha.Placement{}, rookalpha.NetworkSpec{}, v1.ResourceRequirements{}, metav1.OwnerReference{})
+	n := c.Storage.ResolveNode("node1")
+	devices := []rookalpha.Device{{Name: "sda"}}
+
+	// the config init container opens the encrypted partitions from the /dev of the host
+	osd := OSDInfo{ID: 0, IsEncrypted: true}
+	deployment, err := c.makeDeployment(n.Name, devices, n.Selection, v1.ResourceRequirements{}, config.StoreConfig{}, "", n.Location, osd)
+	assert.Nil(t, err)
+	initCont := deployment.Spec.Template.Spec.InitContainers[0]
+	assert.Equal(t, 4, len(initCont.VolumeMounts))
+	assert.Equal(t, v1.VolumeMount{Name: "devices", MountPath: "/dev"}, initCont.VolumeMounts[3])
+
+	// filestore mounts the opened device of the encrypted partition
+	osd = OSDInfo{ID: 0, IsEncrypted: true, IsFileStore: true, DevicePartUUID: "part-uuid"}
+	deployment, err = c.makeDeployment(n.Name, devices, n.Selection, v1.ResourceRequirements{}, config.StoreConfig{}, "", n.Location, osd)
+	assert.Nil(t, err)
+	cont := deployment.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "/dev/mapper/part-uuid", cont.Args[6])
+
+	osd = OSDInfo{ID: 0}
+	deployment, err = c.makeDeployment(n.Name, devices, n.Selection, v1.ResourceRequirements{}, config.StoreConfig{}, "", n.Location, osd)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(deployment.Spec.Template.Spec.InitContainers[0].VolumeMounts))
+}
+
+func TestCephVolumeOSDDeployment(t *testing.T) {
+	storageSpec := rookalpha.StorageScopeSpec{Nodes: []rookalpha.Node{{Name: "node1"}}}
+	clientset := fake.NewSimpleClientset()
+	c := New(&clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "rook/rook:myversion", cephv1beta1.CephVersionSpec{}, "",
+		storageSpec, "", rookalpha.Placement{}, rookalpha.NetworkSpec{}, v1.ResourceRequirements{}, metav1.OwnerReference{})
+	n := c.Storage.ResolveNode("node1")
+	devices := []rookalpha.Device{{Name: "sda"}}
+	storeConfig := config.ToStoreConfig(map[string]string{"backend": "ceph-volume"})
+	assert.Equal(t, config.CephVolumeBackend, storeConfig.Backend)
+
+	// rook activates the osd with ceph-volume before it runs the osd daemon
+	osd := OSDInfo{ID: 3, UUID: "osd-uuid", DataPath: "/var/lib/ceph/osd/ceph-3", KeyringPath: "/var/lib/ceph/osd/ceph-3/keyring",
+		Config: "/var/lib/rook/osd3/ns.config", Cluster: "ns", IsCephVolume: true}
+	deployment, err := c.makeDeployment(n.Name, devices, n.Selection, v1.ResourceRequirements{}, storeConfig, "", n.Location, osd)
+	assert.Nil(t, err)
+	cont := deployment.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, []string{"/rook/tini"}, cont.Command)
+	assert.Equal(t, []string{"--", "/rook/rook", "ceph", "osd", "activate", "--osd-id", "3", "--osd-uuid", "osd-uuid", "--",
+		"--foreground", "--id", "3", "--conf", "/var/lib/rook/osd3/ns.config", "--osd-data", "/var/lib/ceph/osd/ceph-3",
+		"--keyring", "/var/lib/ceph/osd/ceph-3/keyring", "--cluster", "ns", "--osd-uuid", "osd-uuid"}, cont.Args)
+	assert.Equal(t, v1.VolumeMount{Name: "udev", MountPath: "/run/udev"}, cont.VolumeMounts[3])
+	assert.Equal(t, "copy-bins", deployment.Spec.Template.Spec.InitContainers[1].Name)
+
+	// the config init container writes the config of a ceph-volume osd
+	initCont := deployment.Spec.Template.Spec.InitContainers[0]
+	verifyEnvVar(t, initCont.Env, "ROOK_OSD_CEPH_VOLUME", "true", true)
+	verifyEnvVar(t, initCont.Env, "ROOK_OSD_BACKEND", "ceph-volume", true)
+
+	osd.IsFileStore = true
+	osd.Journal = "/var/lib/ceph/osd/ceph-3/journal"
+	deployment, err = c.makeDeployment(n.Name, devices, n.Selection, v1.ResourceRequirements{}, storeConfig, "", n.Location, osd)
+	assert.Nil(t, err)
+	cont = deployment.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "--filestore", cont.Args[9])
+	assert.Equal(t, "--osd-journal=/var/lib/ceph/osd/ceph-3/journal", cont.Args[len(cont.Args)-1])
+
+	// the backend of the osds is passed to the prepare job
+	job, err := c.makeJob(n.Name, devices, n.Selection, v1.ResourceRequirements{}, storeConfig, "", n.Location)
+	assert.Nil(t, err)
+	container := job.Spec.Template.Spec.Containers[1]
+	verifyEnvVar(t, container.Env, "ROOK_OSD_BACKEND", "ceph-volume", true)
+	assert.Equal(t, map[string]string{"backend": "ceph-volume"}, getConfigFromContainer(container))
+}
+
 func TestHostNetwork(t *testing.T) {
 	storageSpec := rookalpha.StorageScopeSpec{
 		Nodes: []rookalpha.Node{
@@ -298,7 +413,7 @@ func TestHostNetwork(t *testing.T) {
 
 	clientset := fake.NewSimpleClientset()
 	c := New(&clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "myversion", cephv1beta1.CephVersionSpec{}, "",
-		storageSpec, "", rookalpha.Placement{}, true, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		storageSpec, "", rookalpha.Placement{}, rookalpha.NetworkSpec{HostNetwork: true}, v1.ResourceRequirements{}, metav1.OwnerReference{})
 
 	n := c.Storage.ResolveNode(storageSpec.Nodes[0].Name)
 	osd := OSDInfo{
@@ -312,3 +427,30 @@ func TestHostNetwork(t *testing.T) {
 	assert.Equal(t, true, r.Spec.Template.Spec.HostNetwork)
 	assert.Equal(t, v1.DNSClusterFirstWithHostNet, r.Spec.Template.Spec.DNSPolicy)
 }
+
+func TestNetworkAttachments(t *testing.T) {
+	storageSpec := rookalpha.StorageScopeSpec{
+		Nodes: []rookalpha.Node{{Name: "node1"}},
+	}
+	network := rookalpha.NetworkSpec{
+		PublicNetwork:  rookalpha.NetworkSelection{CIDR: "10.1.1.0/24", Attachment: "public"},
+		ClusterNetwork: rookalpha.NetworkSelection{CIDR: "10.1.2.0/24", Attachment: "rook-ceph/cluster"},
+	}
+
+	clientset := fake.NewSimpleClientset()
+	c := New(&clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "myversion", cephv1beta1.CephVersionSpec{}, "",
+		storageSpec, "", rookalpha.Placement{}, network, v1.ResourceRequirements{}, metav1.OwnerReference{})
+
+	n := c.Storage.ResolveNode(storageSpec.Nodes[0].Name)
+	r, err := c.makeDeployment(n.Name, n.Devices, n.Selection, v1.ResourceRequirements{}, config.StoreConfig{}, "", n.Location, OSDInfo{ID: 0})
+	assert.Nil(t, err)
+
+	// the osds are attached to both networks
+	assert.Equal(t, "public,rook-ceph/cluster", r.Spec.Template.Annotations["k8s.v1.cni.cncf.io/networks"])
+	assert.False(t, r.Spec.Template.Spec.HostNetwork)
+
+	// the config of the osd binds it to its addresses in the networks
+	env := r.Spec.Template.Spec.InitContainers[0].Env
+	assert.Contains(t, env, v1.EnvVar{Name: "ROOK_PUBLIC_NETWORK", Value: "10.1.1.0/24"})
+	assert.Contains(t, env, v1.EnvVar{Name: "ROOK_CLUSTER_NETWORK", Value: "10.1.2.0/24"})
+}
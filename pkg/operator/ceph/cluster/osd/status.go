@@ -45,6 +45,7 @@ const (
 	nodeLabelKey                     = "node"
 	completeProvisionTimeout         = 20
 	completeProvisionSkipOSDTimeout  = 5
+	provisionFailedReason            = "OSDProvisionFailed"
 )
 
 type provisionConfig struct {
@@ -67,7 +68,7 @@ func (c *Cluster) updateNodeStatus(node string, status OrchestrationStatus) erro
 
 func UpdateNodeStatus(kv *k8sutil.ConfigMapKVStore, node string, status OrchestrationStatus) error {
 	labels := map[string]string{
-		k8sutil.AppAttr:        appName,
+		k8sutil.AppAttr:        AppName,
 		orchestrationStatusKey: provisioningLabelKey,
 		nodeLabelKey:           node,
 	}
@@ -162,7 +163,7 @@ func (c *Cluster) checkNodesCompleted(selector string, config *provisionConfig,
 
 func (c *Cluster) completeOSDsForAllNodes(config *provisionConfig, configOSDs bool, timeoutMinutes int) bool {
 	selector := fmt.Sprintf("%s=%s,%s=%s",
-		k8sutil.AppAttr, appName,
+		k8sutil.AppAttr, AppName,
 		orchestrationStatusKey, provisioningLabelKey,
 	)
 
@@ -264,6 +265,8 @@ func (c *Cluster) handleStatusConfigMapStatus(nodeName string, config *provision
 
 	if status.Status == OrchestrationStatusFailed {
 		config.addError("orchestration for node %s failed: %+v", nodeName, status)
+		k8sutil.RecordEvent(c.context, k8sutil.OwnerObjectReference(c.Namespace, c.ownerRef), v1.EventTypeWarning, provisionFailedReason,
+			"failed to provision osds on node %s. %s", nodeName, status.Message)
 		return true
 	}
 	return false
@@ -283,6 +286,11 @@ func (c *Cluster) findRemovedNodes() (map[string][]*extensions.Deployment, error
 	}
 
 	for existingNode, osdDeployments := range discoveredNodes {
+		if len(osdDeployments) > 0 && isDeviceSetDeployment(osdDeployments[0]) {
+			// the osds on the pvcs of device sets are not removed with the nodes
+			continue
+		}
+
 		found := false
 		for _, declaredNode := range c.Storage.Nodes {
 			// discovered storage node still exists in the current storage spec, move on to next discovered node
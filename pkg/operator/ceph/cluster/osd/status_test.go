@@ -32,12 +32,13 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
 )
 
 func TestOrchestrationStatus(t *testing.T) {
 	clientset := fake.NewSimpleClientset()
 	c := New(&clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "myversion", cephv1beta1.CephVersionSpec{}, "",
-		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, rookalpha.NetworkSpec{}, v1.ResourceRequirements{}, metav1.OwnerReference{})
 	kv := k8sutil.NewConfigMapKVStore(c.Namespace, clientset, metav1.OwnerReference{})
 	nodeName := "mynode"
 	cmName := fmt.Sprintf(orchestrationStatusMapName, nodeName)
@@ -60,6 +61,25 @@ func TestOrchestrationStatus(t *testing.T) {
 	assert.Equal(t, status, *retrievedStatus)
 }
 
+func TestOrchestrationFailedEvent(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	recorder := record.NewFakeRecorder(10)
+	c := New(&clusterd.Context{Clientset: clientset, Executor: &exectest.MockExecutor{}, Recorder: recorder}, "ns", "myversion", cephv1beta1.CephVersionSpec{}, "",
+		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, rookalpha.NetworkSpec{}, v1.ResourceRequirements{}, metav1.OwnerReference{Name: "ns"})
+
+	// a node that is still orchestrating does not record an event
+	config := newProvisionConfig()
+	s, _ := json.Marshal(OrchestrationStatus{Status: OrchestrationStatusOrchestrating})
+	assert.False(t, c.handleStatusConfigMapStatus("node1", config, &v1.ConfigMap{Data: map[string]string{orchestrationStatusKey: string(s)}}, true))
+	assert.Equal(t, 0, len(recorder.Events))
+
+	// the failure of the node is recorded on the cluster crd
+	s, _ = json.Marshal(OrchestrationStatus{Status: OrchestrationStatusFailed, Message: "no devices"})
+	assert.True(t, c.handleStatusConfigMapStatus("node1", config, &v1.ConfigMap{Data: map[string]string{orchestrationStatusKey: string(s)}}, true))
+	assert.Equal(t, 1, len(config.errorMessages))
+	assert.Equal(t, "Warning OSDProvisionFailed failed to provision osds on node node1. no devices", <-recorder.Events)
+}
+
 func mockNodeOrchestrationCompletion(c *Cluster, nodeName string, statusMapWatcher *watch.FakeWatcher) {
 	// if no valid osd node, don't need to check its status, return immediately
 	if len(c.Storage.Nodes) == 0 {
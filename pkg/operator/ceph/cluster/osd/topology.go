@@ -0,0 +1,217 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	opspec "github.com/rook/rook/pkg/operator/ceph/spec"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/kubelet/apis"
+)
+
+const (
+	// the label of the rack of a node, since kubernetes doesn't define a well known label for racks
+	rackLabel = "topology.rook.io/rack"
+
+	osdLocationChangedReason = "OSDLocationChanged"
+)
+
+var (
+	// the labels of the nodes that set the crush location of the osds when the storage spec doesn't set them
+	defaultTopologyLabels = map[string]string{
+		"region": apis.LabelZoneRegion,
+		"zone":   apis.LabelZoneFailureDomain,
+		"rack":   rackLabel,
+	}
+
+	topologyCheckInterval = 5 * time.Minute
+)
+
+func (c *Cluster) topologyLabels() map[string]string {
+	if c.Storage.TopologyLabels == nil {
+		return defaultTopologyLabels
+	}
+	return c.Storage.TopologyLabels
+}
+
+// topologyLocation adds the crush buckets of the topology labels to the location. The buckets that are
+// already in the location are not changed, so the location in the storage spec takes precedence over the labels.
+func topologyLocation(topologyLabels, labels map[string]string, location string) string {
+	var pairs []string
+	if location != "" {
+		pairs = strings.Split(location, ",")
+	}
+
+	bucketTypes := []string{}
+	for bucketType := range topologyLabels {
+		bucketTypes = append(bucketTypes, bucketType)
+	}
+	sort.Strings(bucketTypes)
+
+	for _, bucketType := range bucketTypes {
+		value := labels[topologyLabels[bucketType]]
+		if value == "" || isLocationFieldSet(bucketType, pairs) {
+			continue
+		}
+		pairs = append(pairs, fmt.Sprintf("%s=%s", bucketType, value))
+	}
+	return strings.Join(pairs, ",")
+}
+
+func isLocationFieldSet(bucketType string, pairs []string) bool {
+	for _, p := range pairs {
+		if strings.HasPrefix(p, bucketType+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeTopologyLocation returns the location of the osds of a node from the topology labels of the node
+func (c *Cluster) nodeTopologyLocation(hostname, location string) string {
+	topologyLabels := c.topologyLabels()
+	if len(topologyLabels) == 0 {
+		return location
+	}
+
+	options := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", apis.LabelHostname, hostname)}
+	nodes, err := c.context.Clientset.CoreV1().Nodes().List(options)
+	if err != nil || len(nodes.Items) == 0 {
+		logger.Warningf("failed to get the topology labels of node %s. %+v", hostname, err)
+		return location
+	}
+	return topologyLocation(topologyLabels, nodes.Items[0].Labels, location)
+}
+
+// pvcTopologyLocation returns the location of the osd on a pvc from the topology labels of the volume bound to
+// the pvc, since the osd follows its pvc across the nodes
+func (c *Cluster) pvcTopologyLocation(pvcName, location string) string {
+	topologyLabels := c.topologyLabels()
+	if len(topologyLabels) == 0 {
+		return location
+	}
+
+	pvc, err := c.context.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Get(pvcName, metav1.GetOptions{})
+	if err != nil || pvc.Spec.VolumeName == "" {
+		// the pvc is not bound yet, the location is updated when the osd is started
+		logger.Debugf("pvc %s is not bound to a volume yet. %+v", pvcName, err)
+		return location
+	}
+	pv, err := c.context.Clientset.CoreV1().PersistentVolumes().Get(pvc.Spec.VolumeName, metav1.GetOptions{})
+	if err != nil {
+		logger.Warningf("failed to get the topology labels of volume %s. %+v", pvc.Spec.VolumeName, err)
+		return location
+	}
+	return topologyLocation(topologyLabels, pv.Labels, location)
+}
+
+// CheckTopology periodically moves the osds to the crush location of the current topology labels of their nodes.
+// The osds are resolved on each check since they are created again when the cluster spec is updated. The lock of
+// the orchestration of the cluster is held during the check so the osds are not updated at the same time.
+func CheckTopology(osds func() *Cluster, orchestrationLock sync.Locker, stopCh chan struct{}) {
+	for {
+		select {
+		case <-time.After(topologyCheckInterval):
+			checkTopology(osds, orchestrationLock)
+
+		case <-stopCh:
+			logger.Infof("stopping the topology check of the osds")
+			return
+		}
+	}
+}
+
+func checkTopology(osds func() *Cluster, orchestrationLock sync.Locker) {
+	orchestrationLock.Lock()
+	defer orchestrationLock.Unlock()
+
+	c := osds()
+	if c == nil {
+		return
+	}
+	if err := c.updateTopology(); err != nil {
+		logger.Warningf("failed to update the topology of the osds in namespace %s. %+v", c.Namespace, err)
+	}
+}
+
+// updateTopology updates the location of the osds whose topology labels changed. The osd is restarted with the
+// new location in its config, and it moves itself in the crush map when it starts.
+func (c *Cluster) updateTopology() error {
+	if len(c.topologyLabels()) == 0 {
+		return nil
+	}
+
+	listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", k8sutil.AppAttr, AppName)}
+	deployments, err := c.context.Clientset.Extensions().Deployments(c.Namespace).List(listOpts)
+	if err != nil {
+		return fmt.Errorf("failed to list osd deployments. %+v", err)
+	}
+
+	for i := range deployments.Items {
+		dp := &deployments.Items[i]
+		var location string
+		if isDeviceSetDeployment(dp) {
+			location = c.pvcTopologyLocation(dp.Labels[pvcLabelKey], c.Storage.Location)
+		} else {
+			n := c.resolveNode(dp.Spec.Template.Spec.NodeSelector[apis.LabelHostname])
+			if n == nil {
+				// the node was removed from the storage spec
+				continue
+			}
+			location = n.Location
+		}
+
+		updated := false
+		containers := dp.Spec.Template.Spec.InitContainers
+		for j := range containers {
+			if containers[j].Name != opspec.ConfigInitContainerName || rookalpha.GetLocationFromContainer(containers[j]) == location {
+				continue
+			}
+			setLocationEnvVar(&containers[j], location)
+			updated = true
+		}
+		if !updated {
+			continue
+		}
+
+		logger.Infof("moving osd deployment %s to location %s", dp.Name, location)
+		if err := k8sutil.UpdateDeploymentAndWait(c.context, dp, c.Namespace); err != nil {
+			return fmt.Errorf("failed to update the location of osd deployment %s. %+v", dp.Name, err)
+		}
+		k8sutil.RecordEvent(c.context, k8sutil.OwnerObjectReference(c.Namespace, c.ownerRef), v1.EventTypeNormal, osdLocationChangedReason,
+			"moved osd %s to crush location %s", dp.Labels[osdLabelKey], location)
+	}
+	return nil
+}
+
+func setLocationEnvVar(container *v1.Container, location string) {
+	for i := range container.Env {
+		if container.Env[i].Name == rookalpha.LocationEnvVarName {
+			container.Env[i].Value = location
+			return
+		}
+	}
+	container.Env = append(container.Env, rookalpha.LocationEnvVar(location))
+}
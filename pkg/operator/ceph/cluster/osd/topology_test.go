@@ -0,0 +1,162 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	opspec "github.com/rook/rook/pkg/operator/ceph/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/kubernetes/pkg/kubelet/apis"
+)
+
+func TestTopologyLocation(t *testing.T) {
+	labels := map[string]string{
+		apis.LabelZoneRegion:        "us-east-1",
+		apis.LabelZoneFailureDomain: "us-east-1a",
+		"other":                     "foo",
+	}
+	assert.Equal(t, "region=us-east-1,zone=us-east-1a", topologyLocation(defaultTopologyLabels, labels, ""))
+
+	// the location of the storage spec takes precedence over the labels
+	assert.Equal(t, "zone=myzone,root=myroot,region=us-east-1", topologyLocation(defaultTopologyLabels, labels, "zone=myzone,root=myroot"))
+
+	// the labels can be mapped to any bucket type
+	assert.Equal(t, "datacenter=foo", topologyLocation(map[string]string{"datacenter": "other"}, labels, ""))
+	assert.Equal(t, "", topologyLocation(map[string]string{}, labels, ""))
+}
+
+func TestNodeTopologyLocation(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	clientset.CoreV1().Nodes().Create(&v1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:   "node1",
+		Labels: map[string]string{apis.LabelHostname: "node1", apis.LabelZoneFailureDomain: "zone1", rackLabel: "rack1"},
+	}})
+	storageSpec := rookalpha.StorageScopeSpec{Location: "root=myroot", Nodes: []rookalpha.Node{{Name: "node1"}}}
+	c := New(&clusterd.Context{Clientset: clientset}, "ns", "myversion", cephv1beta1.CephVersionSpec{}, "",
+		storageSpec, "", rookalpha.Placement{}, rookalpha.NetworkSpec{}, v1.ResourceRequirements{}, metav1.OwnerReference{})
+
+	n := c.resolveNode("node1")
+	require.NotNil(t, n)
+	assert.Equal(t, "root=myroot,rack=rack1,zone=zone1", n.Location)
+
+	// the location is not set from the labels with an empty mapping
+	c.Storage.TopologyLabels = map[string]string{}
+	assert.Equal(t, "root=myroot", c.resolveNode("node1").Location)
+
+	// the location of an unknown node is not changed
+	c.Storage.TopologyLabels = nil
+	assert.Equal(t, "root=myroot", c.nodeTopologyLocation("node2", "root=myroot"))
+}
+
+func TestPVCTopologyLocation(t *testing.T) {
+	c := newDeviceSetCluster()
+	clientset := c.context.Clientset
+
+	// the location of an unbound pvc is not set from the labels
+	_, err := c.createDeviceSetPVC(c.Storage.StorageClassDeviceSets[0], 0)
+	require.Nil(t, err)
+	assert.Equal(t, "", c.pvcTopologyLocation("set1-0", ""))
+
+	clientset.CoreV1().PersistentVolumes().Create(&v1.PersistentVolume{ObjectMeta: metav1.ObjectMeta{
+		Name:   "pv1",
+		Labels: map[string]string{apis.LabelZoneFailureDomain: "zone1"},
+	}})
+	pvc, _ := clientset.CoreV1().PersistentVolumeClaims("ns").Get("set1-0", metav1.GetOptions{})
+	pvc.Spec.VolumeName = "pv1"
+	clientset.CoreV1().PersistentVolumeClaims("ns").Update(pvc)
+	assert.Equal(t, "zone=zone1", c.pvcTopologyLocation("set1-0", ""))
+	assert.Equal(t, "zone=zone1", c.deviceSetNode("set1-0", &c.Storage.StorageClassDeviceSets[0]).Location)
+}
+
+func TestUpdateTopology(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	// complete the rollout of the updated deployments
+	clientset.PrependReactor("update", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		dp := action.(k8stesting.UpdateAction).GetObject().(*extensions.Deployment)
+		dp.Status.ObservedGeneration++
+		dp.Status.UpdatedReplicas = 1
+		dp.Status.ReadyReplicas = 1
+		return false, nil, nil
+	})
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:   "node1",
+		Labels: map[string]string{apis.LabelHostname: "node1", apis.LabelZoneFailureDomain: "zone1"},
+	}}
+	clientset.CoreV1().Nodes().Create(node)
+	recorder := record.NewFakeRecorder(10)
+	storageSpec := rookalpha.StorageScopeSpec{Nodes: []rookalpha.Node{{Name: "node1"}}}
+	c := New(&clusterd.Context{Clientset: clientset, Recorder: recorder}, "ns", "myversion", cephv1beta1.CephVersionSpec{}, "",
+		storageSpec, "", rookalpha.Placement{}, rookalpha.NetworkSpec{}, v1.ResourceRequirements{}, metav1.OwnerReference{})
+
+	n := c.resolveNode("node1")
+	osd := OSDInfo{ID: 1, IsDirectory: true, IsFileStore: true, DataPath: "/rook/path"}
+	dp, err := c.makeDeployment(n.Name, nil, rookalpha.Selection{}, v1.ResourceRequirements{}, config.StoreConfig{}, "", n.Location, osd)
+	require.Nil(t, err)
+	_, err = clientset.Extensions().Deployments("ns").Create(dp)
+	require.Nil(t, err)
+
+	// the osd is not moved when the labels did not change
+	assert.Nil(t, c.updateTopology())
+	assert.Equal(t, 0, len(recorder.Events))
+
+	// the osd is moved to the new zone of its node
+	node.Labels[apis.LabelZoneFailureDomain] = "zone2"
+	clientset.CoreV1().Nodes().Update(node)
+	assert.Nil(t, c.updateTopology())
+	dp, err = clientset.Extensions().Deployments("ns").Get(dp.Name, metav1.GetOptions{})
+	require.Nil(t, err)
+	initContainer := dp.Spec.Template.Spec.InitContainers[0]
+	assert.Equal(t, opspec.ConfigInitContainerName, initContainer.Name)
+	assert.Equal(t, "zone=zone2", rookalpha.GetLocationFromContainer(initContainer))
+	assert.Equal(t, "Normal OSDLocationChanged moved osd 1 to crush location zone=zone2", <-recorder.Events)
+}
+
+func TestCheckTopologyWaitsForOrchestration(t *testing.T) {
+	var lock sync.Mutex
+	resolved := make(chan bool, 1)
+	osds := func() *Cluster {
+		resolved <- true
+		return nil
+	}
+
+	// the osds are not resolved while the cluster is orchestrated
+	lock.Lock()
+	go checkTopology(osds, &lock)
+	select {
+	case <-resolved:
+		assert.Fail(t, "the osds were resolved during the orchestration")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	lock.Unlock()
+	assert.True(t, <-resolved)
+}
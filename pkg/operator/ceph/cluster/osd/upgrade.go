@@ -0,0 +1,104 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+)
+
+const (
+	osdContainerName = "osd"
+)
+
+var (
+	// the time to wait for the placement groups to be clean before upgrading the osds in the next failure domain
+	upgradeCleanRetryInterval = 10 * time.Second
+	upgradeCleanRetries       = 60
+)
+
+// UpdateCephVersion rolls the osds to a new version of ceph. The osds are upgraded one failure domain (node)
+// at a time. All the placement groups must be active+clean before the osds on the next node are restarted.
+// If the cluster does not become clean, the upgrade is paused and an error is returned.
+func (c *Cluster) UpdateCephVersion(cephVersion cephv1beta1.CephVersionSpec) error {
+	c.cephVersion = cephVersion
+
+	nodes, err := c.discoverStorageNodes()
+	if err != nil {
+		return fmt.Errorf("failed to discover the osds to upgrade. %+v", err)
+	}
+
+	nodeNames := []string{}
+	for nodeName := range nodes {
+		nodeNames = append(nodeNames, nodeName)
+	}
+	sort.Strings(nodeNames)
+
+	for _, nodeName := range nodeNames {
+		upgradeNeeded := false
+		for _, d := range nodes[nodeName] {
+			image, err := k8sutil.GetDeploymentSpecImage(c.context.Clientset, *d, osdContainerName)
+			if err != nil {
+				return fmt.Errorf("failed to get the image of osd deployment %s. %+v", d.Name, err)
+			}
+			if image != cephVersion.Image {
+				upgradeNeeded = true
+				break
+			}
+		}
+		if !upgradeNeeded {
+			logger.Infof("osds on node %s are already running image %s", nodeName, cephVersion.Image)
+			continue
+		}
+
+		// don't take down another failure domain until the data is fully replicated
+		if err := c.waitForCleanPGs(); err != nil {
+			return fmt.Errorf("pausing the osd upgrade before node %s. %+v", nodeName, err)
+		}
+
+		logger.Infof("upgrading %d osds on node %s to image %s", len(nodes[nodeName]), nodeName, cephVersion.Image)
+		for _, d := range nodes[nodeName] {
+			for i := range d.Spec.Template.Spec.Containers {
+				if d.Spec.Template.Spec.Containers[i].Name == osdContainerName {
+					d.Spec.Template.Spec.Containers[i].Image = cephVersion.Image
+				}
+			}
+			if err := k8sutil.UpdateDeploymentAndWait(c.context, d, c.Namespace); err != nil {
+				return fmt.Errorf("failed to upgrade osd deployment %s on node %s. %+v", d.Name, nodeName, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *Cluster) waitForCleanPGs() error {
+	var err error
+	for i := 0; i < upgradeCleanRetries; i++ {
+		if err = client.IsClusterClean(c.context, c.Namespace); err == nil {
+			return nil
+		}
+		logger.Infof("waiting for the cluster to be clean before continuing the osd upgrade. %+v", err)
+		time.Sleep(upgradeCleanRetryInterval)
+	}
+	return fmt.Errorf("cluster did not become clean. %+v", err)
+}
@@ -0,0 +1,244 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package rbd for the rbd-mirror daemons of a cluster.
+package rbd
+
+import (
+	"fmt"
+
+	"github.com/coreos/pkg/capnslog"
+	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-rbd-mirror")
+
+const (
+	// AppName is the name of Rook's Ceph rbd-mirror sub-app
+	AppName              = "rook-ceph-rbd-mirror"
+	keyringSecretKeyName = "keyring"
+	// the cluster name rbd-mirror uses for the local cluster, which cannot be the name of a peer
+	localClusterName = "ceph"
+)
+
+// Mirroring represents the rbd-mirror daemons of a cluster
+type Mirroring struct {
+	Namespace   string
+	placement   rookalpha.Placement
+	context     *clusterd.Context
+	HostNetwork bool
+	network     rookalpha.NetworkSpec
+	resources   v1.ResourceRequirements
+	ownerRef    metav1.OwnerReference
+	spec        cephv1beta1.RBDMirroringSpec
+	peers       []string
+	cephVersion cephv1beta1.CephVersionSpec
+	rookVersion string
+}
+
+// New creates an instance of the rbd-mirror daemons
+func New(context *clusterd.Context, namespace, rookVersion string, cephVersion cephv1beta1.CephVersionSpec, placement rookalpha.Placement, network rookalpha.NetworkSpec,
+	spec cephv1beta1.RBDMirroringSpec, resources v1.ResourceRequirements, ownerRef metav1.OwnerReference) *Mirroring {
+	return &Mirroring{
+		context:     context,
+		Namespace:   namespace,
+		placement:   placement,
+		rookVersion: rookVersion,
+		cephVersion: cephVersion,
+		spec:        spec,
+		HostNetwork: network.HostNetwork,
+		network:     network,
+		resources:   resources,
+		ownerRef:    ownerRef,
+	}
+}
+
+// ValidatePeers returns an error if a peer is not a valid name for a peer cluster
+func ValidatePeers(peers []string) error {
+	for _, peer := range peers {
+		if peer == localClusterName {
+			return fmt.Errorf("the name of a peer cannot be %s", localClusterName)
+		}
+		// the secret of the peer is mounted with a volume named after the peer
+		if errs := validation.IsDNS1123Label(peerVolumePrefix + peer); len(errs) > 0 {
+			return fmt.Errorf("invalid peer %s. %v", peer, errs)
+		}
+	}
+	return nil
+}
+
+// Start creates or updates the deployments of the rbd-mirror daemons, and removes the daemons above the number of workers
+func (m *Mirroring) Start() error {
+	if err := ValidatePeers(m.spec.Peers); err != nil {
+		return err
+	}
+	if err := m.loadPeers(); err != nil {
+		return err
+	}
+
+	for i := 0; i < m.spec.Workers; i++ {
+		daemonName := k8sutil.IndexToName(i)
+		resourceName := fmt.Sprintf("%s-%s", AppName, daemonName)
+		if err := m.createKeyring(resourceName, daemonName); err != nil {
+			return fmt.Errorf("failed to create %s keyring. %+v", resourceName, err)
+		}
+
+		deployment := m.makeDeployment(daemonName, resourceName)
+		if _, err := m.context.Clientset.ExtensionsV1beta1().Deployments(m.Namespace).Create(deployment); err != nil {
+			if !errors.IsAlreadyExists(err) {
+				return fmt.Errorf("failed to create %s deployment. %+v", resourceName, err)
+			}
+			// the peers may have changed
+			if _, err := m.context.Clientset.ExtensionsV1beta1().Deployments(m.Namespace).Update(deployment); err != nil {
+				return fmt.Errorf("failed to update %s deployment. %+v", resourceName, err)
+			}
+			logger.Infof("%s deployment updated", resourceName)
+		} else {
+			logger.Infof("%s deployment started", resourceName)
+		}
+	}
+
+	return m.removeExtraDaemons()
+}
+
+// UpdateCephVersion rolls the rbd-mirror deployments to a new version of ceph, one daemon at a time.
+func (m *Mirroring) UpdateCephVersion(cephVersion cephv1beta1.CephVersionSpec) error {
+	m.cephVersion = cephVersion
+	if err := m.loadPeers(); err != nil {
+		return err
+	}
+
+	for i := 0; i < m.spec.Workers; i++ {
+		daemonName := k8sutil.IndexToName(i)
+		resourceName := fmt.Sprintf("%s-%s", AppName, daemonName)
+
+		image, err := k8sutil.GetDeploymentImage(m.context.Clientset, m.Namespace, resourceName, "rbd-mirror")
+		if err != nil {
+			return fmt.Errorf("failed to get the image of rbd-mirror %s. %+v", daemonName, err)
+		}
+		if image == cephVersion.Image {
+			logger.Infof("rbd-mirror %s is already running image %s", daemonName, image)
+			continue
+		}
+
+		logger.Infof("upgrading rbd-mirror %s from image %s to %s", daemonName, image, cephVersion.Image)
+		if err := k8sutil.UpdateDeploymentAndWait(m.context, m.makeDeployment(daemonName, resourceName), m.Namespace); err != nil {
+			return fmt.Errorf("failed to upgrade rbd-mirror %s. %+v", daemonName, err)
+		}
+	}
+
+	return nil
+}
+
+// loadPeers finds the peers the daemons can connect to. A peer is skipped until its secret is created since the
+// daemons could not start without it.
+func (m *Mirroring) loadPeers() error {
+	m.peers = []string{}
+	for _, peer := range m.spec.Peers {
+		if _, err := m.context.Clientset.CoreV1().Secrets(m.Namespace).Get(peer, metav1.GetOptions{}); err != nil {
+			if !errors.IsNotFound(err) {
+				return fmt.Errorf("failed to get secret of peer %s. %+v", peer, err)
+			}
+			logger.Warningf("skipping rbd-mirror peer %s until its secret is created in namespace %s", peer, m.Namespace)
+			continue
+		}
+		m.peers = append(m.peers, peer)
+	}
+	return nil
+}
+
+// removeExtraDaemons deletes the deployments, the keyrings and the ceph users of the daemons that are not needed
+// after the number of workers was decreased
+func (m *Mirroring) removeExtraDaemons() error {
+	selector := fmt.Sprintf("%s=%s", k8sutil.AppAttr, AppName)
+	deployments, err := m.context.Clientset.ExtensionsV1beta1().Deployments(m.Namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return fmt.Errorf("failed to list rbd-mirror deployments. %+v", err)
+	}
+
+	needed := map[string]bool{}
+	for i := 0; i < m.spec.Workers; i++ {
+		needed[fmt.Sprintf("%s-%s", AppName, k8sutil.IndexToName(i))] = true
+	}
+
+	for _, d := range deployments.Items {
+		if needed[d.Name] {
+			continue
+		}
+		daemonName := d.Spec.Template.Labels["rbd-mirror"]
+		logger.Infof("removing rbd-mirror %s", daemonName)
+		if err := k8sutil.DeleteDeployment(m.context.Clientset, m.Namespace, d.Name); err != nil {
+			return fmt.Errorf("failed to delete %s deployment. %+v", d.Name, err)
+		}
+		if err := m.context.Clientset.CoreV1().Secrets(m.Namespace).Delete(d.Name, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			logger.Warningf("failed to delete %s keyring. %+v", d.Name, err)
+		}
+		if err := client.AuthDelete(m.context, m.Namespace, getUsername(daemonName)); err != nil {
+			logger.Warningf("failed to delete ceph user of rbd-mirror %s. %+v", daemonName, err)
+		}
+	}
+
+	return nil
+}
+
+func (m *Mirroring) createKeyring(name, daemonName string) error {
+	_, err := m.context.Clientset.CoreV1().Secrets(m.Namespace).Get(name, metav1.GetOptions{})
+	if err == nil {
+		logger.Infof("the rbd-mirror %s keyring was already generated", daemonName)
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to get rbd-mirror secrets. %+v", err)
+	}
+
+	// the daemons only need access to the images of the pools
+	username := getUsername(daemonName)
+	keyring, err := client.AuthGetOrCreateKey(m.context, m.Namespace, username, []string{"mon", "profile rbd", "osd", "profile rbd"})
+	if err != nil {
+		return fmt.Errorf("failed to get or create auth key for %s. %+v", username, err)
+	}
+
+	// Store the keyring in a secret
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: m.Namespace,
+		},
+		StringData: map[string]string{
+			keyringSecretKeyName: keyring,
+		},
+		Type: k8sutil.RookType,
+	}
+	k8sutil.SetOwnerRef(m.context.Clientset, m.Namespace, &secret.ObjectMeta, &m.ownerRef)
+
+	if _, err = m.context.Clientset.CoreV1().Secrets(m.Namespace).Create(secret); err != nil {
+		return fmt.Errorf("failed to save rbd-mirror secrets. %+v", err)
+	}
+
+	return nil
+}
+
+func getUsername(daemonName string) string {
+	return fmt.Sprintf("client.rbd-mirror.%s", daemonName)
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rbd
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	opspec "github.com/rook/rook/pkg/operator/ceph/spec"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestStartRBDMirror(t *testing.T) {
+	var authArgs [][]string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			authArgs = append(authArgs, args)
+			return `{"key":"mysecurekey"}`, nil
+		},
+	}
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	clientset := testop.New(3)
+	context := &clusterd.Context{Executor: executor, ConfigDir: configDir, Clientset: clientset}
+
+	spec := cephv1beta1.RBDMirroringSpec{Workers: 2, Peers: []string{"site-b"}}
+	m := New(context, "ns", "myversion", cephv1beta1.CephVersionSpec{Image: "ceph/ceph:v13"}, rookalpha.Placement{}, rookalpha.NetworkSpec{},
+		spec, v1.ResourceRequirements{}, metav1.OwnerReference{})
+
+	// the peer is skipped until its secret is created
+	err := m.Start()
+	assert.Nil(t, err)
+	d, err := clientset.ExtensionsV1beta1().Deployments("ns").Get("rook-ceph-rbd-mirror-a", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "--peers=", d.Spec.Template.Spec.InitContainers[0].Args[4])
+	assert.Equal(t, len(opspec.PodVolumes("")), len(d.Spec.Template.Spec.Volumes))
+
+	clientset.CoreV1().Secrets("ns").Create(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "site-b", Namespace: "ns"}})
+	err = m.Start()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"auth", "get-or-create-key", "client.rbd-mirror.a", "mon", "profile rbd", "osd", "profile rbd"}, authArgs[0][:7])
+
+	d, err = clientset.ExtensionsV1beta1().Deployments("ns").Get("rook-ceph-rbd-mirror-b", metav1.GetOptions{})
+	assert.Nil(t, err)
+	podSpec := d.Spec.Template.Spec
+	assert.Equal(t, "b", d.Spec.Template.Labels["rbd-mirror"])
+	assert.Equal(t, []string{"--foreground", "--id", "rbd-mirror.b"}, podSpec.Containers[0].Args)
+	assert.Equal(t, "ceph/ceph:v13", podSpec.Containers[0].Image)
+	assert.Equal(t, "--peers=site-b", podSpec.InitContainers[0].Args[4])
+	peerMount := podSpec.InitContainers[0].VolumeMounts[len(podSpec.InitContainers[0].VolumeMounts)-1]
+	assert.Equal(t, v1.VolumeMount{Name: "peer-site-b", MountPath: "/etc/rook-peers/site-b", ReadOnly: true}, peerMount)
+	assert.Equal(t, "site-b", podSpec.Volumes[len(podSpec.Volumes)-1].Secret.SecretName)
+	_, err = clientset.CoreV1().Secrets("ns").Get("rook-ceph-rbd-mirror-b", metav1.GetOptions{})
+	assert.Nil(t, err)
+
+	// the daemons above the number of workers are removed
+	m.spec.Workers = 1
+	err = m.Start()
+	assert.Nil(t, err)
+	_, err = clientset.ExtensionsV1beta1().Deployments("ns").Get("rook-ceph-rbd-mirror-a", metav1.GetOptions{})
+	assert.Nil(t, err)
+	_, err = clientset.ExtensionsV1beta1().Deployments("ns").Get("rook-ceph-rbd-mirror-b", metav1.GetOptions{})
+	assert.True(t, errors.IsNotFound(err))
+	_, err = clientset.CoreV1().Secrets("ns").Get("rook-ceph-rbd-mirror-b", metav1.GetOptions{})
+	assert.True(t, errors.IsNotFound(err))
+	assert.Equal(t, []string{"auth", "del", "client.rbd-mirror.b"}, authArgs[len(authArgs)-1][:3])
+}
+
+func TestValidatePeers(t *testing.T) {
+	assert.Nil(t, ValidatePeers([]string{"site-b", "site-c"}))
+	assert.Nil(t, ValidatePeers(nil))
+	// the local cluster is named ceph in the config of rbd-mirror
+	assert.NotNil(t, ValidatePeers([]string{"ceph"}))
+	assert.NotNil(t, ValidatePeers([]string{"site.b"}))
+}
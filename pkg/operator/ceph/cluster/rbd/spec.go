@@ -0,0 +1,149 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rbd
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	rbddaemon "github.com/rook/rook/pkg/daemon/ceph/rbd"
+	opmon "github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	opspec "github.com/rook/rook/pkg/operator/ceph/spec"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	rbdMirrorDaemonCommand = "rbd-mirror"
+	peerVolumePrefix       = "peer-"
+)
+
+func (m *Mirroring) makeDeployment(daemonName, resourceName string) *extensions.Deployment {
+	podSpec := v1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   resourceName,
+			Labels: opspec.PodLabels(AppName, m.Namespace, "rbd-mirror", daemonName),
+		},
+		Spec: v1.PodSpec{
+			InitContainers: []v1.Container{
+				m.makeConfigInitContainer(daemonName, resourceName),
+			},
+			Containers: []v1.Container{
+				m.makeMirrorDaemonContainer(daemonName),
+			},
+			RestartPolicy: v1.RestartPolicyAlways,
+			Volumes:       append(opspec.PodVolumes(""), m.peerVolumes()...),
+			HostNetwork:   m.HostNetwork,
+		},
+	}
+	if m.HostNetwork {
+		podSpec.Spec.DNSPolicy = v1.DNSClusterFirstWithHostNet
+	}
+	opspec.AddNetworkAnnotations(&podSpec.ObjectMeta, m.network, false)
+	m.placement.ApplyToPodSpec(&podSpec.Spec)
+
+	replicas := int32(1)
+	d := &extensions.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      resourceName,
+			Namespace: m.Namespace,
+			Labels:    opspec.AppLabels(AppName, m.Namespace),
+		},
+		Spec: extensions.DeploymentSpec{Template: podSpec, Replicas: &replicas},
+	}
+	k8sutil.SetOwnerRef(m.context.Clientset, m.Namespace, &d.ObjectMeta, &m.ownerRef)
+	return d
+}
+
+func (m *Mirroring) makeConfigInitContainer(daemonName, resourceName string) v1.Container {
+	envVars := []v1.EnvVar{
+		// Set '--rbd-mirror-keyring' flag with an env var sourced from the secret
+		{Name: "ROOK_RBD_MIRROR_KEYRING",
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{Name: resourceName},
+					Key:                  keyringSecretKeyName,
+				}}},
+		k8sutil.PodIPEnvVar(k8sutil.PrivateIPEnvVar),
+		k8sutil.PodIPEnvVar(k8sutil.PublicIPEnvVar),
+		opmon.EndpointEnvVar(),
+		opmon.SecretEnvVar(),
+		opmon.AdminSecretEnvVar(),
+		k8sutil.ConfigOverrideEnvVar(),
+		k8sutil.CephConfigEnvVar(),
+	}
+	envVars = append(envVars, opspec.NetworkEnvVars(m.network)...)
+
+	return v1.Container{
+		Name: opspec.ConfigInitContainerName,
+		Args: []string{
+			"ceph",
+			rbddaemon.InitCommand,
+			fmt.Sprintf("--config-dir=%s", k8sutil.DataDir),
+			fmt.Sprintf("--rbd-mirror-name=%s", daemonName),
+			fmt.Sprintf("--peers=%s", strings.Join(m.peers, ",")),
+		},
+		Image:        k8sutil.MakeRookImage(m.rookVersion),
+		Env:          envVars,
+		VolumeMounts: append(opspec.RookVolumeMounts(), m.peerVolumeMounts()...),
+		Resources:    m.resources,
+	}
+}
+
+func (m *Mirroring) makeMirrorDaemonContainer(daemonName string) v1.Container {
+	container := v1.Container{
+		Name: "rbd-mirror",
+		Command: []string{
+			rbdMirrorDaemonCommand,
+		},
+		Args: []string{
+			"--foreground",
+			"--id", fmt.Sprintf("rbd-mirror.%s", daemonName),
+			// do not add the '--cluster/--conf/--keyring' flags; rook wants their default values, and rbd-mirror
+			// finds the configs of the peers by their cluster names
+		},
+		Image:        m.cephVersion.Image,
+		VolumeMounts: opspec.CephVolumeMounts(),
+		Env:          k8sutil.ClusterDaemonEnvVars(),
+		Resources:    m.resources,
+	}
+	container.Env = append(container.Env, opmon.ClusterNameEnvVar(m.Namespace))
+	return container
+}
+
+// peerVolumes returns the volumes of the secrets with the mon hosts and the keys of the peer clusters
+func (m *Mirroring) peerVolumes() []v1.Volume {
+	volumes := []v1.Volume{}
+	for _, peer := range m.peers {
+		volumes = append(volumes, v1.Volume{
+			Name:         peerVolumePrefix + peer,
+			VolumeSource: v1.VolumeSource{Secret: &v1.SecretVolumeSource{SecretName: peer}},
+		})
+	}
+	return volumes
+}
+
+func (m *Mirroring) peerVolumeMounts() []v1.VolumeMount {
+	mounts := []v1.VolumeMount{}
+	for _, peer := range m.peers {
+		mounts = append(mounts, v1.VolumeMount{Name: peerVolumePrefix + peer, MountPath: path.Join(rbddaemon.PeersDir, peer), ReadOnly: true})
+	}
+	return mounts
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	healthOK            = "HEALTH_OK"
+	healthChangedReason = "CephHealthChanged"
+)
+
+var (
+	// statusCheckInterval is the interval to publish the health and capacity of the cluster in the cluster CRD
+	statusCheckInterval = 60 * time.Second
+)
+
+// cephStatusChecker publishes the health and capacity of the ceph cluster in the status of the cluster CRD
+type cephStatusChecker struct {
+	controller *ClusterController
+	namespace  string
+	name       string
+	ownerRef   metav1.OwnerReference
+}
+
+// newCephStatusChecker creates a cephStatusChecker for the cluster CRD with the given name
+func newCephStatusChecker(controller *ClusterController, namespace, name string, ownerRef metav1.OwnerReference) *cephStatusChecker {
+	return &cephStatusChecker{controller: controller, namespace: namespace, name: name, ownerRef: ownerRef}
+}
+
+// checkCephStatus periodically checks the status of the ceph cluster until the cluster is stopped
+func (s *cephStatusChecker) checkCephStatus(stopCh chan struct{}) {
+	for {
+		select {
+		case <-stopCh:
+			logger.Infof("stopping the status checks of cluster in namespace %s", s.namespace)
+			return
+
+		case <-time.After(statusCheckInterval):
+			if err := s.checkStatus(); err != nil {
+				logger.Warningf("failed to check the status of cluster in namespace %s. %+v", s.namespace, err)
+			}
+		}
+	}
+}
+
+// checkStatus reads the status from ceph and updates the status of the cluster CRD
+func (s *cephStatusChecker) checkStatus() error {
+	context := s.controller.context
+	status, err := client.Status(context, s.namespace)
+	if err != nil {
+		return fmt.Errorf("failed to get ceph status. %+v", err)
+	}
+	usage, err := client.Usage(context, s.namespace)
+	if err != nil {
+		return fmt.Errorf("failed to get ceph usage. %+v", err)
+	}
+	monStatus, err := client.GetMonStatus(context, s.namespace, false)
+	if err != nil {
+		return fmt.Errorf("failed to get mon status. %+v", err)
+	}
+	osdDump, err := client.GetOSDDump(context, s.namespace)
+	if err != nil {
+		return fmt.Errorf("failed to get osd dump. %+v", err)
+	}
+
+	cephStatus := toCustomResourceStatus(status, usage, monStatus, osdDump)
+	previousHealth := ""
+	err = s.controller.modifyClusterStatus(s.namespace, s.name, func(status *cephv1beta1.ClusterStatus) {
+		if status.CephStatus != nil {
+			previousHealth = status.CephStatus.Health
+		}
+		status.CephStatus = cephStatus
+	})
+	if err != nil {
+		return err
+	}
+
+	s.recordHealthChange(previousHealth, cephStatus)
+	return nil
+}
+
+// recordHealthChange records an event on the cluster CRD when the health of ceph changed since the last check
+func (s *cephStatusChecker) recordHealthChange(previousHealth string, cephStatus *cephv1beta1.CephStatus) {
+	if previousHealth == "" || previousHealth == cephStatus.Health {
+		return
+	}
+
+	object := k8sutil.OwnerObjectReference(s.namespace, s.ownerRef)
+	if cephStatus.Health == healthOK {
+		k8sutil.RecordEvent(s.controller.context, object, v1.EventTypeNormal, healthChangedReason,
+			"ceph health changed from %s to %s", previousHealth, cephStatus.Health)
+		return
+	}
+
+	// the messages of the health checks are sorted by the name of the checks
+	var names []string
+	for name := range cephStatus.Details {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var messages []string
+	for _, name := range names {
+		messages = append(messages, cephStatus.Details[name].Message)
+	}
+	k8sutil.RecordEvent(s.controller.context, object, v1.EventTypeWarning, healthChangedReason,
+		"ceph health changed from %s to %s: %s", previousHealth, cephStatus.Health, strings.Join(messages, "; "))
+}
+
+// toCustomResourceStatus converts the output of the ceph commands to the status of the cluster CRD
+func toCustomResourceStatus(status client.CephStatus, usage *client.CephUsage, monStatus client.MonStatusResponse,
+	osdDump *client.OSDDump) *cephv1beta1.CephStatus {
+
+	s := &cephv1beta1.CephStatus{
+		Health:      status.Health.Status,
+		LastChecked: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	if len(status.Health.Checks) > 0 {
+		s.Details = map[string]cephv1beta1.CephHealthMessage{}
+		for name, check := range status.Health.Checks {
+			s.Details[name] = cephv1beta1.CephHealthMessage{Severity: check.Severity, Message: check.Summary.Message}
+		}
+	}
+
+	// the members of the quorum are listed by rank
+	s.Mons.Total = len(monStatus.MonMap.Mons)
+	for _, rank := range monStatus.Quorum {
+		for _, m := range monStatus.MonMap.Mons {
+			if m.Rank == rank {
+				s.Mons.Quorum = append(s.Mons.Quorum, m.Name)
+			}
+		}
+	}
+
+	for _, osd := range osdDump.OSDs {
+		s.OSDs.Total++
+		if up, err := osd.Up.Int64(); err == nil && up == 1 {
+			s.OSDs.Up++
+		}
+		if in, err := osd.In.Int64(); err == nil && in == 1 {
+			s.OSDs.In++
+		}
+	}
+
+	s.PGs.Total = status.PgMap.NumPgs
+	if len(status.PgMap.PgsByState) > 0 {
+		s.PGs.States = map[string]int{}
+		for _, state := range status.PgMap.PgsByState {
+			s.PGs.States[state.StateName] = state.Count
+		}
+	}
+
+	s.Capacity.BytesTotal = jsonNumberToUint64(usage.Stats.TotalBytes)
+	s.Capacity.BytesUsed = jsonNumberToUint64(usage.Stats.TotalUsedBytes)
+	s.Capacity.BytesAvailable = jsonNumberToUint64(usage.Stats.TotalAvailBytes)
+
+	return s
+}
+
+func jsonNumberToUint64(n json.Number) uint64 {
+	val, err := strconv.ParseUint(n.String(), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return val
+}
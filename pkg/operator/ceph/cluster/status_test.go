@@ -0,0 +1,102 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume/attachment"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	statusResponse = `{"health":{"status":"HEALTH_WARN","checks":{"OSD_DOWN":{"severity":"HEALTH_WARN","summary":{"message":"1 osds down"}}}},
+		"pgmap":{"num_pgs":100,"pgs_by_state":[{"state_name":"active+clean","count":90},{"state_name":"active+undersized+degraded","count":10}]}}`
+	usageResponse     = `{"stats":{"total_bytes":3000,"total_used_bytes":1000,"total_avail_bytes":2000,"total_objects":5}}`
+	monStatusResponse = `{"quorum":[0,2],"monmap":{"mons":[{"name":"a","rank":0},{"name":"b","rank":1},{"name":"c","rank":2}]}}`
+	osdDumpResponse   = `{"osds":[{"osd":0,"up":1,"in":1},{"osd":1,"up":0,"in":1},{"osd":2,"up":0,"in":0}]}`
+)
+
+func TestCheckCephStatus(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			switch args[0] {
+			case "status":
+				return statusResponse, nil
+			case "df":
+				return usageResponse, nil
+			case "mon_status":
+				return monStatusResponse, nil
+			case "osd":
+				return osdDumpResponse, nil
+			}
+			return "", nil
+		},
+	}
+	recorder := record.NewFakeRecorder(10)
+	context := &clusterd.Context{
+		Clientset:     testop.New(3),
+		RookClientset: rookfake.NewSimpleClientset(),
+		Executor:      executor,
+		Recorder:      recorder,
+	}
+	controller := NewClusterController(context, "", &attachment.MockAttachment{})
+
+	cluster := &cephv1beta1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "ns"},
+		Status: cephv1beta1.ClusterStatus{
+			State:      cephv1beta1.ClusterStateCreated,
+			CephStatus: &cephv1beta1.CephStatus{Health: "HEALTH_OK"},
+		},
+	}
+	_, err := context.RookClientset.CephV1beta1().Clusters(cluster.Namespace).Create(cluster)
+	assert.Nil(t, err)
+
+	checker := newCephStatusChecker(controller, cluster.Namespace, cluster.Name, ClusterOwnerRef(cluster.Namespace, "uid"))
+	err = checker.checkStatus()
+	assert.Nil(t, err)
+
+	cluster, err = context.RookClientset.CephV1beta1().Clusters(cluster.Namespace).Get(cluster.Name, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cephv1beta1.ClusterStateCreated, cluster.Status.State)
+	status := cluster.Status.CephStatus
+	if !assert.NotNil(t, status) {
+		return
+	}
+	assert.Equal(t, "HEALTH_WARN", status.Health)
+	assert.Equal(t, cephv1beta1.CephHealthMessage{Severity: "HEALTH_WARN", Message: "1 osds down"}, status.Details["OSD_DOWN"])
+	assert.NotEqual(t, "", status.LastChecked)
+	assert.Equal(t, cephv1beta1.CephMonsStatus{Quorum: []string{"a", "c"}, Total: 3}, status.Mons)
+	assert.Equal(t, cephv1beta1.CephOSDsStatus{Total: 3, Up: 1, In: 2}, status.OSDs)
+	assert.Equal(t, 100, status.PGs.Total)
+	assert.Equal(t, map[string]int{"active+clean": 90, "active+undersized+degraded": 10}, status.PGs.States)
+	assert.Equal(t, cephv1beta1.CephCapacity{BytesTotal: 3000, BytesUsed: 1000, BytesAvailable: 2000}, status.Capacity)
+
+	// the change of the health is recorded on the cluster crd only once
+	assert.Equal(t, 1, len(recorder.Events))
+	assert.Equal(t, "Warning CephHealthChanged ceph health changed from HEALTH_OK to HEALTH_WARN: 1 osds down", <-recorder.Events)
+	err = checker.checkStatus()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(recorder.Events))
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cluster to manage a Ceph cluster.
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+)
+
+type upgradePhase struct {
+	phase   cephv1beta1.UpgradePhase
+	upgrade func() error
+}
+
+// validateUpgrade detects the major version of the new ceph image and ensures the cluster can be upgraded to it
+func (c *cluster) validateUpgrade(runningVersion string) error {
+	if c.mons == nil {
+		return fmt.Errorf("the cluster has not been created yet")
+	}
+
+	if err := c.setCephMajorVersion(15 * time.Minute); err != nil {
+		return fmt.Errorf("unknown ceph major version for image %s. %+v", c.Spec.CephVersion.Image, err)
+	}
+
+	if !c.Spec.CephVersion.AllowUnsupported && !versionSupported(c.Spec.CephVersion.Name) {
+		return fmt.Errorf("unsupported ceph version %s for image %s. allowUnsupported must be set to true to run with this version",
+			c.Spec.CephVersion.Name, c.Spec.CephVersion.Image)
+	}
+
+	if runningVersion != "" && !cephv1beta1.VersionAtLeast(c.Spec.CephVersion.Name, runningVersion) {
+		return fmt.Errorf("downgrading ceph from %s to %s is not supported", runningVersion, c.Spec.CephVersion.Name)
+	}
+
+	return nil
+}
+
+// upgradeCluster rolls all the ceph daemons to the image in the cluster spec. The daemons are upgraded in the order
+// required by ceph: mons, mgrs, osds, mdses, rgws and finally the rbd-mirror daemons. The health of the cluster is checked before each
+// phase. If the cluster is not healthy the upgrade is paused with an error state until the next retry.
+func (c *ClusterController) upgradeCluster(clusterObj *cephv1beta1.Cluster, cluster *cluster) error {
+	cluster.orchestrationLock.Lock()
+	defer cluster.orchestrationLock.Unlock()
+
+	version := cluster.Spec.CephVersion
+	logger.Infof("upgrading cluster in namespace %s to ceph image %s (%s)", cluster.Namespace, version.Image, version.Name)
+
+	phases := []upgradePhase{
+		{cephv1beta1.UpgradePhaseMon, func() error { return cluster.mons.UpdateCephVersion(version) }},
+		{cephv1beta1.UpgradePhaseMgr, func() error { return cluster.mgrs.UpdateCephVersion(version) }},
+		{cephv1beta1.UpgradePhaseOSD, func() error { return cluster.osds.UpdateCephVersion(version) }},
+	}
+	if cluster.fileController != nil {
+		phases = append(phases, upgradePhase{cephv1beta1.UpgradePhaseMDS, func() error {
+			return cluster.fileController.UpdateCephVersion(cluster.Namespace, version)
+		}})
+	}
+	if cluster.objectController != nil {
+		phases = append(phases, upgradePhase{cephv1beta1.UpgradePhaseRGW, func() error {
+			return cluster.objectController.UpdateCephVersion(cluster.Namespace, version)
+		}})
+	}
+	if cluster.rbdMirrors != nil {
+		phases = append(phases, upgradePhase{cephv1beta1.UpgradePhaseRBDMirror, func() error {
+			return cluster.rbdMirrors.UpdateCephVersion(version)
+		}})
+	}
+
+	for _, p := range phases {
+		if err := checkUpgradeHealth(c.context, cluster.Namespace); err != nil {
+			message := fmt.Sprintf("upgrade paused before upgrading the %s daemons. %+v", p.phase, err)
+			c.updateUpgradeStatus(clusterObj, cephv1beta1.ClusterStateError, p.phase, message)
+			return errors.New(message)
+		}
+
+		c.updateUpgradeStatus(clusterObj, cephv1beta1.ClusterStateUpgrading, p.phase, fmt.Sprintf("upgrading the %s daemons", p.phase))
+		if err := p.upgrade(); err != nil {
+			message := fmt.Sprintf("upgrade paused while upgrading the %s daemons. %+v", p.phase, err)
+			c.updateUpgradeStatus(clusterObj, cephv1beta1.ClusterStateError, p.phase, message)
+			return errors.New(message)
+		}
+	}
+
+	err := c.modifyClusterStatus(clusterObj.Namespace, clusterObj.Name, func(status *cephv1beta1.ClusterStatus) {
+		status.CephVersion = cephv1beta1.CephVersionStatus{Image: version.Image, Name: version.Name}
+		status.Upgrade = cephv1beta1.UpgradeStatus{}
+	})
+	if err != nil {
+		logger.Errorf("failed to update cluster status in namespace %s: %+v", clusterObj.Namespace, err)
+	}
+
+	logger.Infof("finished upgrading cluster in namespace %s to ceph image %s", cluster.Namespace, version.Image)
+	return nil
+}
+
+func (c *ClusterController) updateUpgradeStatus(clusterObj *cephv1beta1.Cluster, state cephv1beta1.ClusterState, phase cephv1beta1.UpgradePhase, message string) {
+	if state == cephv1beta1.ClusterStateError {
+		logger.Error(message)
+	} else {
+		logger.Info(message)
+	}
+
+	err := c.modifyClusterStatus(clusterObj.Namespace, clusterObj.Name, func(status *cephv1beta1.ClusterStatus) {
+		status.State = state
+		status.Message = message
+		status.Upgrade = cephv1beta1.UpgradeStatus{Image: clusterObj.Spec.CephVersion.Image, Phase: phase, Message: message}
+	})
+	if err != nil {
+		logger.Errorf("failed to update cluster status in namespace %s: %+v", clusterObj.Namespace, err)
+	}
+}
+
+// checkUpgradeHealth returns an error if the cluster is not healthy enough to continue upgrading daemons
+func checkUpgradeHealth(context *clusterd.Context, namespace string) error {
+	status, err := client.Status(context, namespace)
+	if err != nil {
+		return fmt.Errorf("failed to get ceph status. %+v", err)
+	}
+	if status.Health.Status == client.CephHealthErr {
+		return fmt.Errorf("ceph health is %s", status.Health.Status)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume/attachment"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckUpgradeHealth(t *testing.T) {
+	health := "HEALTH_OK"
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			return `{"health":{"status":"` + health + `"}}`, nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	assert.Nil(t, checkUpgradeHealth(context, "ns"))
+
+	// warnings are expected while daemons are restarting
+	health = "HEALTH_WARN"
+	assert.Nil(t, checkUpgradeHealth(context, "ns"))
+
+	// the upgrade must pause when the cluster is in error
+	health = "HEALTH_ERR"
+	assert.NotNil(t, checkUpgradeHealth(context, "ns"))
+}
+
+func TestUpgradeStatus(t *testing.T) {
+	context := &clusterd.Context{
+		Clientset:     testop.New(3),
+		RookClientset: rookfake.NewSimpleClientset(),
+	}
+	controller := NewClusterController(context, "", &attachment.MockAttachment{})
+
+	cluster := &cephv1beta1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "ns"},
+		Spec:       cephv1beta1.ClusterSpec{CephVersion: cephv1beta1.CephVersionSpec{Image: "ceph/ceph:v13.2.2"}},
+		Status: cephv1beta1.ClusterStatus{
+			State:       cephv1beta1.ClusterStateCreated,
+			CephVersion: cephv1beta1.CephVersionStatus{Image: "ceph/ceph:v12.2.9", Name: "luminous"},
+		},
+	}
+	_, err := context.RookClientset.CephV1beta1().Clusters(cluster.Namespace).Create(cluster)
+	assert.Nil(t, err)
+
+	// the upgrade progress is reported without losing the running version
+	controller.updateUpgradeStatus(cluster, cephv1beta1.ClusterStateUpgrading, cephv1beta1.UpgradePhaseOSD, "upgrading")
+	cluster, err = context.RookClientset.CephV1beta1().Clusters(cluster.Namespace).Get(cluster.Name, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cephv1beta1.ClusterStateUpgrading, cluster.Status.State)
+	assert.Equal(t, cephv1beta1.UpgradePhaseOSD, cluster.Status.Upgrade.Phase)
+	assert.Equal(t, "ceph/ceph:v13.2.2", cluster.Status.Upgrade.Image)
+	assert.Equal(t, "ceph/ceph:v12.2.9", cluster.Status.CephVersion.Image)
+
+	// updating the state must not clear the upgrade progress
+	err = controller.updateClusterStatus(cluster.Namespace, cluster.Name, cephv1beta1.ClusterStateError, "paused")
+	assert.Nil(t, err)
+	cluster, err = context.RookClientset.CephV1beta1().Clusters(cluster.Namespace).Get(cluster.Name, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cephv1beta1.ClusterStateError, cluster.Status.State)
+	assert.Equal(t, "paused", cluster.Status.Message)
+	assert.Equal(t, cephv1beta1.UpgradePhaseOSD, cluster.Status.Upgrade.Phase)
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"fmt"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	clientSecretPrefix  = "rook-ceph-client-"
+	clientSecretKeyName = "key"
+)
+
+// FilesystemClientName returns the name of the cephx client that mounts any path of the filesystem
+func FilesystemClientName(fsName string) string {
+	return fmt.Sprintf("fs-%s", fsName)
+}
+
+// VolumeClientName returns the name of the cephx client that only mounts the directory of a provisioned volume
+func VolumeClientName(volumeName string) string {
+	return fmt.Sprintf("fs-volume-%s", volumeName)
+}
+
+// CreateClient creates a cephx client that can only read and write the given path of the filesystem and the
+// data pools of the filesystem. The key of the client is stored in a secret in the namespace of the cluster.
+func CreateClient(context *clusterd.Context, clusterNamespace, clientName, fsName, path string, ownerRefs []metav1.OwnerReference) error {
+	access := []string{
+		"mon", "allow r",
+		"mds", fmt.Sprintf("allow rw path=%s", path),
+		// the data pools of the filesystem are tagged with the name of the filesystem when the filesystem is created
+		"osd", fmt.Sprintf("allow rw tag cephfs data=%s", fsName),
+	}
+	username := fmt.Sprintf("client.%s", clientName)
+	key, err := client.AuthGetOrCreateKey(context, clusterNamespace, username, access)
+	if err != nil {
+		return fmt.Errorf("failed to get or create auth key for client %s: %+v", username, err)
+	}
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      clientSecretPrefix + clientName,
+			Namespace: clusterNamespace,
+		},
+		StringData: map[string]string{
+			clientSecretKeyName: key,
+		},
+		Type: k8sutil.RookType,
+	}
+	k8sutil.SetOwnerRefs(context.Clientset, clusterNamespace, &secret.ObjectMeta, ownerRefs)
+
+	_, err = context.Clientset.CoreV1().Secrets(clusterNamespace).Create(secret)
+	if err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to save secret of client %s: %+v", username, err)
+		}
+		if _, err := context.Clientset.CoreV1().Secrets(clusterNamespace).Update(secret); err != nil {
+			return fmt.Errorf("failed to update secret of client %s: %+v", username, err)
+		}
+	}
+
+	logger.Infof("created client %s with access to path %s of filesystem %s", username, path, fsName)
+	return nil
+}
+
+// DeleteClient removes the cephx client and the secret with its key
+func DeleteClient(context *clusterd.Context, clusterNamespace, clientName string) error {
+	username := fmt.Sprintf("client.%s", clientName)
+	if err := client.AuthDelete(context, clusterNamespace, username); err != nil {
+		return fmt.Errorf("failed to delete client %s: %+v", username, err)
+	}
+
+	err := context.Clientset.CoreV1().Secrets(clusterNamespace).Delete(clientSecretPrefix+clientName, &metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete secret of client %s: %+v", username, err)
+	}
+
+	logger.Infof("deleted client %s", username)
+	return nil
+}
+
+// LoadClientKey returns the key of a cephx client from its secret. An error that satisfies errors.IsNotFound
+// is returned if the client has not been created.
+func LoadClientKey(clientset kubernetes.Interface, clusterNamespace, clientName string) (string, error) {
+	secret, err := clientset.CoreV1().Secrets(clusterNamespace).Get(clientSecretPrefix+clientName, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	key, ok := secret.Data[clientSecretKeyName]
+	if !ok {
+		return "", fmt.Errorf("secret of client %s has no key", clientName)
+	}
+	return string(key), nil
+}
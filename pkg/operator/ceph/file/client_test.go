@@ -0,0 +1,77 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCreateAndDeleteClient(t *testing.T) {
+	var authArgs []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			authArgs = args
+			return `{"key":"mysecurekey"}`, nil
+		},
+	}
+	clientset := testop.New(3)
+	context := &clusterd.Context{Executor: executor, Clientset: clientset}
+
+	err := CreateClient(context, "ns", VolumeClientName("pvc-1"), "myfs", "/volumes/pvc-1", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"auth", "get-or-create-key", "client.fs-volume-pvc-1",
+		"mon", "allow r", "mds", "allow rw path=/volumes/pvc-1", "osd", "allow rw tag cephfs data=myfs"}, authArgs[:9])
+
+	secret, err := clientset.CoreV1().Secrets("ns").Get("rook-ceph-client-fs-volume-pvc-1", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "mysecurekey", secret.StringData["key"])
+
+	// creating the client again updates the secret
+	err = CreateClient(context, "ns", VolumeClientName("pvc-1"), "myfs", "/volumes/pvc-1", nil)
+	assert.Nil(t, err)
+
+	err = DeleteClient(context, "ns", VolumeClientName("pvc-1"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"auth", "del", "client.fs-volume-pvc-1"}, authArgs[:3])
+	_, err = clientset.CoreV1().Secrets("ns").Get("rook-ceph-client-fs-volume-pvc-1", metav1.GetOptions{})
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestLoadClientKey(t *testing.T) {
+	clientset := testop.New(1)
+
+	_, err := LoadClientKey(clientset, "ns", FilesystemClientName("myfs"))
+	assert.True(t, errors.IsNotFound(err))
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-client-fs-myfs", Namespace: "ns"},
+		Data:       map[string][]byte{"key": []byte("mysecurekey")},
+	}
+	clientset.CoreV1().Secrets("ns").Create(secret)
+
+	key, err := LoadClientKey(clientset, "ns", FilesystemClientName("myfs"))
+	assert.Nil(t, err)
+	assert.Equal(t, "mysecurekey", key)
+}
@@ -22,13 +22,16 @@ import (
 	"reflect"
 
 	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
 	"github.com/rook/rook/pkg/operator/ceph/pool"
+	"github.com/rook/rook/pkg/operator/k8sutil"
 
 	"github.com/coreos/pkg/capnslog"
 	opkit "github.com/rook/operator-kit"
 	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
 	rookv1alpha1 "github.com/rook/rook/pkg/apis/rook.io/v1alpha1"
 	rookv1alpha2 "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"k8s.io/api/core/v1"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -66,7 +69,7 @@ type FilesystemController struct {
 	context     *clusterd.Context
 	rookVersion string
 	cephVersion cephv1beta1.CephVersionSpec
-	hostNetwork bool
+	network     rookv1alpha2.NetworkSpec
 	ownerRef    metav1.OwnerReference
 }
 
@@ -75,14 +78,14 @@ func NewFilesystemController(
 	context *clusterd.Context,
 	rookVersion string,
 	cephVersion cephv1beta1.CephVersionSpec,
-	hostNetwork bool,
+	network rookv1alpha2.NetworkSpec,
 	ownerRef metav1.OwnerReference,
 ) *FilesystemController {
 	return &FilesystemController{
 		context:     context,
 		rookVersion: rookVersion,
 		cephVersion: cephVersion,
-		hostNetwork: hostNetwork,
+		network:     network,
 		ownerRef:    ownerRef,
 	}
 }
@@ -120,10 +123,13 @@ func (c *FilesystemController) onAdd(obj interface{}) {
 		return
 	}
 
-	err = createFilesystem(c.context, *filesystem, c.rookVersion, c.cephVersion, c.hostNetwork, c.filesystemOwners(filesystem))
+	err = createFilesystem(c.context, *filesystem, c.rookVersion, c.cephVersion, c.network, c.filesystemOwners(filesystem))
 	if err != nil {
 		logger.Errorf("failed to create file system %s: %+v", filesystem.Name, err)
+		k8sutil.RecordEvent(c.context, filesystem, v1.EventTypeWarning, k8sutil.EventReasonCreateFailed, "failed to create filesystem %s. %+v", filesystem.Name, err)
+		return
 	}
+	k8sutil.RecordEvent(c.context, filesystem, v1.EventTypeNormal, k8sutil.EventReasonCreated, "created filesystem %s", filesystem.Name)
 }
 
 func (c *FilesystemController) onUpdate(oldObj, newObj interface{}) {
@@ -152,7 +158,7 @@ func (c *FilesystemController) onUpdate(oldObj, newObj interface{}) {
 
 	// if the file system is modified, allow the file system to be created if it wasn't already
 	logger.Infof("updating filesystem %s", newFS.Name)
-	err = createFilesystem(c.context, *newFS, c.rookVersion, c.cephVersion, c.hostNetwork, c.filesystemOwners(newFS))
+	err = createFilesystem(c.context, *newFS, c.rookVersion, c.cephVersion, c.network, c.filesystemOwners(newFS))
 	if err != nil {
 		logger.Errorf("failed to create (modify) file system %s: %+v", newFS.Name, err)
 	}
@@ -173,7 +179,36 @@ func (c *FilesystemController) onDelete(obj interface{}) {
 	err = deleteFilesystem(c.context, *filesystem)
 	if err != nil {
 		logger.Errorf("failed to delete file system %s: %+v", filesystem.Name, err)
+		k8sutil.RecordEvent(c.context, filesystem, v1.EventTypeWarning, k8sutil.EventReasonDeleteFailed, "failed to delete filesystem %s. %+v", filesystem.Name, err)
+		return
+	}
+	k8sutil.RecordEvent(c.context, filesystem, v1.EventTypeNormal, k8sutil.EventReasonDeleted, "deleted filesystem %s", filesystem.Name)
+}
+
+// UpdateCephVersion rolls the mdses of all the filesystems in the namespace to a new version of ceph.
+// Filesystems created or updated after this call will also run the new version.
+func (c *FilesystemController) UpdateCephVersion(namespace string, cephVersion cephv1beta1.CephVersionSpec) error {
+	c.cephVersion = cephVersion
+
+	filesystems, err := c.context.RookClientset.CephV1beta1().Filesystems(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list filesystems in namespace %s: %+v", namespace, err)
 	}
+
+	for _, fs := range filesystems.Items {
+		filesystem, err := client.GetFilesystem(c.context, fs.Namespace, fs.Name)
+		if err != nil {
+			return fmt.Errorf("failed to get file system %s: %+v", fs.Name, err)
+		}
+
+		logger.Infof("upgrading mdses for filesystem %s", fs.Name)
+		cluster := newCluster(c.context, c.rookVersion, c.cephVersion, c.network, fs, filesystem, c.filesystemOwners(&fs))
+		if err := cluster.upgrade(); err != nil {
+			return fmt.Errorf("failed to upgrade filesystem %s: %+v", fs.Name, err)
+		}
+	}
+
+	return nil
 }
 
 func (c *FilesystemController) filesystemOwners(fs *cephv1beta1.Filesystem) []metav1.OwnerReference {
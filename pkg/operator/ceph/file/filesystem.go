@@ -20,6 +20,7 @@ import (
 	"fmt"
 
 	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
 	mdsdaemon "github.com/rook/rook/pkg/daemon/ceph/mds"
@@ -34,7 +35,7 @@ func createFilesystem(
 	fs cephv1beta1.Filesystem,
 	rookVersion string,
 	cephVersion cephv1beta1.CephVersionSpec,
-	hostNetwork bool,
+	network rookalpha.NetworkSpec,
 	ownerRefs []metav1.OwnerReference,
 ) error {
 	if err := validateFilesystem(context, fs); err != nil {
@@ -55,8 +56,13 @@ func createFilesystem(
 		return fmt.Errorf("failed to get file system %s: %+v", fs.Name, err)
 	}
 
+	// the clients mounting the filesystem use a restricted key instead of the admin key
+	if err := CreateClient(context, fs.Namespace, FilesystemClientName(fs.Name), fs.Name, "/", ownerRefs); err != nil {
+		return fmt.Errorf("failed to create client for file system %s: %+v", fs.Name, err)
+	}
+
 	logger.Infof("start running mdses for file system %s", fs.Name)
-	c := newCluster(context, rookVersion, cephVersion, hostNetwork, fs, filesystem, ownerRefs)
+	c := newCluster(context, rookVersion, cephVersion, network, fs, filesystem, ownerRefs)
 	if err := c.start(); err != nil {
 		return err
 	}
@@ -72,6 +78,10 @@ func deleteFilesystem(context *clusterd.Context, fs cephv1beta1.Filesystem) erro
 		return fmt.Errorf("failed to delete filesystem %s: %+v", fs.Name, err)
 	}
 
+	if err := DeleteClient(context, fs.Namespace, FilesystemClientName(fs.Name)); err != nil {
+		logger.Warningf("failed to delete client of filesystem %s. %+v", fs.Name, err)
+	}
+
 	return deleteMdsCluster(context, fs.Namespace, fs.Name)
 }
 
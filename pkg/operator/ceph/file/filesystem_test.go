@@ -25,6 +25,7 @@ import (
 	"testing"
 
 	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	"github.com/rook/rook/pkg/clusterd"
 	cephtest "github.com/rook/rook/pkg/daemon/ceph/test"
 	testop "github.com/rook/rook/pkg/operator/test"
@@ -106,12 +107,12 @@ func TestCreateFilesystem(t *testing.T) {
 	}
 
 	// start a basic cluster
-	err := createFilesystem(context, fs, "v0.1", cephv1beta1.CephVersionSpec{}, false, []metav1.OwnerReference{})
+	err := createFilesystem(context, fs, "v0.1", cephv1beta1.CephVersionSpec{}, rookalpha.NetworkSpec{}, []metav1.OwnerReference{})
 	assert.Nil(t, err)
 	validateStart(t, context, fs)
 
 	// starting again should be a no-op
-	err = createFilesystem(context, fs, "v0.1", cephv1beta1.CephVersionSpec{}, false, []metav1.OwnerReference{})
+	err = createFilesystem(context, fs, "v0.1", cephv1beta1.CephVersionSpec{}, rookalpha.NetworkSpec{}, []metav1.OwnerReference{})
 	assert.Nil(t, err)
 	validateStart(t, context, fs)
 
@@ -130,7 +131,7 @@ func TestCreateFilesystem(t *testing.T) {
 		Clientset: testop.New(3)}
 
 	//Create another filesystem which should fail
-	err = createFilesystem(context, fs, "v0.1", cephv1beta1.CephVersionSpec{}, false, []metav1.OwnerReference{})
+	err = createFilesystem(context, fs, "v0.1", cephv1beta1.CephVersionSpec{}, rookalpha.NetworkSpec{}, []metav1.OwnerReference{})
 	assert.Equal(t, "failed to create file system myfs: Cannot create multiple filesystems. Enable ROOK_ALLOW_MULTIPLE_FILESYSTEMS env variable to create more than one", err.Error())
 }
 
@@ -19,9 +19,11 @@ package file
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
 	mdsdaemon "github.com/rook/rook/pkg/daemon/ceph/mds"
@@ -37,6 +39,8 @@ const (
 	AppName = "rook-ceph-mds"
 
 	keyringSecretKeyName = "keyring"
+	// the mds daemon container has historically been named "mgr"
+	mdsContainerName = "mgr"
 
 	// timeout if mds is not ready for upgrade after some time
 	fsWaitForActiveTimeout = 3 * time.Minute
@@ -47,6 +51,7 @@ type cluster struct {
 	rookVersion string
 	cephVersion cephv1beta1.CephVersionSpec
 	HostNetwork bool
+	network     rookalpha.NetworkSpec
 	fs          cephv1beta1.Filesystem
 	fsID        string
 	ownerRefs   []metav1.OwnerReference
@@ -56,7 +61,7 @@ func newCluster(
 	context *clusterd.Context,
 	rookVersion string,
 	cephVersion cephv1beta1.CephVersionSpec,
-	hostNetwork bool,
+	network rookalpha.NetworkSpec,
 	fs cephv1beta1.Filesystem,
 	fsdetails *client.CephFilesystemDetails,
 	ownerRefs []metav1.OwnerReference,
@@ -65,7 +70,8 @@ func newCluster(
 		context:     context,
 		rookVersion: rookVersion,
 		cephVersion: cephVersion,
-		HostNetwork: hostNetwork,
+		HostNetwork: network.HostNetwork,
+		network:     network,
 		fs:          fs,
 		fsID:        strconv.Itoa(fsdetails.ID),
 		ownerRefs:   ownerRefs,
@@ -198,6 +204,54 @@ func (c *cluster) start() error {
 	return nil
 }
 
+// upgrade rolls the mds deployments of the filesystem to the ceph version of the cluster. The filesystem is
+// reduced to a single active rank while the mdses are restarted, as required by ceph for mds upgrades.
+func (c *cluster) upgrade() error {
+	deps, err := getMdsDeployments(c.context, c.fs.Namespace, c.fs.Name)
+	if err != nil {
+		return err
+	}
+
+	upgradeNeeded := []*mdsConfig{}
+	for _, d := range deps.Items {
+		image, err := k8sutil.GetDeploymentSpecImage(c.context.Clientset, d, mdsContainerName)
+		if err != nil {
+			return fmt.Errorf("failed to get the image of mds deployment %s: %+v", d.Name, err)
+		}
+		if image == c.cephVersion.Image {
+			continue
+		}
+		// resource name is rook-ceph-mds-<fs_name>-<letter_id> and the daemon name is <fs_name>-<letter_id>
+		upgradeNeeded = append(upgradeNeeded, &mdsConfig{
+			ResourceName: d.Name,
+			DaemonName:   strings.TrimPrefix(d.Name, AppName+"-"),
+		})
+	}
+	if len(upgradeNeeded) == 0 {
+		logger.Infof("mdses for filesystem %s are already running image %s", c.fs.Name, c.cephVersion.Image)
+		return nil
+	}
+
+	if err := mdsdaemon.PrepareForDaemonUpgrade(c.context, c.fs.Namespace, c.fs.Name, fsWaitForActiveTimeout); err != nil {
+		return fmt.Errorf("failed to prepare filesystem %s for mds upgrade: %+v", c.fs.Name, err)
+	}
+	defer func() {
+		if err := mdsdaemon.FinishedWithDaemonUpgrade(c.context, c.fs.Namespace, c.fs.Name, c.fs.Spec.MetadataServer.ActiveCount); err != nil {
+			logger.Errorf("for filesystem %s, USER should make sure the Ceph fs max_mds property is set to %d: %+v",
+				c.fs.Name, c.fs.Spec.MetadataServer.ActiveCount, err)
+		}
+	}()
+
+	for _, mdsConfig := range upgradeNeeded {
+		logger.Infof("upgrading mds %s to image %s", mdsConfig.DaemonName, c.cephVersion.Image)
+		if err := k8sutil.UpdateDeploymentAndWait(c.context, c.makeDeployment(mdsConfig), c.fs.Namespace); err != nil {
+			return fmt.Errorf("failed to upgrade mds %s: %+v", mdsConfig.DaemonName, err)
+		}
+	}
+
+	return nil
+}
+
 func (c *cluster) getOrCreateKeyring(mdsConfig *mdsConfig) error {
 	_, err := c.context.Clientset.CoreV1().Secrets(c.fs.Namespace).Get(
 		mdsConfig.ResourceName, metav1.GetOptions{})
@@ -54,6 +54,7 @@ func (c *cluster) makeDeployment(mdsConfig *mdsConfig) *extensions.Deployment {
 	if c.HostNetwork {
 		podSpec.Spec.DNSPolicy = v1.DNSClusterFirstWithHostNet
 	}
+	opspec.AddNetworkAnnotations(&podSpec.ObjectMeta, c.network, false)
 	c.fs.Spec.MetadataServer.Placement.ApplyToPodSpec(&podSpec.Spec)
 
 	replicas := int32(1)
@@ -69,6 +70,25 @@ func (c *cluster) makeDeployment(mdsConfig *mdsConfig) *extensions.Deployment {
 }
 
 func (c *cluster) makeConfigInitContainer(mdsConfig *mdsConfig) v1.Container {
+	envVars := []v1.EnvVar{
+		// Set '--mds-keyring' flag with an env var sourced from the secret
+		{Name: "ROOK_MDS_KEYRING",
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{Name: mdsConfig.ResourceName},
+					Key:                  keyringSecretKeyName,
+				}}},
+		k8sutil.PodIPEnvVar(k8sutil.PrivateIPEnvVar),
+		k8sutil.PodIPEnvVar(k8sutil.PublicIPEnvVar),
+		opmon.ClusterNameEnvVar(c.fs.Namespace),
+		opmon.EndpointEnvVar(),
+		opmon.SecretEnvVar(),
+		opmon.AdminSecretEnvVar(),
+		k8sutil.ConfigOverrideEnvVar(),
+		k8sutil.CephConfigEnvVar(),
+	}
+	envVars = append(envVars, opspec.NetworkEnvVars(c.network)...)
+
 	return v1.Container{
 		Name: opspec.ConfigInitContainerName,
 		Args: []string{
@@ -79,23 +99,8 @@ func (c *cluster) makeConfigInitContainer(mdsConfig *mdsConfig) v1.Container {
 			"--filesystem-id", c.fsID,
 			"--active-standby", strconv.FormatBool(c.fs.Spec.MetadataServer.ActiveStandby),
 		},
-		Image: k8sutil.MakeRookImage(c.rookVersion),
-		Env: []v1.EnvVar{
-			// Set '--mds-keyring' flag with an env var sourced from the secret
-			{Name: "ROOK_MDS_KEYRING",
-				ValueFrom: &v1.EnvVarSource{
-					SecretKeyRef: &v1.SecretKeySelector{
-						LocalObjectReference: v1.LocalObjectReference{Name: mdsConfig.ResourceName},
-						Key:                  keyringSecretKeyName,
-					}}},
-			k8sutil.PodIPEnvVar(k8sutil.PrivateIPEnvVar),
-			k8sutil.PodIPEnvVar(k8sutil.PublicIPEnvVar),
-			opmon.ClusterNameEnvVar(c.fs.Namespace),
-			opmon.EndpointEnvVar(),
-			opmon.SecretEnvVar(),
-			opmon.AdminSecretEnvVar(),
-			k8sutil.ConfigOverrideEnvVar(),
-		},
+		Image:        k8sutil.MakeRookImage(c.rookVersion),
+		Env:          envVars,
 		VolumeMounts: opspec.RookVolumeMounts(),
 		Resources:    c.fs.Spec.MetadataServer.Resources,
 	}
@@ -103,7 +108,7 @@ func (c *cluster) makeConfigInitContainer(mdsConfig *mdsConfig) v1.Container {
 
 func (c *cluster) makeMdsDaemonContainer(mdsConfig *mdsConfig) v1.Container {
 	return v1.Container{
-		Name: "mgr",
+		Name: mdsContainerName,
 		Command: []string{
 			mdsDaemonCommand,
 		},
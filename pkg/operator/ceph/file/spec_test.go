@@ -20,6 +20,7 @@ import (
 	"testing"
 
 	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
@@ -34,7 +35,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-func testDeploymentObject(hostNetwork bool) *extensions.Deployment {
+func testDeploymentObject(network rookalpha.NetworkSpec) *extensions.Deployment {
 	fs := cephv1beta1.Filesystem{
 		ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "ns"},
 		Spec: cephv1beta1.FilesystemSpec{
@@ -53,7 +54,7 @@ func testDeploymentObject(hostNetwork bool) *extensions.Deployment {
 		&clusterd.Context{Clientset: testop.New(1)},
 		"rook/rook:myversion",
 		cephv1beta1.CephVersionSpec{Image: "ceph/ceph:testversion"},
-		hostNetwork,
+		network,
 		fs,
 		&client.CephFilesystemDetails{ID: 15},
 		[]metav1.OwnerReference{{}},
@@ -66,7 +67,7 @@ func testDeploymentObject(hostNetwork bool) *extensions.Deployment {
 }
 
 func TestPodSpecs(t *testing.T) {
-	d := testDeploymentObject(false) // no host network
+	d := testDeploymentObject(rookalpha.NetworkSpec{}) // no host network
 
 	assert.NotNil(t, d)
 	assert.Equal(t, "rook-ceph-mds-myfs-a", d.Name)
@@ -87,7 +88,7 @@ func TestPodSpecs(t *testing.T) {
 	assert.Equal(t, 1, len(pod.Spec.Containers))
 
 	configImage := "rook/rook:myversion"
-	configEnvs := 8
+	configEnvs := 9
 	configContainerDefinition := cephtest.ContainerTestDefinition{
 		Image:   &configImage,
 		Command: []string{}, // no command
@@ -150,8 +151,22 @@ func TestPodSpecs(t *testing.T) {
 }
 
 func TestHostNetwork(t *testing.T) {
-	d := testDeploymentObject(true) // host network
+	d := testDeploymentObject(rookalpha.NetworkSpec{HostNetwork: true}) // host network
 
 	assert.Equal(t, true, d.Spec.Template.Spec.HostNetwork)
 	assert.Equal(t, v1.DNSClusterFirstWithHostNet, d.Spec.Template.Spec.DNSPolicy)
 }
+
+func TestNetworkAttachment(t *testing.T) {
+	network := rookalpha.NetworkSpec{
+		PublicNetwork:  rookalpha.NetworkSelection{CIDR: "10.1.1.0/24", Attachment: "public"},
+		ClusterNetwork: rookalpha.NetworkSelection{CIDR: "10.1.2.0/24", Attachment: "cluster"},
+	}
+	d := testDeploymentObject(network)
+
+	// the mds is only attached to the public network
+	assert.Equal(t, "public", d.Spec.Template.Annotations["k8s.v1.cni.cncf.io/networks"])
+	env := d.Spec.Template.Spec.InitContainers[0].Env
+	assert.Equal(t, v1.EnvVar{Name: "ROOK_PUBLIC_NETWORK", Value: "10.1.1.0/24"}, env[len(env)-2])
+	assert.Equal(t, v1.EnvVar{Name: "ROOK_CLUSTER_NETWORK", Value: "10.1.2.0/24"}, env[len(env)-1])
+}
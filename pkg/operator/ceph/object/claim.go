@@ -0,0 +1,391 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	opkit "github.com/rook/operator-kit"
+	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	"github.com/rook/rook/pkg/clusterd"
+	rgwdaemon "github.com/rook/rook/pkg/daemon/ceph/rgw"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	claimResourceName       = "objectbucketclaim"
+	claimResourceNamePlural = "objectbucketclaims"
+
+	// BucketProvisionerName is the provisioner that must be set in the storage class of a bucket claim
+	BucketProvisionerName = "ceph.rook.io/bucket"
+
+	// The keys in the config map and secret created for a bucket claim
+	BucketHostKey      = "BUCKET_HOST"
+	BucketPortKey      = "BUCKET_PORT"
+	BucketNameKey      = "BUCKET_NAME"
+	BucketAccessKeyKey = "AWS_ACCESS_KEY_ID"
+	BucketSecretKeyKey = "AWS_SECRET_ACCESS_KEY"
+)
+
+// ObjectBucketClaimResource represents the object bucket claim custom resource
+var ObjectBucketClaimResource = opkit.CustomResource{
+	Name:    claimResourceName,
+	Plural:  claimResourceNamePlural,
+	Group:   cephv1beta1.CustomResourceGroup,
+	Version: cephv1beta1.Version,
+	Scope:   apiextensionsv1beta1.NamespaceScoped,
+	Kind:    reflect.TypeOf(cephv1beta1.ObjectBucketClaim{}).Name(),
+}
+
+// ObjectBucketClaimController represents a controller object for object bucket claim custom resources
+type ObjectBucketClaimController struct {
+	context      *clusterd.Context
+	createBucket func(endpoint, accessKey, secretKey, bucketName string) error
+}
+
+type bucketClassConfig struct {
+	// Required: The name of the object store where buckets are provisioned
+	objectStoreName string
+
+	// Required: The namespace of the object store
+	objectStoreNamespace string
+}
+
+// NewObjectBucketClaimController create controller for watching object bucket claim custom resources created
+func NewObjectBucketClaimController(context *clusterd.Context) *ObjectBucketClaimController {
+	return &ObjectBucketClaimController{
+		context:      context,
+		createBucket: rgwdaemon.CreateBucket,
+	}
+}
+
+// StartWatch watches for instances of ObjectBucketClaim custom resources and acts on them
+func (c *ObjectBucketClaimController) StartWatch(namespace string, stopCh chan struct{}) error {
+
+	resourceHandlerFuncs := cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.onAdd,
+		UpdateFunc: c.onUpdate,
+		DeleteFunc: c.onDelete,
+	}
+
+	logger.Infof("start watching object bucket claim resources in namespace %s", namespace)
+	watcher := opkit.NewWatcher(ObjectBucketClaimResource, namespace, resourceHandlerFuncs, c.context.RookClientset.CephV1beta1().RESTClient())
+	go watcher.Watch(&cephv1beta1.ObjectBucketClaim{}, stopCh)
+
+	return nil
+}
+
+func (c *ObjectBucketClaimController) onAdd(obj interface{}) {
+	claim := obj.(*cephv1beta1.ObjectBucketClaim).DeepCopy()
+
+	// claims are added again when the operator restarts, but a bound claim was already provisioned
+	if claim.Status.Phase == cephv1beta1.ObjectBucketClaimPhaseBound {
+		logger.Infof("object bucket claim %s/%s is already bound to bucket %s", claim.Namespace, claim.Name, claim.Status.BucketName)
+		return
+	}
+
+	if err := c.provision(claim); err != nil {
+		logger.Errorf("failed to provision bucket for claim %s/%s. %+v", claim.Namespace, claim.Name, err)
+		c.updateStatus(claim, cephv1beta1.ObjectBucketClaimPhaseFailed, err.Error())
+	}
+}
+
+func (c *ObjectBucketClaimController) onUpdate(oldObj, newObj interface{}) {
+	oldClaim := oldObj.(*cephv1beta1.ObjectBucketClaim).DeepCopy()
+	newClaim := newObj.(*cephv1beta1.ObjectBucketClaim).DeepCopy()
+
+	if reflect.DeepEqual(oldClaim.Spec, newClaim.Spec) {
+		logger.Debugf("object bucket claim %s/%s did not change", newClaim.Namespace, newClaim.Name)
+		return
+	}
+	logger.Errorf("the spec of object bucket claim %s/%s cannot be changed after the bucket is provisioned", newClaim.Namespace, newClaim.Name)
+}
+
+func (c *ObjectBucketClaimController) onDelete(obj interface{}) {
+	claim := obj.(*cephv1beta1.ObjectBucketClaim).DeepCopy()
+
+	if err := c.deleteClaim(claim); err != nil {
+		logger.Errorf("failed to delete bucket for claim %s/%s. %+v", claim.Namespace, claim.Name, err)
+	}
+}
+
+// provision creates the bucket and the user that owns it, then publishes how to access the bucket in the namespace of the claim
+func (c *ObjectBucketClaimController) provision(claim *cephv1beta1.ObjectBucketClaim) error {
+	cfg, reclaimPolicy, err := c.getBucketClass(claim)
+	if err != nil {
+		return err
+	}
+
+	store, err := c.context.RookClientset.CephV1beta1().ObjectStores(cfg.objectStoreNamespace).Get(cfg.objectStoreName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to find object store %s in namespace %s. %+v", cfg.objectStoreName, cfg.objectStoreNamespace, err)
+	}
+	if store.Spec.Gateway.Port == 0 {
+		return fmt.Errorf("object store %s does not expose an http port", store.Name)
+	}
+
+	bucketName := claimBucketName(claim)
+	userID := claimUserID(claim)
+	logger.Infof("provisioning bucket %s in object store %s for claim %s/%s", bucketName, store.Name, claim.Namespace, claim.Name)
+
+	// create the user that will own the bucket
+	objContext := rgwdaemon.NewContext(c.context, store.Name, store.Namespace)
+	displayName := fmt.Sprintf("bucket claim %s/%s", claim.Namespace, claim.Name)
+	user, rgwerr, err := rgwdaemon.CreateUser(objContext, rgwdaemon.ObjectUser{UserID: userID, DisplayName: &displayName})
+	if err != nil {
+		if rgwerr != rgwdaemon.RGWErrorBadData {
+			return fmt.Errorf("failed to create user %s. %+v", userID, err)
+		}
+		user, _, err = rgwdaemon.GetUser(objContext, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get user %s. %+v", userID, err)
+		}
+	}
+	if user.AccessKey == nil || user.SecretKey == nil {
+		return fmt.Errorf("no keys found for user %s", userID)
+	}
+
+	// a bucket that already exists can only be claimed if it was provisioned for this claim
+	bucket, rgwerr, err := rgwdaemon.GetBucket(objContext, bucketName)
+	if err != nil && rgwerr != rgwdaemon.RGWErrorNotFound {
+		return fmt.Errorf("failed to get bucket %s. %+v", bucketName, err)
+	}
+	if err == nil && bucket.Owner != userID {
+		return fmt.Errorf("bucket %s already exists and is owned by %s", bucketName, bucket.Owner)
+	}
+
+	host := fmt.Sprintf("%s-%s.%s", AppName, store.Name, store.Namespace)
+	port := strconv.Itoa(int(store.Spec.Gateway.Port))
+	if err := c.createBucket(fmt.Sprintf("%s:%s", host, port), *user.AccessKey, *user.SecretKey, bucketName); err != nil {
+		return err
+	}
+
+	// publish the bucket endpoint and the keys in the namespace of the claim
+	labels := map[string]string{
+		"app":               AppName,
+		"rook_object_store": store.Name,
+		"bucket":            bucketName,
+	}
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: claim.Name, Namespace: claim.Namespace, Labels: labels},
+		Data: map[string]string{
+			BucketHostKey: host,
+			BucketPortKey: port,
+			BucketNameKey: bucketName,
+		},
+	}
+	k8sutil.SetOwnerRef(c.context.Clientset, claim.Namespace, &configMap.ObjectMeta, claimOwnerRef(claim))
+	if _, err := c.context.Clientset.CoreV1().ConfigMaps(claim.Namespace).Create(configMap); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create bucket config map. %+v", err)
+		}
+		if _, err := c.context.Clientset.CoreV1().ConfigMaps(claim.Namespace).Update(configMap); err != nil {
+			return fmt.Errorf("failed to update bucket config map. %+v", err)
+		}
+	}
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: claim.Name, Namespace: claim.Namespace, Labels: labels},
+		StringData: map[string]string{
+			BucketAccessKeyKey: *user.AccessKey,
+			BucketSecretKeyKey: *user.SecretKey,
+		},
+		Type: k8sutil.RookType,
+	}
+	k8sutil.SetOwnerRef(c.context.Clientset, claim.Namespace, &secret.ObjectMeta, claimOwnerRef(claim))
+	if _, err := c.context.Clientset.CoreV1().Secrets(claim.Namespace).Create(secret); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create bucket secret. %+v", err)
+		}
+		if _, err := c.context.Clientset.CoreV1().Secrets(claim.Namespace).Update(secret); err != nil {
+			return fmt.Errorf("failed to update bucket secret. %+v", err)
+		}
+	}
+
+	// record where the bucket was provisioned so it can be cleaned up even if the storage class is removed
+	claim.Status = cephv1beta1.ObjectBucketClaimStatus{
+		Phase:                cephv1beta1.ObjectBucketClaimPhaseBound,
+		BucketName:           bucketName,
+		ObjectStoreName:      store.Name,
+		ObjectStoreNamespace: store.Namespace,
+		UserID:               userID,
+		ReclaimPolicy:        reclaimPolicy,
+	}
+	if _, err := c.context.RookClientset.CephV1beta1().ObjectBucketClaims(claim.Namespace).Update(claim); err != nil {
+		return fmt.Errorf("failed to update status of claim. %+v", err)
+	}
+
+	logger.Infof("bucket %s bound to claim %s/%s", bucketName, claim.Namespace, claim.Name)
+	return nil
+}
+
+// deleteClaim removes the config map and secret of the claim and purges the bucket and its user unless the bucket must be kept
+func (c *ObjectBucketClaimController) deleteClaim(claim *cephv1beta1.ObjectBucketClaim) error {
+	if claim.Status.Phase == cephv1beta1.ObjectBucketClaimPhaseBound {
+		if claim.Status.ReclaimPolicy == v1.PersistentVolumeReclaimRetain {
+			logger.Infof("keeping bucket %s of deleted claim %s/%s", claim.Status.BucketName, claim.Namespace, claim.Name)
+		} else {
+			logger.Infof("purging bucket %s of deleted claim %s/%s", claim.Status.BucketName, claim.Namespace, claim.Name)
+			objContext := rgwdaemon.NewContext(c.context, claim.Status.ObjectStoreName, claim.Status.ObjectStoreNamespace)
+			if err := purgeBucket(objContext, claim.Status.BucketName, claim.Status.UserID, false); err != nil {
+				return err
+			}
+		}
+	} else if err := c.purgeUnboundClaim(claim); err != nil {
+		return err
+	}
+
+	// the config map and secret are owned by the claim, but remove them now in case garbage collection is not enabled
+	err := c.context.Clientset.CoreV1().ConfigMaps(claim.Namespace).Delete(claim.Name, &metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete bucket config map. %+v", err)
+	}
+	err = c.context.Clientset.CoreV1().Secrets(claim.Namespace).Delete(claim.Name, &metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete bucket secret. %+v", err)
+	}
+
+	return nil
+}
+
+// purgeUnboundClaim removes the bucket and user that a claim may have created before it failed to be bound.
+// The object store is not recorded in the status of the claim so it is found from the storage class.
+func (c *ObjectBucketClaimController) purgeUnboundClaim(claim *cephv1beta1.ObjectBucketClaim) error {
+	cfg, reclaimPolicy, err := c.getBucketClass(claim)
+	if err != nil {
+		logger.Warningf("cannot find the object store of unbound claim %s/%s to clean up its bucket. %+v", claim.Namespace, claim.Name, err)
+		return nil
+	}
+	bucketName := claimBucketName(claim)
+	if reclaimPolicy == v1.PersistentVolumeReclaimRetain {
+		logger.Infof("keeping bucket %s of deleted unbound claim %s/%s", bucketName, claim.Namespace, claim.Name)
+		return nil
+	}
+
+	logger.Infof("cleaning up bucket %s of deleted unbound claim %s/%s", bucketName, claim.Namespace, claim.Name)
+	objContext := rgwdaemon.NewContext(c.context, cfg.objectStoreName, cfg.objectStoreNamespace)
+	return purgeBucket(objContext, bucketName, claimUserID(claim), true)
+}
+
+// purgeBucket deletes the bucket with its objects and the user of a claim, ignoring what does not exist.
+// If the owner must be checked, a bucket that belongs to another user is left untouched.
+func purgeBucket(objContext *rgwdaemon.Context, bucketName, userID string, checkOwner bool) error {
+	deleteBucket := true
+	if checkOwner {
+		bucket, rgwerr, err := rgwdaemon.GetBucket(objContext, bucketName)
+		if err != nil && rgwerr != rgwdaemon.RGWErrorNotFound {
+			return fmt.Errorf("failed to get bucket %s. %+v", bucketName, err)
+		}
+		if err != nil {
+			deleteBucket = false
+		} else if bucket.Owner != userID {
+			logger.Infof("not deleting bucket %s owned by %s", bucketName, bucket.Owner)
+			deleteBucket = false
+		}
+	}
+	if deleteBucket {
+		if rgwerr, err := rgwdaemon.DeleteBucket(objContext, bucketName, true); err != nil && rgwerr != rgwdaemon.RGWErrorNotFound {
+			return fmt.Errorf("failed to delete bucket %s. %+v", bucketName, err)
+		}
+	}
+	if _, rgwerr, err := rgwdaemon.DeleteUser(objContext, userID); err != nil && rgwerr != rgwdaemon.RGWErrorNotFound {
+		return fmt.Errorf("failed to delete user %s. %+v", userID, err)
+	}
+	return nil
+}
+
+// getBucketClass returns the object store settings and the reclaim policy from the storage class of the claim
+func (c *ObjectBucketClaimController) getBucketClass(claim *cephv1beta1.ObjectBucketClaim) (*bucketClassConfig, v1.PersistentVolumeReclaimPolicy, error) {
+	class, err := c.context.Clientset.StorageV1().StorageClasses().Get(claim.Spec.StorageClassName, metav1.GetOptions{})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get storage class %s. %+v", claim.Spec.StorageClassName, err)
+	}
+	if class.Provisioner != BucketProvisionerName {
+		return nil, "", fmt.Errorf("storage class %s is not provisioned by %s", class.Name, BucketProvisionerName)
+	}
+	cfg, err := parseBucketClassParameters(class.Parameters)
+	if err != nil {
+		return nil, "", err
+	}
+	reclaimPolicy := v1.PersistentVolumeReclaimDelete
+	if class.ReclaimPolicy != nil {
+		reclaimPolicy = *class.ReclaimPolicy
+	}
+	return cfg, reclaimPolicy, nil
+}
+
+func (c *ObjectBucketClaimController) updateStatus(claim *cephv1beta1.ObjectBucketClaim, phase cephv1beta1.ObjectBucketClaimPhase, message string) {
+	claim.Status.Phase = phase
+	claim.Status.Message = message
+	if _, err := c.context.RookClientset.CephV1beta1().ObjectBucketClaims(claim.Namespace).Update(claim); err != nil {
+		logger.Errorf("failed to update status of claim %s/%s. %+v", claim.Namespace, claim.Name, err)
+	}
+}
+
+func parseBucketClassParameters(params map[string]string) (*bucketClassConfig, error) {
+	var cfg bucketClassConfig
+
+	for k, v := range params {
+		switch strings.ToLower(k) {
+		case "objectstorename":
+			cfg.objectStoreName = v
+		case "objectstorenamespace":
+			cfg.objectStoreNamespace = v
+		default:
+			return nil, fmt.Errorf("invalid option %q for bucket provisioner %s", k, BucketProvisionerName)
+		}
+	}
+
+	if len(cfg.objectStoreName) == 0 {
+		return nil, fmt.Errorf("StorageClass for provisioner %s must contain 'objectStoreName' parameter", BucketProvisionerName)
+	}
+	if len(cfg.objectStoreNamespace) == 0 {
+		return nil, fmt.Errorf("StorageClass for provisioner %s must contain 'objectStoreNamespace' parameter", BucketProvisionerName)
+	}
+
+	return &cfg, nil
+}
+
+func claimBucketName(claim *cephv1beta1.ObjectBucketClaim) string {
+	if claim.Spec.BucketName != "" {
+		return claim.Spec.BucketName
+	}
+	return fmt.Sprintf("%s-%s", claim.Namespace, claim.Name)
+}
+
+func claimUserID(claim *cephv1beta1.ObjectBucketClaim) string {
+	return fmt.Sprintf("obc-%s-%s", claim.Namespace, claim.Name)
+}
+
+func claimOwnerRef(claim *cephv1beta1.ObjectBucketClaim) *metav1.OwnerReference {
+	blockOwner := true
+	return &metav1.OwnerReference{
+		APIVersion:         fmt.Sprintf("%s/%s", ObjectBucketClaimResource.Group, ObjectBucketClaimResource.Version),
+		Kind:               ObjectBucketClaimResource.Kind,
+		Name:               claim.Name,
+		UID:                claim.UID,
+		BlockOwnerDeletion: &blockOwner,
+	}
+}
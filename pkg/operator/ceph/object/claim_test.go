@@ -0,0 +1,257 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"testing"
+
+	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestProvisionAndDeleteBucketClaim(t *testing.T) {
+	store := &cephv1beta1.ObjectStore{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-store", Namespace: "rook-ceph"},
+		Spec:       cephv1beta1.ObjectStoreSpec{Gateway: cephv1beta1.GatewaySpec{Port: 80}},
+	}
+	claim := &cephv1beta1.ObjectBucketClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-claim", Namespace: "app"},
+		Spec:       cephv1beta1.ObjectBucketClaimSpec{StorageClassName: "bucket-class"},
+	}
+	commands := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			commands = append(commands, args[0]+" "+args[1])
+			if args[0] == "user" && args[1] == "create" {
+				return `{"user_id":"obc-app-my-claim","keys":[{"access_key":"myaccesskey","secret_key":"mysecretkey"}]}`, nil
+			}
+			if args[0] == "bucket" && args[1] == "stats" {
+				return "could not get bucket info for bucket=app-my-claim", nil
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{
+		Clientset:     testop.New(3),
+		RookClientset: rookfake.NewSimpleClientset(store, claim),
+		Executor:      executor,
+	}
+	class := &storagev1.StorageClass{
+		ObjectMeta:  metav1.ObjectMeta{Name: "bucket-class"},
+		Provisioner: BucketProvisionerName,
+		Parameters:  map[string]string{"objectStoreName": "my-store", "objectStoreNamespace": "rook-ceph"},
+	}
+	_, err := context.Clientset.StorageV1().StorageClasses().Create(class)
+	assert.Nil(t, err)
+
+	controller := NewObjectBucketClaimController(context)
+	createdBucket := ""
+	createdEndpoint := ""
+	controller.createBucket = func(endpoint, accessKey, secretKey, bucketName string) error {
+		createdEndpoint = endpoint
+		createdBucket = bucketName
+		return nil
+	}
+
+	err = controller.provision(claim)
+	assert.Nil(t, err)
+	assert.Equal(t, "app-my-claim", createdBucket)
+	assert.Equal(t, "rook-ceph-rgw-my-store.rook-ceph:80", createdEndpoint)
+
+	// the endpoint and keys are published in the namespace of the claim
+	cm, err := context.Clientset.CoreV1().ConfigMaps("app").Get("my-claim", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "rook-ceph-rgw-my-store.rook-ceph", cm.Data[BucketHostKey])
+	assert.Equal(t, "80", cm.Data[BucketPortKey])
+	assert.Equal(t, "app-my-claim", cm.Data[BucketNameKey])
+	secret, err := context.Clientset.CoreV1().Secrets("app").Get("my-claim", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "myaccesskey", secret.StringData[BucketAccessKeyKey])
+	assert.Equal(t, "mysecretkey", secret.StringData[BucketSecretKeyKey])
+
+	// the claim is bound
+	bound, err := context.RookClientset.CephV1beta1().ObjectBucketClaims("app").Get("my-claim", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cephv1beta1.ObjectBucketClaimPhaseBound, bound.Status.Phase)
+	assert.Equal(t, "my-store", bound.Status.ObjectStoreName)
+	assert.Equal(t, "obc-app-my-claim", bound.Status.UserID)
+	assert.Equal(t, v1.PersistentVolumeReclaimDelete, bound.Status.ReclaimPolicy)
+
+	// the bucket and user are kept with the retain policy
+	commands = []string{}
+	bound.Status.ReclaimPolicy = v1.PersistentVolumeReclaimRetain
+	err = controller.deleteClaim(bound)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(commands))
+	_, err = context.Clientset.CoreV1().Secrets("app").Get("my-claim", metav1.GetOptions{})
+	assert.True(t, errors.IsNotFound(err))
+
+	// the bucket and user are purged with the delete policy
+	bound.Status.ReclaimPolicy = v1.PersistentVolumeReclaimDelete
+	err = controller.deleteClaim(bound)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"bucket rm", "user rm"}, commands)
+}
+
+func TestProvisionBucketOwnedByOtherUser(t *testing.T) {
+	store := &cephv1beta1.ObjectStore{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-store", Namespace: "rook-ceph"},
+		Spec:       cephv1beta1.ObjectStoreSpec{Gateway: cephv1beta1.GatewaySpec{Port: 80}},
+	}
+	claim := &cephv1beta1.ObjectBucketClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-claim", Namespace: "app"},
+		Spec:       cephv1beta1.ObjectBucketClaimSpec{StorageClassName: "bucket-class", BucketName: "shared"},
+	}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			if args[0] == "user" && args[1] == "create" {
+				return `{"user_id":"obc-app-my-claim","keys":[{"access_key":"myaccesskey","secret_key":"mysecretkey"}]}`, nil
+			}
+			if args[0] == "bucket" && args[1] == "stats" {
+				return `{"bucket":"shared","usage":{}}`, nil
+			}
+			if args[0] == "metadata" {
+				return `{"data":{"owner":"someone-else","creation_time":"2018-10-23 01:02:03.000000Z"}}`, nil
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{
+		Clientset:     testop.New(3),
+		RookClientset: rookfake.NewSimpleClientset(store, claim),
+		Executor:      executor,
+	}
+	class := &storagev1.StorageClass{
+		ObjectMeta:  metav1.ObjectMeta{Name: "bucket-class"},
+		Provisioner: BucketProvisionerName,
+		Parameters:  map[string]string{"objectStoreName": "my-store", "objectStoreNamespace": "rook-ceph"},
+	}
+	_, err := context.Clientset.StorageV1().StorageClasses().Create(class)
+	assert.Nil(t, err)
+
+	controller := NewObjectBucketClaimController(context)
+	controller.createBucket = func(endpoint, accessKey, secretKey, bucketName string) error {
+		assert.Fail(t, "the bucket should not be created")
+		return nil
+	}
+	err = controller.provision(claim)
+	assert.NotNil(t, err)
+}
+
+func TestParseBucketClassParameters(t *testing.T) {
+	cfg, err := parseBucketClassParameters(map[string]string{"objectStoreName": "my-store", "objectStoreNamespace": "rook-ceph"})
+	assert.Nil(t, err)
+	assert.Equal(t, "my-store", cfg.objectStoreName)
+	assert.Equal(t, "rook-ceph", cfg.objectStoreNamespace)
+
+	// the object store is required
+	_, err = parseBucketClassParameters(map[string]string{"objectStoreNamespace": "rook-ceph"})
+	assert.NotNil(t, err)
+
+	// the namespace is required
+	_, err = parseBucketClassParameters(map[string]string{"objectStoreName": "my-store"})
+	assert.NotNil(t, err)
+
+	// unknown parameters are not allowed
+	_, err = parseBucketClassParameters(map[string]string{"objectStoreName": "my-store", "objectStoreNamespace": "rook-ceph", "pool": "a"})
+	assert.NotNil(t, err)
+}
+
+func TestBoundClaimIsNotProvisionedAgain(t *testing.T) {
+	claim := &cephv1beta1.ObjectBucketClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-claim", Namespace: "app"},
+		Spec:       cephv1beta1.ObjectBucketClaimSpec{StorageClassName: "bucket-class"},
+		Status:     cephv1beta1.ObjectBucketClaimStatus{Phase: cephv1beta1.ObjectBucketClaimPhaseBound, BucketName: "app-my-claim"},
+	}
+	context := &clusterd.Context{
+		Clientset:     testop.New(3),
+		RookClientset: rookfake.NewSimpleClientset(claim),
+		Executor:      &exectest.MockExecutor{},
+	}
+
+	// the storage class is missing, which would fail the claim if it were provisioned again
+	controller := NewObjectBucketClaimController(context)
+	controller.onAdd(claim)
+	current, err := context.RookClientset.CephV1beta1().ObjectBucketClaims("app").Get("my-claim", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cephv1beta1.ObjectBucketClaimPhaseBound, current.Status.Phase)
+}
+
+func TestDeleteUnboundClaim(t *testing.T) {
+	claim := &cephv1beta1.ObjectBucketClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-claim", Namespace: "app"},
+		Spec:       cephv1beta1.ObjectBucketClaimSpec{StorageClassName: "bucket-class"},
+		Status:     cephv1beta1.ObjectBucketClaimStatus{Phase: cephv1beta1.ObjectBucketClaimPhaseFailed},
+	}
+	owner := "obc-app-my-claim"
+	commands := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			commands = append(commands, args[0]+" "+args[1])
+			if args[0] == "bucket" && args[1] == "stats" {
+				return `{"bucket":"app-my-claim","usage":{}}`, nil
+			}
+			if args[0] == "metadata" {
+				return `{"data":{"owner":"` + owner + `","creation_time":"2018-10-23 01:02:03.000000Z"}}`, nil
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{
+		Clientset:     testop.New(3),
+		RookClientset: rookfake.NewSimpleClientset(claim),
+		Executor:      executor,
+	}
+	class := &storagev1.StorageClass{
+		ObjectMeta:  metav1.ObjectMeta{Name: "bucket-class"},
+		Provisioner: BucketProvisionerName,
+		Parameters:  map[string]string{"objectStoreName": "my-store", "objectStoreNamespace": "rook-ceph"},
+	}
+	_, err := context.Clientset.StorageV1().StorageClasses().Create(class)
+	assert.Nil(t, err)
+	controller := NewObjectBucketClaimController(context)
+
+	// the bucket and user created before the claim failed are purged
+	err = controller.deleteClaim(claim)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"bucket stats", "metadata get", "bucket rm", "user rm"}, commands)
+
+	// a bucket owned by another user is kept
+	owner = "someone-else"
+	commands = []string{}
+	err = controller.deleteClaim(claim)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"bucket stats", "metadata get", "user rm"}, commands)
+
+	// nothing is purged with the retain policy
+	retain := v1.PersistentVolumeReclaimRetain
+	class.ReclaimPolicy = &retain
+	_, err = context.Clientset.StorageV1().StorageClasses().Update(class)
+	assert.Nil(t, err)
+	commands = []string{}
+	err = controller.deleteClaim(claim)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(commands))
+}
@@ -28,6 +28,8 @@ import (
 	rookv1alpha2 "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/operator/ceph/pool"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -65,17 +67,17 @@ type ObjectStoreController struct {
 	context     *clusterd.Context
 	rookImage   string
 	cephVersion cephv1beta1.CephVersionSpec
-	hostNetwork bool
+	network     rookv1alpha2.NetworkSpec
 	ownerRef    metav1.OwnerReference
 }
 
 // NewObjectStoreController create controller for watching object store custom resources created
-func NewObjectStoreController(context *clusterd.Context, rookImage string, cephVersion cephv1beta1.CephVersionSpec, hostNetwork bool, ownerRef metav1.OwnerReference) *ObjectStoreController {
+func NewObjectStoreController(context *clusterd.Context, rookImage string, cephVersion cephv1beta1.CephVersionSpec, network rookv1alpha2.NetworkSpec, ownerRef metav1.OwnerReference) *ObjectStoreController {
 	return &ObjectStoreController{
 		context:     context,
 		rookImage:   rookImage,
 		cephVersion: cephVersion,
-		hostNetwork: hostNetwork,
+		network:     network,
 		ownerRef:    ownerRef,
 	}
 }
@@ -113,10 +115,13 @@ func (c *ObjectStoreController) onAdd(obj interface{}) {
 		return
 	}
 
-	cfg := config{c.context, *objectstore, c.rookImage, c.cephVersion, c.hostNetwork, c.storeOwners(objectstore)}
+	cfg := config{c.context, *objectstore, c.rookImage, c.cephVersion, c.network, c.storeOwners(objectstore)}
 	if err = cfg.createStore(); err != nil {
 		logger.Errorf("failed to create object store %s. %+v", objectstore.Name, err)
+		k8sutil.RecordEvent(c.context, objectstore, v1.EventTypeWarning, k8sutil.EventReasonCreateFailed, "failed to create object store %s. %+v", objectstore.Name, err)
+		return
 	}
+	k8sutil.RecordEvent(c.context, objectstore, v1.EventTypeNormal, k8sutil.EventReasonCreated, "created object store %s", objectstore.Name)
 }
 
 func (c *ObjectStoreController) onUpdate(oldObj, newObj interface{}) {
@@ -145,7 +150,7 @@ func (c *ObjectStoreController) onUpdate(oldObj, newObj interface{}) {
 	}
 
 	logger.Infof("applying object store %s changes", newStore.Name)
-	cfg := config{c.context, *newStore, c.rookImage, c.cephVersion, c.hostNetwork, c.storeOwners(newStore)}
+	cfg := config{c.context, *newStore, c.rookImage, c.cephVersion, c.network, c.storeOwners(newStore)}
 	if err = cfg.updateStore(); err != nil {
 		logger.Errorf("failed to create (modify) object store %s. %+v", newStore.Name, err)
 	}
@@ -166,7 +171,31 @@ func (c *ObjectStoreController) onDelete(obj interface{}) {
 	cfg := config{context: c.context, store: *objectstore}
 	if err = cfg.deleteStore(); err != nil {
 		logger.Errorf("failed to delete object store %s. %+v", objectstore.Name, err)
+		k8sutil.RecordEvent(c.context, objectstore, v1.EventTypeWarning, k8sutil.EventReasonDeleteFailed, "failed to delete object store %s. %+v", objectstore.Name, err)
+		return
+	}
+	k8sutil.RecordEvent(c.context, objectstore, v1.EventTypeNormal, k8sutil.EventReasonDeleted, "deleted object store %s", objectstore.Name)
+}
+
+// UpdateCephVersion restarts the rgw daemons of all the object stores in the namespace with a new version of ceph.
+// Object stores created or updated after this call will also run the new version.
+func (c *ObjectStoreController) UpdateCephVersion(namespace string, cephVersion cephv1beta1.CephVersionSpec) error {
+	c.cephVersion = cephVersion
+
+	stores, err := c.context.RookClientset.CephV1beta1().ObjectStores(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list object stores in namespace %s. %+v", namespace, err)
+	}
+
+	for _, store := range stores.Items {
+		logger.Infof("upgrading rgw for object store %s", store.Name)
+		cfg := config{c.context, store, c.rookImage, c.cephVersion, c.network, c.storeOwners(&store)}
+		if err := cfg.startRGWPods(true); err != nil {
+			return fmt.Errorf("failed to upgrade object store %s. %+v", store.Name, err)
+		}
 	}
+
+	return nil
 }
 
 func (c *ObjectStoreController) storeOwners(store *cephv1beta1.ObjectStore) []metav1.OwnerReference {
@@ -21,6 +21,7 @@ import (
 	"fmt"
 
 	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
 	rgwdaemon "github.com/rook/rook/pkg/daemon/ceph/rgw"
@@ -32,7 +33,8 @@ import (
 )
 
 const (
-	appName        = "rook-ceph-rgw"
+	// AppName is the name of Rook's Ceph rgw (Object) sub-app
+	AppName        = "rook-ceph-rgw"
 	keyringName    = "keyring"
 	certVolumeName = "rook-rgw-cert"
 	certMountPath  = "/etc/rook/private"
@@ -45,7 +47,7 @@ type config struct {
 	store       cephv1beta1.ObjectStore
 	rookVersion string
 	cephVersion cephv1beta1.CephVersionSpec
-	hostNetwork bool
+	network     rookalpha.NetworkSpec
 	ownerRefs   []metav1.OwnerReference
 }
 
@@ -253,7 +255,7 @@ func (c *config) createKeyring() error {
 }
 
 func (c *config) instanceName() string {
-	return fmt.Sprintf("%s-%s", appName, c.store.Name)
+	return fmt.Sprintf("%s-%s", AppName, c.store.Name)
 }
 
 // create a keyring for the rgw client with a limited set of privileges
@@ -22,6 +22,7 @@ import (
 	"testing"
 
 	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	"github.com/rook/rook/pkg/clusterd"
 	testop "github.com/rook/rook/pkg/operator/test"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
@@ -49,7 +50,7 @@ func TestStartRGW(t *testing.T) {
 	version := "v1.1.0"
 
 	// start a basic cluster
-	c := &config{context, store, version, cephv1beta1.CephVersionSpec{}, false, []metav1.OwnerReference{}}
+	c := &config{context, store, version, cephv1beta1.CephVersionSpec{}, rookalpha.NetworkSpec{}, []metav1.OwnerReference{}}
 	err := c.createStore()
 	assert.Nil(t, err)
 
@@ -116,7 +117,7 @@ func TestCreateObjectStore(t *testing.T) {
 	context := &clusterd.Context{Executor: executor, Clientset: clientset}
 
 	// create the pools
-	c := &config{context, store, "1.2.3.4", cephv1beta1.CephVersionSpec{}, false, []metav1.OwnerReference{}}
+	c := &config{context, store, "1.2.3.4", cephv1beta1.CephVersionSpec{}, rookalpha.NetworkSpec{}, []metav1.OwnerReference{}}
 	err := c.createStore()
 	assert.Nil(t, err)
 }
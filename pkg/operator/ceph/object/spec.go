@@ -76,9 +76,9 @@ func (c *config) makeRGWPodSpec() v1.PodTemplateSpec {
 		},
 		RestartPolicy: v1.RestartPolicyAlways,
 		Volumes:       opspec.PodVolumes(""),
-		HostNetwork:   c.hostNetwork,
+		HostNetwork:   c.network.HostNetwork,
 	}
-	if c.hostNetwork {
+	if c.network.HostNetwork {
 		podSpec.DNSPolicy = v1.DNSClusterFirstWithHostNet
 	}
 
@@ -93,7 +93,7 @@ func (c *config) makeRGWPodSpec() v1.PodTemplateSpec {
 
 	c.store.Spec.Gateway.Placement.ApplyToPodSpec(&podSpec)
 
-	return v1.PodTemplateSpec{
+	podTemplateSpec := v1.PodTemplateSpec{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        c.instanceName(),
 			Labels:      c.getLabels(),
@@ -101,6 +101,8 @@ func (c *config) makeRGWPodSpec() v1.PodTemplateSpec {
 		},
 		Spec: podSpec,
 	}
+	opspec.AddNetworkAnnotations(&podTemplateSpec.ObjectMeta, c.network, false)
+	return podTemplateSpec
 }
 
 func (c *config) makeConfigInitContainer() v1.Container {
@@ -124,9 +126,11 @@ func (c *config) makeConfigInitContainer() v1.Container {
 			opmon.EndpointEnvVar(),
 			opmon.SecretEnvVar(),
 			k8sutil.ConfigOverrideEnvVar(),
+			k8sutil.CephConfigEnvVar(),
 		},
 		Resources: c.store.Spec.Gateway.Resources,
 	}
+	container.Env = append(container.Env, opspec.NetworkEnvVars(c.network)...)
 
 	if c.store.Spec.Gateway.SSLCertificateRef != "" {
 		// Add a volume mount for the ssl certificate
@@ -176,7 +180,7 @@ func (c *config) startService() (string, error) {
 		},
 	}
 	k8sutil.SetOwnerRefs(c.context.Clientset, c.store.Namespace, &svc.ObjectMeta, c.ownerRefs)
-	if c.hostNetwork {
+	if c.network.HostNetwork {
 		svc.Spec.ClusterIP = v1.ClusterIPNone
 	}
 
@@ -213,7 +217,7 @@ func addPort(service *v1.Service, name string, port int32) {
 
 func (c *config) getLabels() map[string]string {
 	return map[string]string{
-		k8sutil.AppAttr:     appName,
+		k8sutil.AppAttr:     AppName,
 		k8sutil.ClusterAttr: c.store.Namespace,
 		"rook_object_store": c.store.Name,
 	}
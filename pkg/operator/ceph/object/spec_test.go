@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	"github.com/rook/rook/pkg/clusterd"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	"github.com/rook/rook/pkg/operator/k8sutil"
@@ -41,7 +42,7 @@ func TestPodSpecs(t *testing.T) {
 		},
 	}
 
-	c := &config{store: store, rookVersion: "rook/rook:myversion", cephVersion: cephv1beta1.CephVersionSpec{Image: "ceph/ceph:v13.2.1"}, hostNetwork: true}
+	c := &config{store: store, rookVersion: "rook/rook:myversion", cephVersion: cephv1beta1.CephVersionSpec{Image: "ceph/ceph:v13.2.1"}, network: rookalpha.NetworkSpec{HostNetwork: true}}
 	s := c.makeRGWPodSpec()
 	assert.NotNil(t, s)
 	//assert.Equal(t, instanceName(store), s.Name)
@@ -51,7 +52,7 @@ func TestPodSpecs(t *testing.T) {
 	assert.Equal(t, cephconfig.DefaultConfigMountName, s.Spec.Volumes[1].Name)
 
 	assert.Equal(t, c.instanceName(), s.ObjectMeta.Name)
-	assert.Equal(t, appName, s.ObjectMeta.Labels["app"])
+	assert.Equal(t, AppName, s.ObjectMeta.Labels["app"])
 	assert.Equal(t, store.Namespace, s.ObjectMeta.Labels["rook_cluster"])
 	assert.Equal(t, store.Name, s.ObjectMeta.Labels["rook_object_store"])
 	assert.Equal(t, 0, len(s.ObjectMeta.Annotations))
@@ -91,7 +92,7 @@ func TestSSLPodSpec(t *testing.T) {
 	store.Spec.Gateway.SSLCertificateRef = "mycert"
 	store.Spec.Gateway.SecurePort = 443
 
-	c := &config{store: store, rookVersion: "v1.0", hostNetwork: true}
+	c := &config{store: store, rookVersion: "v1.0", network: rookalpha.NetworkSpec{HostNetwork: true}}
 	s := c.makeRGWPodSpec()
 	assert.NotNil(t, s)
 	assert.Equal(t, c.instanceName(), s.Name)
@@ -0,0 +1,235 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"fmt"
+	"reflect"
+
+	opkit "github.com/rook/operator-kit"
+	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	"github.com/rook/rook/pkg/clusterd"
+	rgwdaemon "github.com/rook/rook/pkg/daemon/ceph/rgw"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	userResourceName       = "objectstoreuser"
+	userResourceNamePlural = "objectstoreusers"
+
+	// AccessKeyName is the key in the user secret with the S3 access key
+	AccessKeyName = "AccessKey"
+	// SecretKeyName is the key in the user secret with the S3 secret key
+	SecretKeyName = "SecretKey"
+)
+
+// ObjectStoreUserResource represents the object store user custom resource
+var ObjectStoreUserResource = opkit.CustomResource{
+	Name:    userResourceName,
+	Plural:  userResourceNamePlural,
+	Group:   cephv1beta1.CustomResourceGroup,
+	Version: cephv1beta1.Version,
+	Scope:   apiextensionsv1beta1.NamespaceScoped,
+	Kind:    reflect.TypeOf(cephv1beta1.ObjectStoreUser{}).Name(),
+}
+
+// ObjectStoreUserController represents a controller object for object store user custom resources
+type ObjectStoreUserController struct {
+	context *clusterd.Context
+}
+
+// NewObjectStoreUserController create controller for watching object store user custom resources created
+func NewObjectStoreUserController(context *clusterd.Context) *ObjectStoreUserController {
+	return &ObjectStoreUserController{
+		context: context,
+	}
+}
+
+// StartWatch watches for instances of ObjectStoreUser custom resources and acts on them
+func (c *ObjectStoreUserController) StartWatch(namespace string, stopCh chan struct{}) error {
+
+	resourceHandlerFuncs := cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.onAdd,
+		UpdateFunc: c.onUpdate,
+		DeleteFunc: c.onDelete,
+	}
+
+	logger.Infof("start watching object store user resources in namespace %s", namespace)
+	watcher := opkit.NewWatcher(ObjectStoreUserResource, namespace, resourceHandlerFuncs, c.context.RookClientset.CephV1beta1().RESTClient())
+	go watcher.Watch(&cephv1beta1.ObjectStoreUser{}, stopCh)
+
+	return nil
+}
+
+func (c *ObjectStoreUserController) onAdd(obj interface{}) {
+	user := obj.(*cephv1beta1.ObjectStoreUser).DeepCopy()
+
+	if err := c.createUser(user); err != nil {
+		logger.Errorf("failed to create object store user %s. %+v", user.Name, err)
+	}
+}
+
+func (c *ObjectStoreUserController) onUpdate(oldObj, newObj interface{}) {
+	oldUser := oldObj.(*cephv1beta1.ObjectStoreUser).DeepCopy()
+	newUser := newObj.(*cephv1beta1.ObjectStoreUser).DeepCopy()
+
+	if oldUser.Spec.Store != newUser.Spec.Store {
+		logger.Errorf("the store of object store user %s cannot be changed from %s to %s", newUser.Name, oldUser.Spec.Store, newUser.Spec.Store)
+		return
+	}
+	if oldUser.Spec.DisplayName == newUser.Spec.DisplayName {
+		logger.Debugf("object store user %s did not change", newUser.Name)
+		return
+	}
+
+	logger.Infof("updating object store user %s", newUser.Name)
+	displayName := userDisplayName(newUser)
+	objContext := rgwdaemon.NewContext(c.context, newUser.Spec.Store, newUser.Namespace)
+	if _, _, err := rgwdaemon.UpdateUser(objContext, rgwdaemon.ObjectUser{UserID: newUser.Name, DisplayName: &displayName}); err != nil {
+		logger.Errorf("failed to update object store user %s. %+v", newUser.Name, err)
+	}
+}
+
+func (c *ObjectStoreUserController) onDelete(obj interface{}) {
+	user := obj.(*cephv1beta1.ObjectStoreUser).DeepCopy()
+
+	if err := c.deleteUser(user); err != nil {
+		logger.Errorf("failed to delete object store user %s. %+v", user.Name, err)
+	}
+}
+
+func (c *ObjectStoreUserController) createUser(u *cephv1beta1.ObjectStoreUser) error {
+	if err := validateUser(c.context, u); err != nil {
+		return fmt.Errorf("invalid object store user %s arguments. %+v", u.Name, err)
+	}
+
+	logger.Infof("creating object store user %s in object store %s", u.Name, u.Spec.Store)
+	displayName := userDisplayName(u)
+	objContext := rgwdaemon.NewContext(c.context, u.Spec.Store, u.Namespace)
+	user, rgwerr, err := rgwdaemon.CreateUser(objContext, rgwdaemon.ObjectUser{UserID: u.Name, DisplayName: &displayName})
+	if err != nil {
+		if rgwerr != rgwdaemon.RGWErrorBadData {
+			return fmt.Errorf("failed to create user. %+v", err)
+		}
+
+		// the user already exists, retrieve the keys of the existing user
+		logger.Infof("object store user %s already exists", u.Name)
+		user, _, err = rgwdaemon.GetUser(objContext, u.Name)
+		if err != nil {
+			return fmt.Errorf("failed to get user. %+v", err)
+		}
+	}
+	if user.AccessKey == nil || user.SecretKey == nil {
+		return fmt.Errorf("no keys found for user %s", u.Name)
+	}
+
+	// store the keys in a secret that is owned by the user resource
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      userSecretName(u),
+			Namespace: u.Namespace,
+			Labels: map[string]string{
+				"app":               AppName,
+				"rook_object_store": u.Spec.Store,
+				"user":              u.Name,
+			},
+		},
+		StringData: map[string]string{
+			AccessKeyName: *user.AccessKey,
+			SecretKeyName: *user.SecretKey,
+		},
+		Type: k8sutil.RookType,
+	}
+	k8sutil.SetOwnerRef(c.context.Clientset, u.Namespace, &secret.ObjectMeta, userOwnerRef(u))
+
+	if _, err = c.context.Clientset.CoreV1().Secrets(u.Namespace).Create(secret); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to save user %s secret. %+v", u.Name, err)
+		}
+		if _, err = c.context.Clientset.CoreV1().Secrets(u.Namespace).Update(secret); err != nil {
+			return fmt.Errorf("failed to update user %s secret. %+v", u.Name, err)
+		}
+	}
+
+	logger.Infof("created object store user %s with secret %s", u.Name, secret.Name)
+	return nil
+}
+
+func (c *ObjectStoreUserController) deleteUser(u *cephv1beta1.ObjectStoreUser) error {
+	logger.Infof("deleting object store user %s from object store %s", u.Name, u.Spec.Store)
+	objContext := rgwdaemon.NewContext(c.context, u.Spec.Store, u.Namespace)
+	if _, rgwerr, err := rgwdaemon.DeleteUser(objContext, u.Name); err != nil && rgwerr != rgwdaemon.RGWErrorNotFound {
+		return fmt.Errorf("failed to delete user. %+v", err)
+	}
+
+	// the secret is owned by the user resource, but remove it now in case garbage collection is not enabled
+	err := c.context.Clientset.CoreV1().Secrets(u.Namespace).Delete(userSecretName(u), &metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete user %s secret. %+v", u.Name, err)
+	}
+
+	logger.Infof("deleted object store user %s", u.Name)
+	return nil
+}
+
+func validateUser(context *clusterd.Context, u *cephv1beta1.ObjectStoreUser) error {
+	if u.Name == "" {
+		return fmt.Errorf("missing name")
+	}
+	if u.Namespace == "" {
+		return fmt.Errorf("missing namespace")
+	}
+	if u.Spec.Store == "" {
+		return fmt.Errorf("missing store")
+	}
+	if _, err := context.RookClientset.CephV1beta1().ObjectStores(u.Namespace).Get(u.Spec.Store, metav1.GetOptions{}); err != nil {
+		return fmt.Errorf("failed to find object store %s. %+v", u.Spec.Store, err)
+	}
+	return nil
+}
+
+func userDisplayName(u *cephv1beta1.ObjectStoreUser) string {
+	if u.Spec.DisplayName != "" {
+		return u.Spec.DisplayName
+	}
+	return u.Name
+}
+
+func userSecretName(u *cephv1beta1.ObjectStoreUser) string {
+	return UserSecretName(u.Spec.Store, u.Name)
+}
+
+// UserSecretName returns the name of the secret with the keys of an object store user
+func UserSecretName(store, user string) string {
+	return fmt.Sprintf("rook-ceph-object-user-%s-%s", store, user)
+}
+
+func userOwnerRef(u *cephv1beta1.ObjectStoreUser) *metav1.OwnerReference {
+	blockOwner := true
+	return &metav1.OwnerReference{
+		APIVersion:         fmt.Sprintf("%s/%s", ObjectStoreUserResource.Group, ObjectStoreUserResource.Version),
+		Kind:               ObjectStoreUserResource.Kind,
+		Name:               u.Name,
+		UID:                u.UID,
+		BlockOwnerDeletion: &blockOwner,
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"testing"
+
+	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCreateAndDeleteUser(t *testing.T) {
+	store := &cephv1beta1.ObjectStore{ObjectMeta: metav1.ObjectMeta{Name: "my-store", Namespace: "ns"}}
+	commands := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			commands = append(commands, args[0]+" "+args[1])
+			if args[1] == "create" {
+				return `{"user_id":"my-user","display_name":"my display name","keys":[{"access_key":"myaccesskey","secret_key":"mysecretkey"}]}`, nil
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{
+		Clientset:     testop.New(3),
+		RookClientset: rookfake.NewSimpleClientset(store),
+		Executor:      executor,
+	}
+	controller := NewObjectStoreUserController(context)
+
+	user := &cephv1beta1.ObjectStoreUser{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-user", Namespace: "ns"},
+		Spec:       cephv1beta1.ObjectStoreUserSpec{Store: "my-store", DisplayName: "my display name"},
+	}
+	err := controller.createUser(user)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"user create"}, commands)
+
+	// the keys are stored in a secret
+	secret, err := context.Clientset.CoreV1().Secrets("ns").Get("rook-ceph-object-user-my-store-my-user", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "myaccesskey", secret.StringData[AccessKeyName])
+	assert.Equal(t, "mysecretkey", secret.StringData[SecretKeyName])
+	assert.Equal(t, "my-user", secret.Labels["user"])
+
+	// creating the user again updates the secret
+	err = controller.createUser(user)
+	assert.Nil(t, err)
+
+	// the user and the secret are removed
+	err = controller.deleteUser(user)
+	assert.Nil(t, err)
+	assert.Equal(t, "user rm", commands[len(commands)-1])
+	_, err = context.Clientset.CoreV1().Secrets("ns").Get("rook-ceph-object-user-my-store-my-user", metav1.GetOptions{})
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestValidateUser(t *testing.T) {
+	store := &cephv1beta1.ObjectStore{ObjectMeta: metav1.ObjectMeta{Name: "my-store", Namespace: "ns"}}
+	context := &clusterd.Context{RookClientset: rookfake.NewSimpleClientset(store)}
+
+	user := &cephv1beta1.ObjectStoreUser{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-user", Namespace: "ns"},
+		Spec:       cephv1beta1.ObjectStoreUserSpec{Store: "my-store"},
+	}
+	assert.Nil(t, validateUser(context, user))
+	assert.Equal(t, "my-user", userDisplayName(user))
+
+	// the store must exist
+	user.Spec.Store = "other-store"
+	assert.NotNil(t, validateUser(context, user))
+
+	// the store is required
+	user.Spec.Store = ""
+	assert.NotNil(t, validateUser(context, user))
+
+	// the name is required
+	user.Spec.Store = "my-store"
+	user.Name = ""
+	assert.NotNil(t, validateUser(context, user))
+}
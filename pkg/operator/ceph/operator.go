@@ -47,8 +47,9 @@ import (
 
 // volume provisioner constant
 const (
-	provisionerName       = "ceph.rook.io/block"
-	provisionerNameLegacy = "rook.io/block"
+	provisionerName           = "ceph.rook.io/block"
+	provisionerNameLegacy     = "rook.io/block"
+	filesystemProvisionerName = "ceph.rook.io/filesystem"
 )
 
 var logger = capnslog.NewPackageLogger("github.com/rook/rook", "operator")
@@ -75,7 +76,8 @@ func New(context *clusterd.Context, volumeAttachmentWrapper attachment.Attachmen
 	clusterController := cluster.NewClusterController(context, rookImage, volumeAttachmentWrapper)
 
 	schemes := []opkit.CustomResource{cluster.ClusterResource, pool.PoolResource, object.ObjectStoreResource,
-		file.FilesystemResource, attachment.VolumeResource}
+		object.ObjectStoreUserResource, object.ObjectBucketClaimResource, file.FilesystemResource, attachment.VolumeResource,
+		provisioner.VolumeSnapshotResource}
 	return &Operator{
 		context:           context,
 		clusterController: clusterController,
@@ -114,8 +116,15 @@ func (o *Operator) Run() error {
 
 	rookAgent := agent.New(o.context.Clientset)
 
-	if err := rookAgent.Start(namespace, o.rookImage, o.securityAccount); err != nil {
-		return fmt.Errorf("Error starting agent daemonset: %v", err)
+	// the csi driver replaces the agent and the flex driver when it is enabled
+	if agent.CSIEnabled() {
+		if err := rookAgent.StartCSI(namespace, o.rookImage, o.securityAccount); err != nil {
+			return fmt.Errorf("Error starting csi driver: %v", err)
+		}
+	} else {
+		if err := rookAgent.Start(namespace, o.rookImage, o.securityAccount); err != nil {
+			return fmt.Errorf("Error starting agent daemonset: %v", err)
+		}
 	}
 
 	rookDiscover := discover.New(o.context.Clientset)
@@ -145,9 +154,28 @@ func (o *Operator) Run() error {
 		logger.Infof("rook-provisioner %s started using %s flex vendor dir", name, vendor)
 	}
 
+	// Run the filesystem volume provisioner
+	filesystemProvisioner := provisioner.NewFilesystemProvisioner(o.context, flexvolume.FlexvolumeVendor, o.rookImage)
+	fpc := controller.NewProvisionController(
+		o.context.Clientset,
+		filesystemProvisionerName,
+		filesystemProvisioner,
+		serverVersion.GitVersion,
+	)
+	go fpc.Run(stopChan)
+	logger.Infof("rook-provisioner %s started using %s flex vendor dir", filesystemProvisionerName, flexvolume.FlexvolumeVendor)
+
 	// watch for changes to the rook clusters
 	o.clusterController.StartWatch(v1.NamespaceAll, stopChan)
 
+	// watch for bucket claims in all namespaces
+	bucketClaimController := object.NewObjectBucketClaimController(o.context)
+	bucketClaimController.StartWatch(v1.NamespaceAll, stopChan)
+
+	// watch for volume snapshots in all namespaces
+	snapshotController := provisioner.NewSnapshotController(o.context)
+	snapshotController.StartWatch(v1.NamespaceAll, stopChan)
+
 	for {
 		select {
 		case <-signalChan:
@@ -28,6 +28,8 @@ import (
 	"github.com/rook/rook/pkg/clusterd"
 	ceph "github.com/rook/rook/pkg/daemon/ceph/client"
 	"github.com/rook/rook/pkg/daemon/ceph/model"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -91,6 +93,8 @@ func (c *PoolController) StartWatch(namespace string, stopCh chan struct{}) erro
 	// watch for events on all legacy types too
 	c.watchLegacyPools(namespace, stopCh, resourceHandlerFuncs)
 
+	go c.checkDrift(namespace, stopCh)
+
 	return nil
 }
 
@@ -111,7 +115,12 @@ func (c *PoolController) onAdd(obj interface{}) {
 	err = createPool(c.context, pool)
 	if err != nil {
 		logger.Errorf("failed to create pool %s. %+v", pool.ObjectMeta.Name, err)
+		k8sutil.RecordEvent(c.context, pool, v1.EventTypeWarning, k8sutil.EventReasonCreateFailed, "failed to create pool %s. %+v", pool.Name, err)
+		c.updateStatus(pool, cephv1beta1.PoolStatus{Phase: cephv1beta1.PoolPhaseFailed, Message: err.Error()})
+		return
 	}
+	k8sutil.RecordEvent(c.context, pool, v1.EventTypeNormal, k8sutil.EventReasonCreated, "created pool %s", pool.Name)
+	c.updateStatus(pool, cephv1beta1.PoolStatus{Phase: cephv1beta1.PoolPhaseReady, Mirroring: mirroringStatus(c.context, pool)})
 }
 
 func (c *PoolController) onUpdate(oldObj, newObj interface{}) {
@@ -137,7 +146,7 @@ func (c *PoolController) onUpdate(oldObj, newObj interface{}) {
 		logger.Errorf("failed to update pool %s. name update not allowed", pool.Name)
 		return
 	}
-	if pool.Spec.ErasureCoded.CodingChunks != 0 && pool.Spec.ErasureCoded.DataChunks != 0 {
+	if oldPool.Spec.ErasureCoded != pool.Spec.ErasureCoded {
 		logger.Errorf("failed to update pool %s. erasurecoded update not allowed", pool.Name)
 		return
 	}
@@ -150,7 +159,17 @@ func (c *PoolController) onUpdate(oldObj, newObj interface{}) {
 	logger.Infof("updating pool %s", pool.Name)
 	if err := createPool(c.context, pool); err != nil {
 		logger.Errorf("failed to create (modify) pool %s. %+v", pool.ObjectMeta.Name, err)
+		c.updateStatus(pool, cephv1beta1.PoolStatus{Phase: cephv1beta1.PoolPhaseFailed, Message: err.Error()})
+		return
 	}
+	if oldPool.Spec.RBDMirroring.Mode != "" && pool.Spec.RBDMirroring.Mode == "" {
+		if err := disablePoolMirroring(c.context, pool); err != nil {
+			logger.Errorf("failed to disable mirroring of pool %s. %+v", pool.Name, err)
+			c.updateStatus(pool, cephv1beta1.PoolStatus{Phase: cephv1beta1.PoolPhaseFailed, Message: err.Error()})
+			return
+		}
+	}
+	c.updateStatus(pool, cephv1beta1.PoolStatus{Phase: cephv1beta1.PoolPhaseReady, Mirroring: mirroringStatus(c.context, pool)})
 }
 
 func poolChanged(old, new cephv1beta1.PoolSpec) bool {
@@ -158,9 +177,34 @@ func poolChanged(old, new cephv1beta1.PoolSpec) bool {
 		logger.Infof("pool replication changed from %d to %d", old.Replicated.Size, new.Replicated.Size)
 		return true
 	}
+	if old.Quotas != new.Quotas {
+		logger.Infof("pool quotas changed from %+v to %+v", old.Quotas, new.Quotas)
+		return true
+	}
+	if !reflect.DeepEqual(poolProperties(old), poolProperties(new)) {
+		logger.Infof("pool properties changed from %+v to %+v", poolProperties(old), poolProperties(new))
+		return true
+	}
+	if old.RBDMirroring != new.RBDMirroring {
+		logger.Infof("pool mirroring changed from %+v to %+v", old.RBDMirroring, new.RBDMirroring)
+		return true
+	}
 	return false
 }
 
+// updateStatus updates the status of the pool if it changed
+func (c *PoolController) updateStatus(p *cephv1beta1.Pool, status cephv1beta1.PoolStatus) {
+	if reflect.DeepEqual(p.Status, status) {
+		return
+	}
+
+	p = p.DeepCopy()
+	p.Status = status
+	if _, err := c.context.RookClientset.CephV1beta1().Pools(p.Namespace).Update(p); err != nil {
+		logger.Errorf("failed to update status of pool %s. %+v", p.Name, err)
+	}
+}
+
 func (c *PoolController) onDelete(obj interface{}) {
 	pool, migrationNeeded, err := getPoolObject(obj)
 	if err != nil {
@@ -175,7 +219,10 @@ func (c *PoolController) onDelete(obj interface{}) {
 
 	if err := deletePool(c.context, pool); err != nil {
 		logger.Errorf("failed to delete pool %s. %+v", pool.ObjectMeta.Name, err)
+		k8sutil.RecordEvent(c.context, pool, v1.EventTypeWarning, k8sutil.EventReasonDeleteFailed, "failed to delete pool %s. %+v", pool.Name, err)
+		return
 	}
+	k8sutil.RecordEvent(c.context, pool, v1.EventTypeNormal, k8sutil.EventReasonDeleted, "deleted pool %s", pool.Name)
 }
 
 // Create the pool
@@ -191,6 +238,16 @@ func createPool(context *clusterd.Context, p *cephv1beta1.Pool) error {
 		return fmt.Errorf("failed to create pool %s. %+v", p.Name, err)
 	}
 
+	if err := setPoolProperties(context, p); err != nil {
+		return fmt.Errorf("failed to set properties of pool %s. %+v", p.Name, err)
+	}
+
+	if p.Spec.RBDMirroring.Mode != "" {
+		if err := setPoolMirroring(context, p); err != nil {
+			return fmt.Errorf("failed to set mirroring of pool %s. %+v", p.Name, err)
+		}
+	}
+
 	logger.Infof("created pool %s", p.Name)
 	return nil
 }
@@ -224,6 +281,7 @@ func ModelToSpec(pool model.Pool) cephv1beta1.PoolSpec {
 	return cephv1beta1.PoolSpec{
 		FailureDomain: pool.FailureDomain,
 		CrushRoot:     pool.CrushRoot,
+		DeviceClass:   pool.DeviceClass,
 		Replicated:    cephv1beta1.ReplicatedSpec{Size: pool.ReplicatedConfig.Size},
 		ErasureCoded:  cephv1beta1.ErasureCodedSpec{CodingChunks: ec.CodingChunkCount, DataChunks: ec.DataChunkCount, Algorithm: ec.Algorithm},
 	}
@@ -250,6 +308,12 @@ func ValidatePoolSpec(context *clusterd.Context, namespace string, p *cephv1beta
 	if p.Replication() == nil && p.ErasureCode() == nil {
 		return fmt.Errorf("neither replication nor erasure code settings were specified")
 	}
+	if err := validatePoolProperties(p); err != nil {
+		return err
+	}
+	if err := validatePoolMirroring(p); err != nil {
+		return err
+	}
 
 	var crush ceph.CrushMap
 	var err error
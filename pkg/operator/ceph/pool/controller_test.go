@@ -29,6 +29,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 )
 
 func TestValidatePool(t *testing.T) {
@@ -141,6 +142,38 @@ func TestCreatePool(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestPoolEvents(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outfile string, args ...string) (string, error) {
+			return "", nil
+		},
+	}
+	recorder := record.NewFakeRecorder(10)
+	context := &clusterd.Context{Executor: executor, Recorder: recorder, RookClientset: rookfake.NewSimpleClientset()}
+	controller := NewPoolController(context)
+
+	p := &cephv1beta1.Pool{ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: "myns"}}
+	p.Spec.Replicated.Size = 1
+	context.RookClientset.CephV1beta1().Pools("myns").Create(p)
+	controller.onAdd(p)
+	assert.Equal(t, "Normal Created created pool mypool", <-recorder.Events)
+
+	// the failure to create the pool is recorded
+	p.Spec.ErasureCoded.CodingChunks = 2
+	p.Spec.ErasureCoded.DataChunks = 2
+	controller.onAdd(p)
+	assert.Contains(t, <-recorder.Events, "Warning CreateFailed failed to create pool mypool.")
+
+	// the status of the pool reports the failure
+	pool, err := context.RookClientset.CephV1beta1().Pools("myns").Get("mypool", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cephv1beta1.PoolPhaseFailed, pool.Status.Phase)
+	assert.Contains(t, pool.Status.Message, "both replication and erasure code settings cannot be specified")
+
+	controller.onDelete(p)
+	assert.Equal(t, "Normal Deleted deleted pool mypool", <-recorder.Events)
+}
+
 func TestUpdatePool(t *testing.T) {
 	// the pool did not change for properties that are updatable
 	old := cephv1beta1.PoolSpec{FailureDomain: "osd", ErasureCoded: cephv1beta1.ErasureCodedSpec{CodingChunks: 2, DataChunks: 2}}
@@ -153,6 +186,19 @@ func TestUpdatePool(t *testing.T) {
 	new = cephv1beta1.PoolSpec{FailureDomain: "osd", Replicated: cephv1beta1.ReplicatedSpec{Size: 2}}
 	changed = poolChanged(old, new)
 	assert.True(t, changed)
+
+	// the pool changed for the properties and quotas of ceph pools
+	old = cephv1beta1.PoolSpec{Replicated: cephv1beta1.ReplicatedSpec{Size: 1}}
+	new = cephv1beta1.PoolSpec{Replicated: cephv1beta1.ReplicatedSpec{Size: 1}, Compression: cephv1beta1.CompressionSpec{Mode: "passive"}}
+	assert.True(t, poolChanged(old, new))
+	new = cephv1beta1.PoolSpec{Replicated: cephv1beta1.ReplicatedSpec{Size: 1}, Parameters: map[string]string{"target_size_ratio": "0.2"}}
+	assert.True(t, poolChanged(old, new))
+	new = cephv1beta1.PoolSpec{Replicated: cephv1beta1.ReplicatedSpec{Size: 1}, Quotas: cephv1beta1.QuotaSpec{MaxObjects: 10}}
+	assert.True(t, poolChanged(old, new))
+
+	// the pool changed for the mirroring mode
+	new = cephv1beta1.PoolSpec{Replicated: cephv1beta1.ReplicatedSpec{Size: 1}, RBDMirroring: cephv1beta1.PoolMirroringSpec{Mode: "image"}}
+	assert.True(t, poolChanged(old, new))
 }
 
 func TestDeletePool(t *testing.T) {
@@ -0,0 +1,174 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pool
+
+import (
+	"fmt"
+
+	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	"github.com/rook/rook/pkg/clusterd"
+	ceph "github.com/rook/rook/pkg/daemon/ceph/client"
+	rbddaemon "github.com/rook/rook/pkg/daemon/ceph/rbd"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const mirroringDisabled = "disabled"
+
+var mirroringModes = []string{"pool", "image"}
+
+func validatePoolMirroring(p *cephv1beta1.PoolSpec) error {
+	if p.RBDMirroring.Mode == "" {
+		return nil
+	}
+	for _, mode := range mirroringModes {
+		if p.RBDMirroring.Mode == mode {
+			return nil
+		}
+	}
+	return fmt.Errorf("unrecognized mirroring mode %s", p.RBDMirroring.Mode)
+}
+
+// setPoolMirroring enables the mirroring of the pool in the mode of the spec with the peers of the cluster
+func setPoolMirroring(context *clusterd.Context, p *cephv1beta1.Pool) error {
+	info, err := ceph.GetPoolMirrorInfo(context, p.Namespace, p.Name)
+	if err != nil {
+		return err
+	}
+
+	peers, err := mirroringPeers(context, p.Namespace)
+	if err != nil {
+		return err
+	}
+
+	mode := p.Spec.RBDMirroring.Mode
+	if info.Mode != mode {
+		logger.Infof("enabling mirroring of pool %s in mode %s", p.Name, mode)
+		if err := ceph.EnablePoolMirroring(context, p.Namespace, p.Name, mode); err != nil {
+			return err
+		}
+	}
+	for peer, clientName := range peers {
+		if clientName == "" {
+			continue
+		}
+		found := false
+		for _, existing := range info.Peers {
+			if existing.ClusterName == peer && existing.ClientName == clientName {
+				found = true
+				break
+			}
+		}
+		if !found {
+			logger.Infof("adding mirroring peer %s to pool %s", peer, p.Name)
+			if err := ceph.AddPoolMirrorPeer(context, p.Namespace, p.Name, clientName, peer); err != nil {
+				return err
+			}
+		}
+	}
+	for _, existing := range info.Peers {
+		clientName, ok := peers[existing.ClusterName]
+		if ok && clientName == "" {
+			// keep the peer until its secret is found again
+			continue
+		}
+		if clientName != existing.ClientName {
+			logger.Infof("removing mirroring peer %s from pool %s", existing.ClusterName, p.Name)
+			if err := ceph.RemovePoolMirrorPeer(context, p.Namespace, p.Name, existing.UUID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// disablePoolMirroring removes the peers of the pool and disables its mirroring. In the image mode, the mirroring
+// of the images is disabled first since the mirroring of the pool cannot be disabled while images are mirrored.
+func disablePoolMirroring(context *clusterd.Context, p *cephv1beta1.Pool) error {
+	info, err := ceph.GetPoolMirrorInfo(context, p.Namespace, p.Name)
+	if err != nil {
+		return err
+	}
+	if info.Mode == mirroringDisabled {
+		return nil
+	}
+
+	logger.Infof("disabling mirroring of pool %s", p.Name)
+	if info.Mode == "image" {
+		images, err := ceph.ListImages(context, p.Namespace, p.Name)
+		if err != nil {
+			return err
+		}
+		for _, image := range images {
+			// the mirroring of the non-primary images is only disabled with force, disabling an image that is not mirrored succeeds
+			if err := ceph.DisableImageMirroring(context, p.Namespace, image.Name, p.Name, true); err != nil {
+				return err
+			}
+		}
+	}
+	for _, peer := range info.Peers {
+		if err := ceph.RemovePoolMirrorPeer(context, p.Namespace, p.Name, peer.UUID); err != nil {
+			return err
+		}
+	}
+	return ceph.DisablePoolMirroring(context, p.Namespace, p.Name)
+}
+
+// mirroringPeers returns the client names the rbd-mirror daemons connect to the peers of the cluster with, by peer name.
+// The client name of a peer is empty if its secret is not found yet.
+func mirroringPeers(context *clusterd.Context, namespace string) (map[string]string, error) {
+	clusters, err := context.RookClientset.CephV1beta1().Clusters(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusters in namespace %s. %+v", namespace, err)
+	}
+
+	peers := map[string]string{}
+	for _, cluster := range clusters.Items {
+		for _, peer := range cluster.Spec.RBDMirroring.Peers {
+			secret, err := context.Clientset.CoreV1().Secrets(namespace).Get(peer, metav1.GetOptions{})
+			if err != nil {
+				if !errors.IsNotFound(err) {
+					return nil, fmt.Errorf("failed to get secret of peer %s. %+v", peer, err)
+				}
+				logger.Warningf("skipping mirroring peer %s until its secret is created in namespace %s", peer, namespace)
+				peers[peer] = ""
+				continue
+			}
+			user := string(secret.Data[rbddaemon.PeerUserKey])
+			if user == "" {
+				user = rbddaemon.DefaultPeerUser
+			}
+			peers[peer] = "client." + user
+		}
+	}
+	return peers, nil
+}
+
+// mirroringStatus returns the health of the mirroring of the pool, or nil if the pool is not mirrored
+func mirroringStatus(context *clusterd.Context, p *cephv1beta1.Pool) *cephv1beta1.PoolMirroringStatus {
+	if p.Spec.RBDMirroring.Mode == "" {
+		return nil
+	}
+
+	status, err := ceph.GetPoolMirrorStatus(context, p.Namespace, p.Name)
+	if err != nil {
+		logger.Warningf("failed to get mirroring status of pool %s. %+v", p.Name, err)
+		return &cephv1beta1.PoolMirroringStatus{Health: "UNKNOWN"}
+	}
+	return &cephv1beta1.PoolMirroringStatus{Health: status.Summary.Health, States: status.Summary.States}
+}
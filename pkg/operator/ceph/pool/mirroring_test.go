@@ -0,0 +1,141 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pool
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestValidatePoolMirroring(t *testing.T) {
+	p := cephv1beta1.PoolSpec{}
+	assert.Nil(t, validatePoolMirroring(&p))
+	p.RBDMirroring.Mode = "image"
+	assert.Nil(t, validatePoolMirroring(&p))
+	p.RBDMirroring.Mode = "pool"
+	assert.Nil(t, validatePoolMirroring(&p))
+	p.RBDMirroring.Mode = "all"
+	assert.NotNil(t, validatePoolMirroring(&p))
+}
+
+func TestSetPoolMirroring(t *testing.T) {
+	info := `{"mode":"disabled","peers":[]}`
+	images := `[]`
+	var commands []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName, command string, args ...string) (string, error) {
+			assert.Equal(t, "rbd", command)
+			if args[0] == "ls" {
+				return images, nil
+			}
+			switch args[2] {
+			case "info":
+				return info, nil
+			case "status":
+				return `{"summary":{"health":"OK","states":{"replaying":3}}}`, nil
+			}
+			// ignore the flags of the config and keyring
+			for i, arg := range args {
+				if strings.HasPrefix(arg, "--") {
+					args = args[:i]
+					break
+				}
+			}
+			commands = append(commands, strings.Join(args, " "))
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor, Clientset: fake.NewSimpleClientset(), RookClientset: rookfake.NewSimpleClientset()}
+
+	cluster := &cephv1beta1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "myns", Namespace: "myns"}}
+	cluster.Spec.RBDMirroring.Peers = []string{"site-b", "site-c"}
+	context.RookClientset.CephV1beta1().Clusters("myns").Create(cluster)
+	context.Clientset.CoreV1().Secrets("myns").Create(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "site-b", Namespace: "myns"}})
+
+	p := &cephv1beta1.Pool{ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: "myns"}}
+	p.Spec.RBDMirroring.Mode = "image"
+
+	// a peer is skipped until its secret is created
+	err := setPoolMirroring(context, p)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"mirror pool enable mypool image", "mirror pool peer add mypool client.rbd-mirror-peer@site-b"}, commands)
+
+	// a peer already added to the pool is kept while its secret is missing
+	info = `{"mode":"image","peers":[{"uuid":"3","cluster_name":"site-c","client_name":"client.mirror"}]}`
+	commands = nil
+	err = setPoolMirroring(context, p)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"mirror pool peer add mypool client.rbd-mirror-peer@site-b"}, commands)
+
+	info = `{"mode":"disabled","peers":[]}`
+	context.Clientset.CoreV1().Secrets("myns").Create(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "site-c", Namespace: "myns"},
+		Data: map[string][]byte{"user": []byte("mirror")}})
+	commands = nil
+	err = setPoolMirroring(context, p)
+	assert.Nil(t, err)
+	assert.Equal(t, "mirror pool enable mypool image", commands[0])
+	assert.Contains(t, commands, "mirror pool peer add mypool client.rbd-mirror-peer@site-b")
+	assert.Contains(t, commands, "mirror pool peer add mypool client.mirror@site-c")
+	assert.Equal(t, 3, len(commands))
+
+	// the existing peers are kept and the peers removed from the cluster are removed from the pool
+	info = `{"mode":"image","peers":[{"uuid":"1","cluster_name":"site-b","client_name":"client.rbd-mirror-peer"},` +
+		`{"uuid":"2","cluster_name":"site-d","client_name":"client.rbd-mirror-peer"}]}`
+	commands = nil
+	err = setPoolMirroring(context, p)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"mirror pool peer add mypool client.mirror@site-c", "mirror pool peer remove mypool 2"}, commands)
+
+	// the mirroring of the images is disabled and the peers are removed before the mirroring of the pool is disabled
+	images = `[{"image":"image1","size":1048576,"format":2},{"image":"image2","size":1048576,"format":2}]`
+	commands = nil
+	err = disablePoolMirroring(context, p)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"mirror image disable mypool/image1", "mirror image disable mypool/image2",
+		"mirror pool peer remove mypool 1", "mirror pool peer remove mypool 2", "mirror pool disable mypool"}, commands)
+
+	// the images are not listed in the pool mode
+	info = `{"mode":"pool","peers":[{"uuid":"1","cluster_name":"site-b","client_name":"client.rbd-mirror-peer"}]}`
+	commands = nil
+	err = disablePoolMirroring(context, p)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"mirror pool peer remove mypool 1", "mirror pool disable mypool"}, commands)
+
+	status := mirroringStatus(context, p)
+	assert.Equal(t, &cephv1beta1.PoolMirroringStatus{Health: "OK", States: map[string]int{"replaying": 3}}, status)
+
+	// the pools that are not mirrored have no mirroring status
+	p.Spec.RBDMirroring.Mode = ""
+	assert.Nil(t, mirroringStatus(context, p))
+
+	// the health is unknown when the status cannot be retrieved
+	executor.MockExecuteCommandWithOutput = func(debug bool, actionName, command string, args ...string) (string, error) {
+		return "", fmt.Errorf("mock failure")
+	}
+	p.Spec.RBDMirroring.Mode = "pool"
+	assert.Equal(t, "UNKNOWN", mirroringStatus(context, p).Health)
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pool
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	"github.com/rook/rook/pkg/clusterd"
+	ceph "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	poolDriftCheckInterval = 5 * time.Minute
+	poolDriftedReason      = "PropertiesDrifted"
+	maxBytesQuota          = "max_bytes"
+	maxObjectsQuota        = "max_objects"
+)
+
+var compressionModes = []string{"none", "passive", "aggressive", "force"}
+
+// poolProperties returns the properties of the pool that are set with "ceph osd pool set" from the spec.
+// The settings of the spec take precedence over the same properties in the parameters.
+func poolProperties(spec cephv1beta1.PoolSpec) map[string]string {
+	props := map[string]string{}
+	for name, value := range spec.Parameters {
+		props[name] = value
+	}
+
+	if r := spec.Replication(); r != nil {
+		props["size"] = strconv.FormatUint(uint64(r.Size), 10)
+	}
+	if spec.MinSize > 0 {
+		props["min_size"] = strconv.FormatUint(uint64(spec.MinSize), 10)
+	}
+	if spec.PGNum > 0 {
+		props["pg_num"] = strconv.FormatUint(uint64(spec.PGNum), 10)
+		props["pgp_num"] = props["pg_num"]
+	}
+	if spec.PGPNum > 0 {
+		props["pgp_num"] = strconv.FormatUint(uint64(spec.PGPNum), 10)
+	}
+	if spec.Compression.Mode != "" {
+		props["compression_mode"] = spec.Compression.Mode
+	}
+	if spec.Compression.Algorithm != "" {
+		props["compression_algorithm"] = spec.Compression.Algorithm
+	}
+
+	return props
+}
+
+// the properties are applied in order so pg_num is increased before pgp_num
+func sortedPropertyNames(props map[string]string) []string {
+	var names []string
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func validatePoolProperties(p *cephv1beta1.PoolSpec) error {
+	if p.PGNum > 0 && p.PGPNum > p.PGNum {
+		return fmt.Errorf("pgpNum %d cannot be greater than pgNum %d", p.PGPNum, p.PGNum)
+	}
+
+	if p.Compression.Mode != "" {
+		found := false
+		for _, mode := range compressionModes {
+			if p.Compression.Mode == mode {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("unrecognized compression mode %s", p.Compression.Mode)
+		}
+	}
+
+	return nil
+}
+
+// setPoolProperties applies the properties and the quotas of the spec to the pool
+func setPoolProperties(context *clusterd.Context, p *cephv1beta1.Pool) error {
+	props := poolProperties(p.Spec)
+	for _, name := range sortedPropertyNames(props) {
+		if err := ceph.SetPoolProperty(context, p.Namespace, p.Name, name, props[name]); err != nil {
+			return err
+		}
+	}
+
+	// a quota of zero removes the quota
+	if err := ceph.SetPoolQuota(context, p.Namespace, p.Name, maxBytesQuota, p.Spec.Quotas.MaxBytes); err != nil {
+		return err
+	}
+	return ceph.SetPoolQuota(context, p.Namespace, p.Name, maxObjectsQuota, p.Spec.Quotas.MaxObjects)
+}
+
+// propertyValuesEqual compares the values of a property numerically when both are numbers, since ceph reports
+// the values in its own format, such as 0.200000 for a ratio set to 0.2
+func propertyValuesEqual(actual, expected string) bool {
+	if actual == expected {
+		return true
+	}
+	a, err := strconv.ParseFloat(actual, 64)
+	if err != nil {
+		return false
+	}
+	e, err := strconv.ParseFloat(expected, 64)
+	if err != nil {
+		return false
+	}
+	return a == e
+}
+
+// poolDrift returns the properties and the quotas of the pool in ceph that differ from the spec
+func poolDrift(context *clusterd.Context, p *cephv1beta1.Pool) ([]cephv1beta1.PoolPropertyDrift, error) {
+	quota, err := ceph.GetPoolQuota(context, p.Namespace, p.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var drift []cephv1beta1.PoolPropertyDrift
+	props := poolProperties(p.Spec)
+	for _, name := range sortedPropertyNames(props) {
+		// ceph fails to get the properties that are not set on the pool
+		actual, err := ceph.GetPoolProperty(context, p.Namespace, p.Name, name)
+		if err != nil {
+			logger.Debugf("pool property %s not found. %+v", name, err)
+			actual = ""
+		}
+		if !propertyValuesEqual(actual, props[name]) {
+			drift = append(drift, cephv1beta1.PoolPropertyDrift{Property: name, Expected: props[name], Actual: actual})
+		}
+	}
+
+	if quota.MaxBytes != p.Spec.Quotas.MaxBytes {
+		drift = append(drift, cephv1beta1.PoolPropertyDrift{Property: "quota_" + maxBytesQuota,
+			Expected: strconv.FormatUint(p.Spec.Quotas.MaxBytes, 10), Actual: strconv.FormatUint(quota.MaxBytes, 10)})
+	}
+	if quota.MaxObjects != p.Spec.Quotas.MaxObjects {
+		drift = append(drift, cephv1beta1.PoolPropertyDrift{Property: "quota_" + maxObjectsQuota,
+			Expected: strconv.FormatUint(p.Spec.Quotas.MaxObjects, 10), Actual: strconv.FormatUint(quota.MaxObjects, 10)})
+	}
+
+	return drift, nil
+}
+
+// checkDrift periodically reports the pools whose properties were changed outside of the operator in their status
+func (c *PoolController) checkDrift(namespace string, stopCh chan struct{}) {
+	for {
+		select {
+		case <-time.After(poolDriftCheckInterval):
+			c.checkPoolsDrift(namespace)
+
+		case <-stopCh:
+			logger.Infof("stopping the drift check of the pools in namespace %s", namespace)
+			return
+		}
+	}
+}
+
+func (c *PoolController) checkPoolsDrift(namespace string) {
+	pools, err := c.context.RookClientset.CephV1beta1().Pools(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		logger.Warningf("failed to list pools in namespace %s. %+v", namespace, err)
+		return
+	}
+
+	for i := range pools.Items {
+		p := &pools.Items[i]
+		if p.Status.Phase == cephv1beta1.PoolPhaseFailed {
+			// the pool is reconciled again when its spec is fixed
+			continue
+		}
+
+		drift, err := poolDrift(c.context, p)
+		if err != nil {
+			logger.Warningf("failed to check the properties of pool %s. %+v", p.Name, err)
+			continue
+		}
+
+		if p.Spec.RBDMirroring.Mode != "" {
+			// the peers of the cluster may have changed
+			if err := setPoolMirroring(c.context, p); err != nil {
+				logger.Warningf("failed to set mirroring of pool %s. %+v", p.Name, err)
+			}
+		}
+
+		status := cephv1beta1.PoolStatus{Phase: cephv1beta1.PoolPhaseReady}
+		if len(drift) > 0 {
+			var names []string
+			for _, d := range drift {
+				names = append(names, d.Property)
+			}
+			message := fmt.Sprintf("properties of pool %s changed outside of the operator: %s", p.Name, strings.Join(names, ", "))
+			if p.Status.Phase != cephv1beta1.PoolPhaseDrifted {
+				logger.Warning(message)
+				k8sutil.RecordEvent(c.context, p, v1.EventTypeWarning, poolDriftedReason, "%s", message)
+			}
+			status = cephv1beta1.PoolStatus{Phase: cephv1beta1.PoolPhaseDrifted, Message: message, Drift: drift}
+		}
+		status.Mirroring = mirroringStatus(c.context, p)
+		c.updateStatus(p, status)
+	}
+}
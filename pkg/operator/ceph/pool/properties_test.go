@@ -0,0 +1,199 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pool
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func newPropertiesPool() *cephv1beta1.Pool {
+	return &cephv1beta1.Pool{
+		ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: "myns"},
+		Spec: cephv1beta1.PoolSpec{
+			Replicated:  cephv1beta1.ReplicatedSpec{Size: 3},
+			MinSize:     2,
+			PGNum:       128,
+			Quotas:      cephv1beta1.QuotaSpec{MaxBytes: 1024},
+			Compression: cephv1beta1.CompressionSpec{Mode: "aggressive", Algorithm: "snappy"},
+			Parameters:  map[string]string{"target_size_ratio": "0.2", "min_size": "1"},
+		},
+	}
+}
+
+func TestPoolProperties(t *testing.T) {
+	spec := newPropertiesPool().Spec
+	assert.Equal(t, map[string]string{
+		"size":                  "3",
+		"min_size":              "2",
+		"pg_num":                "128",
+		"pgp_num":               "128",
+		"compression_mode":      "aggressive",
+		"compression_algorithm": "snappy",
+		"target_size_ratio":     "0.2",
+	}, poolProperties(spec))
+
+	spec.PGPNum = 64
+	assert.Equal(t, "64", poolProperties(spec)["pgp_num"])
+	assert.Nil(t, validatePoolProperties(&spec))
+
+	// the placement groups for placement cannot be more than the placement groups
+	spec.PGPNum = 256
+	assert.NotNil(t, validatePoolProperties(&spec))
+
+	spec.PGPNum = 0
+	spec.Compression.Mode = "always"
+	assert.NotNil(t, validatePoolProperties(&spec))
+
+	// an erasure coded pool has no size
+	assert.Equal(t, 0, len(poolProperties(cephv1beta1.PoolSpec{ErasureCoded: cephv1beta1.ErasureCodedSpec{DataChunks: 2, CodingChunks: 1}})))
+}
+
+func TestSetPoolProperties(t *testing.T) {
+	var commands []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outfile string, args ...string) (string, error) {
+			commands = append(commands, strings.Join(args[:6], " "))
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	err := setPoolProperties(context, newPropertiesPool())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"osd pool set mypool compression_algorithm snappy",
+		"osd pool set mypool compression_mode aggressive",
+		"osd pool set mypool min_size 2",
+		"osd pool set mypool pg_num 128",
+		"osd pool set mypool pgp_num 128",
+		"osd pool set mypool size 3",
+		"osd pool set mypool target_size_ratio 0.2",
+		"osd pool set-quota mypool max_bytes 1024",
+		"osd pool set-quota mypool max_objects 0",
+	}, commands)
+}
+
+func newDriftExecutor(props map[string]string) *exectest.MockExecutor {
+	return &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outfile string, args ...string) (string, error) {
+			if args[2] == "get-quota" {
+				return `{"pool_name":"mypool","pool_id":1,"quota_max_objects":0,"quota_max_bytes":1024}`, nil
+			}
+			if args[2] == "get" {
+				value, ok := props[args[4]]
+				if !ok {
+					return "", fmt.Errorf("option '%s' is not set on pool", args[4])
+				}
+				return fmt.Sprintf(`{"pool":"mypool","%s":%s}`, args[4], value), nil
+			}
+			return "", fmt.Errorf("unexpected ceph command '%v'", args)
+		},
+	}
+}
+
+func TestPoolDrift(t *testing.T) {
+	props := map[string]string{
+		"size":                  "3",
+		"min_size":              "2",
+		"pg_num":                "128",
+		"pgp_num":               "128",
+		"compression_mode":      `"aggressive"`,
+		"compression_algorithm": `"snappy"`,
+		"target_size_ratio":     "0.2",
+	}
+	context := &clusterd.Context{Executor: newDriftExecutor(props)}
+	p := newPropertiesPool()
+
+	drift, err := poolDrift(context, p)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(drift))
+
+	// the numbers are compared by value since ceph reports them in its own format
+	props["target_size_ratio"] = "0.200000"
+	drift, err = poolDrift(context, p)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(drift))
+
+	// the properties changed outside of the operator are reported
+	props["min_size"] = "1"
+	props["target_size_ratio"] = "0.300000"
+	delete(props, "compression_mode")
+	p.Spec.Quotas.MaxObjects = 100
+	drift, err = poolDrift(context, p)
+	assert.Nil(t, err)
+	assert.Equal(t, []cephv1beta1.PoolPropertyDrift{
+		{Property: "compression_mode", Expected: "aggressive", Actual: ""},
+		{Property: "min_size", Expected: "2", Actual: "1"},
+		{Property: "target_size_ratio", Expected: "0.2", Actual: "0.300000"},
+		{Property: "quota_max_objects", Expected: "100", Actual: "0"},
+	}, drift)
+}
+
+func TestCheckPoolsDrift(t *testing.T) {
+	props := map[string]string{"size": "1"}
+	recorder := record.NewFakeRecorder(10)
+	executor := newDriftExecutor(props)
+	context := &clusterd.Context{Executor: executor, Recorder: recorder, RookClientset: rookfake.NewSimpleClientset()}
+	controller := NewPoolController(context)
+
+	p := &cephv1beta1.Pool{ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: "myns"}}
+	p.Spec.Replicated.Size = 3
+	p.Spec.Quotas.MaxBytes = 1024
+	context.RookClientset.CephV1beta1().Pools("myns").Create(p)
+
+	controller.checkPoolsDrift("myns")
+	pool, err := context.RookClientset.CephV1beta1().Pools("myns").Get("mypool", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cephv1beta1.PoolPhaseDrifted, pool.Status.Phase)
+	assert.Equal(t, []cephv1beta1.PoolPropertyDrift{{Property: "size", Expected: "3", Actual: "1"}}, pool.Status.Drift)
+	assert.Equal(t, "Warning PropertiesDrifted properties of pool mypool changed outside of the operator: size", <-recorder.Events)
+
+	// the pool is ready again when the properties match the spec
+	props["size"] = "3"
+	controller.checkPoolsDrift("myns")
+	pool, err = context.RookClientset.CephV1beta1().Pools("myns").Get("mypool", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cephv1beta1.PoolStatus{Phase: cephv1beta1.PoolPhaseReady}, pool.Status)
+
+	// the health of the mirroring is reported for the mirrored pools
+	executor.MockExecuteCommandWithOutput = func(debug bool, actionName, command string, args ...string) (string, error) {
+		switch args[2] {
+		case "info":
+			return `{"mode":"pool","peers":[]}`, nil
+		case "status":
+			return `{"summary":{"health":"WARNING","states":{"starting_replay":1}}}`, nil
+		}
+		return "", fmt.Errorf("unexpected rbd command '%v'", args)
+	}
+	pool.Spec.RBDMirroring.Mode = "pool"
+	context.RookClientset.CephV1beta1().Pools("myns").Update(pool)
+	controller.checkPoolsDrift("myns")
+	pool, err = context.RookClientset.CephV1beta1().Pools("myns").Get("mypool", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cephv1beta1.PoolPhaseReady, pool.Status.Phase)
+	assert.Equal(t, &cephv1beta1.PoolMirroringStatus{Health: "WARNING", States: map[string]int{"starting_replay": 1}}, pool.Status.Mirroring)
+}
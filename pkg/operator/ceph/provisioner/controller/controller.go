@@ -444,6 +444,14 @@ func (ctrl *ProvisionController) addClaim(obj interface{}) {
 		return
 	}
 
+	if ctrl.shouldExpand(claim) {
+		opName := fmt.Sprintf("expand-%s[%s]", claimToClaimKey(claim), string(claim.UID))
+		ctrl.scheduleOperation(opName, func() error {
+			return ctrl.expandClaimOperation(claim)
+		})
+		return
+	}
+
 	if ctrl.shouldProvision(claim) {
 		ctrl.leaderElectorsMutex.Lock()
 		le, ok := ctrl.leaderElectors[claim.UID]
@@ -584,6 +592,35 @@ func (ctrl *ProvisionController) shouldProvision(claim *v1.PersistentVolumeClaim
 	return true
 }
 
+// shouldExpand checks if the claim is bound to a volume created by this
+// provisioner and requests more capacity than the volume has.
+func (ctrl *ProvisionController) shouldExpand(claim *v1.PersistentVolumeClaim) bool {
+	if _, ok := ctrl.provisioner.(ExpandableProvisioner); !ok {
+		return false
+	}
+
+	if claim.Spec.VolumeName == "" || claim.Status.Phase != v1.ClaimBound {
+		return false
+	}
+
+	volumeObj, found, err := ctrl.volumes.GetByKey(claim.Spec.VolumeName)
+	if err != nil || !found {
+		return false
+	}
+	volume, ok := volumeObj.(*v1.PersistentVolume)
+	if !ok {
+		return false
+	}
+
+	if ann := volume.Annotations[annDynamicallyProvisioned]; ann != ctrl.provisionerName {
+		return false
+	}
+
+	requested := claim.Spec.Resources.Requests[v1.ResourceName(v1.ResourceStorage)]
+	capacity := volume.Spec.Capacity[v1.ResourceName(v1.ResourceStorage)]
+	return requested.Cmp(capacity) > 0
+}
+
 func (ctrl *ProvisionController) shouldDelete(volume *v1.PersistentVolume) bool {
 	ctrl.failedDeleteStatsMutex.Lock()
 	if failureCount, exists := ctrl.failedDeleteStats[volume.UID]; exists == true {
@@ -1016,6 +1053,64 @@ func (ctrl *ProvisionController) getPVCEventWatch(claim *v1.PersistentVolumeClai
 	})
 }
 
+// expandClaimOperation grows the volume bound to the given claim to the
+// capacity requested by the claim. The capacity of the PV and the claim are
+// updated to match the new size of the volume.
+func (ctrl *ProvisionController) expandClaimOperation(claim *v1.PersistentVolumeClaim) error {
+	glog.V(4).Infof("expandClaimOperation [%s] started", claimToClaimKey(claim))
+
+	expander, ok := ctrl.provisioner.(ExpandableProvisioner)
+	if !ok {
+		return nil
+	}
+
+	volume, err := ctrl.client.CoreV1().PersistentVolumes().Get(claim.Spec.VolumeName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get volume %q of claim %q: %v", claim.Spec.VolumeName, claimToClaimKey(claim), err)
+	}
+
+	requested := claim.Spec.Resources.Requests[v1.ResourceName(v1.ResourceStorage)]
+	capacity := volume.Spec.Capacity[v1.ResourceName(v1.ResourceStorage)]
+	if requested.Cmp(capacity) <= 0 {
+		glog.V(4).Infof("expandClaimOperation [%s]: volume already expanded, skipping", claimToClaimKey(claim))
+		return nil
+	}
+
+	glog.Infof("expanding volume %q of claim %q from %s to %s", volume.Name, claimToClaimKey(claim), capacity.String(), requested.String())
+	newSize, err := expander.Expand(volume, requested)
+	if err != nil {
+		strerr := fmt.Sprintf("Failed to expand volume %s: %v", volume.Name, err)
+		glog.Errorf("Failed to expand volume %q of claim %q: %v", volume.Name, claimToClaimKey(claim), err)
+		ctrl.eventRecorder.Event(claim, v1.EventTypeWarning, "VolumeResizeFailed", strerr)
+		return err
+	}
+
+	volume.Spec.Capacity[v1.ResourceName(v1.ResourceStorage)] = newSize
+	if _, err = ctrl.client.CoreV1().PersistentVolumes().Update(volume); err != nil {
+		strerr := fmt.Sprintf("Failed to update capacity of volume %s: %v", volume.Name, err)
+		glog.Errorf("Failed to update capacity of volume %q: %v", volume.Name, err)
+		ctrl.eventRecorder.Event(claim, v1.EventTypeWarning, "VolumeResizeFailed", strerr)
+		return err
+	}
+
+	newClaim, err := ctrl.client.CoreV1().PersistentVolumeClaims(claim.Namespace).Get(claim.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get claim %q: %v", claimToClaimKey(claim), err)
+	}
+	if newClaim.Status.Capacity == nil {
+		newClaim.Status.Capacity = v1.ResourceList{}
+	}
+	newClaim.Status.Capacity[v1.ResourceName(v1.ResourceStorage)] = newSize
+	if _, err = ctrl.client.CoreV1().PersistentVolumeClaims(claim.Namespace).UpdateStatus(newClaim); err != nil {
+		return fmt.Errorf("failed to update capacity of claim %q: %v", claimToClaimKey(claim), err)
+	}
+
+	msg := fmt.Sprintf("Successfully expanded volume %s to %s", volume.Name, newSize.String())
+	ctrl.eventRecorder.Event(claim, v1.EventTypeNormal, "VolumeResizeSuccessful", msg)
+	glog.Infof("volume %q of claim %q expanded to %s", volume.Name, claimToClaimKey(claim), newSize.String())
+	return nil
+}
+
 func (ctrl *ProvisionController) deleteVolumeOperation(volume *v1.PersistentVolume) error {
 	glog.V(4).Infof("deleteVolumeOperation [%s] started", volume.Name)
 
@@ -420,6 +420,101 @@ func TestShouldDelete(t *testing.T) {
 	}
 }
 
+func TestShouldExpand(t *testing.T) {
+	tests := []struct {
+		name           string
+		provisioner    Provisioner
+		claim          *v1.PersistentVolumeClaim
+		volume         *v1.PersistentVolume
+		expectedShould bool
+	}{
+		{
+			name:           "should expand",
+			provisioner:    newTestExpandableProvisioner(),
+			claim:          newBoundClaim("claim-1", "uid-1-1", "volume-1", "2Mi"),
+			volume:         newVolume("volume-1", v1.VolumeBound, v1.PersistentVolumeReclaimDelete, map[string]string{annDynamicallyProvisioned: "foo.bar/baz"}),
+			expectedShould: true,
+		},
+		{
+			name:           "capacity not increased",
+			provisioner:    newTestExpandableProvisioner(),
+			claim:          newBoundClaim("claim-1", "uid-1-1", "volume-1", "1Mi"),
+			volume:         newVolume("volume-1", v1.VolumeBound, v1.PersistentVolumeReclaimDelete, map[string]string{annDynamicallyProvisioned: "foo.bar/baz"}),
+			expectedShould: false,
+		},
+		{
+			name:           "provisioner can't expand",
+			provisioner:    newTestProvisioner(),
+			claim:          newBoundClaim("claim-1", "uid-1-1", "volume-1", "2Mi"),
+			volume:         newVolume("volume-1", v1.VolumeBound, v1.PersistentVolumeReclaimDelete, map[string]string{annDynamicallyProvisioned: "foo.bar/baz"}),
+			expectedShould: false,
+		},
+		{
+			name:           "not this provisioner's job",
+			provisioner:    newTestExpandableProvisioner(),
+			claim:          newBoundClaim("claim-1", "uid-1-1", "volume-1", "2Mi"),
+			volume:         newVolume("volume-1", v1.VolumeBound, v1.PersistentVolumeReclaimDelete, map[string]string{annDynamicallyProvisioned: "abc.def/ghi"}),
+			expectedShould: false,
+		},
+		{
+			name:           "claim not bound",
+			provisioner:    newTestExpandableProvisioner(),
+			claim:          newClaim("claim-1", "uid-1-1", "class-1", "", nil),
+			volume:         newVolume("volume-1", v1.VolumeBound, v1.PersistentVolumeReclaimDelete, map[string]string{annDynamicallyProvisioned: "foo.bar/baz"}),
+			expectedShould: false,
+		},
+	}
+	for _, test := range tests {
+		client := fake.NewSimpleClientset()
+		ctrl := newTestProvisionController(client, "foo.bar/baz", test.provisioner, "v1.5.0")
+		ctrl.volumes.Add(test.volume)
+
+		should := ctrl.shouldExpand(test.claim)
+		if test.expectedShould != should {
+			t.Logf("test case: %s", test.name)
+			t.Errorf("expected should expand %v but got %v\n", test.expectedShould, should)
+		}
+	}
+}
+
+func TestExpandClaimOperation(t *testing.T) {
+	claim := newBoundClaim("claim-1", "uid-1-1", "volume-1", "2Mi")
+	volume := newVolume("volume-1", v1.VolumeBound, v1.PersistentVolumeReclaimDelete, map[string]string{annDynamicallyProvisioned: "foo.bar/baz"})
+	client := fake.NewSimpleClientset(claim, volume)
+	provisioner := newTestExpandableProvisioner()
+	ctrl := newTestProvisionController(client, "foo.bar/baz", provisioner, "v1.5.0")
+
+	err := ctrl.expandClaimOperation(claim)
+	if err != nil {
+		t.Fatalf("unexpected error expanding claim: %v", err)
+	}
+	if len(provisioner.expandCalls) != 1 {
+		t.Errorf("expected 1 call to expand but got %d", len(provisioner.expandCalls))
+	}
+
+	// the capacity of the volume and the claim match the requested size
+	expected := resource.MustParse("2Mi")
+	newVolume, _ := client.CoreV1().PersistentVolumes().Get("volume-1", metav1.GetOptions{})
+	capacity := newVolume.Spec.Capacity[v1.ResourceName(v1.ResourceStorage)]
+	if capacity.Cmp(expected) != 0 {
+		t.Errorf("expected volume capacity %s but got %s", expected.String(), capacity.String())
+	}
+	newClaim, _ := client.CoreV1().PersistentVolumeClaims(v1.NamespaceDefault).Get("claim-1", metav1.GetOptions{})
+	capacity = newClaim.Status.Capacity[v1.ResourceName(v1.ResourceStorage)]
+	if capacity.Cmp(expected) != 0 {
+		t.Errorf("expected claim capacity %s but got %s", expected.String(), capacity.String())
+	}
+
+	// the volume is not expanded again
+	err = ctrl.expandClaimOperation(claim)
+	if err != nil {
+		t.Fatalf("unexpected error expanding claim: %v", err)
+	}
+	if len(provisioner.expandCalls) != 1 {
+		t.Errorf("expected 1 call to expand but got %d", len(provisioner.expandCalls))
+	}
+}
+
 func TestIsOnlyRecordUpdate(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -525,6 +620,13 @@ func newClaim(name, claimUID, provisioner, volumeName string, annotations map[st
 	return claim
 }
 
+func newBoundClaim(name, claimUID, volumeName, size string) *v1.PersistentVolumeClaim {
+	claim := newClaim(name, claimUID, "class-1", volumeName, nil)
+	claim.Spec.Resources.Requests[v1.ResourceName(v1.ResourceStorage)] = resource.MustParse(size)
+	claim.Status.Phase = v1.ClaimBound
+	return claim
+}
+
 func newVolume(name string, phase v1.PersistentVolumePhase, policy v1.PersistentVolumeReclaimPolicy, annotations map[string]string) *v1.PersistentVolume {
 	pv := &v1.PersistentVolume{
 		ObjectMeta: metav1.ObjectMeta{
@@ -627,6 +729,22 @@ func (p *testProvisioner) Delete(volume *v1.PersistentVolume) error {
 	return nil
 }
 
+func newTestExpandableProvisioner() *testExpandableProvisioner {
+	return &testExpandableProvisioner{testProvisioner: newTestProvisioner(), expandCalls: make(chan bool, 16)}
+}
+
+type testExpandableProvisioner struct {
+	*testProvisioner
+	expandCalls chan bool
+}
+
+var _ ExpandableProvisioner = &testExpandableProvisioner{}
+
+func (p *testExpandableProvisioner) Expand(volume *v1.PersistentVolume, requestedSize resource.Quantity) (resource.Quantity, error) {
+	p.expandCalls <- true
+	return requestedSize, nil
+}
+
 func newBadTestProvisioner() Provisioner {
 	return &badTestProvisioner{}
 }
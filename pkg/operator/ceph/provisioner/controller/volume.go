@@ -20,6 +20,7 @@ import (
 	"fmt"
 
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 // Provisioner is an interface that creates templates for PersistentVolumes
@@ -38,6 +39,16 @@ type Provisioner interface {
 	Delete(*v1.PersistentVolume) error
 }
 
+// ExpandableProvisioner is an optional interface for provisioners that can
+// grow the storage asset backing a volume when the capacity requested by its
+// claim increases.
+type ExpandableProvisioner interface {
+	Provisioner
+	// Expand grows the storage asset backing the given PV to at least the
+	// requested size and returns the new capacity of the volume
+	Expand(volume *v1.PersistentVolume, requestedSize resource.Quantity) (resource.Quantity, error)
+}
+
 // IgnoredError is the value for Delete to return to indicate that the call has
 // been ignored and no action taken. In case multiple provisioners are serving
 // the same storage class, provisioners may ignore PVs they are not responsible
@@ -0,0 +1,260 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume"
+	ceph "github.com/rook/rook/pkg/daemon/ceph/client"
+	filedaemon "github.com/rook/rook/pkg/daemon/ceph/file"
+	"github.com/rook/rook/pkg/operator/ceph/cluster"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	cephfile "github.com/rook/rook/pkg/operator/ceph/file"
+	"github.com/rook/rook/pkg/operator/ceph/provisioner/controller"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	batch "k8s.io/api/batch/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	filesystemVolumeJobPrefix  = "rook-ceph-fs-volume-"
+	filesystemVolumeJobTimeout = 5 * time.Minute
+)
+
+// FilesystemProvisioner is used to provision volumes that are directories in a Ceph filesystem
+type FilesystemProvisioner struct {
+	context *clusterd.Context
+
+	// The flex driver vendor dir to use
+	flexDriverVendor string
+
+	// The rook image that runs the jobs creating and deleting the directories
+	rookImage string
+}
+
+type filesystemProvisionerConfig struct {
+	// Required: The filesystem where the directories of the volumes are created.
+	fsName string
+
+	// Optional: Name of the cluster. Default is `rook-ceph`
+	clusterNamespace string
+
+	// Optional: The directory in the filesystem where the directories of the volumes are created. Default is `/`
+	path string
+}
+
+// NewFilesystemProvisioner creates a FilesystemProvisioner
+func NewFilesystemProvisioner(context *clusterd.Context, flexDriverVendor, rookImage string) controller.Provisioner {
+	return &FilesystemProvisioner{
+		context:          context,
+		flexDriverVendor: flexDriverVendor,
+		rookImage:        rookImage,
+	}
+}
+
+// Provision creates a directory for the volume in the filesystem with a quota of the requested size,
+// and returns a PV object representing it.
+func (p *FilesystemProvisioner) Provision(options controller.VolumeOptions) (*v1.PersistentVolume, error) {
+	if options.PVC.Spec.Selector != nil {
+		return nil, fmt.Errorf("claim Selector is not supported")
+	}
+
+	cfg, err := parseFilesystemClassParameters(options.Parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("creating filesystem volume with configuration %+v", *cfg)
+
+	storageClass, err := parseStorageClass(options)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := ceph.GetFilesystem(p.context, cfg.clusterNamespace, cfg.fsName); err != nil {
+		return nil, fmt.Errorf("failed to get filesystem %s in cluster %s. %+v", cfg.fsName, cfg.clusterNamespace, err)
+	}
+
+	capacity := options.PVC.Spec.Resources.Requests[v1.ResourceName(v1.ResourceStorage)]
+	volumePath := path.Join(cfg.path, options.PVName)
+	if err := p.runVolumeJob(options.PVName, cfg.clusterNamespace, cfg.fsName, volumePath, capacity.Value(), false); err != nil {
+		return nil, fmt.Errorf("failed to create directory %s in filesystem %s. %+v", volumePath, cfg.fsName, err)
+	}
+
+	// the volume is mounted with a client that can only access the directory of the volume
+	if err := cephfile.CreateClient(p.context, cfg.clusterNamespace, cephfile.VolumeClientName(options.PVName), cfg.fsName, volumePath, nil); err != nil {
+		return nil, fmt.Errorf("failed to create client for volume %s. %+v", options.PVName, err)
+	}
+
+	driverName, err := flexvolume.RookDriverName(p.context)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get driver name. %+v", err)
+	}
+
+	pv := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: options.PVName,
+		},
+		Spec: v1.PersistentVolumeSpec{
+			PersistentVolumeReclaimPolicy: options.PersistentVolumeReclaimPolicy,
+			AccessModes:                   options.PVC.Spec.AccessModes,
+			Capacity: v1.ResourceList{
+				v1.ResourceName(v1.ResourceStorage): capacity,
+			},
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				FlexVolume: &v1.FlexPersistentVolumeSource{
+					Driver: fmt.Sprintf("%s/%s", p.flexDriverVendor, driverName),
+					// the flex driver mounts a ceph filesystem instead of attaching an image for this type
+					FSType: flexvolume.CephFSType,
+					Options: map[string]string{
+						flexvolume.StorageClassKey:     storageClass,
+						flexvolume.FsNameKey:           cfg.fsName,
+						flexvolume.PathKey:             volumePath,
+						flexvolume.ClusterNamespaceKey: cfg.clusterNamespace,
+					},
+				},
+			},
+		},
+	}
+	logger.Infof("successfully created Rook filesystem volume %+v", pv.Spec.PersistentVolumeSource.FlexVolume)
+	return pv, nil
+}
+
+// Delete removes the directory of the volume and all its content from the filesystem
+func (p *FilesystemProvisioner) Delete(volume *v1.PersistentVolume) error {
+	logger.Infof("Deleting filesystem volume %s", volume.Name)
+	if volume.Spec.PersistentVolumeSource.FlexVolume == nil {
+		return fmt.Errorf("Failed to delete rook filesystem volume %s: %v", volume.Name, "PersistentVolume is not a FlexVolume")
+	}
+	flexOptions := volume.Spec.PersistentVolumeSource.FlexVolume.Options
+	if flexOptions[flexvolume.FsNameKey] == "" || flexOptions[flexvolume.PathKey] == "" {
+		return fmt.Errorf("Failed to delete rook filesystem volume %s: %v", volume.Name, "PersistentVolume has no filesystem path defined for the FlexVolume")
+	}
+	fsName := flexOptions[flexvolume.FsNameKey]
+	volumePath := flexOptions[flexvolume.PathKey]
+	clusterns := flexOptions[flexvolume.ClusterNamespaceKey]
+
+	if err := p.runVolumeJob(volume.Name, clusterns, fsName, volumePath, 0, true); err != nil {
+		return fmt.Errorf("Failed to delete directory %s from filesystem %s: %v", volumePath, fsName, err)
+	}
+	if err := cephfile.DeleteClient(p.context, clusterns, cephfile.VolumeClientName(volume.Name)); err != nil {
+		logger.Warningf("failed to delete client of volume %s. %+v", volume.Name, err)
+	}
+	logger.Infof("succeeded deleting filesystem volume %s", volume.Name)
+	return nil
+}
+
+// runVolumeJob creates or deletes the directory of a volume in a job that mounts the filesystem,
+// since the operator is not privileged to mount filesystems.
+func (p *FilesystemProvisioner) runVolumeJob(volumeName, clusterNamespace, fsName, volumePath string, quotaBytes int64, deleteVolume bool) error {
+	job := p.volumeJob(volumeName, clusterNamespace, fsName, volumePath, quotaBytes, deleteVolume)
+	if err := k8sutil.RunReplaceableJob(p.context.Clientset, job); err != nil {
+		return fmt.Errorf("failed to start job %s. %+v", job.Name, err)
+	}
+
+	// the job is left behind on failure to see its logs
+	if err := k8sutil.WaitForJobCompletion(p.context.Clientset, job, filesystemVolumeJobTimeout); err != nil {
+		return fmt.Errorf("failed to complete job %s. %+v", job.Name, err)
+	}
+
+	return k8sutil.DeleteBatchJob(p.context.Clientset, clusterNamespace, job.Name, false)
+}
+
+func (p *FilesystemProvisioner) volumeJob(volumeName, clusterNamespace, fsName, volumePath string, quotaBytes int64, deleteVolume bool) *batch.Job {
+	name := filesystemVolumeJobPrefix + volumeName
+	privileged := true
+	return &batch.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: clusterNamespace,
+		},
+		Spec: batch.JobSpec{
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"job": name,
+					},
+				},
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Args: []string{
+								"ceph",
+								filedaemon.VolumeCommand,
+								fmt.Sprintf("--fs-name=%s", fsName),
+								fmt.Sprintf("--volume-path=%s", volumePath),
+								fmt.Sprintf("--quota-bytes=%d", quotaBytes),
+								fmt.Sprintf("--delete=%t", deleteVolume),
+							},
+							Name:  "volume",
+							Image: p.rookImage,
+							Env: []v1.EnvVar{
+								mon.ClusterNameEnvVar(clusterNamespace),
+								mon.EndpointEnvVar(),
+								mon.AdminSecretEnvVar(),
+							},
+							// the filesystem is mounted with the kernel client
+							SecurityContext: &v1.SecurityContext{
+								Privileged: &privileged,
+							},
+						},
+					},
+					HostNetwork:   true,
+					RestartPolicy: v1.RestartPolicyOnFailure,
+				},
+			},
+		},
+	}
+}
+
+func parseFilesystemClassParameters(params map[string]string) (*filesystemProvisionerConfig, error) {
+	var cfg filesystemProvisionerConfig
+
+	for k, v := range params {
+		switch strings.ToLower(k) {
+		case "fsname":
+			cfg.fsName = v
+		case "clusternamespace":
+			cfg.clusterNamespace = v
+		case "clustername":
+			cfg.clusterNamespace = v
+		case "path":
+			cfg.path = v
+		default:
+			return nil, fmt.Errorf("invalid option %q for volume plugin %s", k, "rookFilesystemProvisioner")
+		}
+	}
+
+	if len(cfg.fsName) == 0 {
+		return nil, fmt.Errorf("StorageClass for provisioner %s must contain 'fsName' parameter", "rookFilesystemProvisioner")
+	}
+
+	if len(cfg.clusterNamespace) == 0 {
+		cfg.clusterNamespace = cluster.DefaultClusterName
+	}
+
+	// the directories of the volumes cannot be outside of the filesystem
+	cfg.path = path.Clean("/" + cfg.path)
+
+	return &cfg, nil
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestProvisionFilesystemVolume(t *testing.T) {
+	clientset := test.New(3)
+	os.Setenv("POD_NAMESPACE", "rook-system")
+	defer os.Setenv("POD_NAMESPACE", "")
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command, outfileArg string, args ...string) (string, error) {
+			if args[0] == "fs" && args[1] == "get" {
+				return `{"mdsmap":{"fs_name":"myfs"}}`, nil
+			}
+			if args[0] == "auth" {
+				assert.Equal(t, "client.fs-volume-pvc-uid-1-1", args[2])
+				assert.Equal(t, "allow rw path=/volumes/pvc-uid-1-1", args[6])
+				return `{"key":"mysecurekey"}`, nil
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Clientset: clientset, Executor: executor}
+
+	// complete the job that creates the directory when it is started
+	go func() {
+		for i := 0; i < 20; i++ {
+			job, err := clientset.Batch().Jobs("testCluster").Get("rook-ceph-fs-volume-pvc-uid-1-1", metav1.GetOptions{})
+			if err == nil {
+				job.Status.Succeeded = 1
+				clientset.Batch().Jobs("testCluster").Update(job)
+				return
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	}()
+
+	provisioner := NewFilesystemProvisioner(context, "foo.io", "rook/rook:myversion")
+	claim := newClaim("claim-1", "uid-1-1", "class-1", "", "class-1", nil)
+	claim.Spec.AccessModes = []v1.PersistentVolumeAccessMode{v1.ReadWriteMany}
+	claim.Spec.Resources.Requests = v1.ResourceList{v1.ResourceStorage: resource.MustParse("1Gi")}
+	volume := newVolumeOptions(newStorageClass("class-1", "foo.io/filesystem", map[string]string{"fsName": "myfs", "clusterNamespace": "testCluster", "path": "/volumes"}, v1.PersistentVolumeReclaimDelete), claim, v1.PersistentVolumeReclaimDelete)
+
+	pv, err := provisioner.Provision(volume)
+	assert.Nil(t, err)
+	assert.Equal(t, "pvc-uid-1-1", pv.Name)
+	assert.Equal(t, []v1.PersistentVolumeAccessMode{v1.ReadWriteMany}, pv.Spec.AccessModes)
+	capacity := pv.Spec.Capacity[v1.ResourceStorage]
+	assert.Equal(t, int64(1073741824), capacity.Value())
+	assert.Equal(t, "foo.io/rook", pv.Spec.PersistentVolumeSource.FlexVolume.Driver)
+	assert.Equal(t, "ceph", pv.Spec.PersistentVolumeSource.FlexVolume.FSType)
+	assert.Equal(t, "myfs", pv.Spec.PersistentVolumeSource.FlexVolume.Options["fsName"])
+	assert.Equal(t, "/volumes/pvc-uid-1-1", pv.Spec.PersistentVolumeSource.FlexVolume.Options["path"])
+	assert.Equal(t, "testCluster", pv.Spec.PersistentVolumeSource.FlexVolume.Options["clusterNamespace"])
+	assert.Equal(t, "class-1", pv.Spec.PersistentVolumeSource.FlexVolume.Options["storageClass"])
+
+	// the key of the client of the volume is stored in a secret
+	_, err = clientset.CoreV1().Secrets("testCluster").Get("rook-ceph-client-fs-volume-pvc-uid-1-1", metav1.GetOptions{})
+	assert.Nil(t, err)
+
+	// a volume that is not a filesystem volume cannot be deleted
+	delete(pv.Spec.PersistentVolumeSource.FlexVolume.Options, "fsName")
+	err = provisioner.Delete(pv)
+	assert.NotNil(t, err)
+}
+
+func TestFilesystemVolumeJob(t *testing.T) {
+	p := &FilesystemProvisioner{rookImage: "rook/rook:myversion"}
+
+	job := p.volumeJob("pvc-1", "rook-ceph", "myfs", "/volumes/pvc-1", 1024, false)
+	assert.Equal(t, "rook-ceph-fs-volume-pvc-1", job.Name)
+	assert.Equal(t, "rook-ceph", job.Namespace)
+	container := job.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "rook/rook:myversion", container.Image)
+	assert.Equal(t, []string{"ceph", "filesystem-volume", "--fs-name=myfs", "--volume-path=/volumes/pvc-1", "--quota-bytes=1024", "--delete=false"}, container.Args)
+	assert.True(t, *container.SecurityContext.Privileged)
+	assert.Equal(t, 3, len(container.Env))
+
+	job = p.volumeJob("pvc-1", "rook-ceph", "myfs", "/volumes/pvc-1", 0, true)
+	assert.Equal(t, "--delete=true", job.Spec.Template.Spec.Containers[0].Args[5])
+}
+
+func TestParseFilesystemClassParameters(t *testing.T) {
+	cfg, err := parseFilesystemClassParameters(map[string]string{"fsName": "myfs", "clusterNamespace": "mycluster", "path": "volumes/"})
+	assert.Nil(t, err)
+	assert.Equal(t, "myfs", cfg.fsName)
+	assert.Equal(t, "mycluster", cfg.clusterNamespace)
+	assert.Equal(t, "/volumes", cfg.path)
+
+	cfg, err = parseFilesystemClassParameters(map[string]string{"fsName": "myfs"})
+	assert.Nil(t, err)
+	assert.Equal(t, "rook-ceph", cfg.clusterNamespace)
+	assert.Equal(t, "/", cfg.path)
+
+	// the directories of the volumes cannot be outside of the filesystem
+	cfg, err = parseFilesystemClassParameters(map[string]string{"fsName": "myfs", "path": "../../etc"})
+	assert.Nil(t, err)
+	assert.Equal(t, "/etc", cfg.path)
+
+	_, err = parseFilesystemClassParameters(map[string]string{"pool": "mypool"})
+	assert.NotNil(t, err)
+	_, err = parseFilesystemClassParameters(map[string]string{})
+	assert.NotNil(t, err)
+}
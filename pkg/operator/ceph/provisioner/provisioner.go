@@ -22,6 +22,7 @@ import (
 	"strings"
 
 	"github.com/coreos/pkg/capnslog"
+	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume"
 	ceph "github.com/rook/rook/pkg/daemon/ceph/client"
@@ -95,7 +96,12 @@ func (p *RookVolumeProvisioner) Provision(options controller.VolumeOptions) (*v1
 		return nil, err
 	}
 
-	blockImage, err := p.createVolume(imageName, cfg.pool, cfg.dataPool, cfg.clusterNamespace, requestBytes)
+	var blockImage *ceph.CephBlockImage
+	if snapshotName, ok := options.PVC.Annotations[SnapshotAnnotationKey]; ok {
+		blockImage, err = p.restoreVolume(imageName, cfg, options.PVC.Namespace, snapshotName, requestBytes)
+	} else {
+		blockImage, err = p.createVolume(imageName, cfg.pool, cfg.dataPool, cfg.clusterNamespace, requestBytes)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -158,6 +164,38 @@ func (p *RookVolumeProvisioner) createVolume(image, pool, dataPool string, clust
 	return createdImage, nil
 }
 
+// restoreVolume creates a rook block volume that is cloned from the snapshot of another volume.
+func (p *RookVolumeProvisioner) restoreVolume(image string, cfg *provisionerConfig, namespace, snapshotName string, size int64) (*ceph.CephBlockImage, error) {
+	snapshot, err := p.context.RookClientset.CephV1beta1().VolumeSnapshots(namespace).Get(snapshotName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get volume snapshot %s/%s: %+v", namespace, snapshotName, err)
+	}
+	if snapshot.Status.Phase != cephv1beta1.VolumeSnapshotPhaseReady {
+		return nil, fmt.Errorf("volume snapshot %s/%s is not ready", namespace, snapshotName)
+	}
+	if snapshot.Status.ClusterNamespace != cfg.clusterNamespace {
+		return nil, fmt.Errorf("volume snapshot %s/%s is in cluster %s and cannot be restored to cluster %s",
+			namespace, snapshotName, snapshot.Status.ClusterNamespace, cfg.clusterNamespace)
+	}
+
+	status := snapshot.Status
+	clonedImage, err := ceph.CloneImage(p.context, cfg.clusterNamespace, status.Image, status.Pool, status.SnapshotName, image, cfg.pool, cfg.dataPool)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to restore rook block image %s/%s from snapshot %s: %v", cfg.pool, image, status.SnapshotName, err)
+	}
+	logger.Infof("Rook block image restored: %s, size = %d", clonedImage.Name, clonedImage.Size)
+
+	// the clone has the size of the snapshot, grow it if a larger volume was requested
+	if uint64(size) > clonedImage.Size {
+		if err := ceph.ResizeImage(p.context, cfg.clusterNamespace, image, cfg.pool, uint64(size)); err != nil {
+			return nil, fmt.Errorf("Failed to resize restored rook block image %s/%s: %v", cfg.pool, image, err)
+		}
+		clonedImage.Size = ((uint64(size) + sizeMB - 1) / sizeMB) * sizeMB
+	}
+
+	return clonedImage, nil
+}
+
 // Delete removes the storage asset that was created by Provision represented
 // by the given PV.
 func (p *RookVolumeProvisioner) Delete(volume *v1.PersistentVolume) error {
@@ -171,7 +209,25 @@ func (p *RookVolumeProvisioner) Delete(volume *v1.PersistentVolume) error {
 	name := volume.Spec.PersistentVolumeSource.FlexVolume.Options[flexvolume.ImageKey]
 	clusterns := volume.Spec.PersistentVolumeSource.FlexVolume.Options[flexvolume.ClusterNamespaceKey]
 	pool := volume.Spec.PersistentVolumeSource.FlexVolume.Options[flexvolume.PoolKey]
-	err := ceph.DeleteImage(p.context, clusterns, name, pool)
+
+	// the volume snapshots cannot be restored once the rbd snapshots are removed with the image
+	if err := failVolumeSnapshots(p.context, clusterns, name, pool); err != nil {
+		return fmt.Errorf("Failed to update the volume snapshots of rook block image %s/%s: %v", pool, name, err)
+	}
+
+	// the snapshots of the image are protected so they can be cloned, which prevents the image from being removed
+	snapshots, err := ceph.ListSnapshots(p.context, clusterns, name, pool)
+	if err != nil {
+		return fmt.Errorf("Failed to list the snapshots of rook block image %s/%s: %v", pool, name, err)
+	}
+	for _, snapName := range snapshots {
+		logger.Infof("removing snapshot %s of volume %s", snapName, volume.Name)
+		if err := removeImageSnapshot(p.context, clusterns, name, pool, snapName); err != nil {
+			return fmt.Errorf("Failed to remove snapshot %s of rook block image %s/%s: %v", snapName, pool, name, err)
+		}
+	}
+
+	err = ceph.DeleteImage(p.context, clusterns, name, pool)
 	if err != nil {
 		return fmt.Errorf("Failed to delete rook block image %s/%s: %v", pool, volume.Name, err)
 	}
@@ -179,6 +235,37 @@ func (p *RookVolumeProvisioner) Delete(volume *v1.PersistentVolume) error {
 	return nil
 }
 
+// Expand grows the rook block image backing the given PV to the requested size.
+// The filesystem on the volume is grown by the flex driver when the volume is mounted.
+func (p *RookVolumeProvisioner) Expand(volume *v1.PersistentVolume, requestedSize resource.Quantity) (resource.Quantity, error) {
+	logger.Infof("Expanding volume %s to %s", volume.Name, requestedSize.String())
+	if volume.Spec.PersistentVolumeSource.FlexVolume == nil {
+		return resource.Quantity{}, fmt.Errorf("Failed to expand rook block image %s: %v", volume.Name, "PersistentVolume is not a FlexVolume")
+	}
+	if volume.Spec.PersistentVolumeSource.FlexVolume.Options == nil {
+		return resource.Quantity{}, fmt.Errorf("Failed to expand rook block image %s: %v", volume.Name, "PersistentVolume has no image defined for the FlexVolume")
+	}
+	name := volume.Spec.PersistentVolumeSource.FlexVolume.Options[flexvolume.ImageKey]
+	clusterns := volume.Spec.PersistentVolumeSource.FlexVolume.Options[flexvolume.ClusterNamespaceKey]
+	pool := volume.Spec.PersistentVolumeSource.FlexVolume.Options[flexvolume.PoolKey]
+
+	// the image is resized on MB boundaries, so round up the requested size the same way as when the image is created
+	requestBytes := requestedSize.Value()
+	sizeInMB := (requestBytes + sizeMB - 1) / sizeMB
+	err := ceph.ResizeImage(p.context, clusterns, name, pool, uint64(sizeInMB*sizeMB))
+	if err != nil {
+		return resource.Quantity{}, fmt.Errorf("Failed to expand rook block image %s/%s: %v", pool, name, err)
+	}
+
+	s := fmt.Sprintf("%dMi", sizeInMB)
+	quantity, err := resource.ParseQuantity(s)
+	if err != nil {
+		return resource.Quantity{}, fmt.Errorf("cannot parse '%v': %v", s, err)
+	}
+	logger.Infof("succeeded expanding volume %s to %s", volume.Name, quantity.String())
+	return quantity, nil
+}
+
 func parseStorageClass(options controller.VolumeOptions) (string, error) {
 	if options.PVC.Spec.StorageClassName != nil {
 		return *options.PVC.Spec.StorageClassName, nil
@@ -24,6 +24,8 @@ import (
 	"strings"
 	"testing"
 
+	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
 	"github.com/rook/rook/pkg/clusterd"
 	cephtest "github.com/rook/rook/pkg/daemon/ceph/test"
 	"github.com/rook/rook/pkg/operator/ceph/provisioner/controller"
@@ -101,6 +103,74 @@ func TestProvisionImage(t *testing.T) {
 	assert.Equal(t, "iamdatapool", pv.Spec.PersistentVolumeSource.FlexVolume.Options["dataPool"])
 }
 
+func TestProvisionImageFromSnapshot(t *testing.T) {
+	snapshot := &cephv1beta1.VolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-snapshot", Namespace: v1.NamespaceDefault},
+		Status: cephv1beta1.VolumeSnapshotStatus{
+			Phase:            cephv1beta1.VolumeSnapshotPhaseReady,
+			ClusterNamespace: "testCluster",
+			Pool:             "srcpool",
+			Image:            "pvc-uid-0",
+			SnapshotName:     "snapshot-uid-2",
+		},
+	}
+	commands := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			if command != "rbd" {
+				return "", nil
+			}
+			commands = append(commands, args[0])
+			if args[0] == "clone" {
+				assert.Equal(t, "srcpool/pvc-uid-0@snapshot-uid-2", args[1])
+				assert.Equal(t, "testpool/pvc-uid-1-1", args[2])
+			}
+			if args[0] == "resize" {
+				assert.Equal(t, "2", args[3])
+			}
+			if args[0] == "ls" {
+				return `[{"image":"pvc-uid-1-1","size":1048576,"format":2}]`, nil
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{
+		Clientset:     test.New(3),
+		RookClientset: rookfake.NewSimpleClientset(snapshot),
+		Executor:      executor,
+	}
+
+	provisioner := New(context, "foo.io")
+	class := newStorageClass("class-1", "foo.io/block", map[string]string{"pool": "testpool", "clusterNamespace": "testCluster"}, v1.PersistentVolumeReclaimDelete)
+	claim := newClaim("claim-1", "uid-1-1", "class-1", "", "class-1", nil)
+	claim.Annotations = map[string]string{SnapshotAnnotationKey: "my-snapshot"}
+	claim.Spec.Resources.Requests[v1.ResourceName(v1.ResourceStorage)] = resource.MustParse("2Mi")
+	volume := newVolumeOptions(class, claim, v1.PersistentVolumeReclaimDelete)
+
+	// the image is cloned from the snapshot and grown to the requested size
+	pv, err := provisioner.Provision(volume)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"clone", "ls", "resize"}, commands)
+	assert.Equal(t, "pvc-uid-1-1", pv.Spec.PersistentVolumeSource.FlexVolume.Options["image"])
+	capacity := pv.Spec.Capacity[v1.ResourceName(v1.ResourceStorage)]
+	assert.Equal(t, "2Mi", capacity.String())
+
+	// the snapshot must be in the same cluster
+	class.Parameters["clusterNamespace"] = "otherCluster"
+	volume = newVolumeOptions(class, claim, v1.PersistentVolumeReclaimDelete)
+	_, err = provisioner.Provision(volume)
+	assert.NotNil(t, err)
+
+	// the snapshot must be ready
+	snapshot.Status.Phase = cephv1beta1.VolumeSnapshotPhasePending
+	_, err = context.RookClientset.CephV1beta1().VolumeSnapshots(v1.NamespaceDefault).Update(snapshot)
+	assert.Nil(t, err)
+	class.Parameters["clusterNamespace"] = "testCluster"
+	volume = newVolumeOptions(class, claim, v1.PersistentVolumeReclaimDelete)
+	_, err = provisioner.Provision(volume)
+	assert.NotNil(t, err)
+}
+
 func TestReclaimPolicyForProvisionedImages(t *testing.T) {
 	clientset := test.New(3)
 	namespace := "ns"
@@ -141,6 +211,51 @@ func TestReclaimPolicyForProvisionedImages(t *testing.T) {
 	}
 }
 
+func TestExpandImage(t *testing.T) {
+	resizeArgs := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			if command == "rbd" && args[0] == "resize" {
+				resizeArgs = args
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{
+		Clientset: test.New(3),
+		Executor:  executor,
+	}
+
+	provisioner := New(context, "foo.io").(controller.ExpandableProvisioner)
+	pv := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pvc-uid-1-1"},
+		Spec: v1.PersistentVolumeSpec{
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				FlexVolume: &v1.FlexPersistentVolumeSource{
+					Driver: "foo.io/rook",
+					Options: map[string]string{
+						"pool":             "testpool",
+						"image":            "pvc-uid-1-1",
+						"clusterNamespace": "testCluster",
+					},
+				},
+			},
+		},
+	}
+
+	// the requested size is rounded up to the next MB
+	size, err := provisioner.Expand(pv, resource.MustParse("2G"))
+	assert.Nil(t, err)
+	assert.Equal(t, "1908Mi", size.String())
+	assert.Equal(t, "testpool/pvc-uid-1-1", resizeArgs[1])
+	assert.Equal(t, "1908", resizeArgs[3])
+
+	// only flex volumes can be expanded
+	pv.Spec.PersistentVolumeSource.FlexVolume = nil
+	_, err = provisioner.Expand(pv, resource.MustParse("2G"))
+	assert.NotNil(t, err)
+}
+
 func TestParseClassParameters(t *testing.T) {
 	cfg := make(map[string]string)
 	cfg["pool"] = "testPool"
@@ -233,3 +348,80 @@ func newClaim(name, claimUID, provisioner, volumeName, storageclassName string,
 	}
 	return claim
 }
+
+func TestDeleteImageWithSnapshots(t *testing.T) {
+	commands := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			if command != "rbd" {
+				return "", nil
+			}
+			switch args[0] {
+			case "snap":
+				commands = append(commands, args[0]+" "+args[1]+" "+args[2])
+				if args[1] == "ls" {
+					return `[{"id":4,"name":"snapshot-uid-2","size":1048576}]`, nil
+				}
+			case "children":
+				commands = append(commands, args[0])
+				return "testpool/pvc-uid-3\n", nil
+			default:
+				commands = append(commands, args[0]+" "+args[1])
+			}
+			return "", nil
+		},
+	}
+	snapshot := &cephv1beta1.VolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-snapshot", Namespace: "app"},
+		Status: cephv1beta1.VolumeSnapshotStatus{
+			Phase:            cephv1beta1.VolumeSnapshotPhaseReady,
+			ClusterNamespace: "testCluster",
+			Pool:             "testpool",
+			Image:            "pvc-uid-1",
+			SnapshotName:     "snapshot-uid-2",
+		},
+	}
+	otherSnapshot := snapshot.DeepCopy()
+	otherSnapshot.Name = "other-snapshot"
+	otherSnapshot.Status.Image = "pvc-uid-3"
+	context := &clusterd.Context{
+		Clientset:     test.New(3),
+		RookClientset: rookfake.NewSimpleClientset(snapshot, otherSnapshot),
+		Executor:      executor,
+	}
+
+	provisioner := New(context, "foo.io")
+	pv := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pvc-uid-1"},
+		Spec: v1.PersistentVolumeSpec{
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				FlexVolume: &v1.FlexPersistentVolumeSource{
+					Driver:  "foo.io/rook",
+					Options: map[string]string{"pool": "testpool", "image": "pvc-uid-1", "clusterNamespace": "testCluster"},
+				},
+			},
+		},
+	}
+
+	// the protected snapshots are removed before the image, and the volumes restored from them are flattened
+	err := provisioner.Delete(pv)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"snap ls testpool/pvc-uid-1",
+		"children",
+		"flatten testpool/pvc-uid-3",
+		"snap unprotect testpool/pvc-uid-1@snapshot-uid-2",
+		"snap rm testpool/pvc-uid-1@snapshot-uid-2",
+		"rm testpool/pvc-uid-1",
+	}, commands)
+
+	// only the volume snapshots of the deleted image fail
+	snapshot, err = context.RookClientset.CephV1beta1().VolumeSnapshots("app").Get("my-snapshot", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cephv1beta1.VolumeSnapshotPhaseFailed, snapshot.Status.Phase)
+	assert.Equal(t, "the image testpool/pvc-uid-1 of the snapshot was deleted", snapshot.Status.Message)
+	assert.Equal(t, "", snapshot.Status.SnapshotName)
+	otherSnapshot, err = context.RookClientset.CephV1beta1().VolumeSnapshots("app").Get("other-snapshot", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cephv1beta1.VolumeSnapshotPhaseReady, otherSnapshot.Status.Phase)
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	opkit "github.com/rook/operator-kit"
+	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume"
+	ceph "github.com/rook/rook/pkg/daemon/ceph/client"
+	"k8s.io/api/core/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	snapshotResourceName       = "volumesnapshot"
+	snapshotResourceNamePlural = "volumesnapshots"
+
+	// SnapshotAnnotationKey is the annotation on a claim that names the volume snapshot in the same namespace
+	// from which the volume will be restored
+	SnapshotAnnotationKey = "ceph.rook.io/snapshot"
+)
+
+// VolumeSnapshotResource represents the volume snapshot custom resource
+var VolumeSnapshotResource = opkit.CustomResource{
+	Name:    snapshotResourceName,
+	Plural:  snapshotResourceNamePlural,
+	Group:   cephv1beta1.CustomResourceGroup,
+	Version: cephv1beta1.Version,
+	Scope:   apiextensionsv1beta1.NamespaceScoped,
+	Kind:    reflect.TypeOf(cephv1beta1.VolumeSnapshot{}).Name(),
+}
+
+// SnapshotController represents a controller object for volume snapshot custom resources
+type SnapshotController struct {
+	context *clusterd.Context
+}
+
+// NewSnapshotController create controller for watching volume snapshot custom resources created
+func NewSnapshotController(context *clusterd.Context) *SnapshotController {
+	return &SnapshotController{
+		context: context,
+	}
+}
+
+// StartWatch watches for instances of VolumeSnapshot custom resources and acts on them
+func (c *SnapshotController) StartWatch(namespace string, stopCh chan struct{}) error {
+
+	resourceHandlerFuncs := cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.onAdd,
+		UpdateFunc: c.onUpdate,
+		DeleteFunc: c.onDelete,
+	}
+
+	logger.Infof("start watching volume snapshot resources in namespace %s", namespace)
+	watcher := opkit.NewWatcher(VolumeSnapshotResource, namespace, resourceHandlerFuncs, c.context.RookClientset.CephV1beta1().RESTClient())
+	go watcher.Watch(&cephv1beta1.VolumeSnapshot{}, stopCh)
+
+	return nil
+}
+
+func (c *SnapshotController) onAdd(obj interface{}) {
+	snapshot := obj.(*cephv1beta1.VolumeSnapshot).DeepCopy()
+	if snapshot.Status.Phase == cephv1beta1.VolumeSnapshotPhaseReady {
+		logger.Debugf("volume snapshot %s/%s is already created", snapshot.Namespace, snapshot.Name)
+		return
+	}
+
+	if err := c.createSnapshot(snapshot); err != nil {
+		logger.Errorf("failed to create volume snapshot %s/%s. %+v", snapshot.Namespace, snapshot.Name, err)
+		snapshot.Status.Phase = cephv1beta1.VolumeSnapshotPhaseFailed
+		snapshot.Status.Message = err.Error()
+		if _, err := c.context.RookClientset.CephV1beta1().VolumeSnapshots(snapshot.Namespace).Update(snapshot); err != nil {
+			logger.Errorf("failed to update status of volume snapshot %s/%s. %+v", snapshot.Namespace, snapshot.Name, err)
+		}
+	}
+}
+
+func (c *SnapshotController) onUpdate(oldObj, newObj interface{}) {
+	oldSnapshot := oldObj.(*cephv1beta1.VolumeSnapshot).DeepCopy()
+	newSnapshot := newObj.(*cephv1beta1.VolumeSnapshot).DeepCopy()
+
+	if oldSnapshot.Spec.PersistentVolumeClaimName != newSnapshot.Spec.PersistentVolumeClaimName {
+		logger.Errorf("the claim of volume snapshot %s/%s cannot be changed from %s to %s. create a new snapshot instead",
+			newSnapshot.Namespace, newSnapshot.Name, oldSnapshot.Spec.PersistentVolumeClaimName, newSnapshot.Spec.PersistentVolumeClaimName)
+	}
+}
+
+func (c *SnapshotController) onDelete(obj interface{}) {
+	snapshot := obj.(*cephv1beta1.VolumeSnapshot).DeepCopy()
+
+	if err := c.deleteSnapshot(snapshot); err != nil {
+		logger.Errorf("failed to delete volume snapshot %s/%s. %+v", snapshot.Namespace, snapshot.Name, err)
+	}
+}
+
+func (c *SnapshotController) createSnapshot(snapshot *cephv1beta1.VolumeSnapshot) error {
+	if snapshot.Spec.PersistentVolumeClaimName == "" {
+		return fmt.Errorf("missing persistentVolumeClaimName")
+	}
+
+	// find the rbd image that backs the claim
+	claim, err := c.context.Clientset.CoreV1().PersistentVolumeClaims(snapshot.Namespace).Get(snapshot.Spec.PersistentVolumeClaimName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get claim %s. %+v", snapshot.Spec.PersistentVolumeClaimName, err)
+	}
+	if claim.Spec.VolumeName == "" {
+		return fmt.Errorf("claim %s is not bound to a volume", claim.Name)
+	}
+	pv, err := c.context.Clientset.CoreV1().PersistentVolumes().Get(claim.Spec.VolumeName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get volume %s. %+v", claim.Spec.VolumeName, err)
+	}
+	if pv.Spec.PersistentVolumeSource.FlexVolume == nil || pv.Spec.PersistentVolumeSource.FlexVolume.Options == nil {
+		return fmt.Errorf("volume %s is not a rook block volume", pv.Name)
+	}
+	options := pv.Spec.PersistentVolumeSource.FlexVolume.Options
+	image := options[flexvolume.ImageKey]
+	pool := options[flexvolume.PoolKey]
+	clusterNamespace := options[flexvolume.ClusterNamespaceKey]
+	if image == "" || pool == "" || clusterNamespace == "" {
+		return fmt.Errorf("volume %s is missing required options (image=%s, pool=%s, clusterNamespace=%s)", pv.Name, image, pool, clusterNamespace)
+	}
+
+	// the snapshot is protected so that volumes can be cloned from it
+	snapName := snapshotName(snapshot)
+	logger.Infof("creating snapshot %s of image %s/%s for volume snapshot %s/%s", snapName, pool, image, snapshot.Namespace, snapshot.Name)
+	if err := ceph.CreateSnapshot(c.context, clusterNamespace, image, pool, snapName); err != nil {
+		return err
+	}
+	if err := ceph.ProtectSnapshot(c.context, clusterNamespace, image, pool, snapName); err != nil {
+		return err
+	}
+
+	snapshot.Status = cephv1beta1.VolumeSnapshotStatus{
+		Phase:            cephv1beta1.VolumeSnapshotPhaseReady,
+		ClusterNamespace: clusterNamespace,
+		Pool:             pool,
+		Image:            image,
+		SnapshotName:     snapName,
+		RestoreSize:      pv.Spec.Capacity[v1.ResourceName(v1.ResourceStorage)],
+	}
+	if _, err := c.context.RookClientset.CephV1beta1().VolumeSnapshots(snapshot.Namespace).Update(snapshot); err != nil {
+		return fmt.Errorf("failed to update status of volume snapshot. %+v", err)
+	}
+
+	logger.Infof("created volume snapshot %s/%s", snapshot.Namespace, snapshot.Name)
+	return nil
+}
+
+func (c *SnapshotController) deleteSnapshot(snapshot *cephv1beta1.VolumeSnapshot) error {
+	status := snapshot.Status
+	if status.SnapshotName == "" {
+		logger.Infof("volume snapshot %s/%s has no rbd snapshot to remove", snapshot.Namespace, snapshot.Name)
+		return nil
+	}
+
+	if err := removeImageSnapshot(c.context, status.ClusterNamespace, status.Image, status.Pool, status.SnapshotName); err != nil {
+		return err
+	}
+
+	logger.Infof("deleted volume snapshot %s/%s", snapshot.Namespace, snapshot.Name)
+	return nil
+}
+
+// failVolumeSnapshots marks the ready volume snapshots of an image as failed when the image is deleted. The rbd
+// snapshots are removed with the image, so the volume snapshots don't reference them anymore.
+func failVolumeSnapshots(context *clusterd.Context, clusterNamespace, image, pool string) error {
+	snapshots, err := context.RookClientset.CephV1beta1().VolumeSnapshots(v1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list volume snapshots. %+v", err)
+	}
+	for i := range snapshots.Items {
+		snapshot := &snapshots.Items[i]
+		status := snapshot.Status
+		if status.Phase != cephv1beta1.VolumeSnapshotPhaseReady || status.ClusterNamespace != clusterNamespace ||
+			status.Pool != pool || status.Image != image {
+			continue
+		}
+
+		logger.Infof("volume snapshot %s/%s failed since its image %s/%s is deleted", snapshot.Namespace, snapshot.Name, pool, image)
+		snapshot.Status.Phase = cephv1beta1.VolumeSnapshotPhaseFailed
+		snapshot.Status.Message = fmt.Sprintf("the image %s/%s of the snapshot was deleted", pool, image)
+		snapshot.Status.SnapshotName = ""
+		if _, err := context.RookClientset.CephV1beta1().VolumeSnapshots(snapshot.Namespace).Update(snapshot); err != nil {
+			return fmt.Errorf("failed to update status of volume snapshot %s/%s. %+v", snapshot.Namespace, snapshot.Name, err)
+		}
+	}
+	return nil
+}
+
+// removeImageSnapshot flattens the images cloned from a snapshot so they do not depend on it anymore, then
+// unprotects and removes the snapshot
+func removeImageSnapshot(context *clusterd.Context, clusterNamespace, image, pool, snapName string) error {
+	children, err := ceph.ListSnapshotChildren(context, clusterNamespace, image, pool, snapName)
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		parts := strings.SplitN(child, "/", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("unexpected child image %s of snapshot %s", child, snapName)
+		}
+		logger.Infof("flattening image %s that was cloned from snapshot %s of image %s/%s", child, snapName, pool, image)
+		if err := ceph.FlattenImage(context, clusterNamespace, parts[1], parts[0]); err != nil {
+			return err
+		}
+	}
+
+	if err := ceph.UnprotectSnapshot(context, clusterNamespace, image, pool, snapName); err != nil {
+		return err
+	}
+	return ceph.DeleteSnapshot(context, clusterNamespace, image, pool, snapName)
+}
+
+func snapshotName(snapshot *cephv1beta1.VolumeSnapshot) string {
+	return fmt.Sprintf("snapshot-%s", snapshot.UID)
+}
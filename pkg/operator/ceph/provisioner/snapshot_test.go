@@ -0,0 +1,118 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"testing"
+
+	cephv1beta1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCreateAndDeleteSnapshot(t *testing.T) {
+	snapshot := &cephv1beta1.VolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-snapshot", Namespace: "app", UID: "uid-2"},
+		Spec:       cephv1beta1.VolumeSnapshotSpec{PersistentVolumeClaimName: "my-claim"},
+	}
+	commands := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			if command != "rbd" {
+				return "", nil
+			}
+			switch args[0] {
+			case "snap":
+				commands = append(commands, args[0]+" "+args[1])
+				assert.Equal(t, "testpool/pvc-uid-1@snapshot-uid-2", args[2])
+			case "children":
+				commands = append(commands, args[0])
+				return "testpool/pvc-uid-3\n", nil
+			case "flatten":
+				commands = append(commands, args[0])
+				assert.Equal(t, "testpool/pvc-uid-3", args[1])
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{
+		Clientset:     test.New(3),
+		RookClientset: rookfake.NewSimpleClientset(snapshot),
+		Executor:      executor,
+	}
+	controller := NewSnapshotController(context)
+
+	// the claim must exist
+	err := controller.createSnapshot(snapshot)
+	assert.NotNil(t, err)
+
+	// the claim must be bound
+	claim := &v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "my-claim", Namespace: "app"}}
+	_, err = context.Clientset.CoreV1().PersistentVolumeClaims("app").Create(claim)
+	assert.Nil(t, err)
+	err = controller.createSnapshot(snapshot)
+	assert.NotNil(t, err)
+
+	claim.Spec.VolumeName = "pvc-uid-1"
+	_, err = context.Clientset.CoreV1().PersistentVolumeClaims("app").Update(claim)
+	assert.Nil(t, err)
+	pv := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pvc-uid-1"},
+		Spec: v1.PersistentVolumeSpec{
+			Capacity: v1.ResourceList{v1.ResourceName(v1.ResourceStorage): resource.MustParse("10Mi")},
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				FlexVolume: &v1.FlexPersistentVolumeSource{
+					Driver:  "foo.io/rook",
+					Options: map[string]string{"pool": "testpool", "image": "pvc-uid-1", "clusterNamespace": "testCluster"},
+				},
+			},
+		},
+	}
+	_, err = context.Clientset.CoreV1().PersistentVolumes().Create(pv)
+	assert.Nil(t, err)
+
+	// the snapshot is created and protected
+	err = controller.createSnapshot(snapshot)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"snap create", "snap protect"}, commands)
+	created, err := context.RookClientset.CephV1beta1().VolumeSnapshots("app").Get("my-snapshot", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cephv1beta1.VolumeSnapshotPhaseReady, created.Status.Phase)
+	assert.Equal(t, "testCluster", created.Status.ClusterNamespace)
+	assert.Equal(t, "testpool", created.Status.Pool)
+	assert.Equal(t, "pvc-uid-1", created.Status.Image)
+	assert.Equal(t, "snapshot-uid-2", created.Status.SnapshotName)
+	assert.Equal(t, "10Mi", created.Status.RestoreSize.String())
+
+	// the clones are flattened before the snapshot is removed
+	commands = []string{}
+	err = controller.deleteSnapshot(created)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"children", "flatten", "snap unprotect", "snap rm"}, commands)
+
+	// a snapshot that was never created has nothing to remove
+	commands = []string{}
+	err = controller.deleteSnapshot(snapshot)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(commands))
+}
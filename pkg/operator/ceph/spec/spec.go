@@ -18,15 +18,23 @@ limitations under the License.
 package spec
 
 import (
+	"strings"
+
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
 	// ConfigInitContainerName is the name which is given to the config initialization container
 	// in all Ceph pods.
 	ConfigInitContainerName = "config-init"
+
+	// NetworkAttachmentAnnotation is the pod annotation with the network attachments that Multus
+	// connects the pod to in addition to the pod network.
+	NetworkAttachmentAnnotation = "k8s.v1.cni.cncf.io/networks"
 )
 
 // PodVolumes fills in the volumes parameter with the common list of Kubernetes volumes for use in Ceph pods.
@@ -79,3 +87,36 @@ func PodLabels(appName, namespace, daemonType, daemonID string) map[string]strin
 	labels[daemonType] = daemonID
 	return labels
 }
+
+// NetworkEnvVars returns the env vars with the public and cluster networks for the containers that generate
+// the Ceph config. The daemons bind to their addresses in the networks.
+func NetworkEnvVars(network rookalpha.NetworkSpec) []v1.EnvVar {
+	envVars := []v1.EnvVar{}
+	if network.PublicNetwork.CIDR != "" {
+		envVars = append(envVars, v1.EnvVar{Name: k8sutil.PublicNetworkEnvVar, Value: network.PublicNetwork.CIDR})
+	}
+	if network.ClusterNetwork.CIDR != "" {
+		envVars = append(envVars, v1.EnvVar{Name: k8sutil.ClusterNetworkEnvVar, Value: network.ClusterNetwork.CIDR})
+	}
+	return envVars
+}
+
+// AddNetworkAnnotations adds the annotation that attaches the pod to the public network, and to the cluster
+// network if the daemon needs it. Only OSDs use the cluster network.
+func AddNetworkAnnotations(objectMeta *metav1.ObjectMeta, network rookalpha.NetworkSpec, clusterNetwork bool) {
+	attachments := []string{}
+	if network.PublicNetwork.Attachment != "" {
+		attachments = append(attachments, network.PublicNetwork.Attachment)
+	}
+	if clusterNetwork && network.ClusterNetwork.Attachment != "" && network.ClusterNetwork.Attachment != network.PublicNetwork.Attachment {
+		attachments = append(attachments, network.ClusterNetwork.Attachment)
+	}
+	if len(attachments) == 0 {
+		return
+	}
+
+	if objectMeta.Annotations == nil {
+		objectMeta.Annotations = map[string]string{}
+	}
+	objectMeta.Annotations[NetworkAttachmentAnnotation] = strings.Join(attachments, ",")
+}
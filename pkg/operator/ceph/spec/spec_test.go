@@ -19,8 +19,12 @@ package spec
 import (
 	"testing"
 
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestPodVolumes(t *testing.T) {
@@ -42,3 +46,38 @@ func TestMountsMatchVolumes(t *testing.T) {
 	}
 	volsMountsTestDef.TestMountsMatchVolumes(t)
 }
+
+func TestNetworkEnvVars(t *testing.T) {
+	assert.Equal(t, 0, len(NetworkEnvVars(rookalpha.NetworkSpec{})))
+
+	network := rookalpha.NetworkSpec{
+		PublicNetwork:  rookalpha.NetworkSelection{CIDR: "10.1.1.0/24"},
+		ClusterNetwork: rookalpha.NetworkSelection{CIDR: "10.1.2.0/24"},
+	}
+	assert.Equal(t, []v1.EnvVar{
+		{Name: "ROOK_PUBLIC_NETWORK", Value: "10.1.1.0/24"},
+		{Name: "ROOK_CLUSTER_NETWORK", Value: "10.1.2.0/24"},
+	}, NetworkEnvVars(network))
+}
+
+func TestAddNetworkAnnotations(t *testing.T) {
+	// pods are not attached to networks without attachments
+	objectMeta := metav1.ObjectMeta{}
+	AddNetworkAnnotations(&objectMeta, rookalpha.NetworkSpec{}, true)
+	assert.Nil(t, objectMeta.Annotations)
+
+	network := rookalpha.NetworkSpec{
+		PublicNetwork:  rookalpha.NetworkSelection{CIDR: "10.1.1.0/24", Attachment: "public"},
+		ClusterNetwork: rookalpha.NetworkSelection{CIDR: "10.1.2.0/24", Attachment: "rook-ceph/cluster"},
+	}
+	AddNetworkAnnotations(&objectMeta, network, false)
+	assert.Equal(t, "public", objectMeta.Annotations[NetworkAttachmentAnnotation])
+
+	AddNetworkAnnotations(&objectMeta, network, true)
+	assert.Equal(t, "public,rook-ceph/cluster", objectMeta.Annotations[NetworkAttachmentAnnotation])
+
+	// the same network is attached only once
+	network.ClusterNetwork = network.PublicNetwork
+	AddNetworkAnnotations(&objectMeta, network, true)
+	assert.Equal(t, "public", objectMeta.Annotations[NetworkAttachmentAnnotation])
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sutil
+
+import (
+	"fmt"
+
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	"github.com/rook/rook/pkg/clusterd"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	// EventReasonCreated is the reason of the events recorded when a resource was created
+	EventReasonCreated = "Created"
+	// EventReasonCreateFailed is the reason of the events recorded when a resource failed to be created
+	EventReasonCreateFailed = "CreateFailed"
+	// EventReasonDeleted is the reason of the events recorded when a resource was deleted
+	EventReasonDeleted = "Deleted"
+	// EventReasonDeleteFailed is the reason of the events recorded when a resource failed to be deleted
+	EventReasonDeleteFailed = "DeleteFailed"
+)
+
+// NewEventRecorder creates a recorder that publishes events about the rook custom resources to the
+// kubernetes API
+func NewEventRecorder(clientset kubernetes.Interface, component string) record.EventRecorder {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartLogging(logger.Debugf)
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events(v1.NamespaceAll)})
+	return broadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: component})
+}
+
+// RecordEvent records an event on the object with the recorder of the context. Nothing is recorded
+// when the context has no recorder.
+func RecordEvent(context *clusterd.Context, object runtime.Object, eventType, reason, messageFmt string, args ...interface{}) {
+	if context == nil || context.Recorder == nil || object == nil {
+		return
+	}
+	context.Recorder.Event(object, eventType, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+// OwnerObjectReference returns a reference to the owner of resources in the namespace, which can be
+// used to record events on the owner when only its owner reference is known
+func OwnerObjectReference(namespace string, ownerRef metav1.OwnerReference) *v1.ObjectReference {
+	return &v1.ObjectReference{
+		APIVersion: ownerRef.APIVersion,
+		Kind:       ownerRef.Kind,
+		Name:       ownerRef.Name,
+		Namespace:  namespace,
+		UID:        ownerRef.UID,
+	}
+}
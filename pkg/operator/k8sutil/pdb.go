@@ -0,0 +1,59 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sutil
+
+import (
+	"fmt"
+	"reflect"
+
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// CreateOrUpdatePodDisruptionBudget creates a pod disruption budget, or replaces it if its spec changed.
+// The spec of a budget cannot be updated in the policy/v1beta1 API, so the budget is deleted and created again.
+func CreateOrUpdatePodDisruptionBudget(clientset kubernetes.Interface, pdb *policyv1beta1.PodDisruptionBudget) error {
+	existing, err := clientset.PolicyV1beta1().PodDisruptionBudgets(pdb.Namespace).Get(pdb.Name, metav1.GetOptions{})
+	if err == nil {
+		if reflect.DeepEqual(existing.Spec, pdb.Spec) {
+			return nil
+		}
+		logger.Infof("replacing pod disruption budget %s in namespace %s", pdb.Name, pdb.Namespace)
+		if err := DeletePodDisruptionBudget(clientset, pdb.Namespace, pdb.Name); err != nil {
+			return err
+		}
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to get pod disruption budget %s. %+v", pdb.Name, err)
+	}
+
+	if _, err := clientset.PolicyV1beta1().PodDisruptionBudgets(pdb.Namespace).Create(pdb); err != nil {
+		return fmt.Errorf("failed to create pod disruption budget %s. %+v", pdb.Name, err)
+	}
+	logger.Infof("pod disruption budget %s created in namespace %s", pdb.Name, pdb.Namespace)
+	return nil
+}
+
+// DeletePodDisruptionBudget deletes a pod disruption budget if it exists
+func DeletePodDisruptionBudget(clientset kubernetes.Interface, namespace, name string) error {
+	err := clientset.PolicyV1beta1().PodDisruptionBudgets(namespace).Delete(name, &metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete pod disruption budget %s. %+v", name, err)
+	}
+	return nil
+}
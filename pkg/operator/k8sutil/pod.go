@@ -41,6 +41,10 @@ const (
 	PublicIPEnvVar = "ROOK_PUBLIC_IP"
 	// PrivateIPEnvVar pod IP env var
 	PrivateIPEnvVar = "ROOK_PRIVATE_IP"
+	// PublicNetworkEnvVar public network env var
+	PublicNetworkEnvVar = "ROOK_PUBLIC_NETWORK"
+	// ClusterNetworkEnvVar cluster network env var
+	ClusterNetworkEnvVar = "ROOK_CLUSTER_NETWORK"
 
 	// DefaultRepoPrefix repo prefix
 	DefaultRepoPrefix = "rook"
@@ -48,9 +52,15 @@ const (
 	ConfigOverrideName = "rook-config-override"
 	// ConfigOverrideVal config override value
 	ConfigOverrideVal = "config"
-	defaultVersion    = "rook/rook:latest"
-	configMountDir    = "/etc/rook/config"
-	overrideFilename  = "override.conf"
+	// CephConfigName is the name of the configmap with the ceph config settings of the cluster CRD
+	CephConfigName = "rook-ceph-config"
+	// CephConfigVal is the key of the ceph config settings in the configmap
+	CephConfigVal = "config"
+	// CephConfigEnvVarName is the env var with the ceph config settings of the daemons
+	CephConfigEnvVarName = "ROOK_CEPH_CONFIG"
+	defaultVersion       = "rook/rook:latest"
+	configMountDir       = "/etc/rook/config"
+	overrideFilename     = "override.conf"
 )
 
 // ConfigOverrideMount is an override mount
@@ -70,6 +80,17 @@ func ConfigOverrideEnvVar() v1.EnvVar {
 	return v1.EnvVar{Name: "ROOK_CEPH_CONFIG_OVERRIDE", Value: path.Join(configMountDir, overrideFilename)}
 }
 
+// CephConfigEnvVar is the env var with the ceph config settings from the cluster CRD. The settings are
+// loaded from the configmap when the pod starts so changing them does not change the pod spec.
+func CephConfigEnvVar() v1.EnvVar {
+	optional := true
+	return v1.EnvVar{Name: CephConfigEnvVarName, ValueFrom: &v1.EnvVarSource{ConfigMapKeyRef: &v1.ConfigMapKeySelector{
+		LocalObjectReference: v1.LocalObjectReference{Name: CephConfigName},
+		Key:                  CephConfigVal,
+		Optional:             &optional,
+	}}}
+}
+
 // PodIPEnvVar private ip env var
 func PodIPEnvVar(property string) v1.EnvVar {
 	return v1.EnvVar{Name: property, ValueFrom: &v1.EnvVarSource{FieldRef: &v1.ObjectFieldSelector{FieldPath: "status.podIP"}}}
@@ -0,0 +1,360 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nfs
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	s "strings"
+
+	nfsv1alpha1 "github.com/rook/rook/pkg/apis/nfs.rook.io/v1alpha1"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	"github.com/rook/rook/pkg/operator/ceph/object"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	nfsCephVolumeName = "nfs-ganesha-ceph"
+	cephConfigPath    = "/etc/ceph"
+	cephConfigKey     = "ceph.conf"
+	cephKeyringKey    = "keyring"
+	rgwExportsKey     = "rgw-exports.conf"
+	graceTool         = "ganesha-rados-grace"
+)
+
+// cephUserID returns the id of the cephx client that NFS Ganesha uses to access the Ceph exports and the recovery data.
+// Each NFS server has its own client, so deleting a server does not remove the access of the other servers.
+func cephUserID(nfsServer *nfsServer) string {
+	return fmt.Sprintf("nfs-ganesha-%s-%s", nfsServer.namespace, nfsServer.serverName)
+}
+
+// cephSecretName returns the name of the secret with the Ceph config and the keyring of the NFS server
+func cephSecretName(nfsServer *nfsServer) string {
+	return fmt.Sprintf("nfs-ganesha-ceph-%s", nfsServer.serverName)
+}
+
+// recoveryNamespace returns the RADOS namespace of the grace database and the client recovery data of the NFS server.
+// The servers of another NFSServer resource must not share the grace database.
+func recoveryNamespace(nfsServer *nfsServer) string {
+	if nfsServer.spec.CephCluster.RecoveryNamespace != "" {
+		return nfsServer.spec.CephCluster.RecoveryNamespace
+	}
+	return fmt.Sprintf("%s-%s", nfsServer.namespace, nfsServer.serverName)
+}
+
+func cephFilesystemPath(export *nfsv1alpha1.CephFilesystemExportSpec) string {
+	// the exported directory cannot be outside of the filesystem
+	return path.Clean("/" + export.Path)
+}
+
+func hasCephObjectStoreExports(spec *nfsv1alpha1.NFSServerSpec) bool {
+	for _, export := range spec.Exports {
+		if export.CephObjectStore != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// cephClientAccess returns the caps of the cephx client of the NFS server, which can only access the exported
+// directories and the data pools of the exported filesystems, the object stores and the recovery data
+func cephClientAccess(nfsServer *nfsServer) []string {
+	monCaps := "allow r"
+	mdsCaps := []string{}
+	osdCaps := []string{}
+	filesystems := map[string]bool{}
+	for _, export := range nfsServer.spec.Exports {
+		if export.CephFilesystem == nil {
+			continue
+		}
+		mdsCaps = append(mdsCaps, fmt.Sprintf("allow rw path=%s", cephFilesystemPath(export.CephFilesystem)))
+		if !filesystems[export.CephFilesystem.Name] {
+			filesystems[export.CephFilesystem.Name] = true
+			osdCaps = append(osdCaps, fmt.Sprintf("allow rw tag cephfs data=%s", export.CephFilesystem.Name))
+		}
+	}
+
+	if hasCephObjectStoreExports(&nfsServer.spec) {
+		// the RGW library needs the same access to the cluster as the RGW daemons
+		monCaps = "allow rw"
+		osdCaps = append(osdCaps, "allow rwx")
+	}
+
+	if nfsServer.spec.CephCluster.RecoveryPool != "" {
+		osdCaps = append(osdCaps, fmt.Sprintf("allow rw pool=%s namespace=%s", nfsServer.spec.CephCluster.RecoveryPool, recoveryNamespace(nfsServer)))
+	}
+
+	access := []string{"mon", monCaps}
+	if len(mdsCaps) > 0 {
+		access = append(access, "mds", s.Join(mdsCaps, ", "))
+	}
+	if len(osdCaps) > 0 {
+		access = append(access, "osd", s.Join(osdCaps, ", "))
+	}
+	return access
+}
+
+// loadCephCluster writes the admin config of the Ceph cluster to run the ceph commands against the cluster
+func (c *Controller) loadCephCluster(clusterNamespace string) (*cephconfig.ClusterInfo, error) {
+	clusterInfo, _, _, err := mon.LoadClusterInfo(c.context, clusterNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ceph cluster %s. %+v", clusterNamespace, err)
+	}
+	if err := cephconfig.GenerateAdminConnectionConfig(c.context, clusterInfo); err != nil {
+		return nil, fmt.Errorf("failed to write config of ceph cluster %s. %+v", clusterNamespace, err)
+	}
+	return clusterInfo, nil
+}
+
+// createCephConfig creates the cephx client of the NFS server and stores the Ceph config, the keyring of the client
+// and the exports of the object store buckets in a secret that is mounted in the NFS server pods
+func (c *Controller) createCephConfig(nfsServer *nfsServer) error {
+	if nfsServer.spec.CephCluster == nil {
+		return nil
+	}
+	clusterNamespace := nfsServer.spec.CephCluster.Namespace
+	clusterInfo, err := c.loadCephCluster(clusterNamespace)
+	if err != nil {
+		return err
+	}
+
+	userID := cephUserID(nfsServer)
+	username := fmt.Sprintf("client.%s", userID)
+	access := cephClientAccess(nfsServer)
+	// get-or-create fails when the client already exists with other caps, so the caps of an existing client are updated first
+	if err := client.AuthUpdateCaps(c.context, clusterNamespace, username, access); err != nil {
+		logger.Debugf("client %s does not exist yet. %+v", username, err)
+	}
+	key, err := client.AuthGetOrCreateKey(c.context, clusterNamespace, username, access)
+	if err != nil {
+		return fmt.Errorf("failed to get or create auth key for client %s. %+v", username, err)
+	}
+
+	// the keys of the object store users are kept out of the config map with the other exports
+	nfsServer.rgwExports, err = c.createRGWExportsConfig(nfsServer)
+	if err != nil {
+		return err
+	}
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            cephSecretName(nfsServer),
+			Namespace:       nfsServer.namespace,
+			OwnerReferences: []metav1.OwnerReference{nfsServer.ownerRef},
+			Labels:          createAppLabels(),
+		},
+		StringData: map[string]string{
+			cephConfigKey:  createCephConfigFile(clusterInfo, userID),
+			cephKeyringKey: fmt.Sprintf("[%s]\n\tkey = %s\n", username, key),
+			rgwExportsKey:  nfsServer.rgwExports,
+		},
+		Type: k8sutil.RookType,
+	}
+	if _, err := c.context.Clientset.CoreV1().Secrets(nfsServer.namespace).Create(secret); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create secret %s. %+v", secret.Name, err)
+		}
+		if _, err := c.context.Clientset.CoreV1().Secrets(nfsServer.namespace).Update(secret); err != nil {
+			return fmt.Errorf("failed to update secret %s. %+v", secret.Name, err)
+		}
+	}
+
+	logger.Infof("created ceph client %s for nfs server %s in namespace %s", username, nfsServer.serverName, nfsServer.namespace)
+	return nil
+}
+
+// deleteCephConfig removes the cephx client of the NFS server and the NFS server pods from the grace database.
+// The secret with the Ceph config is owned by the NFS server.
+func (c *Controller) deleteCephConfig(nfsServer *nfsServer) error {
+	if nfsServer.spec.CephCluster == nil {
+		return nil
+	}
+	clusterNamespace := nfsServer.spec.CephCluster.Namespace
+	if _, err := c.loadCephCluster(clusterNamespace); err != nil {
+		return err
+	}
+
+	if err := c.updateGraceMembers(nfsServer, 0, nfsServer.spec.Replicas); err != nil {
+		return err
+	}
+
+	username := fmt.Sprintf("client.%s", cephUserID(nfsServer))
+	if err := client.AuthDelete(c.context, clusterNamespace, username); err != nil {
+		return err
+	}
+
+	logger.Infof("deleted ceph client %s of nfs server %s in namespace %s", username, nfsServer.serverName, nfsServer.namespace)
+	return nil
+}
+
+func createCephConfigFile(clusterInfo *cephconfig.ClusterInfo, userID string) string {
+	monHosts := make([]string, 0, len(clusterInfo.Monitors))
+	for _, monitor := range clusterInfo.Monitors {
+		monHosts = append(monHosts, monitor.Endpoint)
+	}
+	sort.Strings(monHosts)
+
+	return `[global]
+fsid = ` + clusterInfo.FSID + `
+mon host = ` + s.Join(monHosts, ",") + `
+
+[client.` + userID + `]
+keyring = ` + path.Join(cephConfigPath, cephKeyringKey) + `
+`
+}
+
+func (c *Controller) createRGWExportsConfig(nfsServer *nfsServer) (string, error) {
+	exportsList := make([]string, 0)
+	for i, export := range nfsServer.spec.Exports {
+		if export.CephObjectStore == nil {
+			continue
+		}
+		store := export.CephObjectStore
+		secretName := object.UserSecretName(store.Name, store.User)
+		secret, err := c.context.Clientset.CoreV1().Secrets(nfsServer.spec.CephCluster.Namespace).Get(secretName, metav1.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("failed to get keys of user %s of object store %s. %+v", store.User, store.Name, err)
+		}
+		fsal := `FSAL {
+		Name = RGW;
+		User_Id = "` + store.User + `";
+		Access_Key_Id = "` + string(secret.Data[object.AccessKeyName]) + `";
+		Secret_Access_Key = "` + string(secret.Data[object.SecretKeyName]) + `";
+	}`
+		exportsList = append(exportsList, createGaneshaExportBlock(exportID(i), "/"+store.Bucket, "/"+export.Name, export.Server.AccessMode, export.Server.Squash, fsal))
+	}
+
+	return s.Join(exportsList, "\n"), nil
+}
+
+// createCephGaneshaConfig returns the exports of the Ceph filesystems and the config of the Ceph FSALs and the
+// recovery backend
+func createCephGaneshaConfig(nfsServer *nfsServer) []string {
+	configList := make([]string, 0)
+	userID := cephUserID(nfsServer)
+	confFile := path.Join(cephConfigPath, cephConfigKey)
+
+	for i, export := range nfsServer.spec.Exports {
+		if export.CephFilesystem == nil {
+			continue
+		}
+		fsal := `FSAL {
+		Name = CEPH;
+		User_Id = "` + userID + `";
+		Filesystem = "` + export.CephFilesystem.Name + `";
+	}`
+		configList = append(configList, createGaneshaExportBlock(exportID(i), cephFilesystemPath(export.CephFilesystem), "/"+export.Name, export.Server.AccessMode, export.Server.Squash, fsal))
+	}
+
+	configList = append(configList, `CEPH
+{
+	Ceph_Conf = "`+confFile+`";
+}`)
+
+	if hasCephObjectStoreExports(&nfsServer.spec) {
+		configList = append(configList, `%include "`+path.Join(cephConfigPath, rgwExportsKey)+`"`)
+		configList = append(configList, `RGW
+{
+	ceph_conf = "`+confFile+`";
+	name = "client.`+userID+`";
+	cluster = "ceph";
+}`)
+	}
+
+	// the servers keep the state of their clients in RADOS so the clients of a failed server can reclaim their
+	// state from another server
+	if nfsServer.spec.CephCluster.RecoveryPool != "" {
+		configList = append(configList, `NFSv4
+{
+	RecoveryBackend = rados_cluster;
+	Minor_Versions = 1, 2;
+}
+RADOS_KV
+{
+	ceph_conf = "`+confFile+`";
+	userid = "`+userID+`";
+	pool = "`+nfsServer.spec.CephCluster.RecoveryPool+`";
+	namespace = "`+recoveryNamespace(nfsServer)+`";
+}`)
+	}
+
+	return configList
+}
+
+// updateGraceMembers adds the NFS server pods to the grace database in RADOS and removes the pods that were
+// scaled down, since the servers cannot leave the grace period while a member of the database is not running.
+// The pods are members of the database with their host name, which is the default node id of NFS Ganesha.
+func (c *Controller) updateGraceMembers(nfsServer *nfsServer, replicas, oldReplicas int) error {
+	if nfsServer.spec.CephCluster == nil || nfsServer.spec.CephCluster.RecoveryPool == "" {
+		return nil
+	}
+
+	for i := 0; i < replicas || i < oldReplicas; i++ {
+		nodeID := fmt.Sprintf("%s-%d", nfsServer.name, i)
+		isMember := c.runGraceTool(nfsServer, "member", nodeID) == nil
+		if i < replicas && !isMember {
+			if err := c.runGraceTool(nfsServer, "add", nodeID); err != nil {
+				return fmt.Errorf("failed to add %s to the grace database. %+v", nodeID, err)
+			}
+		} else if i >= replicas && isMember {
+			if err := c.runGraceTool(nfsServer, "remove", nodeID); err != nil {
+				return fmt.Errorf("failed to remove %s from the grace database. %+v", nodeID, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Controller) runGraceTool(nfsServer *nfsServer, command, nodeID string) error {
+	clusterNamespace := nfsServer.spec.CephCluster.Namespace
+	args := []string{
+		"--cephconf", cephconfig.GetConfFilePath(path.Join(c.context.ConfigDir, clusterNamespace), clusterNamespace),
+		"--userid", "admin",
+		"--pool", nfsServer.spec.CephCluster.RecoveryPool,
+		"--ns", recoveryNamespace(nfsServer),
+		command, nodeID,
+	}
+	return c.context.Executor.ExecuteCommand(false, "", graceTool, args...)
+}
+
+func validateCephExport(spec nfsv1alpha1.NFSServerSpec, export nfsv1alpha1.ExportsSpec) error {
+	if export.CephFilesystem == nil && export.CephObjectStore == nil {
+		return nil
+	}
+	if export.PersistentVolumeClaim.ClaimName != "" || (export.CephFilesystem != nil && export.CephObjectStore != nil) {
+		return fmt.Errorf("export %s must share only one of a persistentVolumeClaim, a cephFilesystem or a cephObjectStore", export.Name)
+	}
+	if spec.CephCluster == nil || spec.CephCluster.Namespace == "" {
+		return fmt.Errorf("the namespace of the cephCluster is required for export %s", export.Name)
+	}
+	if export.Name == "" {
+		return fmt.Errorf("the name of an export of a ceph filesystem or object store is required")
+	}
+	if export.CephFilesystem != nil && export.CephFilesystem.Name == "" {
+		return fmt.Errorf("the name of the filesystem of export %s is required", export.Name)
+	}
+	if export.CephObjectStore != nil && (export.CephObjectStore.Name == "" || export.CephObjectStore.User == "" || export.CephObjectStore.Bucket == "") {
+		return fmt.Errorf("the name, user and bucket of the object store of export %s are required", export.Name)
+	}
+	return nil
+}
@@ -0,0 +1,288 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package nfs
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	nfsv1alpha1 "github.com/rook/rook/pkg/apis/nfs.rook.io/v1alpha1"
+	"github.com/rook/rook/pkg/clusterd"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newCephNFSServer() *nfsv1alpha1.NFSServer {
+	return &nfsv1alpha1.NFSServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "nfs-server-X",
+			Namespace: "rook-nfs-test",
+		},
+		Spec: nfsv1alpha1.NFSServerSpec{
+			Replicas: 2,
+			CephCluster: &nfsv1alpha1.CephClusterSpec{
+				Namespace:    "rook-ceph",
+				RecoveryPool: "myfs-data0",
+			},
+			Exports: []nfsv1alpha1.ExportsSpec{
+				{
+					Name: "cephfs",
+					Server: nfsv1alpha1.ServerSpec{
+						AccessMode: "ReadWrite",
+						Squash:     "none",
+					},
+					CephFilesystem: &nfsv1alpha1.CephFilesystemExportSpec{
+						Name: "myfs",
+						Path: "/shares/a",
+					},
+				},
+				{
+					Name: "bucket",
+					Server: nfsv1alpha1.ServerSpec{
+						AccessMode: "ReadOnly",
+						Squash:     "root",
+					},
+					CephObjectStore: &nfsv1alpha1.CephObjectStoreExportSpec{
+						Name:   "my-store",
+						User:   "my-user",
+						Bucket: "my-bucket",
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestValidateCephExports(t *testing.T) {
+	spec := newCephNFSServer().Spec
+	assert.Nil(t, validateNFSServerSpec(spec))
+
+	// more than one replica requires a recovery pool
+	spec = newCephNFSServer().Spec
+	spec.CephCluster.RecoveryPool = ""
+	assert.NotNil(t, validateNFSServerSpec(spec))
+	spec.Replicas = 1
+	assert.Nil(t, validateNFSServerSpec(spec))
+
+	// ceph exports require the ceph cluster
+	spec = newCephNFSServer().Spec
+	spec.Replicas = 1
+	spec.CephCluster = nil
+	assert.NotNil(t, validateNFSServerSpec(spec))
+
+	// an export cannot share a pvc and a filesystem
+	spec = newCephNFSServer().Spec
+	spec.Exports[0].PersistentVolumeClaim.ClaimName = "test-claim"
+	assert.NotNil(t, validateNFSServerSpec(spec))
+
+	spec = newCephNFSServer().Spec
+	spec.Exports[1].CephObjectStore.Bucket = ""
+	assert.NotNil(t, validateNFSServerSpec(spec))
+}
+
+func TestCephClientAccess(t *testing.T) {
+	nfsServer := newNfsServer(newCephNFSServer(), nil)
+	assert.Equal(t, []string{
+		"mon", "allow rw",
+		"mds", "allow rw path=/shares/a",
+		"osd", "allow rw tag cephfs data=myfs, allow rwx, allow rw pool=myfs-data0 namespace=rook-nfs-test-nfs-server-X",
+	}, cephClientAccess(nfsServer))
+
+	// without buckets only the filesystem is accessible
+	nfsServer.spec.Exports = nfsServer.spec.Exports[:1]
+	nfsServer.spec.CephCluster.RecoveryPool = ""
+	assert.Equal(t, []string{
+		"mon", "allow r",
+		"mds", "allow rw path=/shares/a",
+		"osd", "allow rw tag cephfs data=myfs",
+	}, cephClientAccess(nfsServer))
+}
+
+func TestCreateCephGaneshaConfig(t *testing.T) {
+	nfsServer := newNfsServer(newCephNFSServer(), nil)
+	config := createGaneshaConfig(nfsServer)
+
+	assert.True(t, strings.Contains(config, `
+EXPORT {
+	Export_Id = 10;
+	Path = /shares/a;
+	Pseudo = /cephfs;
+	Protocols = 4;
+	Transports = TCP;
+	Sectype = sys;
+	Access_Type = RW;
+	Squash = none;
+	FSAL {
+		Name = CEPH;
+		User_Id = "nfs-ganesha-rook-nfs-test-nfs-server-X";
+		Filesystem = "myfs";
+	}
+}`))
+	assert.True(t, strings.Contains(config, `%include "/etc/ceph/rgw-exports.conf"`))
+	assert.True(t, strings.Contains(config, `name = "client.nfs-ganesha-rook-nfs-test-nfs-server-X";`))
+	assert.True(t, strings.Contains(config, "RecoveryBackend = rados_cluster;"))
+	assert.True(t, strings.Contains(config, `pool = "myfs-data0";`))
+	assert.True(t, strings.Contains(config, `namespace = "rook-nfs-test-nfs-server-X";`))
+	// the keys of the object store user are not in the config map
+	assert.False(t, strings.Contains(config, "Access_Key_Id"))
+}
+
+func TestOnAddCephExports(t *testing.T) {
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+
+	var authArgs [][]string
+	graceMembers := map[string]bool{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			authArgs = append(authArgs, args)
+			return `{"key":"mysecurekey"}`, nil
+		},
+		MockExecuteCommand: func(debug bool, actionName string, command string, args ...string) error {
+			assert.Equal(t, graceTool, command)
+			nodeID := args[len(args)-1]
+			switch args[len(args)-2] {
+			case "member":
+				if !graceMembers[nodeID] {
+					return os.ErrNotExist
+				}
+			case "add":
+				graceMembers[nodeID] = true
+			case "remove":
+				delete(graceMembers, nodeID)
+			}
+			return nil
+		},
+	}
+	clientset := testop.New(3)
+	context := &clusterd.Context{Clientset: clientset, Executor: executor, ConfigDir: configDir}
+
+	clientset.CoreV1().Secrets("rook-ceph").Create(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon", Namespace: "rook-ceph"},
+		Data:       map[string][]byte{"cluster-name": []byte("rook-ceph"), "fsid": []byte("myfsid"), "admin-secret": []byte("adminkey")},
+	})
+	clientset.CoreV1().ConfigMaps("rook-ceph").Create(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon-endpoints", Namespace: "rook-ceph"},
+		Data:       map[string]string{"data": "a=1.2.3.4:6790"},
+	})
+	clientset.CoreV1().Secrets("rook-ceph").Create(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-object-user-my-store-my-user", Namespace: "rook-ceph"},
+		Data:       map[string][]byte{"AccessKey": []byte("myaccesskey"), "SecretKey": []byte("mysecretkey")},
+	})
+
+	controller := NewController(context, "rook/nfs:mockTag")
+	nfsserver := newCephNFSServer()
+	controller.onAdd(nfsserver)
+
+	// the client of the nfs server is created with the caps of the exports
+	assert.Equal(t, []string{"auth", "get-or-create-key", "client.nfs-ganesha-rook-nfs-test-nfs-server-X"}, authArgs[1][:3])
+	assert.Equal(t, map[string]bool{"rook-nfs-0": true, "rook-nfs-1": true}, graceMembers)
+
+	secret, err := clientset.CoreV1().Secrets("rook-nfs-test").Get("nfs-ganesha-ceph-nfs-server-X", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(secret.StringData[cephConfigKey], "mon host = 1.2.3.4:6790"))
+	assert.Equal(t, "[client.nfs-ganesha-rook-nfs-test-nfs-server-X]\n\tkey = mysecurekey\n", secret.StringData[cephKeyringKey])
+	rgwExports := secret.StringData[rgwExportsKey]
+	assert.True(t, strings.Contains(rgwExports, "Export_Id = 11;\n\tPath = /my-bucket;\n\tPseudo = /bucket;"))
+	assert.True(t, strings.Contains(rgwExports, `Access_Key_Id = "myaccesskey";`))
+	assert.True(t, strings.Contains(rgwExports, `Secret_Access_Key = "mysecretkey";`))
+
+	ss, err := clientset.AppsV1beta1().StatefulSets("rook-nfs-test").Get(appName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	container := ss.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, v1.VolumeMount{Name: nfsCephVolumeName, MountPath: "/etc/ceph"}, container.VolumeMounts[1])
+
+	// scaling down removes the server from the grace database
+	newServer := nfsserver.DeepCopy()
+	newServer.Spec.Replicas = 1
+	controller.onUpdate(nfsserver, newServer)
+	assert.Equal(t, map[string]bool{"rook-nfs-0": true}, graceMembers)
+
+	controller.onDelete(newServer)
+	assert.Equal(t, 0, len(graceMembers))
+	assert.Equal(t, []string{"auth", "del", "client.nfs-ganesha-rook-nfs-test-nfs-server-X"}, authArgs[len(authArgs)-1][:3])
+}
+
+func TestCephConfigOfServersInNamespace(t *testing.T) {
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+
+	var authArgs [][]string
+	var graceArgs [][]string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			authArgs = append(authArgs, args)
+			return `{"key":"mysecurekey"}`, nil
+		},
+		MockExecuteCommand: func(debug bool, actionName string, command string, args ...string) error {
+			graceArgs = append(graceArgs, args)
+			return nil
+		},
+	}
+	clientset := testop.New(3)
+	context := &clusterd.Context{Clientset: clientset, Executor: executor, ConfigDir: configDir}
+	clientset.CoreV1().Secrets("rook-ceph").Create(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon", Namespace: "rook-ceph"},
+		Data:       map[string][]byte{"cluster-name": []byte("rook-ceph"), "fsid": []byte("myfsid"), "admin-secret": []byte("adminkey")},
+	})
+	clientset.CoreV1().ConfigMaps("rook-ceph").Create(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon-endpoints", Namespace: "rook-ceph"},
+		Data:       map[string]string{"data": "a=1.2.3.4:6790"},
+	})
+	controller := NewController(context, "rook/nfs:mockTag")
+
+	// two servers in the same namespace with only filesystem exports
+	serverA := newCephNFSServer()
+	serverA.Name = "nfs-a"
+	serverA.Spec.Exports = serverA.Spec.Exports[:1]
+	serverB := serverA.DeepCopy()
+	serverB.Name = "nfs-b"
+	nfsServerA := newNfsServer(serverA, context)
+	nfsServerB := newNfsServer(serverB, context)
+
+	// the servers have their own client, secret and grace database
+	assert.Equal(t, "nfs-ganesha-rook-nfs-test-nfs-a", cephUserID(nfsServerA))
+	assert.Equal(t, "nfs-ganesha-rook-nfs-test-nfs-b", cephUserID(nfsServerB))
+	assert.Equal(t, "nfs-ganesha-ceph-nfs-a", cephSecretName(nfsServerA))
+	assert.Equal(t, "nfs-ganesha-ceph-nfs-b", cephSecretName(nfsServerB))
+	assert.Equal(t, "rook-nfs-test-nfs-a", recoveryNamespace(nfsServerA))
+	assert.Equal(t, "rook-nfs-test-nfs-b", recoveryNamespace(nfsServerB))
+
+	assert.Nil(t, controller.createCephConfig(nfsServerA))
+	assert.Nil(t, controller.createCephConfig(nfsServerB))
+	secretA, err := clientset.CoreV1().Secrets("rook-nfs-test").Get("nfs-ganesha-ceph-nfs-a", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "[client.nfs-ganesha-rook-nfs-test-nfs-a]\n\tkey = mysecurekey\n", secretA.StringData[cephKeyringKey])
+	secretB, err := clientset.CoreV1().Secrets("rook-nfs-test").Get("nfs-ganesha-ceph-nfs-b", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "[client.nfs-ganesha-rook-nfs-test-nfs-b]\n\tkey = mysecurekey\n", secretB.StringData[cephKeyringKey])
+
+	// deleting a server only removes its own client and the members of its own grace database
+	authArgs = nil
+	graceArgs = nil
+	assert.Nil(t, controller.deleteCephConfig(nfsServerA))
+	assert.Equal(t, 1, len(authArgs))
+	assert.Equal(t, []string{"auth", "del", "client.nfs-ganesha-rook-nfs-test-nfs-a"}, authArgs[0][:3])
+	assert.True(t, len(graceArgs) > 0)
+	for _, args := range graceArgs {
+		assert.Contains(t, strings.Join(args, " "), "--ns rook-nfs-test-nfs-a ")
+	}
+}
@@ -45,6 +45,9 @@ const (
 	nfsConfigMapPath         = "/nfs-ganesha/config"
 	nfsPort                  = 2049
 	rpcPort                  = 111
+
+	// the pods are restarted to reload the exports when the hash of the ganesha config changes
+	configHashAnnotation = "nfs.rook.io/config-hash"
 )
 
 var logger = capnslog.NewPackageLogger("github.com/rook/rook", "nfs-operator")
@@ -89,20 +92,26 @@ func (c *Controller) StartWatch(namespace string, stopCh chan struct{}) error {
 }
 
 type nfsServer struct {
-	name      string
-	context   *clusterd.Context
-	namespace string
-	spec      nfsv1alpha1.NFSServerSpec
-	ownerRef  metav1.OwnerReference
+	name string
+	// the name of the NFSServer resource
+	serverName string
+	context    *clusterd.Context
+	namespace  string
+	spec       nfsv1alpha1.NFSServerSpec
+	ownerRef   metav1.OwnerReference
+
+	// the exports of the object store buckets, which contain the keys of the object store users
+	rgwExports string
 }
 
 func newNfsServer(c *nfsv1alpha1.NFSServer, context *clusterd.Context) *nfsServer {
 	return &nfsServer{
-		name:      appName,
-		context:   context,
-		namespace: c.Namespace,
-		spec:      c.Spec,
-		ownerRef:  nfsOwnerRef(c.Namespace, string(c.UID)),
+		name:       appName,
+		serverName: c.Name,
+		context:    context,
+		namespace:  c.Namespace,
+		spec:       c.Spec,
+		ownerRef:   nfsOwnerRef(c.Namespace, string(c.UID)),
 	}
 }
 
@@ -117,22 +126,6 @@ func nfsOwnerRef(namespace, nfsServerID string) metav1.OwnerReference {
 	}
 }
 
-func getServerConfig(exports []nfsv1alpha1.ExportsSpec) map[string]map[string]string {
-	claimConfigOpt := make(map[string]map[string]string)
-	configOpt := make(map[string]string)
-
-	for _, export := range exports {
-		claimName := export.PersistentVolumeClaim.ClaimName
-		if claimName != "" {
-			configOpt["accessMode"] = export.Server.AccessMode
-			configOpt["squash"] = export.Server.Squash
-			claimConfigOpt[claimName] = configOpt
-		}
-	}
-
-	return claimConfigOpt
-}
-
 func createAppLabels() map[string]string {
 	return map[string]string{
 		k8sutil.AppAttr: appName,
@@ -182,7 +175,17 @@ func (c *Controller) createNFSService(nfsServer *nfsServer) error {
 	return nil
 }
 
+func exportID(index int) int {
+	return 10 + index
+}
+
 func createGaneshaExport(id int, path string, access string, squash string) string {
+	return createGaneshaExportBlock(id, "/"+path, "/"+path, access, squash, `FSAL {
+		Name = VFS;
+	}`)
+}
+
+func createGaneshaExportBlock(id int, path, pseudo, access, squash, fsal string) string {
 	var accessType string
 	// validateNFSServerSpec guarantees `access` will be one of these values at this point
 	switch s.ToLower(access) {
@@ -198,29 +201,32 @@ func createGaneshaExport(id int, path string, access string, squash string) stri
 	nfsGaneshaConfig := `
 EXPORT {
 	Export_Id = ` + idStr + `;
-	Path = /` + path + `;
-	Pseudo = /` + path + `;
+	Path = ` + path + `;
+	Pseudo = ` + pseudo + `;
 	Protocols = 4;
 	Transports = TCP;
 	Sectype = sys;
 	Access_Type = ` + accessType + `;
 	Squash = ` + s.ToLower(squash) + `;
-	FSAL {
-		Name = VFS;
-	}
+	` + fsal + `
 }`
 
 	return nfsGaneshaConfig
 }
 
-func createGaneshaConfig(spec *nfsv1alpha1.NFSServerSpec) string {
-	serverConfig := getServerConfig(spec.Exports)
-
+func createGaneshaConfig(nfsServer *nfsServer) string {
 	exportsList := make([]string, 0)
-	id := 10
-	for claimName, claimConfig := range serverConfig {
-		exportsList = append(exportsList, createGaneshaExport(id, claimName, claimConfig["accessMode"], claimConfig["squash"]))
-		id++
+	// the exports are generated in the order of the spec so the config only changes when the spec changes
+	for i, export := range nfsServer.spec.Exports {
+		claimName := export.PersistentVolumeClaim.ClaimName
+		if claimName == "" {
+			continue
+		}
+		exportsList = append(exportsList, createGaneshaExport(exportID(i), claimName, export.Server.AccessMode, export.Server.Squash))
+	}
+
+	if nfsServer.spec.CephCluster != nil {
+		exportsList = append(exportsList, createCephGaneshaConfig(nfsServer)...)
 	}
 
 	// fsid_device parameter is important as in case of an overlayfs there is a chance that the fsid of the mounted share is same as that of the fsid of "/"
@@ -235,10 +241,10 @@ func createGaneshaConfig(spec *nfsv1alpha1.NFSServerSpec) string {
 	return nfsGaneshaConfig
 }
 
-func (c *Controller) createNFSConfigMap(nfsServer *nfsServer) error {
-	nfsGaneshaConfig := createGaneshaConfig(&nfsServer.spec)
+func (c *Controller) makeNFSConfigMap(nfsServer *nfsServer) *v1.ConfigMap {
+	nfsGaneshaConfig := createGaneshaConfig(nfsServer)
 
-	configMap := &v1.ConfigMap{
+	return &v1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            nfsConfigMapName,
 			Namespace:       nfsServer.namespace,
@@ -249,6 +255,10 @@ func (c *Controller) createNFSConfigMap(nfsServer *nfsServer) error {
 			nfsConfigMapName: nfsGaneshaConfig,
 		},
 	}
+}
+
+func (c *Controller) createNFSConfigMap(nfsServer *nfsServer) error {
+	configMap := c.makeNFSConfigMap(nfsServer)
 	_, err := c.context.Clientset.CoreV1().ConfigMaps(nfsServer.namespace).Create(configMap)
 	if err != nil {
 		return err
@@ -257,6 +267,20 @@ func (c *Controller) createNFSConfigMap(nfsServer *nfsServer) error {
 	return nil
 }
 
+func (c *Controller) updateNFSConfigMap(nfsServer *nfsServer) error {
+	configMap := c.makeNFSConfigMap(nfsServer)
+	_, err := c.context.Clientset.CoreV1().ConfigMaps(nfsServer.namespace).Update(configMap)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		_, err = c.context.Clientset.CoreV1().ConfigMaps(nfsServer.namespace).Create(configMap)
+		return err
+	}
+
+	return nil
+}
+
 func getPVCNameList(spec *nfsv1alpha1.NFSServerSpec) []string {
 	exports := spec.Exports
 	pvcNameList := make([]string, 0)
@@ -270,7 +294,8 @@ func getPVCNameList(spec *nfsv1alpha1.NFSServerSpec) []string {
 	return pvcNameList
 }
 
-func createPVCSpecList(spec *nfsv1alpha1.NFSServerSpec) []v1.Volume {
+func createPVCSpecList(nfsServer *nfsServer) []v1.Volume {
+	spec := &nfsServer.spec
 	pvcSpecList := make([]v1.Volume, 0)
 	pvcNameList := getPVCNameList(spec)
 	for _, claimName := range pvcNameList {
@@ -301,6 +326,15 @@ func createPVCSpecList(spec *nfsv1alpha1.NFSServerSpec) []v1.Volume {
 	}
 	pvcSpecList = append(pvcSpecList, configMapVol)
 
+	if spec.CephCluster != nil {
+		pvcSpecList = append(pvcSpecList, v1.Volume{
+			Name: nfsCephVolumeName,
+			VolumeSource: v1.VolumeSource{
+				Secret: &v1.SecretVolumeSource{SecretName: cephSecretName(nfsServer)},
+			},
+		})
+	}
+
 	return pvcSpecList
 }
 
@@ -320,6 +354,13 @@ func createVolumeMountList(spec *nfsv1alpha1.NFSServerSpec) []v1.VolumeMount {
 	}
 	volumeMountList = append(volumeMountList, configMapVolMount)
 
+	if spec.CephCluster != nil {
+		volumeMountList = append(volumeMountList, v1.VolumeMount{
+			Name:      nfsCephVolumeName,
+			MountPath: cephConfigPath,
+		})
+	}
+
 	return volumeMountList
 }
 
@@ -329,6 +370,9 @@ func (c *Controller) createNfsPodSpec(nfsServer *nfsServer) v1.PodTemplateSpec {
 			Name:      nfsServer.name,
 			Namespace: nfsServer.namespace,
 			Labels:    createAppLabels(),
+			Annotations: map[string]string{
+				configHashAnnotation: k8sutil.Hash(createGaneshaConfig(nfsServer) + nfsServer.rgwExports),
+			},
 		},
 		Spec: v1.PodSpec{
 			Containers: []v1.Container{
@@ -357,19 +401,17 @@ func (c *Controller) createNfsPodSpec(nfsServer *nfsServer) v1.PodTemplateSpec {
 					},
 				},
 			},
-			Volumes: createPVCSpecList(&nfsServer.spec),
+			Volumes: createPVCSpecList(nfsServer),
 		},
 	}
 
 	return nfsPodSpec
 }
 
-func (c *Controller) createNfsStatefulSet(nfsServer *nfsServer, replicas int32) error {
-	appsClient := c.context.Clientset.AppsV1beta1()
-
+func (c *Controller) makeNfsStatefulSet(nfsServer *nfsServer, replicas int32) *v1beta1.StatefulSet {
 	nfsPodSpec := c.createNfsPodSpec(nfsServer)
 
-	statefulSet := v1beta1.StatefulSet{
+	return &v1beta1.StatefulSet{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            nfsServer.name,
 			Namespace:       nfsServer.namespace,
@@ -383,10 +425,19 @@ func (c *Controller) createNfsStatefulSet(nfsServer *nfsServer, replicas int32)
 			},
 			Template:    nfsPodSpec,
 			ServiceName: nfsServer.name,
+			// the pods are replaced one at a time when the exports are updated
+			UpdateStrategy: v1beta1.StatefulSetUpdateStrategy{
+				Type: v1beta1.RollingUpdateStatefulSetStrategyType,
+			},
 		},
 	}
+}
+
+func (c *Controller) createNfsStatefulSet(nfsServer *nfsServer, replicas int32) error {
+	appsClient := c.context.Clientset.AppsV1beta1()
+	statefulSet := c.makeNfsStatefulSet(nfsServer, replicas)
 
-	if _, err := appsClient.StatefulSets(nfsServer.namespace).Create(&statefulSet); err != nil {
+	if _, err := appsClient.StatefulSets(nfsServer.namespace).Create(statefulSet); err != nil {
 		if !errors.IsAlreadyExists(err) {
 			return err
 		}
@@ -398,6 +449,30 @@ func (c *Controller) createNfsStatefulSet(nfsServer *nfsServer, replicas int32)
 	return nil
 }
 
+func (c *Controller) updateNfsStatefulSet(nfsServer *nfsServer, replicas int32) error {
+	appsClient := c.context.Clientset.AppsV1beta1()
+
+	existing, err := appsClient.StatefulSets(nfsServer.namespace).Get(nfsServer.name, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get stateful set %s. %+v", nfsServer.name, err)
+		}
+		return c.createNfsStatefulSet(nfsServer, replicas)
+	}
+
+	// only the replicas, the pod template and the update strategy of a stateful set can be updated
+	statefulSet := c.makeNfsStatefulSet(nfsServer, replicas)
+	existing.Spec.Replicas = statefulSet.Spec.Replicas
+	existing.Spec.Template = statefulSet.Spec.Template
+	existing.Spec.UpdateStrategy = statefulSet.Spec.UpdateStrategy
+	if _, err := appsClient.StatefulSets(nfsServer.namespace).Update(existing); err != nil {
+		return fmt.Errorf("failed to update stateful set %s. %+v", nfsServer.name, err)
+	}
+
+	logger.Infof("stateful set %s updated in namespace %s", existing.Name, existing.Namespace)
+	return nil
+}
+
 func (c *Controller) onAdd(obj interface{}) {
 	nfsObj := obj.(*nfsv1alpha1.NFSServer).DeepCopy()
 
@@ -416,6 +491,17 @@ func (c *Controller) onAdd(obj interface{}) {
 		logger.Errorf("Unable to create NFS service %+v", err)
 	}
 
+	if nfsServer.spec.CephCluster != nil {
+		logger.Infof("creating nfs server ceph configuration in namespace %s", nfsServer.namespace)
+		if err := c.createCephConfig(nfsServer); err != nil {
+			logger.Errorf("Unable to create NFS ceph configuration %+v", err)
+			return
+		}
+		if err := c.updateGraceMembers(nfsServer, nfsServer.spec.Replicas, 0); err != nil {
+			logger.Errorf("Unable to add NFS servers to the grace database %+v", err)
+		}
+	}
+
 	logger.Infof("creating nfs server configuration in namespace %s", nfsServer.namespace)
 	if err := c.createNFSConfigMap(nfsServer); err != nil {
 		logger.Errorf("Unable to create NFS ConfigMap %+v", err)
@@ -429,13 +515,60 @@ func (c *Controller) onAdd(obj interface{}) {
 
 func (c *Controller) onUpdate(oldObj, newObj interface{}) {
 	oldNfsServ := oldObj.(*nfsv1alpha1.NFSServer).DeepCopy()
+	newNfsServ := newObj.(*nfsv1alpha1.NFSServer).DeepCopy()
+
+	if reflect.DeepEqual(oldNfsServ.Spec, newNfsServ.Spec) {
+		logger.Debugf("nfs server %s in namespace %s did not change", newNfsServ.Name, newNfsServ.Namespace)
+		return
+	}
 
-	logger.Infof("Received update on NFS server %s in namespace %s. This is currently unsupported.", oldNfsServ.Name, oldNfsServ.Namespace)
+	nfsServer := newNfsServer(newNfsServ, c.context)
+
+	logger.Infof("NFS server %s updated in namespace %s", newNfsServ.Name, nfsServer.namespace)
+
+	logger.Infof("validating nfs server spec in namespace %s", nfsServer.namespace)
+	if err := validateNFSServerSpec(nfsServer.spec); err != nil {
+		logger.Errorf("Invalid NFS Server spec: %+v", err)
+		return
+	}
+
+	if nfsServer.spec.CephCluster != nil {
+		logger.Infof("updating nfs server ceph configuration in namespace %s", nfsServer.namespace)
+		if err := c.createCephConfig(nfsServer); err != nil {
+			logger.Errorf("Unable to update NFS ceph configuration %+v", err)
+			return
+		}
+		if err := c.updateGraceMembers(nfsServer, nfsServer.spec.Replicas, oldNfsServ.Spec.Replicas); err != nil {
+			logger.Errorf("Unable to update the NFS servers in the grace database %+v", err)
+		}
+	}
+
+	if oldNfsServ.Spec.CephCluster != nil && nfsServer.spec.CephCluster == nil {
+		if err := c.deleteCephConfig(newNfsServer(oldNfsServ, c.context)); err != nil {
+			logger.Errorf("Unable to delete NFS ceph configuration %+v", err)
+		}
+	}
+
+	logger.Infof("updating nfs server configuration in namespace %s", nfsServer.namespace)
+	if err := c.updateNFSConfigMap(nfsServer); err != nil {
+		logger.Errorf("Unable to update NFS ConfigMap %+v", err)
+		return
+	}
+
+	// the config hash in the pod template changes with the exports, which rolls the pods to reload the new config
+	logger.Infof("updating nfs server stateful set in namespace %s", nfsServer.namespace)
+	if err := c.updateNfsStatefulSet(nfsServer, int32(nfsServer.spec.Replicas)); err != nil {
+		logger.Errorf("Unable to update NFS stateful set %+v", err)
+	}
 }
 
 func (c *Controller) onDelete(obj interface{}) {
 	cluster := obj.(*nfsv1alpha1.NFSServer).DeepCopy()
 	logger.Infof("cluster %s deleted from namespace %s", cluster.Name, cluster.Namespace)
+
+	if err := c.deleteCephConfig(newNfsServer(cluster, c.context)); err != nil {
+		logger.Errorf("Unable to delete NFS ceph configuration %+v", err)
+	}
 }
 
 func validateNFSServerSpec(spec nfsv1alpha1.NFSServerSpec) error {
@@ -447,6 +580,12 @@ func validateNFSServerSpec(spec nfsv1alpha1.NFSServerSpec) error {
 		if err := validateSquashMode(export.Server.Squash); err != nil {
 			return err
 		}
+		if err := validateCephExport(spec, export); err != nil {
+			return err
+		}
+	}
+	if spec.CephCluster != nil && spec.CephCluster.RecoveryPool == "" && spec.Replicas > 1 {
+		return fmt.Errorf("a recoveryPool of the cephCluster is required for more than one replica")
 	}
 	return nil
 }
@@ -165,6 +165,80 @@ NFS_Core_Param
 	assert.Equal(t, expectedVolumeMounts, container.VolumeMounts)
 }
 
+func TestOnUpdate(t *testing.T) {
+	namespace := "rook-nfs-test"
+	oldServer := &nfsv1alpha1.NFSServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "nfs-server-X",
+			Namespace: namespace,
+		},
+		Spec: nfsv1alpha1.NFSServerSpec{
+			Replicas: 1,
+			Exports: []nfsv1alpha1.ExportsSpec{
+				{
+					Name: "export-test",
+					Server: nfsv1alpha1.ServerSpec{
+						AccessMode: "ReadWrite",
+						Squash:     "none",
+					},
+					PersistentVolumeClaim: v1.PersistentVolumeClaimVolumeSource{
+						ClaimName: "test-claim",
+					},
+				},
+			},
+		},
+	}
+
+	clientset := testop.New(3)
+	context := &clusterd.Context{Clientset: clientset}
+	controller := NewController(context, "rook/nfs:mockTag")
+	controller.onAdd(oldServer)
+
+	ss, err := clientset.AppsV1beta1().StatefulSets(namespace).Get(appName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	oldHash := ss.Spec.Template.Annotations[configHashAnnotation]
+	assert.NotEqual(t, "", oldHash)
+
+	// an invalid spec is not applied
+	newServer := oldServer.DeepCopy()
+	newServer.Spec.Exports[0].Server.Squash = "badValue"
+	controller.onUpdate(oldServer, newServer)
+	configMap, err := clientset.CoreV1().ConfigMaps(namespace).Get(nfsConfigMapName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(configMap.Data[nfsConfigMapName], "Squash = none;"))
+
+	// add an export, change the squash and scale the server
+	newServer = oldServer.DeepCopy()
+	newServer.Spec.Replicas = 2
+	newServer.Spec.Exports[0].Server.Squash = "root"
+	newServer.Spec.Exports = append(newServer.Spec.Exports, nfsv1alpha1.ExportsSpec{
+		Name: "export-test2",
+		Server: nfsv1alpha1.ServerSpec{
+			AccessMode: "ReadOnly",
+			Squash:     "all",
+		},
+		PersistentVolumeClaim: v1.PersistentVolumeClaimVolumeSource{
+			ClaimName: "test-claim2",
+		},
+	})
+	controller.onUpdate(oldServer, newServer)
+
+	configMap, err = clientset.CoreV1().ConfigMaps(namespace).Get(nfsConfigMapName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	config := configMap.Data[nfsConfigMapName]
+	assert.True(t, strings.Contains(config, "Path = /test-claim;\n\tPseudo = /test-claim;"))
+	assert.True(t, strings.Contains(config, "Squash = root;"))
+	assert.True(t, strings.Contains(config, "Export_Id = 11;\n\tPath = /test-claim2;"))
+	assert.True(t, strings.Contains(config, "Access_Type = RO;\n\tSquash = all;"))
+
+	ss, err = clientset.AppsV1beta1().StatefulSets(namespace).Get(appName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), *ss.Spec.Replicas)
+	assert.NotEqual(t, oldHash, ss.Spec.Template.Annotations[configHashAnnotation])
+	assert.Equal(t, 3, len(ss.Spec.Template.Spec.Containers[0].VolumeMounts))
+	assert.Equal(t, 3, len(ss.Spec.Template.Spec.Volumes))
+}
+
 func simulatePodsRunning(clientset *fake.Clientset, namespace string, podCount int) {
 	for i := 0; i < podCount; i++ {
 		pod := &v1.Pod{
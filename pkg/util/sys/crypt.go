@@ -0,0 +1,48 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sys
+
+import (
+	"fmt"
+
+	"github.com/rook/rook/pkg/util/exec"
+)
+
+const (
+	cryptsetup = "cryptsetup"
+	// DevMapperDir is the dir of the device mapper devices, such as the opened encrypted devices
+	DevMapperDir = "/dev/mapper"
+)
+
+// EncryptDevice formats the device as a LUKS device with the key in the key file
+func EncryptDevice(devicePath, keyFile string, executor exec.Executor) error {
+	cmd := fmt.Sprintf("luksFormat %s", devicePath)
+	if err := executor.ExecuteCommand(false, cmd, cryptsetup, "--batch-mode", "--key-file", keyFile, "luksFormat", devicePath); err != nil {
+		return fmt.Errorf("command %s failed: %+v", cmd, err)
+	}
+
+	return nil
+}
+
+// OpenEncryptedDevice opens the LUKS device with the key in the key file as the device mapper device with the given name
+func OpenEncryptedDevice(devicePath, name, keyFile string, executor exec.Executor) error {
+	cmd := fmt.Sprintf("luksOpen %s", devicePath)
+	if err := executor.ExecuteCommand(false, cmd, cryptsetup, "--key-file", keyFile, "luksOpen", devicePath, name); err != nil {
+		return fmt.Errorf("command %s failed: %+v", cmd, err)
+	}
+
+	return nil
+}
@@ -90,6 +90,22 @@ func ListDevices(executor exec.Executor) ([]string, error) {
 	return strings.Split(devices, "\n"), nil
 }
 
+// GetDeviceKernelName returns the kernel name of the block device at the given path, such as the
+// device node of a block mode volume that is attached to a container
+func GetDeviceKernelName(devicePath string, executor exec.Executor) (string, error) {
+	cmd := fmt.Sprintf("lsblk %s", devicePath)
+	output, err := executor.ExecuteCommandWithOutput(false, cmd, "lsblk", devicePath, "--nodeps", "--noheadings", "--output", "KNAME")
+	if err != nil {
+		return "", fmt.Errorf("failed to get the kernel name of device %s. %+v", devicePath, err)
+	}
+
+	name := strings.TrimSpace(output)
+	if name == "" {
+		return "", fmt.Errorf("device %s does not have a kernel name", devicePath)
+	}
+	return name, nil
+}
+
 func GetDevicePartitions(device string, executor exec.Executor) (partitions []Partition, unusedSpace uint64, err error) {
 	cmd := fmt.Sprintf("lsblk /dev/%s", device)
 	output, err := executor.ExecuteCommandWithOutput(false, cmd, "lsblk", fmt.Sprintf("/dev/%s", device),
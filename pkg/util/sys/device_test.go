@@ -201,3 +201,21 @@ func TestParseUdevInfo(t *testing.T) {
 	m := parseUdevInfo(udevOutput)
 	assert.Equal(t, m["ID_FS_TYPE"], "ext2")
 }
+
+func TestGetDeviceKernelName(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, arg ...string) (string, error) {
+			assert.Equal(t, []string{"/mnt/set1-0", "--nodeps", "--noheadings", "--output", "KNAME"}, arg)
+			return "xvdf\n", nil
+		},
+	}
+	name, err := GetDeviceKernelName("/mnt/set1-0", executor)
+	assert.Nil(t, err)
+	assert.Equal(t, "xvdf", name)
+
+	executor.MockExecuteCommandWithOutput = func(debug bool, actionName string, command string, arg ...string) (string, error) {
+		return "", nil
+	}
+	_, err = GetDeviceKernelName("/mnt/set1-0", executor)
+	assert.NotNil(t, err)
+}
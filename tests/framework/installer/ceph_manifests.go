@@ -79,6 +79,40 @@ spec:
     singular: cluster
   scope: Namespaced
   version: v1beta1
+  additionalPrinterColumns:
+  - name: State
+    type: string
+    description: The state of the orchestration of the cluster
+    JSONPath: .status.state
+  - name: Health
+    type: string
+    description: The health of the Ceph cluster
+    JSONPath: .status.ceph.health
+  - name: Quorum
+    type: string
+    description: The mons in quorum
+    JSONPath: .status.ceph.mons.quorum
+  - name: OSDs-Up
+    type: integer
+    description: The number of OSDs that are up
+    JSONPath: .status.ceph.osds.up
+  - name: OSDs-In
+    type: integer
+    description: The number of OSDs that are in
+    JSONPath: .status.ceph.osds.in
+  - name: Used-Bytes
+    type: integer
+    description: The raw capacity used by the OSDs
+    JSONPath: .status.ceph.capacity.bytesUsed
+  - name: Total-Bytes
+    type: integer
+    description: The raw capacity of the OSDs
+    JSONPath: .status.ceph.capacity.bytesTotal
+  - name: Age
+    type: date
+    JSONPath: .metadata.creationTimestamp
+  subresources:
+    status: {}
 ---
 apiVersion: apiextensions.k8s.io/v1beta1
 kind: CustomResourceDefinition
@@ -382,6 +416,10 @@ rules:
 - apiGroups: [""]
   resources: ["configmaps"]
   verbs: [ "get", "list", "watch", "create", "update", "delete" ]
+# The prepare pods store the keys of encrypted osds in secrets
+- apiGroups: [""]
+  resources: ["secrets"]
+  verbs: [ "get", "create" ]
 ---
 # Allow the operator to create resources in this cluster's namespace
 kind: RoleBinding